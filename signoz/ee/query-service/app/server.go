@@ -182,7 +182,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		if err != nil {
 			return nil, err
 		}
-		c = cache.NewCache(cacheOpts)
+		c = cache.NewCache(cacheOpts, serverOptions.SigNoz.Cache)
 	}
 
 	<-readerReady
@@ -225,6 +225,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 	// ingestion pipelines manager
 	logParsingPipelineController, err := logparsingpipeline.NewLogParsingPipelinesController(
 		serverOptions.SigNoz.SQLStore.SQLxDB(), integrationsController.GetPipelinesForInstalledIntegrations,
+		logparsingpipeline.NewLogsSampler(reader, serverOptions.UseLogsNewSchema),
 	)
 	if err != nil {
 		return nil, err
@@ -232,8 +233,9 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 
 	// initiate agent config handler
 	agentConfMgr, err := agentConf.Initiate(&agentConf.ManagerOptions{
-		DB:            serverOptions.SigNoz.SQLStore.SQLxDB(),
-		AgentFeatures: []agentConf.AgentFeature{logParsingPipelineController},
+		DB:                 serverOptions.SigNoz.SQLStore.SQLxDB(),
+		AgentFeatures:      []agentConf.AgentFeature{logParsingPipelineController},
+		IngestionErrorRate: agentConf.NewIngestionErrorRate(reader),
 	})
 	if err != nil {
 		return nil, err
@@ -271,6 +273,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		LogsParsingPipelineController: logParsingPipelineController,
 		Cache:                         c,
 		FluxInterval:                  fluxInterval,
+		QueryLimits:                   serverOptions.Config.QueryLimit,
 		Gateway:                       gatewayProxy,
 		GatewayUrl:                    serverOptions.GatewayUrl,
 		UseLogsNewSchema:              serverOptions.UseLogsNewSchema,
@@ -742,6 +745,8 @@ func makeRulesManager(
 		UseLogsNewSchema:    useLogsNewSchema,
 		UseTraceNewSchema:   useTraceNewSchema,
 		PrepareTestRuleFunc: rules.TestNotification,
+
+		PrepareBackfillRuleFunc: rules.PrepareBackfillRule,
 	}
 
 	// create Manager
@@ -78,6 +78,47 @@ func PrepareTaskFunc(opts baserules.PrepareTaskOptions) (baserules.Task, error)
 		// create anomaly rule task for evalution
 		task = newTask(baserules.TaskTypeCh, opts.TaskName, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
 
+	} else if opts.Rule.RuleType == baserules.RuleTypeSLO {
+		// create slo rule
+		sr, err := baserules.NewSLORule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.RuleDB,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			baserules.WithEvalDelay(opts.ManagerOpts.EvalDelay),
+		)
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, sr)
+
+		// create slo rule task for evalution
+		task = newTask(baserules.TaskTypeCh, opts.TaskName, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+
+	} else if opts.Rule.RuleType == baserules.RuleTypeRecording {
+		// create recording rule
+		rr, err := baserules.NewRecordingRule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			baserules.WithEvalDelay(opts.ManagerOpts.EvalDelay),
+		)
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, rr)
+
+		// create recording rule task for evalution
+		task = newTask(baserules.TaskTypeCh, opts.TaskName, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+
 	} else {
 		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s", opts.Rule.RuleType, baserules.RuleTypeProm, baserules.RuleTypeThreshold)
 	}
@@ -165,6 +206,25 @@ func TestNotification(opts baserules.PrepareTestRuleOptions) (int, *basemodel.Ap
 			zap.L().Error("failed to prepare a new anomaly rule for test", zap.String("name", rule.Name()), zap.Error(err))
 			return 0, basemodel.BadRequest(err)
 		}
+	} else if parsedRule.RuleType == baserules.RuleTypeSLO {
+		// create slo rule
+		rule, err = baserules.NewSLORule(
+			alertname,
+			parsedRule,
+			opts.FF,
+			opts.Reader,
+			opts.RuleDB,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			baserules.WithSendAlways(),
+			baserules.WithSendUnmatched(),
+		)
+		if err != nil {
+			zap.L().Error("failed to prepare a new slo rule for test", zap.String("name", alertname), zap.Error(err))
+			return 0, basemodel.BadRequest(err)
+		}
+	} else if parsedRule.RuleType == baserules.RuleTypeRecording {
+		return 0, basemodel.BadRequest(fmt.Errorf("recording rules do not send notifications"))
 	} else {
 		return 0, basemodel.BadRequest(fmt.Errorf("failed to derive ruletype with given information"))
 	}
@@ -194,3 +254,51 @@ func newTask(taskType baserules.TaskType, name string, frequency time.Duration,
 	}
 	return baserules.NewPromRuleTask(name, "", frequency, rules, opts, notify, ruleDB)
 }
+
+// PrepareBackfillRule creates the rule to evaluate over a past range
+func PrepareBackfillRule(opts baserules.PrepareTestRuleOptions, ruleOpts ...baserules.RuleOption) (baserules.Rule, error) {
+	ruleId := "backfill"
+
+	switch opts.Rule.RuleType {
+	case baserules.RuleTypeThreshold:
+		return baserules.NewThresholdRule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			ruleOpts...,
+		)
+	case baserules.RuleTypeProm:
+		return baserules.NewPromRule(
+			ruleId,
+			opts.Rule,
+			opts.Logger,
+			opts.Reader,
+			opts.ManagerOpts.PqlEngine,
+			ruleOpts...,
+		)
+	case baserules.RuleTypeAnomaly:
+		return NewAnomalyRule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.Cache,
+			ruleOpts...,
+		)
+	case baserules.RuleTypeSLO:
+		return baserules.NewSLORule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.RuleDB,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			ruleOpts...,
+		)
+	}
+	return nil, fmt.Errorf("unsupported rule type %s", opts.Rule.RuleType)
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"fmt"
 	"time"
 
 	go_cache "github.com/patrickmn/go-cache"
@@ -19,10 +20,21 @@ type Redis struct {
 	DB       int    `mapstructure:"db"`
 }
 
+type Disk struct {
+	// Path is the directory in which the cache entries are stored.
+	Path string `mapstructure:"path"`
+	// MaxSize is the maximum size in bytes of all the cache entries on disk.
+	// The least recently used entries are evicted once this size is exceeded.
+	MaxSize int64 `mapstructure:"maxSize"`
+	// TTL is the default time-to-live for the cache entries on disk.
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
 type Config struct {
 	Provider string `mapstructure:"provider"`
 	Memory   Memory `mapstructure:"memory"`
 	Redis    Redis  `mapstructure:"redis"`
+	Disk     Disk   `mapstructure:"disk"`
 }
 
 func NewConfigFactory() factory.ConfigFactory {
@@ -42,10 +54,23 @@ func newConfig() factory.Config {
 			Password: "",
 			DB:       0,
 		},
+		Disk: Disk{
+			Path:    "/var/lib/signoz/cache",
+			MaxSize: 1 << 30,
+			TTL:     go_cache.NoExpiration,
+		},
 	}
 
 }
 
 func (c Config) Validate() error {
+	if c.Provider == "disk" || c.Provider == "tiered" {
+		if c.Disk.Path == "" {
+			return fmt.Errorf("cache::disk::path must be set when using the %q cache provider", c.Provider)
+		}
+		if c.Disk.MaxSize <= 0 {
+			return fmt.Errorf("cache::disk::maxSize must be greater than 0, got %d", c.Disk.MaxSize)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,434 @@
+package diskcache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/cache"
+	"go.signoz.io/signoz/pkg/factory"
+)
+
+const (
+	// entryExtension is the extension of the files holding the cache entries
+	entryExtension = ".entry"
+	// headerSize is the size of the fixed part of an entry header
+	// (8 bytes of expiry followed by 4 bytes of key length)
+	headerSize = 12
+)
+
+// entry is the in-memory index record of a cache entry stored on disk
+type entry struct {
+	key       string
+	path      string
+	size      int64
+	expiresAt time.Time
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+type provider struct {
+	settings factory.ScopedProviderSettings
+	config   cache.Disk
+
+	mtx sync.Mutex
+	// lru holds the entries ordered from the most to the least recently used
+	lru     *list.List
+	entries map[string]*list.Element
+	size    int64
+}
+
+func NewFactory() factory.ProviderFactory[cache.Cache, cache.Config] {
+	return factory.NewProviderFactory(factory.MustNewName("disk"), New)
+}
+
+func New(ctx context.Context, providerSettings factory.ProviderSettings, config cache.Config) (cache.Cache, error) {
+	return newProvider(ctx, providerSettings, config.Disk)
+}
+
+func newProvider(ctx context.Context, providerSettings factory.ProviderSettings, config cache.Disk) (*provider, error) {
+	settings := factory.NewScopedProviderSettings(providerSettings, "go.signoz.io/signoz/pkg/cache/diskcache")
+
+	if err := os.MkdirAll(config.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory %q: %w", config.Path, err)
+	}
+
+	c := &provider{
+		settings: settings,
+		config:   config,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	if err := c.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// load rebuilds the index from the entries already present on disk so that
+// the cached data survives restarts
+func (c *provider) load(ctx context.Context) error {
+	files, err := os.ReadDir(c.config.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory %q: %w", c.config.Path, err)
+	}
+
+	type loaded struct {
+		entry   *entry
+		modTime time.Time
+	}
+
+	now := time.Now()
+	var found []loaded
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), entryExtension) {
+			continue
+		}
+
+		path := filepath.Join(c.config.Path, file.Name())
+		e, err := readHeader(path)
+		if err != nil || e.expired(now) {
+			_ = os.Remove(path)
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+		found = append(found, loaded{entry: e, modTime: info.ModTime()})
+	}
+
+	// oldest first so that the most recently written entry ends up at the front
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].modTime.Before(found[j].modTime)
+	})
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, f := range found {
+		c.entries[f.entry.key] = c.lru.PushFront(f.entry)
+		c.size += f.entry.size
+	}
+	c.evict()
+
+	c.settings.Logger().InfoContext(ctx, "loaded cache entries from disk", "path", c.config.Path, "entries", len(c.entries), "size", c.size)
+	return nil
+}
+
+// Connect does nothing
+func (c *provider) Connect(_ context.Context) error {
+	return nil
+}
+
+// Store stores the data in the cache
+func (c *provider) Store(_ context.Context, cacheKey string, data cache.CacheableEntity, ttl time.Duration) error {
+	// check if the data being passed is a pointer and is not nil
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return cache.WrapCacheableEntityErrors(reflect.TypeOf(data), "disk")
+	}
+
+	payload, err := data.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	e := &entry{
+		key:       cacheKey,
+		path:      c.pathFor(cacheKey),
+		expiresAt: c.expiry(ttl),
+	}
+
+	// the entry is written to a temporary file outside the lock, it is moved in
+	// place under the lock so that the index always matches the file on disk
+	// when the key is stored or removed concurrently
+	tmpPath, size, err := writeTemp(e, payload)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	e.size = size
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if err := os.Rename(tmpPath, e.path); err != nil {
+		return err
+	}
+	if elem, ok := c.entries[cacheKey]; ok {
+		c.size -= elem.Value.(*entry).size
+		c.lru.Remove(elem)
+	}
+	c.entries[cacheKey] = c.lru.PushFront(e)
+	c.size += e.size
+	c.evict()
+
+	return nil
+}
+
+// Retrieve retrieves the data from the cache
+func (c *provider) Retrieve(_ context.Context, cacheKey string, dest cache.CacheableEntity, allowExpired bool) (cache.RetrieveStatus, error) {
+	// check if the destination being passed is a pointer and is not nil
+	dstv := reflect.ValueOf(dest)
+	if dstv.Kind() != reflect.Pointer || dstv.IsNil() {
+		return cache.RetrieveStatusError, cache.WrapCacheableEntityErrors(reflect.TypeOf(dest), "disk")
+	}
+
+	c.mtx.Lock()
+	elem, ok := c.entries[cacheKey]
+	if !ok {
+		c.mtx.Unlock()
+		return cache.RetrieveStatusKeyMiss, nil
+	}
+	e := elem.Value.(*entry)
+	if e.expired(time.Now()) && !allowExpired {
+		c.remove(elem)
+		c.mtx.Unlock()
+		return cache.RetrieveStatusKeyMiss, nil
+	}
+	c.lru.MoveToFront(elem)
+	path := e.path
+	c.mtx.Unlock()
+
+	payload, err := readPayload(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			c.Remove(context.Background(), cacheKey)
+			return cache.RetrieveStatusKeyMiss, nil
+		}
+		return cache.RetrieveStatusError, err
+	}
+
+	if err := dest.UnmarshalBinary(payload); err != nil {
+		return cache.RetrieveStatusError, err
+	}
+
+	return cache.RetrieveStatusHit, nil
+}
+
+// SetTTL sets the TTL for the cache entry
+func (c *provider) SetTTL(_ context.Context, cacheKey string, ttl time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	elem, ok := c.entries[cacheKey]
+	if !ok {
+		return
+	}
+
+	e := elem.Value.(*entry)
+	expiresAt := c.expiry(ttl)
+	if err := writeExpiry(e.path, expiresAt); err != nil {
+		c.settings.Logger().Error("error setting TTL for cache key", "cacheKey", cacheKey, "ttl", ttl, "error", err)
+		return
+	}
+	e.expiresAt = expiresAt
+}
+
+// GetTTL returns the remaining TTL of the cache entry. It is negative if the
+// entry never expires and 0 if the entry is missing or expired.
+func (c *provider) GetTTL(_ context.Context, cacheKey string) time.Duration {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	elem, ok := c.entries[cacheKey]
+	if !ok {
+		return 0
+	}
+
+	e := elem.Value.(*entry)
+	if e.expiresAt.IsZero() {
+		return -1
+	}
+	return max(time.Until(e.expiresAt), 0)
+}
+
+// Remove removes the cache entry
+func (c *provider) Remove(ctx context.Context, cacheKey string) {
+	c.BulkRemove(ctx, []string{cacheKey})
+}
+
+// BulkRemove removes the cache entries
+func (c *provider) BulkRemove(_ context.Context, cacheKeys []string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, cacheKey := range cacheKeys {
+		if elem, ok := c.entries[cacheKey]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// Close does nothing, the entries are persisted as soon as they are stored
+func (c *provider) Close(_ context.Context) error {
+	return nil
+}
+
+// Size returns the total size in bytes of the entries on disk
+func (c *provider) Size() int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.size
+}
+
+// evict removes the least recently used entries until the cache fits in the
+// configured size. It must be called with the lock held.
+func (c *provider) evict() {
+	for c.config.MaxSize > 0 && c.size > c.config.MaxSize {
+		elem := c.lru.Back()
+		if elem == nil {
+			return
+		}
+		c.remove(elem)
+	}
+}
+
+// remove deletes the entry from the index and from disk. It must be called
+// with the lock held.
+func (c *provider) remove(elem *list.Element) {
+	e := elem.Value.(*entry)
+	c.lru.Remove(elem)
+	delete(c.entries, e.key)
+	c.size -= e.size
+	if err := os.Remove(e.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		c.settings.Logger().Error("error removing cache entry from disk", "cacheKey", e.key, "error", err)
+	}
+}
+
+func (c *provider) pathFor(cacheKey string) string {
+	sum := sha256.Sum256([]byte(cacheKey))
+	return filepath.Join(c.config.Path, hex.EncodeToString(sum[:])+entryExtension)
+}
+
+// expiry returns the expiry time for the given ttl. A ttl of 0 falls back to
+// the configured default and a negative ttl never expires.
+func (c *provider) expiry(ttl time.Duration) time.Time {
+	if ttl == 0 {
+		ttl = c.config.TTL
+	}
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// writeTemp writes the entry to a temporary file next to its path, the file
+// is atomically renamed in place so that a crash never leaves a partially
+// written entry behind
+func writeTemp(e *entry, payload []byte) (string, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(e.path), "tmp-*")
+	if err != nil {
+		return "", 0, err
+	}
+
+	header := make([]byte, headerSize, headerSize+len(e.key))
+	binary.BigEndian.PutUint64(header[0:8], uint64(unixNano(e.expiresAt)))
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(e.key)))
+	header = append(header, e.key...)
+
+	if _, err := tmp.Write(header); err == nil {
+		_, err = tmp.Write(payload)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, err
+	}
+
+	return tmp.Name(), int64(len(header) + len(payload)), nil
+}
+
+func writeExpiry(path string, expiresAt time.Time) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(unixNano(expiresAt)))
+	_, err = f.WriteAt(buf, 0)
+	return err
+}
+
+func readHeader(path string) (*entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, err
+	}
+
+	keyLen := int64(binary.BigEndian.Uint32(header[8:12]))
+	if keyLen > info.Size()-headerSize {
+		return nil, fmt.Errorf("corrupted cache entry %q", path)
+	}
+	key := make([]byte, keyLen)
+	if _, err := io.ReadFull(f, key); err != nil {
+		return nil, err
+	}
+
+	return &entry{
+		key:       string(key),
+		path:      path,
+		size:      info.Size(),
+		expiresAt: fromUnixNano(int64(binary.BigEndian.Uint64(header[0:8]))),
+	}, nil
+}
+
+func readPayload(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("corrupted cache entry %q", path)
+	}
+	offset := headerSize + int(binary.BigEndian.Uint32(data[8:12]))
+	if offset > len(data) {
+		return nil, fmt.Errorf("corrupted cache entry %q", path)
+	}
+	return data[offset:], nil
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
@@ -0,0 +1,223 @@
+package diskcache
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/cache"
+	"go.signoz.io/signoz/pkg/factory/providertest"
+)
+
+type CacheableEntity struct {
+	Key    string
+	Value  int
+	Expiry time.Duration
+}
+
+func (ce *CacheableEntity) MarshalBinary() ([]byte, error) {
+	return json.Marshal(ce)
+}
+
+func (ce *CacheableEntity) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, ce)
+}
+
+func newTestConfig(t *testing.T, maxSize int64) cache.Config {
+	return cache.Config{
+		Provider: "disk",
+		Disk: cache.Disk{
+			Path:    t.TempDir(),
+			MaxSize: maxSize,
+			TTL:     10 * time.Second,
+		},
+	}
+}
+
+// TestNew tests the New function
+func TestNew(t *testing.T) {
+	c, err := New(context.Background(), providertest.NewSettings(), newTestConfig(t, 1024))
+	require.NoError(t, err)
+	assert.NotNil(t, c)
+	assert.NoError(t, c.Connect(context.Background()))
+}
+
+// this should fail because of nil pointer error
+func TestStoreWithNilPointer(t *testing.T) {
+	c, err := New(context.Background(), providertest.NewSettings(), newTestConfig(t, 1024))
+	require.NoError(t, err)
+	var storeCacheableEntity *CacheableEntity
+	assert.Error(t, c.Store(context.Background(), "key", storeCacheableEntity, 10*time.Second))
+}
+
+func TestRetrieveWithSameTypes(t *testing.T) {
+	c, err := New(context.Background(), providertest.NewSettings(), newTestConfig(t, 1024))
+	require.NoError(t, err)
+	storeCacheableEntity := &CacheableEntity{
+		Key:    "some-random-key",
+		Value:  1,
+		Expiry: time.Microsecond,
+	}
+	assert.NoError(t, c.Store(context.Background(), "key", storeCacheableEntity, 10*time.Second))
+
+	retrieveCacheableEntity := new(CacheableEntity)
+	retrieveStatus, err := c.Retrieve(context.Background(), "key", retrieveCacheableEntity, false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, storeCacheableEntity, retrieveCacheableEntity)
+}
+
+// TestSetTTL tests the SetTTL function
+func TestSetTTL(t *testing.T) {
+	c, err := New(context.Background(), providertest.NewSettings(), newTestConfig(t, 1024))
+	require.NoError(t, err)
+	storeCacheableEntity := &CacheableEntity{Key: "some-random-key", Value: 1}
+	retrieveCacheableEntity := new(CacheableEntity)
+
+	assert.NoError(t, c.Store(context.Background(), "key", storeCacheableEntity, 100*time.Millisecond))
+	time.Sleep(200 * time.Millisecond)
+	retrieveStatus, err := c.Retrieve(context.Background(), "key", retrieveCacheableEntity, false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusKeyMiss, retrieveStatus)
+
+	assert.NoError(t, c.Store(context.Background(), "key", storeCacheableEntity, 100*time.Millisecond))
+	c.SetTTL(context.Background(), "key", 10*time.Second)
+	time.Sleep(200 * time.Millisecond)
+	retrieveStatus, err = c.Retrieve(context.Background(), "key", retrieveCacheableEntity, false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, storeCacheableEntity, retrieveCacheableEntity)
+}
+
+// TestBulkRemove tests the BulkRemove function
+// TestGetTTL tests the remaining TTL of the entries
+func TestGetTTL(t *testing.T) {
+	c, err := newProvider(context.Background(), providertest.NewSettings(), newTestConfig(t, 1024).Disk)
+	require.NoError(t, err)
+	storeCacheableEntity := &CacheableEntity{Key: "some-random-key", Value: 1}
+
+	assert.NoError(t, c.Store(context.Background(), "key", storeCacheableEntity, 10*time.Second))
+	ttl := c.GetTTL(context.Background(), "key")
+	assert.True(t, ttl > 9*time.Second && ttl <= 10*time.Second)
+
+	assert.NoError(t, c.Store(context.Background(), "forever", storeCacheableEntity, -1))
+	assert.Negative(t, c.GetTTL(context.Background(), "forever"))
+
+	assert.Zero(t, c.GetTTL(context.Background(), "missing"))
+}
+
+func TestBulkRemove(t *testing.T) {
+	c, err := New(context.Background(), providertest.NewSettings(), newTestConfig(t, 1024))
+	require.NoError(t, err)
+	storeCacheableEntity := &CacheableEntity{Key: "some-random-key", Value: 1}
+	retrieveCacheableEntity := new(CacheableEntity)
+	assert.NoError(t, c.Store(context.Background(), "key1", storeCacheableEntity, 10*time.Second))
+	assert.NoError(t, c.Store(context.Background(), "key2", storeCacheableEntity, 10*time.Second))
+	c.BulkRemove(context.Background(), []string{"key1", "key2"})
+
+	for _, key := range []string{"key1", "key2"} {
+		retrieveStatus, err := c.Retrieve(context.Background(), key, retrieveCacheableEntity, false)
+		assert.NoError(t, err)
+		assert.Equal(t, cache.RetrieveStatusKeyMiss, retrieveStatus)
+	}
+	assert.Equal(t, int64(0), c.(*provider).Size())
+}
+
+// TestPersistence tests that the entries survive a restart
+func TestPersistence(t *testing.T) {
+	config := newTestConfig(t, 1024)
+	c, err := New(context.Background(), providertest.NewSettings(), config)
+	require.NoError(t, err)
+	storeCacheableEntity := &CacheableEntity{Key: "some-random-key", Value: 1}
+	assert.NoError(t, c.Store(context.Background(), "key", storeCacheableEntity, 10*time.Second))
+	assert.NoError(t, c.Store(context.Background(), "expired", storeCacheableEntity, time.Millisecond))
+	assert.NoError(t, c.Close(context.Background()))
+	time.Sleep(10 * time.Millisecond)
+
+	c, err = New(context.Background(), providertest.NewSettings(), config)
+	require.NoError(t, err)
+
+	retrieveCacheableEntity := new(CacheableEntity)
+	retrieveStatus, err := c.Retrieve(context.Background(), "key", retrieveCacheableEntity, false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, storeCacheableEntity, retrieveCacheableEntity)
+
+	retrieveStatus, err = c.Retrieve(context.Background(), "expired", new(CacheableEntity), false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusKeyMiss, retrieveStatus)
+}
+
+// TestEviction tests that the least recently used entries are evicted once the max size is exceeded
+func TestEviction(t *testing.T) {
+	storeCacheableEntity := &CacheableEntity{Key: "some-random-key", Value: 1}
+	payload, err := storeCacheableEntity.MarshalBinary()
+	require.NoError(t, err)
+	entrySize := int64(headerSize + len("key1") + len(payload))
+
+	c, err := New(context.Background(), providertest.NewSettings(), newTestConfig(t, 2*entrySize))
+	require.NoError(t, err)
+
+	assert.NoError(t, c.Store(context.Background(), "key1", storeCacheableEntity, 10*time.Second))
+	assert.NoError(t, c.Store(context.Background(), "key2", storeCacheableEntity, 10*time.Second))
+
+	// touch key1 so that key2 becomes the least recently used entry
+	retrieveStatus, err := c.Retrieve(context.Background(), "key1", new(CacheableEntity), false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusHit, retrieveStatus)
+
+	assert.NoError(t, c.Store(context.Background(), "key3", storeCacheableEntity, 10*time.Second))
+
+	expected := map[string]cache.RetrieveStatus{
+		"key1": cache.RetrieveStatusHit,
+		"key2": cache.RetrieveStatusKeyMiss,
+		"key3": cache.RetrieveStatusHit,
+	}
+	for key, expectedStatus := range expected {
+		retrieveStatus, err := c.Retrieve(context.Background(), key, new(CacheableEntity), false)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedStatus, retrieveStatus, key)
+	}
+	assert.LessOrEqual(t, c.(*provider).Size(), 2*entrySize)
+}
+
+// TestConcurrentStoreAndRemove tests that the index matches the files on disk
+// when the same key is stored and removed concurrently
+func TestConcurrentStoreAndRemove(t *testing.T) {
+	c, err := newProvider(context.Background(), providertest.NewSettings(), newTestConfig(t, 0).Disk)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, c.Store(context.Background(), "key", &CacheableEntity{Key: strings.Repeat("k", i), Value: i}, 10*time.Second))
+		}(i)
+		go func() {
+			defer wg.Done()
+			c.Remove(context.Background(), "key")
+		}()
+	}
+	wg.Wait()
+
+	files, err := os.ReadDir(c.config.Path)
+	require.NoError(t, err)
+	elem, ok := c.entries["key"]
+	if !ok {
+		assert.Empty(t, files)
+		assert.Zero(t, c.Size())
+		return
+	}
+	require.Len(t, files, 1)
+	info, err := files[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), elem.Value.(*entry).size)
+	assert.Equal(t, info.Size(), c.Size())
+}
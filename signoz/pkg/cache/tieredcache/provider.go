@@ -0,0 +1,131 @@
+package tieredcache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"go.signoz.io/signoz/pkg/cache"
+	"go.signoz.io/signoz/pkg/cache/diskcache"
+	"go.signoz.io/signoz/pkg/cache/memorycache"
+	"go.signoz.io/signoz/pkg/factory"
+)
+
+// provider uses the in-memory cache as the first tier (L1) and the disk cache
+// as the second tier (L2). Entries are written to both tiers and entries found
+// only on disk are promoted to memory on retrieval.
+type provider struct {
+	settings factory.ScopedProviderSettings
+	l1       cache.Cache
+	l2       ttlCache
+}
+
+// ttlCache is a cache exposing the remaining TTL of its entries
+type ttlCache interface {
+	cache.Cache
+	GetTTL(ctx context.Context, cacheKey string) time.Duration
+}
+
+func NewFactory() factory.ProviderFactory[cache.Cache, cache.Config] {
+	return factory.NewProviderFactory(factory.MustNewName("tiered"), New)
+}
+
+func New(ctx context.Context, providerSettings factory.ProviderSettings, config cache.Config) (cache.Cache, error) {
+	settings := factory.NewScopedProviderSettings(providerSettings, "go.signoz.io/signoz/pkg/cache/tieredcache")
+
+	l1, err := memorycache.New(ctx, providerSettings, config)
+	if err != nil {
+		return nil, err
+	}
+
+	l2, err := diskcache.New(ctx, providerSettings, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider{settings: settings, l1: l1, l2: l2.(ttlCache)}, nil
+}
+
+// Connect connects both the tiers
+func (c *provider) Connect(ctx context.Context) error {
+	if err := c.l1.Connect(ctx); err != nil {
+		return err
+	}
+	return c.l2.Connect(ctx)
+}
+
+// Store stores the data in both the tiers
+func (c *provider) Store(ctx context.Context, cacheKey string, data cache.CacheableEntity, ttl time.Duration) error {
+	if err := c.l1.Store(ctx, cacheKey, data, ttl); err != nil {
+		return err
+	}
+	return c.l2.Store(ctx, cacheKey, data, ttl)
+}
+
+// Retrieve retrieves the data from memory and falls back to disk on a miss
+func (c *provider) Retrieve(ctx context.Context, cacheKey string, dest cache.CacheableEntity, allowExpired bool) (cache.RetrieveStatus, error) {
+	retrieveStatus, err := c.l1.Retrieve(ctx, cacheKey, dest, allowExpired)
+	if err != nil || retrieveStatus != cache.RetrieveStatusKeyMiss {
+		return retrieveStatus, err
+	}
+
+	retrieveStatus, err = c.l2.Retrieve(ctx, cacheKey, dest, allowExpired)
+	if err != nil || retrieveStatus != cache.RetrieveStatusHit {
+		return retrieveStatus, err
+	}
+
+	// promote the entry to memory until it expires on disk, the expired
+	// entries retrieved with allowExpired are not promoted
+	ttl := c.l2.GetTTL(ctx, cacheKey)
+	if ttl == 0 {
+		return retrieveStatus, nil
+	}
+	promoted, err := copyEntity(dest)
+	if err != nil {
+		c.settings.Logger().ErrorContext(ctx, "error copying cache entry", "cacheKey", cacheKey, "error", err)
+		return retrieveStatus, nil
+	}
+	if err := c.l1.Store(ctx, cacheKey, promoted, ttl); err != nil {
+		c.settings.Logger().ErrorContext(ctx, "error promoting cache entry to memory", "cacheKey", cacheKey, "error", err)
+	}
+
+	return retrieveStatus, nil
+}
+
+// copyEntity returns a copy of the entity so that the memory tier does not
+// share the destination of the caller, which may modify it afterwards
+func copyEntity(entity cache.CacheableEntity) (cache.CacheableEntity, error) {
+	data, err := entity.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	entityCopy := reflect.New(reflect.TypeOf(entity).Elem()).Interface().(cache.CacheableEntity)
+	if err := entityCopy.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return entityCopy, nil
+}
+
+// SetTTL sets the TTL for the cache entry in both the tiers
+func (c *provider) SetTTL(ctx context.Context, cacheKey string, ttl time.Duration) {
+	c.l1.SetTTL(ctx, cacheKey, ttl)
+	c.l2.SetTTL(ctx, cacheKey, ttl)
+}
+
+// Remove removes the cache entry from both the tiers
+func (c *provider) Remove(ctx context.Context, cacheKey string) {
+	c.l1.Remove(ctx, cacheKey)
+	c.l2.Remove(ctx, cacheKey)
+}
+
+// BulkRemove removes the cache entries from both the tiers
+func (c *provider) BulkRemove(ctx context.Context, cacheKeys []string) {
+	c.l1.BulkRemove(ctx, cacheKeys)
+	c.l2.BulkRemove(ctx, cacheKeys)
+}
+
+// Close closes both the tiers
+func (c *provider) Close(ctx context.Context) error {
+	return errors.Join(c.l1.Close(ctx), c.l2.Close(ctx))
+}
@@ -0,0 +1,114 @@
+package tieredcache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/cache"
+	"go.signoz.io/signoz/pkg/factory/providertest"
+)
+
+type CacheableEntity struct {
+	Key    string
+	Value  int
+	Expiry time.Duration
+}
+
+func (ce *CacheableEntity) MarshalBinary() ([]byte, error) {
+	return json.Marshal(ce)
+}
+
+func (ce *CacheableEntity) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, ce)
+}
+
+func newTestConfig(t *testing.T) cache.Config {
+	return cache.Config{
+		Provider: "tiered",
+		Memory: cache.Memory{
+			TTL:             10 * time.Second,
+			CleanupInterval: 10 * time.Second,
+		},
+		Disk: cache.Disk{
+			Path:    t.TempDir(),
+			MaxSize: 1024,
+			TTL:     10 * time.Second,
+		},
+	}
+}
+
+// TestRetrieveFromDisk tests that entries missing in memory are served from disk and promoted
+func TestRetrieveFromDisk(t *testing.T) {
+	config := newTestConfig(t)
+	c, err := New(context.Background(), providertest.NewSettings(), config)
+	require.NoError(t, err)
+	storeCacheableEntity := &CacheableEntity{Key: "some-random-key", Value: 1}
+	assert.NoError(t, c.Store(context.Background(), "key", storeCacheableEntity, 10*time.Second))
+
+	// a new instance starts with an empty memory tier
+	c, err = New(context.Background(), providertest.NewSettings(), config)
+	require.NoError(t, err)
+
+	retrieveCacheableEntity := new(CacheableEntity)
+	retrieveStatus, err := c.Retrieve(context.Background(), "key", retrieveCacheableEntity, false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, storeCacheableEntity, retrieveCacheableEntity)
+
+	retrieveStatus, err = c.(*provider).l1.Retrieve(context.Background(), "key", new(CacheableEntity), false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusHit, retrieveStatus)
+}
+
+// TestRemove tests that entries are removed from both the tiers
+func TestRemove(t *testing.T) {
+	c, err := New(context.Background(), providertest.NewSettings(), newTestConfig(t))
+	require.NoError(t, err)
+	storeCacheableEntity := &CacheableEntity{Key: "some-random-key", Value: 1}
+	assert.NoError(t, c.Store(context.Background(), "key", storeCacheableEntity, 10*time.Second))
+	c.Remove(context.Background(), "key")
+
+	retrieveStatus, err := c.Retrieve(context.Background(), "key", new(CacheableEntity), false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusKeyMiss, retrieveStatus)
+
+	retrieveStatus, err = c.(*provider).l2.Retrieve(context.Background(), "key", new(CacheableEntity), false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusKeyMiss, retrieveStatus)
+}
+
+// TestPromotedEntry tests that the promoted entries are copies of the retrieved
+// data and expire from memory with the remaining TTL on disk
+func TestPromotedEntry(t *testing.T) {
+	config := newTestConfig(t)
+	c, err := New(context.Background(), providertest.NewSettings(), config)
+	require.NoError(t, err)
+	storeCacheableEntity := &CacheableEntity{Key: "some-random-key", Value: 1}
+	assert.NoError(t, c.Store(context.Background(), "key", storeCacheableEntity, 200*time.Millisecond))
+
+	c, err = New(context.Background(), providertest.NewSettings(), config)
+	require.NoError(t, err)
+
+	retrieveCacheableEntity := new(CacheableEntity)
+	retrieveStatus, err := c.Retrieve(context.Background(), "key", retrieveCacheableEntity, false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusHit, retrieveStatus)
+
+	// the caller modifying its destination does not modify the promoted entry
+	retrieveCacheableEntity.Value = 2
+	promotedCacheableEntity := new(CacheableEntity)
+	retrieveStatus, err = c.(*provider).l1.Retrieve(context.Background(), "key", promotedCacheableEntity, false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, storeCacheableEntity, promotedCacheableEntity)
+
+	// the promoted entry expires with the entry on disk instead of the default memory TTL
+	time.Sleep(300 * time.Millisecond)
+	retrieveStatus, err = c.(*provider).l1.Retrieve(context.Background(), "key", new(CacheableEntity), false)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.RetrieveStatusKeyMiss, retrieveStatus)
+}
@@ -3,6 +3,7 @@ package agentConf
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -272,3 +273,145 @@ func (r *Repo) updateDeployStatusByHash(
 
 	return nil
 }
+
+// getLatestDeployedVersion returns the latest version agents acknowledged
+// deploying successfully, -1 if there is none.
+func (r *Repo) getLatestDeployedVersion(
+	ctx context.Context, typ ElementTypeDef,
+) (int, *model.ApiError) {
+	var version int
+	err := r.db.GetContext(ctx, &version, `SELECT 
+		COALESCE(MAX(version), -1) 
+		FROM agent_config_versions 
+		WHERE element_type = $1 
+		AND deploy_status = $2`, typ, Deployed)
+	if err != nil {
+		return -1, model.InternalError(errors.Wrap(err, "failed to get latest deployed version"))
+	}
+	return version, nil
+}
+
+// getLatestVersionByHash returns the latest version deployed with the given hash.
+func (r *Repo) getLatestVersionByHash(
+	ctx context.Context, hash string,
+) (*ConfigVersion, *model.ApiError) {
+	var c ConfigVersion
+	err := r.db.GetContext(ctx, &c, `SELECT 
+		id, 
+		version, 
+		element_type 
+		FROM agent_config_versions 
+		WHERE last_hash = $1 
+		ORDER BY version DESC 
+		LIMIT 1`, hash)
+
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(err)
+	}
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	return &c, nil
+}
+
+func (r *Repo) getConfigElementIds(
+	ctx context.Context, typ ElementTypeDef, version int,
+) ([]string, *model.ApiError) {
+	elementIds := []string{}
+	err := r.db.SelectContext(ctx, &elementIds, `SELECT 
+		e.element_id 
+		FROM agent_config_elements e 
+		JOIN agent_config_versions v ON e.version_id = v.id 
+		WHERE v.element_type = $1 
+		AND v.version = $2`, typ, version)
+	if err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to get config elements"))
+	}
+	return elementIds, nil
+}
+
+func (r *Repo) insertRollout(ctx context.Context, rollout *Rollout) *model.ApiError {
+	canaryAgents, err := json.Marshal(rollout.CanaryAgents)
+	if err != nil {
+		return model.InternalError(errors.Wrap(err, "failed to serialize canary agents"))
+	}
+
+	_, err = r.db.ExecContext(ctx, `INSERT INTO agent_config_rollouts(
+		element_type, 
+		version, 
+		stable_version, 
+		canary_agents, 
+		status, 
+		auto_rollback, 
+		message) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		rollout.ElementType,
+		rollout.Version,
+		rollout.StableVersion,
+		string(canaryAgents),
+		rollout.Status,
+		rollout.AutoRollback,
+		rollout.Message,
+	)
+	if err != nil {
+		zap.L().Error("error in inserting config rollout", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to insert config rollout"))
+	}
+	return nil
+}
+
+func (r *Repo) GetRollout(
+	ctx context.Context, typ ElementTypeDef, version int,
+) (*Rollout, *model.ApiError) {
+	var rollout Rollout
+	err := r.db.GetContext(ctx, &rollout, `SELECT 
+		element_type, 
+		version, 
+		stable_version, 
+		canary_agents, 
+		status, 
+		auto_rollback, 
+		message, 
+		rollback_version, 
+		created_at, 
+		updated_at 
+		FROM agent_config_rollouts 
+		WHERE element_type = $1 
+		AND version = $2`, typ, version)
+
+	if err == sql.ErrNoRows {
+		return nil, model.NotFoundError(err)
+	}
+	if err != nil {
+		return nil, model.InternalError(err)
+	}
+
+	if err := json.Unmarshal([]byte(rollout.SerializedCanaryAgents), &rollout.CanaryAgents); err != nil {
+		return nil, model.InternalError(errors.Wrap(err, "failed to read canary agents"))
+	}
+
+	return &rollout, nil
+}
+
+func (r *Repo) updateRolloutStatus(
+	ctx context.Context,
+	typ ElementTypeDef,
+	version int,
+	status RolloutStatus,
+	message string,
+	rollbackVersion int,
+) *model.ApiError {
+	_, err := r.db.ExecContext(ctx, `UPDATE agent_config_rollouts 
+	set status = $1, 
+	message = $2, 
+	rollback_version = $3, 
+	updated_at = CURRENT_TIMESTAMP 
+	WHERE element_type = $4 
+	AND version = $5`, status, message, rollbackVersion, typ, version)
+	if err != nil {
+		zap.L().Error("failed to update rollout status", zap.Error(err))
+		return model.InternalError(errors.Wrap(err, "failed to update rollout status"))
+	}
+	return nil
+}
@@ -36,6 +36,11 @@ type Manager struct {
 	agentFeatures         []AgentFeature
 	configSubscribers     map[string]func()
 	configSubscribersLock sync.Mutex
+
+	// For staged rollouts of agent feature config versions
+	rolloutLock        sync.Mutex
+	listAgentIds       func() []string
+	ingestionErrorRate IngestionErrorRate
 }
 
 type ManagerOptions struct {
@@ -44,6 +49,10 @@ type ManagerOptions struct {
 	// When acting as opamp.AgentConfigProvider, agent conf recommendations are
 	// applied to the base conf in the order the features have been specified here.
 	AgentFeatures []AgentFeature
+
+	// Rollouts with automatic rollback enabled get rolled back on
+	// ingestion error rate spikes if this is specified.
+	IngestionErrorRate IngestionErrorRate
 }
 
 func Initiate(options *ManagerOptions) (*Manager, error) {
@@ -60,15 +69,21 @@ func Initiate(options *ManagerOptions) (*Manager, error) {
 	}
 
 	m = &Manager{
-		Repo:              Repo{options.DB},
-		agentFeatures:     options.AgentFeatures,
-		configSubscribers: map[string]func(){},
+		Repo:               Repo{options.DB},
+		agentFeatures:      options.AgentFeatures,
+		configSubscribers:  map[string]func(){},
+		listAgentIds:       connectedAgentIds,
+		ingestionErrorRate: options.IngestionErrorRate,
 	}
 
 	err := m.initDB(options.DB)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not init agentConf db")
 	}
+
+	if m.ingestionErrorRate != nil {
+		go m.watchRollouts()
+	}
 	return m, nil
 }
 
@@ -94,7 +109,7 @@ func (m *Manager) notifyConfigUpdateSubscribers() {
 }
 
 // Implements opamp.AgentConfigProvider
-func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
+func (m *Manager) RecommendAgentConfig(agentId string, currentConfYaml []byte) (
 	recommendedConfYaml []byte,
 	// Opaque id of the recommended config, used for reporting deployment status updates
 	configId string,
@@ -110,8 +125,14 @@ func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
 			return nil, "", errors.Wrap(apiErr.ToError(), "failed to get latest agent config version")
 		}
 
+		// Agents that aren't part of a canary rollout keep the stable version
+		agentConfig, apiErr := m.configVersionForAgent(context.Background(), agentId, latestConfig)
+		if apiErr != nil {
+			return nil, "", errors.Wrap(apiErr.ToError(), "failed to get agent config version for rollout")
+		}
+
 		updatedConf, serializedSettingsUsed, apiErr := feature.RecommendAgentConfig(
-			recommendation, latestConfig,
+			recommendation, agentConfig,
 		)
 		if apiErr != nil {
 			return nil, "", errors.Wrap(apiErr.ToError(), fmt.Sprintf(
@@ -126,23 +147,25 @@ func (m *Manager) RecommendAgentConfig(currentConfYaml []byte) (
 		// For example, log pipeline config for installed integrations will
 		// have to be recommended even if the user hasn't created any pipelines yet
 		configVersion := -1
-		if latestConfig != nil {
-			configVersion = latestConfig.Version
+		if agentConfig != nil {
+			configVersion = agentConfig.Version
 		}
 		configId := fmt.Sprintf("%s:%d", featureType, configVersion)
 
 		settingVersionsUsed = append(settingVersionsUsed, configId)
 
-		m.updateDeployStatus(
-			context.Background(),
-			featureType,
-			configVersion,
-			string(DeployInitiated),
-			"Deployment has started",
-			configId,
-			serializedSettingsUsed,
-		)
-
+		// The stable version served during a canary rollout has been deployed already
+		if agentConfig == latestConfig {
+			m.updateDeployStatus(
+				context.Background(),
+				featureType,
+				configVersion,
+				string(DeployInitiated),
+				"Deployment has started",
+				configId,
+				serializedSettingsUsed,
+			)
+		}
 	}
 
 	if len(settingVersionsUsed) > 0 {
@@ -175,6 +198,9 @@ func (m *Manager) ReportConfigDeploymentStatus(
 		m.updateDeployStatusByHash(
 			context.Background(), featureConfId, newStatus, message,
 		)
+		if err != nil {
+			m.onFeatureDeployFailure(featureConfId, message)
+		}
 	}
 }
 
@@ -325,6 +351,13 @@ func (m *Manager) OnConfigUpdate(agentId string, hash string, err error) {
 	}
 
 	m.updateDeployStatusByHash(context.Background(), hash, status, message)
+
+	if err != nil {
+		configVersion, apiErr := m.getLatestVersionByHash(context.Background(), hash)
+		if apiErr == nil {
+			m.onDeployFailure(configVersion.ElementType, configVersion.Version, message)
+		}
+	}
 }
 
 // UpsertSamplingProcessor updates the agent config with new filter processor params
@@ -0,0 +1,429 @@
+package agentConf
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	opampModel "go.signoz.io/signoz/pkg/query-service/app/opamp/model"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+type RolloutStatus string
+
+const (
+	// RolloutCanary versions are only recommended to the canary agents of
+	// the rollout, other agents keep getting the stable version.
+	RolloutCanary     RolloutStatus = "CANARY"
+	RolloutPromoted   RolloutStatus = "PROMOTED"
+	RolloutRolledBack RolloutStatus = "ROLLED_BACK"
+)
+
+const (
+	// Ingestion error rates are watched for this long after a version
+	// reaches canary agents and again after it gets promoted.
+	rolloutWatchPeriod = 15 * time.Minute
+	// Error rates over shorter windows are too noisy to act on.
+	rolloutMinWatchWindow = time.Minute
+	rolloutWatchInterval  = time.Minute
+
+	// A rollout is rolled back if the ingestion error rate since it started
+	// is above minRollbackErrorRate errors per second and more than
+	// errorRateSpikeFactor times the rate of the window before it.
+	minRollbackErrorRate = 1.0
+	errorRateSpikeFactor = 2.0
+)
+
+// RolloutOptions control how a new config version is deployed to agents.
+type RolloutOptions struct {
+	// CanaryPercent is the percentage of connected agents that get the new
+	// version first. 0 and 100 deploy to all agents right away.
+	CanaryPercent int `json:"canaryPercent"`
+
+	// AutoRollback deploys the last good version again if agents fail to
+	// apply the new version or ingestion error rates spike after it.
+	AutoRollback bool `json:"autoRollback"`
+}
+
+func (o *RolloutOptions) Validate() error {
+	if o.CanaryPercent < 0 || o.CanaryPercent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100")
+	}
+	return nil
+}
+
+type Rollout struct {
+	ElementType ElementTypeDef `json:"elementType" db:"element_type"`
+	Version     int            `json:"version" db:"version"`
+	// StableVersion is the version deployed before this rollout started,
+	// -1 if there was none.
+	StableVersion int `json:"stableVersion" db:"stable_version"`
+
+	SerializedCanaryAgents string   `json:"-" db:"canary_agents"`
+	CanaryAgents           []string `json:"canaryAgents" db:"-"`
+
+	Status       RolloutStatus `json:"status" db:"status"`
+	AutoRollback bool          `json:"autoRollback" db:"auto_rollback"`
+	Message      string        `json:"message" db:"message"`
+	// RollbackVersion is the version created to roll this rollout back.
+	RollbackVersion int `json:"rollbackVersion,omitempty" db:"rollback_version"`
+
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+func (r *Rollout) isCanaryAgent(agentId string) bool {
+	return slices.Contains(r.CanaryAgents, agentId)
+}
+
+func (r *Rollout) isActive() bool {
+	return r.Status == RolloutCanary || r.Status == RolloutPromoted
+}
+
+// IngestionErrorRate returns the number of ingestion errors
+// per second reported by collectors in the [start, end) window.
+type IngestionErrorRate func(ctx context.Context, start, end time.Time) (float64, error)
+
+// NewIngestionErrorRate returns an IngestionErrorRate based
+// on the collector metrics stored in ClickHouse.
+func NewIngestionErrorRate(reader interfaces.Reader) IngestionErrorRate {
+	return func(ctx context.Context, start, end time.Time) (float64, error) {
+		count, err := reader.GetIngestionErrorCount(
+			ctx, constants.IngestionErrorMetrics, start.UnixMilli(), end.UnixMilli(),
+		)
+		if err != nil {
+			return 0, err
+		}
+		return count / end.Sub(start).Seconds(), nil
+	}
+}
+
+func connectedAgentIds() []string {
+	agentIds := []string{}
+	for _, agent := range opampModel.AllAgents.GetAllAgents() {
+		agentIds = append(agentIds, agent.ID)
+	}
+	return agentIds
+}
+
+// selectCanaryAgents picks percent of the agents, at least one.
+// Agents are picked in a stable order so that the same agents
+// act as canaries for consecutive rollouts.
+func selectCanaryAgents(agentIds []string, percent int) []string {
+	if len(agentIds) < 1 {
+		return []string{}
+	}
+	sorted := slices.Clone(agentIds)
+	slices.Sort(sorted)
+
+	count := max(1, (len(sorted)*percent+99)/100)
+	return sorted[:count]
+}
+
+// StartNewVersionWithRollout launches a new config version for given set of elements,
+// deploying it to a subset of the connected agents first if requested.
+func StartNewVersionWithRollout(
+	ctx context.Context, userId string, eleType ElementTypeDef, elementIds []string, opts RolloutOptions,
+) (*ConfigVersion, *model.ApiError) {
+	if err := opts.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+	if !m.isAgentFeature(eleType) {
+		return nil, model.BadRequest(fmt.Errorf(
+			"staged rollouts are not supported for %s", eleType,
+		))
+	}
+
+	m.rolloutLock.Lock()
+	defer m.rolloutLock.Unlock()
+
+	stableVersion, apiErr := m.getLatestDeployedVersion(ctx, eleType)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	cfg := NewConfigVersion(eleType)
+	apiErr = m.insertConfig(ctx, userId, cfg, elementIds)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	rollout := &Rollout{
+		ElementType:   eleType,
+		Version:       cfg.Version,
+		StableVersion: stableVersion,
+		CanaryAgents:  []string{},
+		Status:        RolloutPromoted,
+		AutoRollback:  opts.AutoRollback,
+		Message:       "Deploying to all agents",
+	}
+	if opts.CanaryPercent > 0 && opts.CanaryPercent < 100 {
+		rollout.CanaryAgents = selectCanaryAgents(m.listAgentIds(), opts.CanaryPercent)
+		if len(rollout.CanaryAgents) > 0 {
+			rollout.Status = RolloutCanary
+			rollout.Message = fmt.Sprintf("Deploying to %d canary agents", len(rollout.CanaryAgents))
+		}
+	}
+
+	apiErr = m.insertRollout(ctx, rollout)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+
+	return cfg, nil
+}
+
+func GetRollout(
+	ctx context.Context, typ ElementTypeDef, version int,
+) (*Rollout, *model.ApiError) {
+	return m.GetRollout(ctx, typ, version)
+}
+
+// PromoteRollout deploys a version being tried out on canary agents to all agents.
+func PromoteRollout(ctx context.Context, typ ElementTypeDef, version int) *model.ApiError {
+	m.rolloutLock.Lock()
+	defer m.rolloutLock.Unlock()
+
+	rollout, apiErr := m.getLatestRollout(ctx, typ, version)
+	if apiErr != nil {
+		return apiErr
+	}
+	if rollout.Status != RolloutCanary {
+		return model.BadRequest(fmt.Errorf(
+			"only canary rollouts can be promoted, version %d is %s", version, rollout.Status,
+		))
+	}
+
+	apiErr = m.updateRolloutStatus(
+		ctx, typ, version, RolloutPromoted, "Promoted to all agents", 0,
+	)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+	return nil
+}
+
+// RollbackRollout deploys the version that was stable before the rollout of
+// version started again, as a new version.
+func RollbackRollout(
+	ctx context.Context, userId string, typ ElementTypeDef, version int, reason string,
+) (*ConfigVersion, *model.ApiError) {
+	m.rolloutLock.Lock()
+	defer m.rolloutLock.Unlock()
+
+	rollout, apiErr := m.getLatestRollout(ctx, typ, version)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !rollout.isActive() {
+		return nil, model.BadRequest(fmt.Errorf(
+			"version %d has already been rolled back", version,
+		))
+	}
+
+	return m.rollback(ctx, userId, rollout, reason)
+}
+
+// getLatestRollout returns the rollout of version,
+// which must be the latest version of typ.
+func (m *Manager) getLatestRollout(
+	ctx context.Context, typ ElementTypeDef, version int,
+) (*Rollout, *model.ApiError) {
+	latest, apiErr := m.GetLatestVersion(ctx, typ)
+	if apiErr != nil {
+		return nil, model.WrapApiError(apiErr, "could not get latest config version")
+	}
+	if latest.Version != version {
+		return nil, model.BadRequest(fmt.Errorf(
+			"version %d has been superseded by version %d", version, latest.Version,
+		))
+	}
+
+	rollout, apiErr := m.GetRollout(ctx, typ, version)
+	if apiErr != nil {
+		if apiErr.Type() == model.ErrorNotFound {
+			return nil, model.NotFoundError(fmt.Errorf(
+				"version %d was not deployed with a staged rollout", version,
+			))
+		}
+		return nil, model.WrapApiError(apiErr, "could not get config rollout")
+	}
+	return rollout, nil
+}
+
+// rollback expects rolloutLock to be held.
+func (m *Manager) rollback(
+	ctx context.Context, userId string, rollout *Rollout, reason string,
+) (*ConfigVersion, *model.ApiError) {
+	if rollout.StableVersion < 0 {
+		return nil, model.BadRequest(fmt.Errorf(
+			"there is no successfully deployed version before version %d to roll back to",
+			rollout.Version,
+		))
+	}
+
+	elementIds, apiErr := m.getConfigElementIds(ctx, rollout.ElementType, rollout.StableVersion)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	cfg := NewConfigVersion(rollout.ElementType)
+	apiErr = m.insertConfig(ctx, userId, cfg, elementIds)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	message := fmt.Sprintf("Rolled back to version %d", rollout.StableVersion)
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+	apiErr = m.updateRolloutStatus(
+		ctx, rollout.ElementType, rollout.Version, RolloutRolledBack, message, cfg.Version,
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	m.notifyConfigUpdateSubscribers()
+
+	zap.L().Warn("rolled back agent config",
+		zap.String("elementType", string(rollout.ElementType)),
+		zap.Int("version", rollout.Version),
+		zap.Int("rollbackVersion", cfg.Version),
+		zap.String("reason", reason),
+	)
+	return cfg, nil
+}
+
+// autoRollback rolls back version if it is the latest version of typ
+// and was deployed with automatic rollbacks enabled.
+func (m *Manager) autoRollback(
+	ctx context.Context, typ ElementTypeDef, version int, reason string,
+) {
+	m.rolloutLock.Lock()
+	defer m.rolloutLock.Unlock()
+
+	rollout, apiErr := m.getLatestRollout(ctx, typ, version)
+	if apiErr != nil || !rollout.AutoRollback || !rollout.isActive() {
+		return
+	}
+
+	if _, apiErr := m.rollback(ctx, "", rollout, reason); apiErr != nil {
+		zap.L().Error("could not roll back agent config",
+			zap.String("elementType", string(typ)), zap.Int("version", version), zap.Error(apiErr),
+		)
+	}
+}
+
+// onDeployFailure is called when an agent reports failing to apply version.
+func (m *Manager) onDeployFailure(typ ElementTypeDef, version int, message string) {
+	// Agents report failures with their state locked, and rolling back
+	// recommends config to all agents, so the rollback can't run inline.
+	go m.autoRollback(context.Background(), typ, version, message)
+}
+
+// onFeatureDeployFailure handles failures reported for a
+// "type:version" feature config id.
+func (m *Manager) onFeatureDeployFailure(featureConfId string, message string) {
+	typ, versionStr, found := strings.Cut(featureConfId, ":")
+	if !found {
+		return
+	}
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version < 0 {
+		return
+	}
+	m.onDeployFailure(ElementTypeDef(typ), version, message)
+}
+
+func (m *Manager) watchRollouts() {
+	ticker := time.NewTicker(rolloutWatchInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		m.checkRolloutErrorRates(context.Background(), now)
+	}
+}
+
+// checkRolloutErrorRates rolls back rollouts during which the
+// ingestion error rate spiked.
+func (m *Manager) checkRolloutErrorRates(ctx context.Context, now time.Time) {
+	for _, feature := range m.agentFeatures {
+		typ := ElementTypeDef(feature.AgentFeatureType())
+		latest, apiErr := m.GetLatestVersion(ctx, typ)
+		if apiErr != nil {
+			continue
+		}
+		rollout, apiErr := m.GetRollout(ctx, typ, latest.Version)
+		if apiErr != nil || !rollout.AutoRollback || !rollout.isActive() {
+			continue
+		}
+
+		window := now.Sub(rollout.UpdatedAt)
+		if window < rolloutMinWatchWindow || window > rolloutWatchPeriod {
+			continue
+		}
+
+		after, err := m.ingestionErrorRate(ctx, rollout.UpdatedAt, now)
+		if err != nil {
+			zap.L().Error("could not get ingestion error rate", zap.Error(err))
+			continue
+		}
+		before, err := m.ingestionErrorRate(ctx, rollout.UpdatedAt.Add(-window), rollout.UpdatedAt)
+		if err != nil {
+			zap.L().Error("could not get ingestion error rate", zap.Error(err))
+			continue
+		}
+
+		if after >= minRollbackErrorRate && after > before*errorRateSpikeFactor {
+			m.autoRollback(ctx, typ, rollout.Version, fmt.Sprintf(
+				"ingestion error rate rose from %.2f/s to %.2f/s", before, after,
+			))
+		}
+	}
+}
+
+func (m *Manager) isAgentFeature(typ ElementTypeDef) bool {
+	for _, feature := range m.agentFeatures {
+		if ElementTypeDef(feature.AgentFeatureType()) == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// configVersionForAgent returns the version of a feature's config
+// an agent should run given the latest version.
+func (m *Manager) configVersionForAgent(
+	ctx context.Context, agentId string, latest *ConfigVersion,
+) (*ConfigVersion, *model.ApiError) {
+	if latest == nil {
+		return nil, nil
+	}
+
+	rollout, apiErr := m.GetRollout(ctx, latest.ElementType, latest.Version)
+	if apiErr != nil {
+		if apiErr.Type() == model.ErrorNotFound {
+			return latest, nil
+		}
+		return nil, apiErr
+	}
+	if rollout.Status != RolloutCanary || rollout.isCanaryAgent(agentId) {
+		return latest, nil
+	}
+
+	if rollout.StableVersion < 0 {
+		return nil, nil
+	}
+	return m.GetConfigVersion(ctx, latest.ElementType, rollout.StableVersion)
+}
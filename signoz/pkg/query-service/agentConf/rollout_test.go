@@ -0,0 +1,186 @@
+package agentConf
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+const testFeatureType = "test_feature"
+
+type testAgentFeature struct{}
+
+func (f *testAgentFeature) AgentFeatureType() AgentFeatureType {
+	return testFeatureType
+}
+
+func (f *testAgentFeature) RecommendAgentConfig(
+	currentConfYaml []byte, configVersion *ConfigVersion,
+) ([]byte, string, *model.ApiError) {
+	if configVersion == nil {
+		return currentConfYaml, "", nil
+	}
+	return []byte(fmt.Sprintf("version: %d", configVersion.Version)), "", nil
+}
+
+func newRolloutTestManager(t *testing.T, errorRate IngestionErrorRate) *Manager {
+	manager, err := Initiate(&ManagerOptions{
+		DB:                 utils.NewQueryServiceDBForTests(t),
+		AgentFeatures:      []AgentFeature{&testAgentFeature{}},
+		IngestionErrorRate: errorRate,
+	})
+	require.Nil(t, err)
+	manager.listAgentIds = func() []string {
+		return []string{"agent-b", "agent-a", "agent-c"}
+	}
+	return manager
+}
+
+// startDeployedVersion creates a version with elementIds that all agents deployed.
+func startDeployedVersion(t *testing.T, elementIds []string) *ConfigVersion {
+	ctx := context.Background()
+	cfg, apiErr := StartNewVersion(ctx, "test-user", testFeatureType, elementIds)
+	require.Nil(t, apiErr)
+	configId := fmt.Sprintf("%s:%d", testFeatureType, cfg.Version)
+	m.updateDeployStatus(ctx, testFeatureType, cfg.Version, string(Deployed), "", configId, "")
+	return cfg
+}
+
+func TestSelectCanaryAgents(t *testing.T) {
+	require := require.New(t)
+
+	agentIds := []string{"d", "b", "a", "c"}
+	require.Equal([]string{"a"}, selectCanaryAgents(agentIds, 1))
+	require.Equal([]string{"a", "b"}, selectCanaryAgents(agentIds, 50))
+	require.Equal([]string{"a", "b", "c"}, selectCanaryAgents(agentIds, 51))
+	require.Equal([]string{}, selectCanaryAgents([]string{}, 50))
+	require.Equal([]string{"d", "b", "a", "c"}, agentIds)
+}
+
+func TestCanaryRolloutRecommendations(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	manager := newRolloutTestManager(t, nil)
+
+	stable := startDeployedVersion(t, []string{"e1"})
+	canary, apiErr := StartNewVersionWithRollout(
+		ctx, "test-user", testFeatureType, []string{"e2"}, RolloutOptions{CanaryPercent: 34},
+	)
+	require.Nil(apiErr)
+
+	rollout, apiErr := GetRollout(ctx, testFeatureType, canary.Version)
+	require.Nil(apiErr)
+	require.Equal(RolloutCanary, rollout.Status)
+	require.Equal(stable.Version, rollout.StableVersion)
+	require.Equal([]string{"agent-a", "agent-b"}, rollout.CanaryAgents)
+
+	for agentId, expectedVersion := range map[string]int{
+		"agent-a": canary.Version,
+		"agent-b": canary.Version,
+		"agent-c": stable.Version,
+	} {
+		conf, configId, err := manager.RecommendAgentConfig(agentId, []byte{})
+		require.Nil(err)
+		require.Equal(fmt.Sprintf("version: %d", expectedVersion), string(conf))
+		require.Equal(fmt.Sprintf("%s:%d", testFeatureType, expectedVersion), configId)
+	}
+
+	require.Nil(PromoteRollout(ctx, testFeatureType, canary.Version))
+	conf, _, err := manager.RecommendAgentConfig("agent-c", []byte{})
+	require.Nil(err)
+	require.Equal(fmt.Sprintf("version: %d", canary.Version), string(conf))
+
+	// only canary rollouts can be promoted
+	apiErr = PromoteRollout(ctx, testFeatureType, canary.Version)
+	require.NotNil(apiErr)
+	require.Equal(model.ErrorBadData, apiErr.Type())
+}
+
+func TestRolloutAutoRollbackOnDeployFailure(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	manager := newRolloutTestManager(t, nil)
+
+	startDeployedVersion(t, []string{"e1"})
+	canary, apiErr := StartNewVersionWithRollout(
+		ctx, "test-user", testFeatureType, []string{"e2"},
+		RolloutOptions{CanaryPercent: 10, AutoRollback: true},
+	)
+	require.Nil(apiErr)
+
+	_, configId, err := manager.RecommendAgentConfig("agent-a", []byte{})
+	require.Nil(err)
+	manager.ReportConfigDeploymentStatus("agent-a", configId, fmt.Errorf("invalid config"))
+
+	require.Eventually(func() bool {
+		rollout, apiErr := GetRollout(ctx, testFeatureType, canary.Version)
+		return apiErr == nil && rollout.Status == RolloutRolledBack
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rollout, apiErr := GetRollout(ctx, testFeatureType, canary.Version)
+	require.Nil(apiErr)
+	require.Equal(canary.Version+1, rollout.RollbackVersion)
+	require.Contains(rollout.Message, "invalid config")
+
+	elementIds, apiErr := manager.getConfigElementIds(ctx, testFeatureType, rollout.RollbackVersion)
+	require.Nil(apiErr)
+	require.Equal([]string{"e1"}, elementIds)
+
+	// rolled back versions can't be rolled back again
+	_, apiErr = RollbackRollout(ctx, "test-user", testFeatureType, canary.Version, "")
+	require.NotNil(apiErr)
+}
+
+func TestRolloutAutoRollbackOnIngestionErrors(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var rolloutStart time.Time
+	errorRateAfterRollout := 0.0
+	manager := newRolloutTestManager(t, func(ctx context.Context, start, end time.Time) (float64, error) {
+		if start.Equal(rolloutStart) {
+			return errorRateAfterRollout, nil
+		}
+		return 2, nil
+	})
+
+	startDeployedVersion(t, []string{"e1"})
+	cfg, apiErr := StartNewVersionWithRollout(
+		ctx, "test-user", testFeatureType, []string{"e2"}, RolloutOptions{AutoRollback: true},
+	)
+	require.Nil(apiErr)
+	rollout, apiErr := GetRollout(ctx, testFeatureType, cfg.Version)
+	require.Nil(apiErr)
+	require.Equal(RolloutPromoted, rollout.Status)
+	rolloutStart = rollout.UpdatedAt
+
+	// error rates are only checked during the watch period
+	errorRateAfterRollout = 10
+	manager.checkRolloutErrorRates(ctx, rolloutStart.Add(rolloutWatchPeriod+time.Minute))
+	manager.checkRolloutErrorRates(ctx, rolloutStart.Add(time.Second))
+
+	// small increases are tolerated
+	errorRateAfterRollout = 3
+	manager.checkRolloutErrorRates(ctx, rolloutStart.Add(5*time.Minute))
+
+	rollout, apiErr = GetRollout(ctx, testFeatureType, cfg.Version)
+	require.Nil(apiErr)
+	require.Equal(RolloutPromoted, rollout.Status)
+
+	errorRateAfterRollout = 10
+	manager.checkRolloutErrorRates(ctx, rolloutStart.Add(5*time.Minute))
+
+	rollout, apiErr = GetRollout(ctx, testFeatureType, cfg.Version)
+	require.Nil(apiErr)
+	require.Equal(RolloutRolledBack, rollout.Status)
+	require.Contains(rollout.Message, "ingestion error rate")
+
+	latest, apiErr := GetLatestVersion(ctx, testFeatureType)
+	require.Nil(apiErr)
+	require.Equal(rollout.RollbackVersion, latest.Version)
+}
@@ -55,6 +55,20 @@ func InitDB(db *sqlx.DB) error {
 	CREATE UNIQUE INDEX IF NOT EXISTS agent_config_elements_u1 
 	ON agent_config_elements(version_id, element_id, element_type);
 
+	CREATE TABLE IF NOT EXISTS agent_config_rollouts(
+		element_type VARCHAR(120) NOT NULL,
+		version INTEGER NOT NULL,
+		stable_version INTEGER NOT NULL,
+		canary_agents TEXT NOT NULL DEFAULT '[]',
+		status VARCHAR(80) NOT NULL,
+		auto_rollback int NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		rollback_version INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY(element_type, version)
+	);
+
 	`
 
 	_, err = db.Exec(table_schema)
@@ -3845,8 +3845,40 @@ func (r *ClickHouseReader) GetMetricMetadata(ctx context.Context, metricName, se
 		}
 	}
 
-	query = fmt.Sprintf("SELECT JSONExtractString(labels, 'le') as le from %s.%s WHERE metric_name=$1 AND unix_milli >= $2 AND type = 'Histogram' AND JSONExtractString(labels, 'service_name') = $3 GROUP BY le ORDER BY le", signozMetricDBName, signozTSTableNameV41Day)
-	rows, err = r.db.Query(ctx, query, metricName, unixMilli, serviceName)
+	leFloat64, err := r.getHistogramLe(ctx, metricName, serviceName, false, unixMilli)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v3.MetricMetadataResponse{
+		Delta:       deltaExists,
+		Le:          leFloat64,
+		Description: description,
+		Unit:        unit,
+		Type:        metricType,
+		IsMonotonic: isMonotonic,
+		Temporality: temporality,
+	}, nil
+}
+
+// GetHistogramBuckets returns the upper bounds of the buckets of the histogram
+// metric, except +Inf. The buckets are those of the service, or of every
+// service when the service name is empty.
+func (r *ClickHouseReader) GetHistogramBuckets(ctx context.Context, metricName, serviceName string) ([]float64, error) {
+	return r.getHistogramLe(ctx, metricName, serviceName, serviceName == "", common.PastDayRoundOff())
+}
+
+// getHistogramLe returns the upper bounds of the buckets of the histogram metric
+// for the service, the service is not filtered when anyService is true
+func (r *ClickHouseReader) getHistogramLe(ctx context.Context, metricName, serviceName string, anyService bool, unixMilli int64) ([]float64, error) {
+	serviceFilter := " AND JSONExtractString(labels, 'service_name') = $3"
+	args := []interface{}{metricName, unixMilli, serviceName}
+	if anyService {
+		serviceFilter = ""
+		args = args[:2]
+	}
+	query := fmt.Sprintf("SELECT JSONExtractString(labels, 'le') as le from %s.%s WHERE metric_name=$1 AND unix_milli >= $2 AND type = 'Histogram'%s GROUP BY le ORDER BY le", signozMetricDBName, signozTSTableNameV41Day, serviceFilter)
+	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		zap.L().Error("Error while executing query", zap.Error(err))
 		return nil, fmt.Errorf("error while executing query: %s", err.Error())
@@ -3873,15 +3905,7 @@ func (r *ClickHouseReader) GetMetricMetadata(ctx context.Context, metricName, se
 		leFloat64 = append(leFloat64, le)
 	}
 
-	return &v3.MetricMetadataResponse{
-		Delta:       deltaExists,
-		Le:          leFloat64,
-		Description: description,
-		Unit:        unit,
-		Type:        metricType,
-		IsMonotonic: isMonotonic,
-		Temporality: temporality,
-	}, nil
+	return leFloat64, nil
 }
 
 // GetCountOfThings returns the count of things in the query
@@ -3956,6 +3980,47 @@ func (r *ClickHouseReader) GetLatestReceivedMetric(
 	return result, nil
 }
 
+// GetIngestionErrorCount returns the increase of the `metricNames` counters
+// in the [start, end) window, start and end being epoch milliseconds.
+func (r *ClickHouseReader) GetIngestionErrorCount(
+	ctx context.Context, metricNames []string, start, end int64,
+) (float64, error) {
+	if len(metricNames) < 1 {
+		return 0, nil
+	}
+
+	quotedMetricNames := []string{}
+	for _, m := range metricNames {
+		quotedMetricNames = append(quotedMetricNames, fmt.Sprintf(`'%s'`, m))
+	}
+	commaSeparatedMetricNames := strings.Join(quotedMetricNames, ", ")
+
+	// Counters are cumulative, a decrease means the collector reporting them
+	// restarted and the new value is the increase since then.
+	query := fmt.Sprintf(`
+		SELECT sum(if(delta < 0, value, delta))
+		from (
+			SELECT value, value - lagInFrame(value, 1, value) OVER (
+				PARTITION BY fingerprint ORDER BY unix_milli
+				ROWS BETWEEN 1 PRECEDING AND CURRENT ROW
+			) as delta
+			from %s.%s
+			where metric_name in (
+				%s
+			)
+			and unix_milli >= $1 and unix_milli < $2
+		)
+		`, signozMetricDBName, signozSampleTableName, commaSeparatedMetricNames,
+	)
+
+	var count float64
+	err := r.db.QueryRow(ctx, query, start, end).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't query clickhouse for ingestion errors: %w", err)
+	}
+	return count, nil
+}
+
 func isColumn(useLogsNewSchema bool, tableStatement, attrType, field, datType string) bool {
 	// value of attrType will be `resource` or `tag`, if `tag` change it to `attribute`
 	var name string
@@ -4501,6 +4566,49 @@ func (r *ClickHouseReader) GetTimeSeriesResultV3(ctx context.Context, query stri
 	return readRowsForTimeSeriesResult(rows, vars, columnNames, countOfNumberCols)
 }
 
+// EstimateQueryCost estimates the number of rows and uncompressed bytes read by the query
+// using EXPLAIN ESTIMATE. The bytes are derived from the average row size of the active parts
+// of the tables read by the query.
+func (r *ClickHouseReader) EstimateQueryCost(ctx context.Context, query string) (*v3.QueryCostEstimate, error) {
+	defer utils.Elapsed("EstimateQueryCost", map[string]interface{}{"query": query})()
+
+	type tableEstimate struct {
+		Database string `ch:"database"`
+		Table    string `ch:"table"`
+		Parts    uint64 `ch:"parts"`
+		Rows     uint64 `ch:"rows"`
+		Marks    uint64 `ch:"marks"`
+	}
+
+	var tableEstimates []tableEstimate
+	if err := r.db.Select(ctx, &tableEstimates, "EXPLAIN ESTIMATE "+query); err != nil {
+		zap.L().Error("error while estimating query cost", zap.Error(err))
+		return nil, fmt.Errorf("error while estimating query cost: %w", err)
+	}
+
+	estimate := &v3.QueryCostEstimate{}
+	for _, tableEstimate := range tableEstimates {
+		estimate.Rows += tableEstimate.Rows
+
+		var avgRowBytes []struct {
+			AvgRowBytes float64 `ch:"avg_row_bytes"`
+		}
+		err := r.db.Select(ctx, &avgRowBytes,
+			"SELECT sum(data_uncompressed_bytes) / greatest(sum(rows), 1) AS avg_row_bytes FROM system.parts WHERE active AND database = $1 AND table = $2",
+			tableEstimate.Database, tableEstimate.Table,
+		)
+		if err != nil {
+			zap.L().Error("error while getting the average row size", zap.String("table", tableEstimate.Table), zap.Error(err))
+			continue
+		}
+		if len(avgRowBytes) > 0 {
+			estimate.Bytes += uint64(float64(tableEstimate.Rows) * avgRowBytes[0].AvgRowBytes)
+		}
+	}
+
+	return estimate, nil
+}
+
 // GetListResultV3 runs the query and returns list of rows
 func (r *ClickHouseReader) GetListResultV3(ctx context.Context, query string) ([]*v3.Row, error) {
 
@@ -5127,6 +5235,97 @@ func (r *ClickHouseReader) GetLastSavedRuleStateHistory(ctx context.Context, rul
 	return history, nil
 }
 
+// recordedMetricSeriesTables are the time series tables read by the metrics
+// query builder, each keeps a row per series and bucket of the given size
+var recordedMetricSeriesTables = []struct {
+	name   string
+	bucket time.Duration
+}{
+	{signozTSTableNameV4, time.Hour},
+	{signozTSTableNameV46Hrs, 6 * time.Hour},
+	{signozTSTableNameV41Day, 24 * time.Hour},
+	{signozTSTableNameV41Week, 7 * 24 * time.Hour},
+}
+
+// WriteRecordedMetric writes the samples of a recording rule as a gauge. The
+// series are written to the time series tables of every granularity, as the
+// builder picks the table by the length of the queried time range.
+func (r *ClickHouseReader) WriteRecordedMetric(ctx context.Context, metric *model.RecordedMetric) error {
+	if len(metric.Samples) == 0 {
+		return nil
+	}
+
+	for _, table := range recordedMetricSeriesTables {
+		if err := r.writeRecordedMetricSeries(ctx, metric, table.name, table.bucket.Milliseconds()); err != nil {
+			return err
+		}
+	}
+
+	samplesStatement, err := r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (env, temporality, metric_name, fingerprint, unix_milli, value) VALUES ($1, $2, $3, $4, $5, $6)",
+		signozMetricDBName, signozSampleTableName))
+	if err != nil {
+		return err
+	}
+	defer samplesStatement.Abort()
+
+	for _, sample := range metric.Samples {
+		err = samplesStatement.Append("default", string(v3.Unspecified), metric.Name, sample.Fingerprint, sample.UnixMilli, sample.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return samplesStatement.Send()
+}
+
+// writeRecordedMetricSeries writes a row for each series of the recorded
+// metric and bucket of the table
+func (r *ClickHouseReader) writeRecordedMetricSeries(ctx context.Context, metric *model.RecordedMetric, table string, bucket int64) error {
+	statement, err := r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (env, temporality, metric_name, description, unit, type, is_monotonic, fingerprint, unix_milli, labels) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+		signozMetricDBName, table))
+	if err != nil {
+		return err
+	}
+	defer statement.Abort()
+
+	for _, series := range recordedMetricSeries(metric.Samples, bucket) {
+		err = statement.Append("default", string(v3.Unspecified), metric.Name, metric.Description, metric.Unit, string(v3.MetricTypeGauge), false, series.Fingerprint, series.UnixMilli, series.Labels)
+		if err != nil {
+			return err
+		}
+	}
+	return statement.Send()
+}
+
+// recordedMetricSeries returns a sample for each series and bucket of the
+// samples, with the start of the bucket as its time
+func recordedMetricSeries(samples []model.RecordedSample, bucket int64) []model.RecordedSample {
+	series := []model.RecordedSample{}
+	written := make(map[[2]uint64]struct{})
+	for _, sample := range samples {
+		start := sample.UnixMilli - sample.UnixMilli%bucket
+		key := [2]uint64{sample.Fingerprint, uint64(start)}
+		if _, ok := written[key]; ok {
+			continue
+		}
+		written[key] = struct{}{}
+		series = append(series, model.RecordedSample{Fingerprint: sample.Fingerprint, Labels: sample.Labels, UnixMilli: start})
+	}
+	return series
+}
+
+// GetLastRecordedSampleTime returns the timestamp of the latest sample of the
+// metric since the given time, 0 if there is none
+func (r *ClickHouseReader) GetLastRecordedSampleTime(ctx context.Context, metricName string, since int64) (int64, error) {
+	query := fmt.Sprintf("SELECT max(unix_milli) FROM %s.%s WHERE metric_name = $1 AND unix_milli >= $2",
+		signozMetricDBName, signozSampleTableName)
+
+	var last int64
+	if err := r.db.QueryRow(ctx, query, metricName, since).Scan(&last); err != nil {
+		return 0, err
+	}
+	return last, nil
+}
+
 func (r *ClickHouseReader) ReadRuleStateHistoryByRuleID(
 	ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*model.RuleStateTimeline, error) {
 
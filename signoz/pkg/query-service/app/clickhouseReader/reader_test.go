@@ -1,9 +1,13 @@
 package clickhouseReader
 
 import (
+	"context"
+	"regexp"
 	"testing"
 
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type GetStatusFiltersTest struct {
@@ -27,3 +31,41 @@ func TestGetStatusFilters(t *testing.T) {
 		assert.Equal(getStatusFilters(test.query, test.statusParams, test.excludeMap), test.expected)
 	}
 }
+
+func TestGetHistogramBuckets(t *testing.T) {
+	cols := []cmock.ColumnType{{Name: "le", Type: "String"}}
+	values := [][]interface{}{{"0.1"}, {"1"}, {"+Inf"}}
+
+	testCases := []struct {
+		name          string
+		serviceName   string
+		expectedQuery string
+		expectedArgs  []any
+	}{
+		{
+			name:          "service",
+			serviceName:   "frontend",
+			expectedQuery: "AND type = 'Histogram' AND JSONExtractString(labels, 'service_name') = $3 GROUP BY le",
+			expectedArgs:  []any{"latency_bucket", nil, "frontend"},
+		},
+		{
+			name:          "every service",
+			serviceName:   "",
+			expectedQuery: "AND type = 'Histogram' GROUP BY le",
+			expectedArgs:  []any{"latency_bucket", nil},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock, err := cmock.NewClickHouseWithQueryMatcher(nil, nil)
+			require.NoError(t, err)
+			mock.ExpectQuery(regexp.QuoteMeta(tc.expectedQuery)).WithArgs(tc.expectedArgs...).WillReturnRows(cmock.NewRows(cols, values))
+
+			r := &ClickHouseReader{db: mock}
+			buckets, err := r.GetHistogramBuckets(context.Background(), "latency_bucket", tc.serviceName)
+			require.NoError(t, err)
+			require.Equal(t, []float64{0.1, 1}, buckets)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
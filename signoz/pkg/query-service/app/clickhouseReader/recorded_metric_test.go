@@ -0,0 +1,67 @@
+package clickhouseReader
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func newRecordedMetric() *model.RecordedMetric {
+	start := time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC)
+	return &model.RecordedMetric{
+		Name: "recorded_metric",
+		Samples: []model.RecordedSample{
+			{Fingerprint: 1, Labels: `{"__name__":"recorded_metric","a":"1"}`, UnixMilli: start.UnixMilli(), Value: 1},
+			{Fingerprint: 1, Labels: `{"__name__":"recorded_metric","a":"1"}`, UnixMilli: start.Add(time.Hour).UnixMilli(), Value: 2},
+			{Fingerprint: 1, Labels: `{"__name__":"recorded_metric","a":"1"}`, UnixMilli: start.Add(7 * time.Hour).UnixMilli(), Value: 3},
+			{Fingerprint: 2, Labels: `{"__name__":"recorded_metric","a":"2"}`, UnixMilli: start.UnixMilli(), Value: 4},
+		},
+	}
+}
+
+func TestRecordedMetricSeries(t *testing.T) {
+	samples := newRecordedMetric().Samples
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		bucket   time.Duration
+		expected []int64
+	}{
+		{time.Hour, []int64{day.UnixMilli(), day.Add(time.Hour).UnixMilli(), day.Add(7 * time.Hour).UnixMilli(), day.UnixMilli()}},
+		{6 * time.Hour, []int64{day.UnixMilli(), day.Add(6 * time.Hour).UnixMilli(), day.UnixMilli()}},
+		{24 * time.Hour, []int64{day.UnixMilli(), day.UnixMilli()}},
+	}
+	for _, tc := range testCases {
+		series := recordedMetricSeries(samples, tc.bucket.Milliseconds())
+		starts := []int64{}
+		for _, s := range series {
+			starts = append(starts, s.UnixMilli)
+		}
+		require.Equal(t, tc.expected, starts, tc.bucket.String())
+	}
+}
+
+func TestWriteRecordedMetric(t *testing.T) {
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, nil)
+	require.NoError(t, err)
+
+	// the series are written to the time series table of every granularity
+	for _, table := range []string{
+		"distributed_time_series_v4",
+		"distributed_time_series_v4_6hrs",
+		"distributed_time_series_v4_1day",
+		"distributed_time_series_v4_1week",
+		"distributed_samples_v4",
+	} {
+		mock.ExpectPrepareBatch("INSERT INTO signoz_metrics." + regexp.QuoteMeta(table) + " ").ExpectSend()
+	}
+
+	r := &ClickHouseReader{db: mock}
+	require.NoError(t, r.WriteRecordedMetric(context.Background(), newRecordedMetric()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
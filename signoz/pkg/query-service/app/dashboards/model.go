@@ -93,6 +93,81 @@ func InitDB(inputDB *sqlx.DB) error {
 		return fmt.Errorf("error in creating planned_maintenance table: %s", err.Error())
 	}
 
+	tableSchema = `CREATE TABLE IF NOT EXISTS slos (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT,
+		objective TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating slos table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS provisioned_objects (
+		kind TEXT NOT NULL,
+		provision_id TEXT NOT NULL,
+		object_id TEXT NOT NULL,
+		file TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		object_checksum TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		PRIMARY KEY (kind, provision_id)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating provisioned_objects table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_alert_states (
+		rule_id TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		state TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		query_result_labels TEXT NOT NULL,
+		annotations TEXT NOT NULL,
+		generator_url TEXT NOT NULL,
+		receivers TEXT NOT NULL,
+		value REAL NOT NULL,
+		active_at INTEGER NOT NULL,
+		fired_at INTEGER NOT NULL,
+		resolved_at INTEGER NOT NULL,
+		last_sent_at INTEGER NOT NULL,
+		valid_until INTEGER NOT NULL,
+		missing BOOLEAN NOT NULL DEFAULT FALSE,
+		updated_at datetime NOT NULL,
+		PRIMARY KEY (rule_id, fingerprint)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating rule_alert_states table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_replicas (
+		id TEXT PRIMARY KEY,
+		started_at datetime NOT NULL,
+		heartbeat_at INTEGER NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating rule_replicas table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_leases (
+		task_name TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		expires_at INTEGER NOT NULL,
+		updated_at datetime NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return fmt.Errorf("error in creating rule_leases table: %s", err.Error())
+	}
+
 	table_schema = `CREATE TABLE IF NOT EXISTS ttl_status (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		transaction_id TEXT NOT NULL,
@@ -146,6 +221,12 @@ func InitDB(inputDB *sqlx.DB) error {
 		return fmt.Errorf("error in adding column locked to dashboards table: %s", err.Error())
 	}
 
+	matchers := `ALTER TABLE planned_maintenance ADD COLUMN matchers TEXT;`
+	_, err = db.Exec(matchers)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("error in adding column matchers to planned_maintenance table: %s", err.Error())
+	}
+
 	telemetry.GetInstance().SetDashboardsInfoCallback(GetDashboardsInfo)
 
 	return nil
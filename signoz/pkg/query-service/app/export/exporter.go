@@ -0,0 +1,358 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	logsV3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/postprocess"
+)
+
+// DefaultPageSize is the number of rows fetched at once when exporting list queries
+const DefaultPageSize = 10000
+
+// ErrTooManyRows is returned before any row is exported when the export has
+// more rows than the querier can paginate
+var ErrTooManyRows = errors.New("too many rows to export")
+
+// maxTracesRows is the number of traces ordered by timestamp that can be
+// exported. The querier paginates these traces with an offset capped at
+// constants.TRACE_V4_MAX_PAGINATION_LIMIT, one row is kept to detect that the
+// export has more rows than that.
+const maxTracesRows = constants.TRACE_V4_MAX_PAGINATION_LIMIT - 1
+
+// Exporter runs the queries of the query range params and returns their results
+// as rows that can be written in any of the export formats
+type Exporter struct {
+	querier  interfaces.Querier
+	pageSize uint64
+}
+
+func NewExporter(querier interfaces.Querier, pageSize uint64) *Exporter {
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	return &Exporter{
+		querier:  querier,
+		pageSize: pageSize,
+	}
+}
+
+// Rows are the exported rows of the queries. The rows of the list queries are
+// fetched page by page as they are read so that the results are not limited
+// to the page size of the query.
+type Rows struct {
+	columns []Column
+	page    [][]interface{}
+	fetch   func(ctx context.Context) ([][]interface{}, error)
+}
+
+// Columns returns the columns of the rows
+func (r *Rows) Columns() []Column {
+	return r.columns
+}
+
+// Next returns the next page of rows, it returns nil when all the rows are read
+func (r *Rows) Next(ctx context.Context) ([][]interface{}, error) {
+	if r.page != nil {
+		page := r.page
+		r.page = nil
+		return page, nil
+	}
+	if r.fetch == nil {
+		return nil, nil
+	}
+	page, err := r.fetch(ctx)
+	if err != nil || len(page) == 0 {
+		r.fetch = nil
+		return nil, err
+	}
+	return page, nil
+}
+
+// Query runs the queries and returns the rows. The first page of the list
+// queries is fetched before returning so that the query errors can be
+// reported before any row is written.
+func (e *Exporter) Query(ctx context.Context, params *v3.QueryRangeParamsV3) (*Rows, map[string]error, error) {
+	if params.CompositeQuery.PanelType == v3.PanelTypeList {
+		if name, query, ok := paginatedListQuery(params); ok {
+			return e.queryPaginatedList(ctx, params, name, query)
+		}
+		results, errQueriesByName, err := e.querier.QueryRange(ctx, params)
+		if err != nil {
+			return nil, errQueriesByName, err
+		}
+		columns := listColumns(results)
+		return &Rows{columns: columns, page: listRows(columns, results)}, nil, nil
+	}
+
+	results, errQueriesByName, err := e.querier.QueryRange(ctx, params)
+	if err != nil {
+		return nil, errQueriesByName, err
+	}
+
+	// apply the same post processing as the query range API
+	postprocess.ApplyHavingClause(results, params)
+	postprocess.ApplyMetricLimit(results, params)
+	if params.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+		postprocess.ApplyFunctions(results, params)
+		postprocess.ApplyHeatmap(results, params)
+	}
+	if params.CompositeQuery.ShouldFillGaps() {
+		postprocess.FillGaps(results, params)
+	}
+
+	columns := seriesColumns(results)
+	return &Rows{columns: columns, page: seriesRows(columns, results)}, nil, nil
+}
+
+// paginatedListQuery returns the builder query of the list params if it can be
+// fetched page by page
+func paginatedListQuery(params *v3.QueryRangeParamsV3) (string, *v3.BuilderQuery, bool) {
+	if params.CompositeQuery.QueryType != v3.QueryTypeBuilder || len(params.CompositeQuery.BuilderQueries) != 1 {
+		return "", nil, false
+	}
+	for name, query := range params.CompositeQuery.BuilderQueries {
+		if query.DataSource == v3.DataSourceLogs || query.DataSource == v3.DataSourceTraces {
+			return name, query, true
+		}
+	}
+	return "", nil, false
+}
+
+// queryPaginatedList fetches the list query page by page. The logs ordered by
+// timestamp are paginated with a cursor on the id of the last log, which does
+// not get slower as the export progresses. The other queries are paginated
+// with the offset. The traces ordered by timestamp can only be paginated up to
+// maxTracesRows rows, the larger exports are rejected before streaming starts.
+func (e *Exporter) queryPaginatedList(ctx context.Context, params *v3.QueryRangeParamsV3, name string, query *v3.BuilderQuery) (*Rows, map[string]error, error) {
+	// the limit of the logs query caps the number of exported logs, the limit
+	// of the traces query is the page size of the traces explorer
+	maxRows := uint64(0)
+	if query.DataSource == v3.DataSourceLogs {
+		maxRows = query.Limit
+	}
+	cappedTraces := query.DataSource == v3.DataSourceTraces && logsV3.IsOrderByTs(query.OrderBy)
+	if cappedTraces {
+		maxRows = maxTracesRows
+	}
+	cursorOperator := v3.FilterOperatorLessThan
+	if len(query.OrderBy) == 1 && query.OrderBy[0].Order == v3.DirectionAsc {
+		cursorOperator = v3.FilterOperatorGreaterThan
+	}
+	useCursor := query.DataSource == v3.DataSourceLogs && logsV3.IsOrderByTs(query.OrderBy)
+
+	var (
+		exported uint64
+		cursor   interface{}
+		columns  []Column
+		done     bool
+	)
+
+	fetch := func(ctx context.Context) ([][]interface{}, error) {
+		if done {
+			return nil, nil
+		}
+
+		size := e.pageSize
+		if maxRows > 0 && maxRows-exported < size {
+			size = maxRows - exported
+		}
+
+		// the querier modifies the params of the list queries, clone them for every page
+		pageParams := params.Clone()
+		pageQuery := pageParams.CompositeQuery.BuilderQueries[name]
+		if pageQuery.DataSource == v3.DataSourceLogs {
+			pageQuery.PageSize = size
+			pageQuery.Limit = 0
+		} else {
+			pageQuery.Limit = size
+		}
+		pageQuery.Offset = exported
+		if useCursor && cursor != nil {
+			if pageQuery.Filters == nil {
+				pageQuery.Filters = &v3.FilterSet{Operator: "AND"}
+			}
+			pageQuery.Filters.Items = append(pageQuery.Filters.Items, v3.FilterItem{
+				Key: v3.AttributeKey{
+					Key:      "id",
+					IsColumn: true,
+					DataType: v3.AttributeKeyDataTypeString,
+				},
+				Operator: cursorOperator,
+				Value:    cursor,
+			})
+		}
+
+		results, errQueriesByName, err := e.querier.QueryRange(ctx, pageParams)
+		if err != nil {
+			if queryErr, ok := errQueriesByName[name]; ok {
+				return nil, queryErr
+			}
+			return nil, err
+		}
+
+		var list []*v3.Row
+		for _, result := range results {
+			if result.QueryName == name {
+				list = append(list, result.List...)
+			}
+		}
+		if len(list) > 0 {
+			cursor = normalize(list[len(list)-1].Data["id"])
+		}
+		exported += uint64(len(list))
+		done = uint64(len(list)) < size || (maxRows > 0 && exported >= maxRows)
+
+		if columns == nil {
+			columns = listColumns(results)
+		}
+		return listRows(columns, []*v3.Result{{QueryName: name, List: list}}), nil
+	}
+
+	page, err := fetch(ctx)
+	if err != nil {
+		return nil, map[string]error{name: err}, err
+	}
+	if cappedTraces && (!done || exported >= maxRows) {
+		if err := e.checkTracesRows(ctx, params, name); err != nil {
+			return nil, map[string]error{name: err}, err
+		}
+	}
+	return &Rows{columns: columns, page: page, fetch: fetch}, nil, nil
+}
+
+// checkTracesRows returns ErrTooManyRows if the traces query has more than
+// maxTracesRows rows, the export would otherwise fail once the status and the
+// first pages are sent
+func (e *Exporter) checkTracesRows(ctx context.Context, params *v3.QueryRangeParamsV3, name string) error {
+	probeParams := params.Clone()
+	probeQuery := probeParams.CompositeQuery.BuilderQueries[name]
+	probeQuery.Offset = maxTracesRows
+	probeQuery.Limit = 1
+
+	results, errQueriesByName, err := e.querier.QueryRange(ctx, probeParams)
+	if err != nil {
+		if queryErr, ok := errQueriesByName[name]; ok {
+			return queryErr
+		}
+		return err
+	}
+	for _, result := range results {
+		if result.QueryName == name && len(result.List) > 0 {
+			return fmt.Errorf("%w: the export of traces ordered by timestamp is limited to %d rows, narrow down the time range or the filters", ErrTooManyRows, maxTracesRows)
+		}
+	}
+	return nil
+}
+
+// listColumns returns the timestamp column followed by the columns of the rows
+// sorted by name. The columns are taken from the first page and the values of
+// the columns missing from the later pages are null.
+func listColumns(results []*v3.Result) []Column {
+	types := map[string]ColumnType{}
+	nullColumns := map[string]bool{}
+	for _, result := range results {
+		for _, row := range result.List {
+			for key, value := range row.Data {
+				// the type of the column is inferred from its first non null value
+				if _, ok := types[key]; ok && !nullColumns[key] {
+					continue
+				}
+				types[key] = columnType(value)
+				nullColumns[key] = normalize(value) == nil
+			}
+		}
+	}
+	delete(types, constants.TIMESTAMP)
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	columns := []Column{{Name: constants.TIMESTAMP, Type: ColumnTypeInt64}}
+	for _, name := range names {
+		columns = append(columns, Column{Name: name, Type: types[name]})
+	}
+	return columns
+}
+
+func listRows(columns []Column, results []*v3.Result) [][]interface{} {
+	rows := [][]interface{}{}
+	for _, result := range results {
+		for _, row := range result.List {
+			values := make([]interface{}, len(columns))
+			// the timestamp of the row is exported in nanoseconds
+			values[0] = row.Timestamp.UnixNano()
+			for i, column := range columns[1:] {
+				values[i+1] = row.Data[column.Name]
+			}
+			rows = append(rows, values)
+		}
+	}
+	return rows
+}
+
+// seriesColumns returns the query name, timestamp and value columns followed
+// by the labels of the series sorted by name. The labels named after the
+// first columns are not exported.
+func seriesColumns(results []*v3.Result) []Column {
+	labels := map[string]struct{}{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			for key := range series.Labels {
+				labels[key] = struct{}{}
+			}
+		}
+	}
+	delete(labels, "query")
+	delete(labels, constants.TIMESTAMP)
+	delete(labels, "value")
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	columns := []Column{
+		{Name: "query", Type: ColumnTypeString},
+		{Name: constants.TIMESTAMP, Type: ColumnTypeInt64},
+		{Name: "value", Type: ColumnTypeFloat64},
+	}
+	for _, name := range names {
+		columns = append(columns, Column{Name: name, Type: ColumnTypeString})
+	}
+	return columns
+}
+
+func seriesRows(columns []Column, results []*v3.Result) [][]interface{} {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].QueryName < results[j].QueryName
+	})
+
+	rows := [][]interface{}{}
+	for _, result := range results {
+		for _, series := range result.Series {
+			for _, point := range series.Points {
+				values := make([]interface{}, len(columns))
+				values[0] = result.QueryName
+				values[1] = point.Timestamp
+				values[2] = point.Value
+				for i, column := range columns[3:] {
+					if label, ok := series.Labels[column.Name]; ok {
+						values[i+3] = label
+					}
+				}
+				rows = append(rows, values)
+			}
+		}
+	}
+	return rows
+}
@@ -0,0 +1,242 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// logsQuerier returns the logs with the ids lower than the cursor of the query, newest first
+type logsQuerier struct {
+	ids    []string
+	params []*v3.QueryRangeParamsV3
+}
+
+func (q *logsQuerier) QueryRange(_ context.Context, params *v3.QueryRangeParamsV3) ([]*v3.Result, map[string]error, error) {
+	q.params = append(q.params, params)
+	query := params.CompositeQuery.BuilderQueries["A"]
+
+	cursor := ""
+	if query.Filters != nil {
+		for _, item := range query.Filters.Items {
+			if item.Key.Key == "id" {
+				cursor = item.Value.(string)
+			}
+		}
+	}
+
+	list := []*v3.Row{}
+	for _, id := range q.ids {
+		if cursor != "" && id >= cursor {
+			continue
+		}
+		if uint64(len(list)) == query.PageSize {
+			break
+		}
+		list = append(list, &v3.Row{
+			Timestamp: time.Unix(0, 1000),
+			Data:      map[string]interface{}{"id": &id, "body": "log " + id},
+		})
+	}
+	return []*v3.Result{{QueryName: "A", List: list}}, nil, nil
+}
+
+func (q *logsQuerier) QueriesExecuted() []string { return nil }
+func (q *logsQuerier) TimeRanges() [][]int       { return nil }
+
+// tracesQuerier paginates the traces with the offset, capped like the querier
+type tracesQuerier struct {
+	count  int
+	params []*v3.QueryRangeParamsV3
+}
+
+func (q *tracesQuerier) QueryRange(_ context.Context, params *v3.QueryRangeParamsV3) ([]*v3.Result, map[string]error, error) {
+	q.params = append(q.params, params)
+	query := params.CompositeQuery.BuilderQueries["A"]
+	if query.Limit+query.Offset > constants.TRACE_V4_MAX_PAGINATION_LIMIT {
+		return nil, nil, fmt.Errorf("maximum traces that can be paginated is 10000")
+	}
+
+	list := []*v3.Row{}
+	for i := int(query.Offset); i < q.count && uint64(len(list)) < query.Limit; i++ {
+		list = append(list, &v3.Row{
+			Timestamp: time.Unix(0, 1000),
+			Data:      map[string]interface{}{"spanID": fmt.Sprintf("span%d", i)},
+		})
+	}
+	return []*v3.Result{{QueryName: "A", List: list}}, nil, nil
+}
+
+func (q *tracesQuerier) QueriesExecuted() []string { return nil }
+func (q *tracesQuerier) TimeRanges() [][]int       { return nil }
+
+type seriesQuerier struct{}
+
+func (q *seriesQuerier) QueryRange(_ context.Context, params *v3.QueryRangeParamsV3) ([]*v3.Result, map[string]error, error) {
+	return []*v3.Result{
+		{
+			QueryName: "B",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service": "frontend"}, Points: []v3.Point{{Timestamp: 60000, Value: 2}}},
+			},
+		},
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"method": "GET"}, Points: []v3.Point{{Timestamp: 60000, Value: 1}}},
+			},
+		},
+	}, nil, nil
+}
+
+func (q *seriesQuerier) QueriesExecuted() []string { return nil }
+func (q *seriesQuerier) TimeRanges() [][]int       { return nil }
+
+func logsListParams(limit uint64) *v3.QueryRangeParamsV3 {
+	return &v3.QueryRangeParamsV3{
+		Start: 0,
+		End:   60000,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeList,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:  "A",
+					DataSource: v3.DataSourceLogs,
+					Expression: "A",
+					PageSize:   2,
+					Limit:      limit,
+					OrderBy:    []v3.OrderBy{{ColumnName: "timestamp", Order: v3.DirectionDesc}},
+					Filters:    &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{}},
+				},
+			},
+		},
+	}
+}
+
+func readAll(t *testing.T, rows *Rows) [][]interface{} {
+	all := [][]interface{}{}
+	for {
+		page, err := rows.Next(context.Background())
+		require.NoError(t, err)
+		if page == nil {
+			return all
+		}
+		all = append(all, page...)
+	}
+}
+
+func TestExporterListPagination(t *testing.T) {
+	ids := []string{}
+	for i := 9; i >= 0; i-- {
+		ids = append(ids, fmt.Sprintf("id%d", i))
+	}
+
+	testCases := []struct {
+		name          string
+		limit         uint64
+		expectedRows  int
+		expectedPages int
+	}{
+		{name: "all logs beyond the page size", limit: 0, expectedRows: 10, expectedPages: 3},
+		{name: "logs capped by the limit", limit: 5, expectedRows: 5, expectedPages: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := &logsQuerier{ids: ids}
+			params := logsListParams(tc.limit)
+
+			rows, errQueriesByName, err := NewExporter(querier, 4).Query(context.Background(), params)
+			require.NoError(t, err)
+			assert.Nil(t, errQueriesByName)
+			assert.Equal(t, []Column{
+				{Name: "timestamp", Type: ColumnTypeInt64},
+				{Name: "body", Type: ColumnTypeString},
+				{Name: "id", Type: ColumnTypeString},
+			}, rows.Columns())
+
+			all := readAll(t, rows)
+			require.Len(t, all, tc.expectedRows)
+			for i, row := range all {
+				assert.Equal(t, int64(1000), row[0])
+				assert.Equal(t, ids[i], normalize(row[2]))
+			}
+			assert.Len(t, querier.params, tc.expectedPages)
+
+			// the params of the request are not modified by the pagination
+			assert.Empty(t, params.CompositeQuery.BuilderQueries["A"].Filters.Items)
+			assert.Equal(t, uint64(2), params.CompositeQuery.BuilderQueries["A"].PageSize)
+		})
+	}
+}
+
+func TestExporterTracesPagination(t *testing.T) {
+	testCases := []struct {
+		name          string
+		count         int
+		expectedErr   bool
+		expectedPages int
+	}{
+		{name: "traces within the pagination limit", count: 4500, expectedPages: 2},
+		{name: "traces at the export limit", count: maxTracesRows, expectedPages: 3},
+		{name: "traces beyond the pagination limit", count: constants.TRACE_V4_MAX_PAGINATION_LIMIT + 1, expectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			querier := &tracesQuerier{count: tc.count}
+			params := logsListParams(0)
+			params.CompositeQuery.BuilderQueries["A"].DataSource = v3.DataSourceTraces
+
+			rows, errQueriesByName, err := NewExporter(querier, 4000).Query(context.Background(), params)
+			if tc.expectedErr {
+				// the export is rejected before any row is written
+				require.ErrorIs(t, err, ErrTooManyRows)
+				assert.Contains(t, errQueriesByName, "A")
+				assert.Nil(t, rows)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, readAll(t, rows), tc.count)
+			// the pages and the probe of the rows beyond the limit
+			assert.Len(t, querier.params, tc.expectedPages+1)
+		})
+	}
+}
+
+func TestExporterSeries(t *testing.T) {
+	params := &v3.QueryRangeParamsV3{
+		Start: 0,
+		End:   60000,
+		Step:  60,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypePromQL,
+			PanelType: v3.PanelTypeGraph,
+			PromQueries: map[string]*v3.PromQuery{
+				"A": {Query: "up"},
+				"B": {Query: "up"},
+			},
+		},
+	}
+
+	rows, _, err := NewExporter(&seriesQuerier{}, 0).Query(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, []Column{
+		{Name: "query", Type: ColumnTypeString},
+		{Name: "timestamp", Type: ColumnTypeInt64},
+		{Name: "value", Type: ColumnTypeFloat64},
+		{Name: "method", Type: ColumnTypeString},
+		{Name: "service", Type: ColumnTypeString},
+	}, rows.Columns())
+	assert.Equal(t, [][]interface{}{
+		{"A", int64(60000), 1.0, "GET", nil},
+		{"B", int64(60000), 2.0, nil, "frontend"},
+	}, readAll(t, rows))
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// Format is the file format of the exported query results
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+// Validate returns an error if the format is not supported
+func (f Format) Validate() error {
+	switch f {
+	case FormatCSV, FormatNDJSON, FormatParquet:
+		return nil
+	default:
+		return fmt.Errorf("invalid export format: %s, supported formats are %s, %s and %s", f, FormatCSV, FormatNDJSON, FormatParquet)
+	}
+}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// ColumnType is the type of the values of an exported column
+type ColumnType int
+
+const (
+	ColumnTypeString ColumnType = iota
+	ColumnTypeInt64
+	ColumnTypeFloat64
+)
+
+// Column is a column of the exported rows
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Writer writes the exported rows in a file format
+type Writer interface {
+	// WriteHeader writes the columns, it must be called once before the rows are written
+	WriteHeader(columns []Column) error
+	// WriteRows writes the rows, each row has one value per column
+	WriteRows(rows [][]interface{}) error
+	// Close flushes the buffered rows and writes the trailer of the format if any
+	Close() error
+}
+
+// NewWriter returns the writer for the format
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatParquet:
+		return newParquetWriter(w), nil
+	default:
+		return nil, format.Validate()
+	}
+}
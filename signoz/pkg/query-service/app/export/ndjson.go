@@ -0,0 +1,43 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+)
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+	columns []Column
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{encoder: json.NewEncoder(w)}
+}
+
+func (n *ndjsonWriter) WriteHeader(columns []Column) error {
+	n.columns = columns
+	return nil
+}
+
+func (n *ndjsonWriter) WriteRows(rows [][]interface{}) error {
+	for _, row := range rows {
+		object := make(map[string]interface{}, len(n.columns))
+		for i, column := range n.columns {
+			v := normalize(row[i])
+			// json has no representation for NaN and infinity
+			if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				v = nil
+			}
+			object[column.Name] = v
+		}
+		if err := n.encoder.Encode(object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,345 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// The parquet writer writes the rows as uncompressed, plain encoded optional
+// columns with a single data page per column chunk. It streams one row group
+// for every parquetRowGroupSize rows so that the memory use is bounded.
+
+const (
+	parquetMagic        = "PAR1"
+	parquetRowGroupSize = 10000
+	parquetCreatedBy    = "signoz query-service"
+)
+
+// physical types, encodings and other enums of the parquet format
+const (
+	parquetTypeInt64     int32 = 2
+	parquetTypeDouble    int32 = 5
+	parquetTypeByteArray int32 = 6
+
+	parquetRepetitionOptional int32 = 1
+	parquetConvertedTypeUTF8  int32 = 0
+
+	parquetEncodingPlain int32 = 0
+	parquetEncodingRLE   int32 = 3
+
+	parquetCodecUncompressed int32 = 0
+	parquetPageTypeData      int32 = 0
+)
+
+type parquetColumnChunk struct {
+	offset           int64
+	numValues        int64
+	uncompressedSize int64
+}
+
+type parquetRowGroup struct {
+	numRows       int64
+	totalByteSize int64
+	columns       []parquetColumnChunk
+}
+
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	columns   []Column
+	rows      [][]interface{}
+	rowGroups []parquetRowGroup
+	numRows   int64
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{w: w}
+}
+
+func (p *parquetWriter) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return err
+}
+
+func (p *parquetWriter) WriteHeader(columns []Column) error {
+	p.columns = columns
+	return p.write([]byte(parquetMagic))
+}
+
+func (p *parquetWriter) WriteRows(rows [][]interface{}) error {
+	p.rows = append(p.rows, rows...)
+	for len(p.rows) >= parquetRowGroupSize {
+		if err := p.flush(parquetRowGroupSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if len(p.rows) > 0 {
+		if err := p.flush(len(p.rows)); err != nil {
+			return err
+		}
+	}
+
+	footer := p.fileMetadata()
+	if err := p.write(footer); err != nil {
+		return err
+	}
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(len(footer)))
+	if err := p.write(length); err != nil {
+		return err
+	}
+	return p.write([]byte(parquetMagic))
+}
+
+// flush writes the first n buffered rows as a row group
+func (p *parquetWriter) flush(n int) error {
+	rows := p.rows[:n]
+	rowGroup := parquetRowGroup{numRows: int64(n)}
+
+	for i, column := range p.columns {
+		page := p.dataPage(column, i, rows)
+
+		var header thriftWriter
+		header.fieldI32(1, parquetPageTypeData)
+		header.fieldI32(2, int32(len(page)))
+		header.fieldI32(3, int32(len(page)))
+		header.fieldStruct(5)
+		header.fieldI32(1, int32(n))
+		header.fieldI32(2, parquetEncodingPlain)
+		header.fieldI32(3, parquetEncodingRLE)
+		header.fieldI32(4, parquetEncodingRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunk := parquetColumnChunk{
+			offset:           p.offset,
+			numValues:        int64(n),
+			uncompressedSize: int64(header.buf.Len() + len(page)),
+		}
+		if err := p.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := p.write(page); err != nil {
+			return err
+		}
+		rowGroup.columns = append(rowGroup.columns, chunk)
+		rowGroup.totalByteSize += chunk.uncompressedSize
+	}
+
+	p.rowGroups = append(p.rowGroups, rowGroup)
+	p.numRows += int64(n)
+	p.rows = p.rows[n:]
+	return nil
+}
+
+// dataPage encodes the values of the column as the definition levels followed by the non null values
+func (p *parquetWriter) dataPage(column Column, idx int, rows [][]interface{}) []byte {
+	// the definition levels have a bit width of 1 and are bit packed in groups of 8 values
+	levels := make([]byte, (len(rows)+7)/8)
+	var values bytes.Buffer
+	for i, row := range rows {
+		if !writeParquetValue(&values, column.Type, row[idx]) {
+			continue
+		}
+		levels[i/8] |= 1 << (i % 8)
+	}
+
+	var page bytes.Buffer
+	var header [binary.MaxVarintLen64]byte
+	headerLen := binary.PutUvarint(header[:], uint64(len(levels))<<1|1)
+	binary.Write(&page, binary.LittleEndian, uint32(headerLen+len(levels)))
+	page.Write(header[:headerLen])
+	page.Write(levels)
+	page.Write(values.Bytes())
+	return page.Bytes()
+}
+
+// writeParquetValue writes the plain encoded value and returns false if the value is null
+func writeParquetValue(buf *bytes.Buffer, columnType ColumnType, v interface{}) bool {
+	switch columnType {
+	case ColumnTypeInt64:
+		i, ok := toInt64(v)
+		if !ok {
+			return false
+		}
+		binary.Write(buf, binary.LittleEndian, i)
+	case ColumnTypeFloat64:
+		f, ok := toFloat64(v)
+		if !ok {
+			return false
+		}
+		binary.Write(buf, binary.LittleEndian, math.Float64bits(f))
+	default:
+		s, ok := toString(v)
+		if !ok {
+			return false
+		}
+		binary.Write(buf, binary.LittleEndian, uint32(len(s)))
+		buf.WriteString(s)
+	}
+	return true
+}
+
+func parquetPhysicalType(columnType ColumnType) int32 {
+	switch columnType {
+	case ColumnTypeInt64:
+		return parquetTypeInt64
+	case ColumnTypeFloat64:
+		return parquetTypeDouble
+	default:
+		return parquetTypeByteArray
+	}
+}
+
+func (p *parquetWriter) fileMetadata() []byte {
+	var t thriftWriter
+	t.fieldI32(1, 1)
+
+	// the schema is a flat list with the root element first
+	t.fieldList(2, thriftTypeStruct, len(p.columns)+1)
+	t.structBegin()
+	t.fieldBinary(4, "schema")
+	t.fieldI32(5, int32(len(p.columns)))
+	t.structEnd()
+	for _, column := range p.columns {
+		t.structBegin()
+		t.fieldI32(1, parquetPhysicalType(column.Type))
+		t.fieldI32(3, parquetRepetitionOptional)
+		t.fieldBinary(4, column.Name)
+		if column.Type == ColumnTypeString {
+			t.fieldI32(6, parquetConvertedTypeUTF8)
+		}
+		t.structEnd()
+	}
+
+	t.fieldI64(3, p.numRows)
+
+	t.fieldList(4, thriftTypeStruct, len(p.rowGroups))
+	for _, rowGroup := range p.rowGroups {
+		t.structBegin()
+		t.fieldList(1, thriftTypeStruct, len(rowGroup.columns))
+		for i, chunk := range rowGroup.columns {
+			t.structBegin()
+			t.fieldI64(2, chunk.offset)
+			t.fieldStruct(3)
+			t.fieldI32(1, parquetPhysicalType(p.columns[i].Type))
+			t.fieldList(2, thriftTypeI32, 2)
+			t.i32(parquetEncodingPlain)
+			t.i32(parquetEncodingRLE)
+			t.fieldList(3, thriftTypeBinary, 1)
+			t.binary(p.columns[i].Name)
+			t.fieldI32(4, parquetCodecUncompressed)
+			t.fieldI64(5, chunk.numValues)
+			t.fieldI64(6, chunk.uncompressedSize)
+			t.fieldI64(7, chunk.uncompressedSize)
+			t.fieldI64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.fieldI64(2, rowGroup.totalByteSize)
+		t.fieldI64(3, rowGroup.numRows)
+		t.structEnd()
+	}
+
+	t.fieldBinary(6, parquetCreatedBy)
+	t.structEnd()
+	return t.buf.Bytes()
+}
+
+// thrift compact protocol types
+const (
+	thriftTypeI32    byte = 5
+	thriftTypeI64    byte = 6
+	thriftTypeBinary byte = 8
+	thriftTypeList   byte = 9
+	thriftTypeStruct byte = 12
+)
+
+// thriftWriter encodes the parquet metadata with the thrift compact protocol
+type thriftWriter struct {
+	buf         bytes.Buffer
+	lastFieldID int16
+	stack       []int16
+}
+
+func (t *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	if delta := id - t.lastFieldID; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.varint(int64(id))
+	}
+	t.lastFieldID = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func (t *thriftWriter) i32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) binary(s string) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], uint64(len(s)))
+	t.buf.Write(b[:n])
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.fieldHeader(id, thriftTypeI32)
+	t.i32(v)
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.fieldHeader(id, thriftTypeI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) fieldBinary(id int16, s string) {
+	t.fieldHeader(id, thriftTypeBinary)
+	t.binary(s)
+}
+
+func (t *thriftWriter) fieldList(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftTypeList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		var b [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(b[:], uint64(size))
+		t.buf.Write(b[:n])
+	}
+}
+
+// fieldStruct begins a struct field, it must be ended with structEnd
+func (t *thriftWriter) fieldStruct(id int16) {
+	t.fieldHeader(id, thriftTypeStruct)
+	t.structBegin()
+}
+
+// structBegin begins a struct element of a list
+func (t *thriftWriter) structBegin() {
+	t.stack = append(t.stack, t.lastFieldID)
+	t.lastFieldID = 0
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	if len(t.stack) == 0 {
+		return
+	}
+	t.lastFieldID = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
@@ -0,0 +1,129 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// normalize dereferences the pointers returned by the clickhouse scan types
+func normalize(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	return rv.Interface()
+}
+
+// columnType infers the column type from a value
+func columnType(v interface{}) ColumnType {
+	switch v := normalize(v).(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return ColumnTypeInt64
+	case float32, float64:
+		return ColumnTypeFloat64
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return ColumnTypeInt64
+		}
+		return ColumnTypeFloat64
+	default:
+		return ColumnTypeString
+	}
+}
+
+func toString(v interface{}) (string, bool) {
+	switch v := normalize(v).(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case json.Number:
+		return v.String(), true
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		// maps and arrays such as the attributes are exported as json
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v), true
+		}
+		return string(b), true
+	}
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch v := normalize(v).(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case float32:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := normalize(v).(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		if i, ok := toInt64(v); ok {
+			return float64(i), true
+		}
+		return math.NaN(), false
+	}
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testColumns = []Column{
+	{Name: "timestamp", Type: ColumnTypeInt64},
+	{Name: "body", Type: ColumnTypeString},
+	{Name: "duration", Type: ColumnTypeFloat64},
+	{Name: "attributes_string", Type: ColumnTypeString},
+}
+
+func testRows() [][]interface{} {
+	body := "GET /api/v1/users"
+	return [][]interface{}{
+		{int64(1700000000000000000), &body, 1.5, map[string]string{"method": "GET"}},
+		{uint64(1700000000000000001), "error, retrying", nil, nil},
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	_, err := NewWriter(Format("xlsx"), &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(FormatCSV, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteHeader(testColumns))
+	require.NoError(t, writer.WriteRows(testRows()))
+	require.NoError(t, writer.Close())
+
+	expected := strings.Join([]string{
+		"timestamp,body,duration,attributes_string",
+		`1700000000000000000,GET /api/v1/users,1.5,"{""method"":""GET""}"`,
+		`1700000000000000001,"error, retrying",,`,
+		"",
+	}, "\n")
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(FormatNDJSON, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteHeader(testColumns))
+	require.NoError(t, writer.WriteRows(testRows()))
+	require.NoError(t, writer.WriteRows([][]interface{}{{int64(1), "nan", math.NaN(), nil}}))
+	require.NoError(t, writer.Close())
+
+	expected := strings.Join([]string{
+		`{"attributes_string":{"method":"GET"},"body":"GET /api/v1/users","duration":1.5,"timestamp":1700000000000000000}`,
+		`{"attributes_string":null,"body":"error, retrying","duration":null,"timestamp":1700000000000000001}`,
+		`{"attributes_string":null,"body":"nan","duration":null,"timestamp":1}`,
+		"",
+	}, "\n")
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestParquetWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewWriter(FormatParquet, &buf)
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteHeader(testColumns))
+
+	// write enough rows for more than one row group
+	rows := [][]interface{}{}
+	for i := 0; i < parquetRowGroupSize/2+1; i++ {
+		rows = append(rows, testRows()...)
+	}
+	require.NoError(t, writer.WriteRows(rows))
+	require.NoError(t, writer.Close())
+
+	data := buf.Bytes()
+	assert.Equal(t, parquetMagic, string(data[:4]))
+	assert.Equal(t, parquetMagic, string(data[len(data)-4:]))
+
+	footerLength := int(binary.LittleEndian.Uint32(data[len(data)-8 : len(data)-4]))
+	footer := data[len(data)-8-footerLength : len(data)-8]
+	pw := writer.(*parquetWriter)
+	assert.Equal(t, pw.fileMetadata(), footer)
+	assert.Len(t, pw.rowGroups, 2)
+	assert.Equal(t, int64(len(rows)), pw.numRows)
+
+	// the first column chunk starts right after the magic bytes and the column chunks are contiguous
+	offset := int64(len(parquetMagic))
+	for _, rowGroup := range pw.rowGroups {
+		for _, chunk := range rowGroup.columns {
+			assert.Equal(t, offset, chunk.offset)
+			offset += chunk.uncompressedSize
+		}
+	}
+	assert.Equal(t, int64(len(data)-8-footerLength), offset)
+}
+
+func TestParquetDataPage(t *testing.T) {
+	pw := newParquetWriter(&bytes.Buffer{})
+	page := pw.dataPage(Column{Name: "duration", Type: ColumnTypeFloat64}, 2, testRows())
+
+	var expected bytes.Buffer
+	// the length of the definition levels, the bit packed run header and the levels of the two rows
+	expected.Write([]byte{2, 0, 0, 0, 3, 0b01})
+	binary.Write(&expected, binary.LittleEndian, math.Float64bits(1.5))
+	assert.Equal(t, expected.Bytes(), page)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"sort"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// populateHeatmapBuckets sets the buckets of the heatmap metrics queries that
+// don't have them to the histogram buckets of the metric, so that the buckets
+// without observations are part of the heatmap
+func (aH *APIHandler) populateHeatmapBuckets(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) {
+	if queryRangeParams.CompositeQuery.PanelType != v3.PanelTypeHeatmap {
+		return
+	}
+	for _, query := range queryRangeParams.CompositeQuery.BuilderQueries {
+		if query.DataSource != v3.DataSourceMetrics || len(query.HeatmapBuckets) > 0 {
+			continue
+		}
+		le, err := aH.reader.GetHistogramBuckets(ctx, query.AggregateAttribute.Key, heatmapServiceName(query))
+		if err != nil {
+			// the heatmap is still built from the buckets that have observations
+			zap.L().Warn("failed to get the buckets of the heatmap metric", zap.String("metric", query.AggregateAttribute.Key), zap.Error(err))
+			continue
+		}
+		buckets := append([]float64(nil), le...)
+		sort.Float64s(buckets)
+		query.HeatmapBuckets = buckets
+	}
+}
+
+// heatmapServiceName returns the service the query is filtered by, if any,
+// as the buckets of a histogram can differ between the services
+func heatmapServiceName(query *v3.BuilderQuery) string {
+	if query.Filters == nil || query.Filters.Operator == "OR" {
+		return ""
+	}
+	for _, item := range query.Filters.Items {
+		if item.Key.Key != "service_name" && item.Key.Key != "service.name" {
+			continue
+		}
+		if item.Operator != v3.FilterOperatorEqual {
+			continue
+		}
+		if serviceName, ok := item.Value.(string); ok {
+			return serviceName
+		}
+	}
+	return ""
+}
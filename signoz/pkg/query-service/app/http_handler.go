@@ -3,6 +3,7 @@ package app
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,6 +29,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/app/cloudintegrations"
 	"go.signoz.io/signoz/pkg/query-service/app/dashboards"
 	"go.signoz.io/signoz/pkg/query-service/app/explorer"
+	"go.signoz.io/signoz/pkg/query-service/app/export"
 	"go.signoz.io/signoz/pkg/query-service/app/inframetrics"
 	"go.signoz.io/signoz/pkg/query-service/app/integrations"
 	queues2 "go.signoz.io/signoz/pkg/query-service/app/integrations/messagingQueues/queues"
@@ -47,8 +49,10 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/contextlinks"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/postprocess"
+	"go.signoz.io/signoz/pkg/query-service/querycache"
 
 	"go.uber.org/zap"
 
@@ -62,6 +66,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/rules"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
 	"go.signoz.io/signoz/pkg/query-service/version"
+	"go.signoz.io/signoz/pkg/querylimit"
 )
 
 type status string
@@ -162,6 +167,9 @@ type APIHandlerOpts struct {
 	// Querier Influx Interval
 	FluxInterval time.Duration
 
+	// Per role limits on the estimated cost of the queries
+	QueryLimits querylimit.Config
+
 	// Use Logs New schema
 	UseLogsNewSchema bool
 
@@ -184,6 +192,7 @@ func NewAPIHandler(opts APIHandlerOpts) (*APIHandler, error) {
 		FeatureLookup:     opts.FeatureFlags,
 		UseLogsNewSchema:  opts.UseLogsNewSchema,
 		UseTraceNewSchema: opts.UseTraceNewSchema,
+		QueryLimits:       opts.QueryLimits,
 	}
 
 	querierOptsV2 := querierV2.QuerierOptions{
@@ -194,6 +203,7 @@ func NewAPIHandler(opts APIHandlerOpts) (*APIHandler, error) {
 		FeatureLookup:     opts.FeatureFlags,
 		UseLogsNewSchema:  opts.UseLogsNewSchema,
 		UseTraceNewSchema: opts.UseTraceNewSchema,
+		QueryLimits:       opts.QueryLimits,
 	}
 
 	querier := querier.NewQuerier(querierOpts)
@@ -383,6 +393,7 @@ func (aH *APIHandler) RegisterQueryRangeV3Routes(router *mux.Router, am *AuthMid
 		withCacheControl(AutoCompleteCacheControlAge, aH.autoCompleteAttributeValues))).Methods(http.MethodGet)
 	subRouter.HandleFunc("/query_range", am.ViewAccess(aH.QueryRangeV3)).Methods(http.MethodPost)
 	subRouter.HandleFunc("/query_range/format", am.ViewAccess(aH.QueryRangeV3Format)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/query_range/export", am.ViewAccess(aH.QueryRangeV3Export)).Methods(http.MethodPost)
 
 	subRouter.HandleFunc("/filter_suggestions", am.ViewAccess(aH.getQueryBuilderSuggestions)).Methods(http.MethodGet)
 
@@ -488,12 +499,14 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/alerts", am.ViewAccess(aH.getAlerts)).Methods(http.MethodGet)
 
 	router.HandleFunc("/api/v1/rules", am.ViewAccess(aH.listRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/ownership", am.ViewAccess(aH.getRuleOwnership)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/rules/{id}", am.ViewAccess(aH.getRule)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/rules", am.EditAccess(aH.createRule)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.editRule)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.deleteRule)).Methods(http.MethodDelete)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.patchRule)).Methods(http.MethodPatch)
 	router.HandleFunc("/api/v1/testRule", am.EditAccess(aH.testRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/testRule/backfill", am.EditAccess(aH.backfillRule)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/stats", am.ViewAccess(aH.getRuleStats)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/timeline", am.ViewAccess(aH.getRuleStateHistory)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/top_contributors", am.ViewAccess(aH.getRuleStateHistoryTopContributors)).Methods(http.MethodPost)
@@ -501,10 +514,21 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 
 	router.HandleFunc("/api/v1/downtime_schedules", am.ViewAccess(aH.listDowntimeSchedules)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.ViewAccess(aH.getDowntimeSchedule)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/downtime_schedules/{id}/windows", am.ViewAccess(aH.getDowntimeScheduleWindows)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/downtime_schedules/windows", am.ViewAccess(aH.previewDowntimeScheduleWindows)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/downtime_schedules", am.EditAccess(aH.createDowntimeSchedule)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.EditAccess(aH.editDowntimeSchedule)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.EditAccess(aH.deleteDowntimeSchedule)).Methods(http.MethodDelete)
 
+	router.HandleFunc("/api/v1/provisioning/status", am.ViewAccess(aH.getProvisioningStatus)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/slos", am.ViewAccess(aH.listSLOs)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos/{id}", am.ViewAccess(aH.getSLO)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos/{id}/budget", am.ViewAccess(aH.getSLOBudget)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/slos", am.EditAccess(aH.createSLO)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/slos/{id}", am.EditAccess(aH.editSLO)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/slos/{id}", am.EditAccess(aH.deleteSLO)).Methods(http.MethodDelete)
+
 	router.HandleFunc("/api/v1/dashboards", am.ViewAccess(aH.getDashboards)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/dashboards", am.EditAccess(aH.createDashboards)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/dashboards/{uuid}", am.ViewAccess(aH.getDashboard)).Methods(http.MethodGet)
@@ -714,6 +738,11 @@ func (aH *APIHandler) listDowntimeSchedules(w http.ResponseWriter, r *http.Reque
 		schedules = recurringSchedules
 	}
 
+	provisioned := aH.ruleManager.ProvisionedObjects(r.Context(), rules.ProvisionKindDowntime)
+	for idx := range schedules {
+		_, schedules[idx].Managed = provisioned[strconv.FormatInt(schedules[idx].Id, 10)]
+	}
+
 	aH.Respond(w, schedules)
 }
 
@@ -724,9 +753,58 @@ func (aH *APIHandler) getDowntimeSchedule(w http.ResponseWriter, r *http.Request
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
+	schedule.Managed = aH.ruleManager.IsProvisioned(r.Context(), rules.ProvisionKindDowntime, id)
 	aH.Respond(w, schedule)
 }
 
+// getDowntimeScheduleWindows lists the next windows of a schedule
+func (aH *APIHandler) getDowntimeScheduleWindows(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	schedule, err := aH.ruleManager.RuleDB().GetPlannedMaintenanceByID(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.respondDowntimeScheduleWindows(w, r, schedule)
+}
+
+// previewDowntimeScheduleWindows lists the next windows of a schedule before it is saved
+func (aH *APIHandler) previewDowntimeScheduleWindows(w http.ResponseWriter, r *http.Request) {
+	var schedule rules.PlannedMaintenance
+	err := json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	aH.respondDowntimeScheduleWindows(w, r, &schedule)
+}
+
+func (aH *APIHandler) respondDowntimeScheduleWindows(w http.ResponseWriter, r *http.Request, schedule *rules.PlannedMaintenance) {
+	count := 10
+	if countStr := r.URL.Query().Get("count"); countStr != "" {
+		var err error
+		count, err = strconv.Atoi(countStr)
+		if err != nil || count <= 0 || count > rules.MaxMaintenanceWindows {
+			RespondError(w, model.BadRequest(fmt.Errorf("count must be between 1 and %d", rules.MaxMaintenanceWindows)), nil)
+			return
+		}
+	}
+	if schedule.Schedule == nil {
+		RespondError(w, model.BadRequest(rules.ErrMissingSchedule), nil)
+		return
+	}
+	windows, err := schedule.Schedule.NextWindows(time.Now(), count)
+	if err != nil {
+		RespondError(w, model.BadRequest(err), nil)
+		return
+	}
+	aH.Respond(w, windows)
+}
+
 func (aH *APIHandler) createDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
 	var schedule rules.PlannedMaintenance
 	err := json.NewDecoder(r.Body).Decode(&schedule)
@@ -749,6 +827,9 @@ func (aH *APIHandler) createDowntimeSchedule(w http.ResponseWriter, r *http.Requ
 
 func (aH *APIHandler) editDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if aH.rejectProvisioned(w, r, rules.ProvisionKindDowntime, id) {
+		return
+	}
 	var schedule rules.PlannedMaintenance
 	err := json.NewDecoder(r.Body).Decode(&schedule)
 	if err != nil {
@@ -769,6 +850,9 @@ func (aH *APIHandler) editDowntimeSchedule(w http.ResponseWriter, r *http.Reques
 
 func (aH *APIHandler) deleteDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if aH.rejectProvisioned(w, r, rules.ProvisionKindDowntime, id) {
+		return
+	}
 	_, err := aH.ruleManager.RuleDB().DeletePlannedMaintenance(r.Context(), id)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
@@ -777,6 +861,164 @@ func (aH *APIHandler) deleteDowntimeSchedule(w http.ResponseWriter, r *http.Requ
 	aH.Respond(w, nil)
 }
 
+// rejectProvisioned responds with an error if the object is managed by the
+// provisioning files, such objects can only be changed in the files
+func (aH *APIHandler) rejectProvisioned(w http.ResponseWriter, r *http.Request, kind rules.ProvisionKind, id string) bool {
+	if !aH.ruleManager.IsProvisioned(r.Context(), kind, id) {
+		return false
+	}
+	RespondError(w, model.ForbiddenError(fmt.Errorf("%s %s is provisioned from a file and can not be changed in the API", kind, id)), nil)
+	return true
+}
+
+// getRuleOwnership returns the replica evaluating each rule
+func (aH *APIHandler) getRuleOwnership(w http.ResponseWriter, r *http.Request) {
+	ownership, err := aH.ruleManager.RuleOwnership(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, ownership)
+}
+
+func (aH *APIHandler) getProvisioningStatus(w http.ResponseWriter, r *http.Request) {
+	aH.Respond(w, aH.ruleManager.ProvisioningStatus())
+}
+
+func (aH *APIHandler) listSLOs(w http.ResponseWriter, r *http.Request) {
+	slos, err := aH.ruleManager.RuleDB().GetAllSLOs(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, slos)
+}
+
+// sloApiError returns not found for the slos missing from the db
+func sloApiError(err error) *model.ApiError {
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: err}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+func (aH *APIHandler) getSLO(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slo, err := aH.ruleManager.RuleDB().GetSLOByID(r.Context(), id)
+	if err != nil {
+		RespondError(w, sloApiError(err), nil)
+		return
+	}
+	aH.Respond(w, slo)
+}
+
+func (aH *APIHandler) createSLO(w http.ResponseWriter, r *http.Request) {
+	var slo rules.SLO
+	err := json.NewDecoder(r.Body).Decode(&slo)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := slo.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	id, err := aH.ruleManager.RuleDB().CreateSLO(r.Context(), slo)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, map[string]int64{"id": id})
+}
+
+func (aH *APIHandler) editSLO(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var slo rules.SLO
+	err := json.NewDecoder(r.Body).Decode(&slo)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := slo.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	err = aH.ruleManager.RuleDB().EditSLO(r.Context(), slo, id)
+	if err != nil {
+		RespondError(w, sloApiError(err), nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+func (aH *APIHandler) deleteSLO(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.ruleManager.RuleDB().DeleteSLO(r.Context(), id)
+	if err != nil {
+		RespondError(w, sloApiError(err), nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
+// getSLOBudget returns the sli, the remaining error budget and the burn rate of
+// the slo between start and end (epoch millis) with a point per step (seconds),
+// the response has the shape of the query range response for the dashboards
+func (aH *APIHandler) getSLOBudget(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	slo, err := aH.ruleManager.RuleDB().GetSLOByID(r.Context(), id)
+	if err != nil {
+		RespondError(w, sloApiError(err), nil)
+		return
+	}
+
+	end := time.Now()
+	var start time.Time
+	var step int64
+	if v := r.URL.Query().Get("end"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid end: %s", v)}, nil)
+			return
+		}
+		end = time.UnixMilli(ms)
+	}
+	if v := r.URL.Query().Get("start"); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid start: %s", v)}, nil)
+			return
+		}
+		start = time.UnixMilli(ms)
+	} else {
+		// the window of the slo ending at the requested end
+		start = end.Add(-time.Duration(slo.Objective.Window))
+	}
+	if v := r.URL.Query().Get("step"); v != "" {
+		step, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid step: %s", v)}, nil)
+			return
+		}
+	}
+	if !start.Before(end) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("start must be before end")}, nil)
+		return
+	}
+
+	evaluator := rules.NewSLOEvaluator(aH.querierV2, aH.reader, aH.UseTraceNewSchema)
+	result, err := evaluator.BudgetHistory(r.Context(), slo, start, end, step)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, &v3.QueryRangeResponse{
+		ResultType: "time_series",
+		Result:     result,
+	})
+}
+
 func (aH *APIHandler) getRuleStats(w http.ResponseWriter, r *http.Request) {
 	ruleID := mux.Vars(r)["id"]
 	params := model.QueryRuleStateHistory{}
@@ -1252,9 +1494,32 @@ func (aH *APIHandler) testRule(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, response)
 }
 
+// backfillRule evaluates the rule over a past range and returns the state
+// transitions without sending notifications
+func (aH *APIHandler) backfillRule(w http.ResponseWriter, r *http.Request) {
+	var req rules.BackfillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Minute)
+	defer cancel()
+
+	result, apiErr := aH.ruleManager.Backfill(ctx, &req)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, result)
+}
+
 func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
+	if aH.rejectProvisioned(w, r, rules.ProvisionKindRule, id) {
+		return
+	}
 
 	err := aH.ruleManager.DeleteRule(r.Context(), id)
 
@@ -1270,6 +1535,9 @@ func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 // patchRule updates only requested changes in the rule
 func (aH *APIHandler) patchRule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if aH.rejectProvisioned(w, r, rules.ProvisionKindRule, id) {
+		return
+	}
 
 	defer r.Body.Close()
 	body, err := io.ReadAll(r.Body)
@@ -1291,6 +1559,9 @@ func (aH *APIHandler) patchRule(w http.ResponseWriter, r *http.Request) {
 
 func (aH *APIHandler) editRule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if aH.rejectProvisioned(w, r, rules.ProvisionKindRule, id) {
+		return
+	}
 
 	defer r.Body.Close()
 	body, err := io.ReadAll(r.Body)
@@ -1318,11 +1589,15 @@ func (aH *APIHandler) getChannel(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErrorObj, nil)
 		return
 	}
+	channel.Managed = aH.ruleManager.IsProvisioned(r.Context(), rules.ProvisionKindChannel, id)
 	aH.Respond(w, channel)
 }
 
 func (aH *APIHandler) deleteChannel(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
+	if aH.rejectProvisioned(w, r, rules.ProvisionKindChannel, id) {
+		return
+	}
 	apiErrorObj := aH.ruleManager.RuleDB().DeleteChannel(id)
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
@@ -1337,6 +1612,10 @@ func (aH *APIHandler) listChannels(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErrorObj, nil)
 		return
 	}
+	provisioned := aH.ruleManager.ProvisionedObjects(r.Context(), rules.ProvisionKindChannel)
+	for idx := range *channels {
+		_, (*channels)[idx].Managed = provisioned[strconv.Itoa((*channels)[idx].Id)]
+	}
 	aH.Respond(w, channels)
 }
 
@@ -1369,6 +1648,9 @@ func (aH *APIHandler) testChannel(w http.ResponseWriter, r *http.Request) {
 func (aH *APIHandler) editChannel(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
+	if aH.rejectProvisioned(w, r, rules.ProvisionKindChannel, id) {
+		return
+	}
 
 	defer r.Body.Close()
 	body, err := io.ReadAll(r.Body)
@@ -1425,6 +1707,17 @@ func (aH *APIHandler) createChannel(w http.ResponseWriter, r *http.Request) {
 }
 
 func (aH *APIHandler) getAlerts(w http.ResponseWriter, r *http.Request) {
+	if dispatcher := aH.ruleManager.Dispatcher(); dispatcher != nil {
+		// respond in the format of the alert manager api
+		body, err := json.Marshal(map[string]interface{}{"status": "success", "data": dispatcher.Alerts()})
+		if err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+			return
+		}
+		aH.Respond(w, string(body))
+		return
+	}
+
 	params := r.URL.Query()
 	amEndpoint := constants.GetAlertManagerApiPrefix()
 	resp, err := http.Get(amEndpoint + "v1/alerts" + "?" + params.Encode())
@@ -1908,6 +2201,12 @@ func (aH *APIHandler) setTTL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the TTL types are named after the data sources they apply to
+	querycache.PublishInvalidation(querycache.InvalidationEvent{
+		DataSource: v3.DataSource(ttlParams.Type),
+		Reason:     "ttl updated",
+	})
+
 	aH.WriteJSON(w, r, result)
 
 }
@@ -4159,6 +4458,9 @@ func (aH *APIHandler) RegisterLogsRoutes(router *mux.Router, am *AuthMiddleware)
 
 	// log pipelines
 	subRouter.HandleFunc("/pipelines/preview", am.ViewAccess(aH.PreviewLogsPipelinesHandler)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/diff", am.ViewAccess(aH.DiffLogsPipelinesHandler)).Methods(http.MethodGet)
+	subRouter.HandleFunc("/pipelines/rollout/{version}/promote", am.EditAccess(aH.PromoteLogsPipelinesRollout)).Methods(http.MethodPost)
+	subRouter.HandleFunc("/pipelines/rollout/{version}/rollback", am.EditAccess(aH.RollbackLogsPipelinesRollout)).Methods(http.MethodPost)
 	subRouter.HandleFunc("/pipelines/{version}", am.ViewAccess(aH.ListLogsPipelinesHandler)).Methods(http.MethodGet)
 	subRouter.HandleFunc("/pipelines", am.EditAccess(aH.CreateLogsPipeline)).Methods(http.MethodPost)
 }
@@ -4192,6 +4494,12 @@ func (aH *APIHandler) logFieldUpdate(w http.ResponseWriter, r *http.Request) {
 		RespondError(w, apiErr, "Failed to update field in the DB")
 		return
 	}
+
+	querycache.PublishInvalidation(querycache.InvalidationEvent{
+		DataSource:    v3.DataSourceLogs,
+		AttributeKeys: []string{field.Name},
+		Reason:        fmt.Sprintf("log field %s updated", field.Name),
+	})
 	aH.WriteJSON(w, r, field)
 }
 
@@ -4408,7 +4716,7 @@ func (aH *APIHandler) CreateLogsPipeline(w http.ResponseWriter, r *http.Request)
 			return nil, validationErr
 		}
 
-		return aH.LogsParsingPipelineController.ApplyPipelines(ctx, postable)
+		return aH.LogsParsingPipelineController.ApplyPipelines(ctx, postable, req.Rollout)
 	}
 
 	res, err := createPipeline(r.Context(), req.Pipelines)
@@ -4420,6 +4728,77 @@ func (aH *APIHandler) CreateLogsPipeline(w http.ResponseWriter, r *http.Request)
 	aH.Respond(w, res)
 }
 
+func (aH *APIHandler) DiffLogsPipelinesHandler(w http.ResponseWriter, r *http.Request) {
+	versions := []int{}
+	for _, param := range []string{"from", "to"} {
+		version, err := strconv.Atoi(r.URL.Query().Get(param))
+		if err != nil || version <= 0 {
+			RespondError(w, model.BadRequest(fmt.Errorf("invalid %s version", param)), nil)
+			return
+		}
+		versions = append(versions, version)
+	}
+
+	diff, apiErr := aH.LogsParsingPipelineController.DiffPipelinesVersions(
+		r.Context(), versions[0], versions[1],
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, diff)
+}
+
+func (aH *APIHandler) PromoteLogsPipelinesRollout(w http.ResponseWriter, r *http.Request) {
+	version, apiErr := parseAgentConfigVersion(r)
+	if apiErr != nil {
+		RespondError(w, model.WrapApiError(apiErr, "Failed to parse agent config version"), nil)
+		return
+	}
+
+	apiErr = agentConf.PromoteRollout(r.Context(), logPipelines, version)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	payload, apiErr := aH.listLogsPipelinesByVersion(r.Context(), version)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
+func (aH *APIHandler) RollbackLogsPipelinesRollout(w http.ResponseWriter, r *http.Request) {
+	version, apiErr := parseAgentConfigVersion(r)
+	if apiErr != nil {
+		RespondError(w, model.WrapApiError(apiErr, "Failed to parse agent config version"), nil)
+		return
+	}
+
+	userId, err := auth.ExtractUserIdFromContext(r.Context())
+	if err != nil {
+		RespondError(w, model.UnauthorizedError(err), nil)
+		return
+	}
+
+	rollbackVersion, apiErr := agentConf.RollbackRollout(
+		r.Context(), userId, logPipelines, version, "requested by user",
+	)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	payload, apiErr := aH.listLogsPipelinesByVersion(r.Context(), rollbackVersion.Version)
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+	aH.Respond(w, payload)
+}
+
 func (aH *APIHandler) getSavedViews(w http.ResponseWriter, r *http.Request) {
 	// get sourcePage, name, and category from the query params
 	sourcePage := r.URL.Query().Get("sourcePage")
@@ -4644,31 +5023,23 @@ func (aH *APIHandler) QueryRangeV3Format(w http.ResponseWriter, r *http.Request)
 	aH.Respond(w, queryRangeParams)
 }
 
-func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
-
-	var result []*v3.Result
-	var err error
-	var errQuriesByName map[string]error
-	var spanKeys map[string]v3.AttributeKey
+// enrichQueryRangeParamsV3 adds the details of the log fields and span keys used in the builder queries
+func (aH *APIHandler) enrichQueryRangeParamsV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3) *model.ApiError {
 	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 		// check if any enrichment is required for logs if yes then enrich them
 		if logsv3.EnrichmentRequired(queryRangeParams) {
 			logsFields, err := aH.reader.GetLogFields(ctx)
 			if err != nil {
-				apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-				RespondError(w, apiErrObj, errQuriesByName)
-				return
+				return &model.ApiError{Typ: model.ErrorInternal, Err: err}
 			}
 			// get the fields if any logs query is present
 			fields := model.GetLogFieldsV3(ctx, queryRangeParams, logsFields)
 			logsv3.Enrich(queryRangeParams, fields)
 		}
 
-		spanKeys, err = aH.getSpanKeysV3(ctx, queryRangeParams)
+		spanKeys, err := aH.getSpanKeysV3(ctx, queryRangeParams)
 		if err != nil {
-			apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-			RespondError(w, apiErrObj, errQuriesByName)
-			return
+			return &model.ApiError{Typ: model.ErrorInternal, Err: err}
 		}
 		if aH.UseTraceNewSchema {
 			tracesV4.Enrich(queryRangeParams, spanKeys)
@@ -4693,6 +5064,19 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 			}
 		}
 	}
+	return nil
+}
+
+func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter, r *http.Request) {
+
+	var result []*v3.Result
+	var err error
+	var errQuriesByName map[string]error
+
+	if apiErrObj := aH.enrichQueryRangeParamsV3(ctx, queryRangeParams); apiErrObj != nil {
+		RespondError(w, apiErrObj, nil)
+		return
+	}
 
 	// Hook up query progress tracking if requested
 	queryIdHeader := r.Header.Get("X-SIGNOZ-QUERY-ID")
@@ -4723,8 +5107,7 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 		for name, err := range errQuriesByName {
 			queryErrors[fmt.Sprintf("Query-%s", name)] = err.Error()
 		}
-		apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-		RespondError(w, apiErrObj, queryErrors)
+		RespondError(w, queryRangeApiError(err), queryErrors)
 		return
 	}
 
@@ -4738,7 +5121,7 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 		postprocess.ApplyFunctions(result, queryRangeParams)
 	}
 
-	if queryRangeParams.CompositeQuery.FillGaps {
+	if queryRangeParams.CompositeQuery.ShouldFillGaps() {
 		postprocess.FillGaps(result, queryRangeParams)
 	}
 
@@ -4767,6 +5150,16 @@ func (aH *APIHandler) queryRangeV3(ctx context.Context, queryRangeParams *v3.Que
 	aH.Respond(w, resp)
 }
 
+// queryRangeApiError returns the api error for an error of the querier. The
+// queries exceeding the resource or query limits are rejected with a 4xx as
+// they need to be narrowed down by the user.
+func queryRangeApiError(err error) *model.ApiError {
+	if chErrors.IsResourceLimitError(err) {
+		return &model.ApiError{Typ: model.ErrorExec, Err: err}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
 func sendQueryResultEvents(r *http.Request, result []*v3.Result, queryRangeParams *v3.QueryRangeParamsV3) {
 	referrer := r.Header.Get("Referer")
 
@@ -4879,6 +5272,91 @@ func (aH *APIHandler) QueryRangeV3(w http.ResponseWriter, r *http.Request) {
 	aH.queryRangeV3(r.Context(), queryRangeParams, w, r)
 }
 
+// QueryRangeV3Export runs the queries of the query range params and streams the
+// results as a file in the format given by the format query param (csv, ndjson or parquet)
+func (aH *APIHandler) QueryRangeV3Export(w http.ResponseWriter, r *http.Request) {
+	format := export.FormatCSV
+	if f := r.URL.Query().Get("format"); f != "" {
+		format = export.Format(f)
+	}
+	if err := format.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	queryRangeParams, apiErrorObj := ParseQueryRangeParams(r)
+	if apiErrorObj != nil {
+		zap.L().Error("error parsing query range params for export", zap.Error(apiErrorObj.Err))
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
+
+	// add temporality for each metric
+	temporalityErr := aH.PopulateTemporality(r.Context(), queryRangeParams)
+	if temporalityErr != nil {
+		zap.L().Error("Error while adding temporality for metrics", zap.Error(temporalityErr))
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: temporalityErr}, nil)
+		return
+	}
+
+	if apiErrObj := aH.enrichQueryRangeParamsV3(r.Context(), queryRangeParams); apiErrObj != nil {
+		RespondError(w, apiErrObj, nil)
+		return
+	}
+
+	rows, errQueriesByName, err := export.NewExporter(aH.querier, export.DefaultPageSize).Query(r.Context(), queryRangeParams)
+	if err != nil {
+		queryErrors := map[string]string{}
+		for name, err := range errQueriesByName {
+			queryErrors[fmt.Sprintf("Query-%s", name)] = err.Error()
+		}
+		if errors.Is(err, export.ErrTooManyRows) {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, queryErrors)
+			return
+		}
+		RespondError(w, queryRangeApiError(err), queryErrors)
+		return
+	}
+
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"query_range.%s\"", format))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	if err := writer.WriteHeader(rows.Columns()); err != nil {
+		zap.L().Error("error writing the export header", zap.Error(err))
+		return
+	}
+	for {
+		page, err := rows.Next(r.Context())
+		if err != nil {
+			// the status is already sent, abort the response so that the client
+			// does not mistake the partial export for a complete one
+			zap.L().Error("error fetching the rows of the export", zap.Error(err))
+			panic(http.ErrAbortHandler)
+		}
+		if page == nil {
+			break
+		}
+		if err := writer.WriteRows(page); err != nil {
+			zap.L().Error("error writing the rows of the export", zap.Error(err))
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	if err := writer.Close(); err != nil {
+		zap.L().Error("error closing the export", zap.Error(err))
+	}
+}
+
 func (aH *APIHandler) GetQueryProgressUpdates(w http.ResponseWriter, r *http.Request) {
 	// Upgrade connection to websocket, sending back the requested protocol
 	// value for sec-websocket-protocol
@@ -5160,6 +5638,8 @@ func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.Que
 		} else {
 			tracesV3.Enrich(queryRangeParams, spanKeys)
 		}
+
+		aH.populateHeatmapBuckets(ctx, queryRangeParams)
 	}
 
 	// WARN: Only works for AND operator in traces query
@@ -5185,8 +5665,13 @@ func (aH *APIHandler) queryRangeV4(ctx context.Context, queryRangeParams *v3.Que
 		for name, err := range errQuriesByName {
 			queryErrors[fmt.Sprintf("Query-%s", name)] = err.Error()
 		}
-		apiErrObj := &model.ApiError{Typ: model.ErrorInternal, Err: err}
-		RespondError(w, apiErrObj, queryErrors)
+		RespondError(w, queryRangeApiError(err), queryErrors)
+		return
+	}
+
+	if streamingRequested(r, queryRangeParams) {
+		sendQueryResultEvents(r, result, queryRangeParams)
+		aH.streamQueryRangeResult(ctx, result, queryRangeParams, w)
 		return
 	}
 
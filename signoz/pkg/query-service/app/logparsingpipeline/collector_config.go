@@ -179,32 +179,29 @@ func GenerateCollectorConfigWithPipelines(
 		))
 	}
 
-	// Escape any `$`s as `$$$` in config generated for pipelines, to ensure any occurrences
-	// like $data do not end up being treated as env vars when loading collector config.
-	// otel-collector-contrib versions 0.111 and above require using $$$ as escaped dollar (and not $$)
+	// Escape any `$`s in config generated for pipelines
 	for _, procName := range signozPipelineProcNames {
-		procConf := signozPipelineProcessors[procName]
-		serializedProcConf, err := yaml.Marshal(procConf)
-		if err != nil {
-			return nil, coreModel.InternalError(fmt.Errorf(
-				"could not marshal processor config for %s: %w", procName, err,
-			))
+		escapedConf, apiErr := escapeDollarSigns(procName, signozPipelineProcessors[procName])
+		if apiErr != nil {
+			return nil, apiErr
 		}
-		escapedSerializedConf := strings.ReplaceAll(
-			string(serializedProcConf), "$", "$$$",
-		)
-
-		var escapedConf map[string]interface{}
-		err = yaml.Unmarshal([]byte(escapedSerializedConf), &escapedConf)
-		if err != nil {
-			return nil, coreModel.InternalError(fmt.Errorf(
-				"could not unmarshal dollar escaped processor config for %s: %w", procName, err,
-			))
-		}
-
 		signozPipelineProcessors[procName] = escapedConf
 	}
 
+	logsToMetricsConnectors, logsToMetricsConnectorNames, err := PrepareLogsToMetricsConnectors(pipelines)
+	if err != nil {
+		return nil, coreModel.BadRequest(errors.Wrap(
+			err, "could not prepare otel collector connectors for log to metric processors",
+		))
+	}
+	for _, connectorName := range logsToMetricsConnectorNames {
+		escapedConf, apiErr := escapeDollarSigns(connectorName, logsToMetricsConnectors[connectorName])
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		logsToMetricsConnectors[connectorName] = escapedConf
+	}
+
 	// Add processors to unmarshaled collector config `c`
 	updateProcessorConfigsInCollectorConf(collectorConf, signozPipelineProcessors)
 
@@ -222,6 +219,14 @@ func GenerateCollectorConfigWithPipelines(
 	updatedProcessorList, _ := buildCollectorPipelineProcessorsList(p.Pipelines.Logs.Processors, signozPipelineProcNames)
 	p.Pipelines.Logs.Processors = updatedProcessorList
 
+	updatedExporterList, err := updateLogsToMetricsInCollectorConf(
+		collectorConf, logsToMetricsConnectors, logsToMetricsConnectorNames, p.Pipelines.Logs.Exporters,
+	)
+	if err != nil {
+		return nil, coreModel.BadRequest(err)
+	}
+	p.Pipelines.Logs.Exporters = updatedExporterList
+
 	// add the new processor to the data ( no checks required as the keys will exists)
 	collectorConf["service"].(map[string]interface{})["pipelines"].(map[string]interface{})["logs"] = p.Pipelines.Logs
 
@@ -233,6 +238,30 @@ func GenerateCollectorConfigWithPipelines(
 	return updatedConf, nil
 }
 
+// escapeDollarSigns escapes any `$`s as `$$$` in config generated for pipelines, to ensure any occurrences
+// like $data do not end up being treated as env vars when loading collector config.
+// otel-collector-contrib versions 0.111 and above require using $$$ as escaped dollar (and not $$)
+func escapeDollarSigns(componentName string, conf interface{}) (map[string]interface{}, *coreModel.ApiError) {
+	serializedConf, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, coreModel.InternalError(fmt.Errorf(
+			"could not marshal config for %s: %w", componentName, err,
+		))
+	}
+	escapedSerializedConf := strings.ReplaceAll(
+		string(serializedConf), "$", "$$$",
+	)
+
+	var escapedConf map[string]interface{}
+	err = yaml.Unmarshal([]byte(escapedSerializedConf), &escapedConf)
+	if err != nil {
+		return nil, coreModel.InternalError(fmt.Errorf(
+			"could not unmarshal dollar escaped config for %s: %w", componentName, err,
+		))
+	}
+	return escapedConf, nil
+}
+
 func hasSignozPipelineProcessorPrefix(procName string) bool {
 	return strings.HasPrefix(procName, constants.LogsPPLPfx) || strings.HasPrefix(procName, constants.OldLogsPPLPfx)
 }
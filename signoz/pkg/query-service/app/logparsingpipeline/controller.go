@@ -14,6 +14,8 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/auth"
 	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/querycache"
 	"go.signoz.io/signoz/pkg/query-service/utils"
 	"go.uber.org/zap"
 )
@@ -23,17 +25,23 @@ type LogParsingPipelineController struct {
 	Repo
 
 	GetIntegrationPipelines func(context.Context) ([]Pipeline, *model.ApiError)
+
+	// SampleLogs fetches stored logs for previewing pipelines against.
+	// Previews can not sample logs if it is nil.
+	SampleLogs LogsSampler
 }
 
 func NewLogParsingPipelinesController(
 	db *sqlx.DB,
 	getIntegrationPipelines func(context.Context) ([]Pipeline, *model.ApiError),
+	sampleLogs LogsSampler,
 ) (*LogParsingPipelineController, error) {
 	repo := NewRepo(db)
 	err := repo.InitDB(db)
 	return &LogParsingPipelineController{
 		Repo:                    repo,
 		GetIntegrationPipelines: getIntegrationPipelines,
+		SampleLogs:              sampleLogs,
 	}, err
 }
 
@@ -43,12 +51,17 @@ type PipelinesResponse struct {
 
 	Pipelines []Pipeline                `json:"pipelines"`
 	History   []agentConf.ConfigVersion `json:"history"`
+
+	// Rollout is set for versions deployed with a staged rollout
+	Rollout *agentConf.Rollout `json:"rollout,omitempty"`
 }
 
-// ApplyPipelines stores new or changed pipelines and initiates a new config update
+// ApplyPipelines stores new or changed pipelines and initiates a new config update.
+// The new version is deployed using a staged rollout if rollout is not nil.
 func (ic *LogParsingPipelineController) ApplyPipelines(
 	ctx context.Context,
 	postable []PostablePipeline,
+	rollout *agentConf.RolloutOptions,
 ) (*PipelinesResponse, *model.ApiError) {
 	// get user id from context
 	userId, authErr := auth.ExtractUserIdFromContext(ctx)
@@ -85,11 +98,25 @@ func (ic *LogParsingPipelineController) ApplyPipelines(
 	}
 
 	// prepare config by calling gen func
-	cfg, err := agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLogPipelines, elements)
+	var cfg *agentConf.ConfigVersion
+	var err *model.ApiError
+	if rollout != nil {
+		cfg, err = agentConf.StartNewVersionWithRollout(
+			ctx, userId, agentConf.ElementTypeLogPipelines, elements, *rollout,
+		)
+	} else {
+		cfg, err = agentConf.StartNewVersion(ctx, userId, agentConf.ElementTypeLogPipelines, elements)
+	}
 	if err != nil || cfg == nil {
 		return nil, err
 	}
 
+	// the new pipelines change the shape of the ingested logs
+	querycache.PublishInvalidation(querycache.InvalidationEvent{
+		DataSource: v3.DataSourceLogs,
+		Reason:     fmt.Sprintf("log pipelines version %d deployed", cfg.Version),
+	})
+
 	return ic.GetPipelinesByVersion(ctx, cfg.Version)
 }
 
@@ -201,6 +228,7 @@ func (ic *LogParsingPipelineController) GetPipelinesByVersion(
 	}
 
 	var configVersion *agentConf.ConfigVersion
+	var rollout *agentConf.Rollout
 	if version >= 0 {
 		cv, err := agentConf.GetConfigVersion(ctx, agentConf.ElementTypeLogPipelines, version)
 		if err != nil {
@@ -208,30 +236,70 @@ func (ic *LogParsingPipelineController) GetPipelinesByVersion(
 			return nil, model.WrapApiError(err, "failed to get config for given version")
 		}
 		configVersion = cv
+
+		rollout, err = agentConf.GetRollout(ctx, agentConf.ElementTypeLogPipelines, version)
+		if err != nil && err.Type() != model.ErrorNotFound {
+			return nil, model.WrapApiError(err, "failed to get rollout for given version")
+		}
 	}
 
 	return &PipelinesResponse{
 		ConfigVersion: configVersion,
 		Pipelines:     pipelines,
+		Rollout:       rollout,
 	}, nil
 }
 
 type PipelinesPreviewRequest struct {
 	Pipelines []Pipeline        `json:"pipelines"`
 	Logs      []model.SignozLog `json:"logs"`
+
+	// Sample adds stored logs matching the filters of the pipelines to Logs.
+	Sample *PipelinesPreviewSample `json:"sample,omitempty"`
+
+	// IncludeSteps adds the state of the logs after every operator to the response.
+	IncludeSteps bool `json:"includeSteps,omitempty"`
 }
 
 type PipelinesPreviewResponse struct {
 	OutputLogs    []model.SignozLog `json:"logs"`
 	CollectorLogs []string          `json:"collectorLogs"`
+
+	// InputLogs are the logs that were previewed, including sampled ones.
+	InputLogs []model.SignozLog     `json:"inputLogs,omitempty"`
+	Steps     []PipelinePreviewStep `json:"steps,omitempty"`
 }
 
 func (ic *LogParsingPipelineController) PreviewLogsPipelines(
 	ctx context.Context,
 	request *PipelinesPreviewRequest,
 ) (*PipelinesPreviewResponse, *model.ApiError) {
+	logs := request.Logs
+	if request.Sample != nil {
+		sampledLogs, apiErr := ic.sampleLogsForPreview(ctx, request.Pipelines, request.Sample)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, "could not sample logs for preview")
+		}
+		logs = append(slices.Clone(logs), sampledLogs...)
+	}
+
+	var steps []PipelinePreviewStep
+	if request.IncludeSteps {
+		var apiErr *model.ApiError
+		steps, apiErr = SimulatePipelinesProcessingSteps(ctx, request.Pipelines, logs)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	var inputLogs []model.SignozLog
+	if request.Sample != nil {
+		// simulation annotates the logs it is given
+		inputLogs = cloneSignozLogs(logs)
+	}
+
 	result, collectorLogs, err := SimulatePipelinesProcessing(
-		ctx, request.Pipelines, request.Logs,
+		ctx, request.Pipelines, logs,
 	)
 
 	if err != nil {
@@ -241,9 +309,55 @@ func (ic *LogParsingPipelineController) PreviewLogsPipelines(
 	return &PipelinesPreviewResponse{
 		OutputLogs:    result,
 		CollectorLogs: collectorLogs,
+		InputLogs:     inputLogs,
+		Steps:         steps,
 	}, nil
 }
 
+// sampleLogsForPreview fetches the latest stored logs matching the filter of
+// every enabled pipeline, so that logs reaching later pipelines get sampled too.
+func (ic *LogParsingPipelineController) sampleLogsForPreview(
+	ctx context.Context,
+	pipelines []Pipeline,
+	sample *PipelinesPreviewSample,
+) ([]model.SignozLog, *model.ApiError) {
+	if ic.SampleLogs == nil {
+		return nil, model.BadRequest(fmt.Errorf("sampling stored logs is not supported"))
+	}
+	if err := sample.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	limit := sample.Limit
+	if limit == 0 {
+		limit = defaultPreviewSampleSize
+	}
+
+	result := []model.SignozLog{}
+	seen := map[string]bool{}
+	for _, p := range pipelines {
+		if !p.Enabled {
+			continue
+		}
+
+		logs, apiErr := ic.SampleLogs(ctx, p.Filter, sample.Start, sample.End, limit)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf(
+				"could not sample logs for pipeline %s", p.Name,
+			))
+		}
+		for _, l := range logs {
+			if seen[l.ID] {
+				continue
+			}
+			seen[l.ID] = true
+			result = append(result, l)
+		}
+	}
+
+	return result, nil
+}
+
 // Implements agentConf.AgentFeature interface.
 func (pc *LogParsingPipelineController) AgentFeatureType() agentConf.AgentFeatureType {
 	return LogPipelinesFeatureType
@@ -0,0 +1,214 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// PipelinesDiff lists the changes made to pipelines between two versions.
+// Pipelines get new ids every time they are saved, so pipelines are
+// matched across versions by their alias.
+type PipelinesDiff struct {
+	FromVersion int `json:"fromVersion"`
+	ToVersion   int `json:"toVersion"`
+
+	Added   []Pipeline     `json:"added"`
+	Removed []Pipeline     `json:"removed"`
+	Changed []PipelineDiff `json:"changed"`
+}
+
+type PipelineDiff struct {
+	Alias string `json:"alias"`
+	Name  string `json:"name"`
+
+	// Fields are the changed pipeline settings other than its operators.
+	Fields    map[string]PipelinePreviewFieldChange `json:"fields,omitempty"`
+	Operators PipelineOperatorsDiff                 `json:"operators"`
+}
+
+// PipelineOperatorsDiff lists the changes made to the operators of a
+// pipeline. Operators are matched by their id.
+type PipelineOperatorsDiff struct {
+	Added   []PipelineOperator     `json:"added,omitempty"`
+	Removed []PipelineOperator     `json:"removed,omitempty"`
+	Changed []PipelineOperatorDiff `json:"changed,omitempty"`
+}
+
+type PipelineOperatorDiff struct {
+	ID     string                                `json:"id"`
+	Name   string                                `json:"name"`
+	Fields map[string]PipelinePreviewFieldChange `json:"fields"`
+}
+
+func (d *PipelineOperatorsDiff) isEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffPipelinesVersions compares the pipelines saved in two config versions.
+func (ic *LogParsingPipelineController) DiffPipelinesVersions(
+	ctx context.Context, fromVersion int, toVersion int,
+) (*PipelinesDiff, *model.ApiError) {
+	versionPipelines := [][]Pipeline{}
+	for _, version := range []int{fromVersion, toVersion} {
+		_, apiErr := agentConf.GetConfigVersion(ctx, agentConf.ElementTypeLogPipelines, version)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf(
+				"could not get pipelines version %d", version,
+			))
+		}
+
+		pipelines, errs := ic.getPipelinesByVersion(ctx, version)
+		if errs != nil {
+			zap.L().Error("failed to get pipelines for version", zap.Int("version", version), zap.Errors("errors", errs))
+			return nil, model.InternalError(fmt.Errorf("failed to get pipelines for version %d", version))
+		}
+		versionPipelines = append(versionPipelines, pipelines)
+	}
+
+	diff, err := diffPipelines(versionPipelines[0], versionPipelines[1])
+	if err != nil {
+		return nil, model.InternalError(fmt.Errorf("could not diff pipelines: %w", err))
+	}
+	diff.FromVersion = fromVersion
+	diff.ToVersion = toVersion
+	return diff, nil
+}
+
+func diffPipelines(from []Pipeline, to []Pipeline) (*PipelinesDiff, error) {
+	diff := &PipelinesDiff{
+		Added:   []Pipeline{},
+		Removed: []Pipeline{},
+		Changed: []PipelineDiff{},
+	}
+
+	fromByAlias := map[string]Pipeline{}
+	for _, p := range from {
+		fromByAlias[p.Alias] = p
+	}
+	toAliases := map[string]struct{}{}
+
+	for _, p := range to {
+		toAliases[p.Alias] = struct{}{}
+		old, exists := fromByAlias[p.Alias]
+		if !exists {
+			diff.Added = append(diff.Added, p)
+			continue
+		}
+
+		// The ids, creators and operators of pipelines always differ
+		// across versions or get compared separately.
+		fields, err := diffJsonFields(old, p, "id", "createdBy", "createdAt", "config")
+		if err != nil {
+			return nil, err
+		}
+		operators, err := diffPipelineOperators(old.Config, p.Config)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 || !operators.isEmpty() {
+			diff.Changed = append(diff.Changed, PipelineDiff{
+				Alias:     p.Alias,
+				Name:      p.Name,
+				Fields:    fields,
+				Operators: operators,
+			})
+		}
+	}
+
+	for _, p := range from {
+		if _, exists := toAliases[p.Alias]; !exists {
+			diff.Removed = append(diff.Removed, p)
+		}
+	}
+
+	return diff, nil
+}
+
+func diffPipelineOperators(from []PipelineOperator, to []PipelineOperator) (
+	PipelineOperatorsDiff, error,
+) {
+	diff := PipelineOperatorsDiff{}
+
+	fromById := map[string]PipelineOperator{}
+	for _, op := range from {
+		fromById[op.ID] = op
+	}
+	toIds := map[string]struct{}{}
+
+	for _, op := range to {
+		toIds[op.ID] = struct{}{}
+		old, exists := fromById[op.ID]
+		if !exists {
+			diff.Added = append(diff.Added, op)
+			continue
+		}
+
+		fields, err := diffJsonFields(old, op)
+		if err != nil {
+			return diff, err
+		}
+		if len(fields) > 0 {
+			diff.Changed = append(diff.Changed, PipelineOperatorDiff{
+				ID: op.ID, Name: op.Name, Fields: fields,
+			})
+		}
+	}
+
+	for _, op := range from {
+		if _, exists := toIds[op.ID]; !exists {
+			diff.Removed = append(diff.Removed, op)
+		}
+	}
+
+	return diff, nil
+}
+
+// diffJsonFields compares the top level fields of the json
+// serializations of from and to, ignoring excludedFields.
+func diffJsonFields(from interface{}, to interface{}, excludedFields ...string) (
+	map[string]PipelinePreviewFieldChange, error,
+) {
+	fromFields, err := toJsonFields(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := toJsonFields(to)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range excludedFields {
+		delete(fromFields, f)
+		delete(toFields, f)
+	}
+
+	changes := map[string]PipelinePreviewFieldChange{}
+	for k, v := range toFields {
+		if old := fromFields[k]; !reflect.DeepEqual(old, v) {
+			changes[k] = PipelinePreviewFieldChange{Old: old, New: v}
+		}
+	}
+	for k, old := range fromFields {
+		if _, exists := toFields[k]; !exists {
+			changes[k] = PipelinePreviewFieldChange{Old: old}
+		}
+	}
+	return changes, nil
+}
+
+func toJsonFields(v interface{}) (map[string]interface{}, error) {
+	serialized, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(serialized, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,85 @@
+package logparsingpipeline
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPipelines(t *testing.T) {
+	require := require.New(t)
+
+	from := []Pipeline{
+		{
+			Id: "1", OrderId: 1, Name: "pipeline1", Alias: "pipeline1", Enabled: true,
+			Filter: getMethodFilter("GET"),
+			Config: []PipelineOperator{
+				{ID: "add", Type: "add", Name: "add", Field: "attributes.team", Value: "payments", Enabled: true},
+				{ID: "remove", Type: "remove", Name: "remove", Field: "attributes.method", Enabled: true},
+			},
+		},
+		{
+			Id: "2", OrderId: 2, Name: "pipeline2", Alias: "pipeline2", Enabled: true,
+			Filter: getMethodFilter("GET"),
+		},
+		{
+			Id: "3", OrderId: 3, Name: "pipeline3", Alias: "pipeline3", Enabled: true,
+			Filter: getMethodFilter("GET"),
+		},
+	}
+
+	// pipelines get new ids and creators every time they are saved
+	to := []Pipeline{
+		{
+			Id: "4", OrderId: 1, Name: "pipeline1", Alias: "pipeline1", Enabled: false,
+			Filter: getMethodFilter("POST"),
+			Config: []PipelineOperator{
+				{ID: "add", Type: "add", Name: "add", Field: "attributes.team", Value: "platform", Enabled: true},
+				{ID: "move", Type: "move", Name: "move", From: "attributes.a", To: "attributes.b", Enabled: true},
+			},
+			Creator: Creator{CreatedBy: "someone"},
+		},
+		{
+			Id: "5", OrderId: 2, Name: "pipeline2", Alias: "pipeline2", Enabled: true,
+			Filter: getMethodFilter("GET"),
+		},
+		{
+			Id: "6", OrderId: 3, Name: "pipeline4", Alias: "pipeline4", Enabled: true,
+			Filter: getMethodFilter("GET"),
+		},
+	}
+
+	diff, err := diffPipelines(from, to)
+	require.Nil(err)
+
+	require.Equal(1, len(diff.Added))
+	require.Equal("pipeline4", diff.Added[0].Alias)
+	require.Equal(1, len(diff.Removed))
+	require.Equal("pipeline3", diff.Removed[0].Alias)
+
+	require.Equal(1, len(diff.Changed))
+	changed := diff.Changed[0]
+	require.Equal("pipeline1", changed.Alias)
+	require.Equal([]string{"enabled", "filter"}, sortedKeys(changed.Fields))
+	require.Equal(PipelinePreviewFieldChange{Old: true, New: false}, changed.Fields["enabled"])
+
+	require.Equal(1, len(changed.Operators.Added))
+	require.Equal("move", changed.Operators.Added[0].ID)
+	require.Equal(1, len(changed.Operators.Removed))
+	require.Equal("remove", changed.Operators.Removed[0].ID)
+	require.Equal([]PipelineOperatorDiff{{
+		ID: "add", Name: "add", Fields: map[string]PipelinePreviewFieldChange{
+			"value": {Old: "payments", New: "platform"},
+		},
+	}}, changed.Operators.Changed)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
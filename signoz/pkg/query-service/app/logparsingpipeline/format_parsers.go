@@ -0,0 +1,338 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+// Parsers for common log formats. The logs pipelines processor in the
+// collector only supports a fixed set of stanza operators, so each format
+// parser is translated to a sequence of supported operators:
+// the values are extracted to temporary attributes, converted to a JSON
+// object without the empty values and parsed into parse_to by a json_parser,
+// after which the temporary attributes are removed.
+
+const (
+	keyValueParserType  = "key_value_parser"
+	csvParserType       = "csv_parser"
+	xmlParserType       = "xml_parser"
+	syslogParserType    = "syslog_parser"
+	urlParserType       = "url_parser"
+	userAgentParserType = "user_agent_parser"
+
+	syslogProtocolRFC3164 = "rfc3164"
+	syslogProtocolRFC5424 = "rfc5424"
+
+	defaultKeyValueDelimiter     = "="
+	defaultKeyValuePairDelimiter = " "
+	defaultCSVDelimiter          = ","
+
+	// temporary attributes used by the translated operators
+	parsedValuesField = "attributes.__signoz_parsed_json__"
+)
+
+var syslogRegex = map[string]string{
+	syslogProtocolRFC3164: `(?s)^<(?P<priority>\d{1,3})>(?P<timestamp>[A-Z][a-z]{2} {1,2}\d{1,2} \d{2}:\d{2}:\d{2}) (?P<hostname>\S+) (?P<appname>[^\s\[:]+)(?:\[(?P<proc_id>[^\]]+)\])?: ?(?P<message>.*)$`,
+	syslogProtocolRFC5424: `(?s)^<(?P<priority>\d{1,3})>(?P<version>\d{1,2}) (?P<timestamp>\S+) (?P<hostname>\S+) (?P<appname>\S+) (?P<proc_id>\S+) (?P<msg_id>\S+) (?P<structured_data>-|(?:\[(?:[^\]\\]|\\.)*\])+)(?: (?P<message>.*))?$`,
+}
+
+// urlRegex matches absolute urls and request paths like the ones in access logs
+const urlRegex = `^(?:(?P<scheme>[a-zA-Z][a-zA-Z0-9+.-]*)://(?:(?P<user>[^@/?#]*)@)?(?P<host>[^:/?#]*)(?::(?P<port>\d+))?)?(?P<path>/[^?#]*)?(?:\?(?P<query>[^#]*))?(?:#(?P<fragment>.*))?$`
+
+// xml elements are extracted into regex capture groups named after them
+var xmlElementNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// userAgentBrowsers are matched in order, the bots and the browsers whose user
+// agents mention other browsers come first
+var userAgentBrowsers = []struct {
+	name    string
+	token   string
+	version string
+}{
+	{"Googlebot", "Googlebot/", "Googlebot/"},
+	{"Bingbot", "bingbot/", "bingbot/"},
+	{"Edge", "Edg/", "Edg/"},
+	{"Opera", "OPR/", "OPR/"},
+	{"Samsung Internet", "SamsungBrowser/", "SamsungBrowser/"},
+	{"Firefox", "Firefox/", "Firefox/"},
+	{"Chrome", "Chrome/", "Chrome/"},
+	{"Safari", "Safari/", "Version/"},
+	{"curl", "curl/", "curl/"},
+	{"Wget", "Wget/", "Wget/"},
+	{"Python Requests", "python-requests/", "python-requests/"},
+	{"Go HTTP Client", "Go-http-client/", "Go-http-client/"},
+}
+
+var userAgentOS = []struct {
+	name   string
+	tokens []string
+}{
+	{"Windows", []string{"Windows"}},
+	{"Android", []string{"Android"}},
+	{"iOS", []string{"iPhone", "iPad"}},
+	{"macOS", []string{"Mac OS X"}},
+	{"ChromeOS", []string{"CrOS"}},
+	{"Linux", []string{"Linux"}},
+}
+
+func isFormatParser(operatorType string) bool {
+	switch operatorType {
+	case keyValueParserType, csvParserType, xmlParserType, syslogParserType, urlParserType, userAgentParserType:
+		return true
+	}
+	return false
+}
+
+// csvHeaderFields returns the column names in the header of a csv parser
+func csvHeaderFields(operator PipelineOperator) []string {
+	return strings.Split(operator.Header, csvDelimiter(operator))
+}
+
+func csvDelimiter(operator PipelineOperator) string {
+	if operator.Delimiter == "" {
+		return defaultCSVDelimiter
+	}
+	return operator.Delimiter
+}
+
+func keyValueDelimiters(operator PipelineOperator) (string, string) {
+	delimiter, pairDelimiter := operator.Delimiter, operator.PairDelimiter
+	if delimiter == "" {
+		delimiter = defaultKeyValueDelimiter
+	}
+	if pairDelimiter == "" {
+		pairDelimiter = defaultKeyValuePairDelimiter
+	}
+	return delimiter, pairDelimiter
+}
+
+// Generates the collector operators for a format parser
+func formatParserOperators(operator PipelineOperator) ([]PipelineOperator, error) {
+	parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate nil check for parseFrom: %w", err)
+	}
+	isString := fmt.Sprintf(`%s && type(%s) == "string"`, parseFromNotNilCheck, operator.ParseFrom)
+	from := operator.ParseFrom
+
+	switch operator.Type {
+	case keyValueParserType:
+		delimiter, pairDelimiter := keyValueDelimiters(operator)
+		values := fmt.Sprintf(
+			`fromPairs(map(filter(split(%s, %s), # contains %s), {[trim(split(#, %s, 2)[0]), trim(trim(split(#, %s, 2)[1]), "\"")]}))`,
+			from, exprString(pairDelimiter), exprString(delimiter), exprString(delimiter), exprString(delimiter),
+		)
+		condition := fmt.Sprintf(`%s && %s contains %s`, isString, from, exprString(delimiter))
+		return parsedValuesOperators(operator, nil, values, condition, []string{""})
+
+	case csvParserType:
+		delimiter := csvDelimiter(operator)
+		header := []string{}
+		for _, name := range csvHeaderFields(operator) {
+			header = append(header, exprString(name))
+		}
+		headerList := "[" + strings.Join(header, ", ") + "]"
+		values := fmt.Sprintf(
+			`fromPairs(map(split(%s, %s), {[%s[#index], trim(trim(#), "\"")]}))`,
+			from, exprString(delimiter), headerList,
+		)
+		condition := fmt.Sprintf(`%s && len(split(%s, %s)) == %d`, isString, from, exprString(delimiter), len(header))
+		return parsedValuesOperators(operator, nil, values, condition, []string{""})
+
+	case xmlParserType:
+		extractors := []PipelineOperator{}
+		values := []string{}
+		for i, name := range operator.Fields {
+			regex := fmt.Sprintf(`(?s)<%s(?:\s[^>]*)?>\s*(?P<%s>.*?)\s*</%s>`, name, name, name)
+			extractor, err := regexExtractor(operator, i, regex)
+			if err != nil {
+				return nil, err
+			}
+			extractors = append(extractors, extractor)
+			values = append(values, fmt.Sprintf(`%s: %s?.%s`, exprString(name), extractor.ParseTo, name))
+		}
+		return parsedValuesOperators(
+			operator, extractors, "{"+strings.Join(values, ", ")+"}", parseFromNotNilCheck, []string{""},
+		)
+
+	case syslogParserType:
+		extractor, err := regexExtractor(operator, 0, syslogRegex[operator.Protocol])
+		if err != nil {
+			return nil, err
+		}
+		priority := extractor.ParseTo + ".priority"
+		values := regexValues(extractor, map[string]string{
+			"priority": fmt.Sprintf("int(%s)", priority),
+			"facility": fmt.Sprintf("int(int(%s) / 8)", priority),
+			"severity": fmt.Sprintf("int(%s) %% 8", priority),
+		})
+		condition := fmt.Sprintf("%s != nil", extractor.ParseTo)
+		// rfc5424 uses - for the fields without a value
+		return parsedValuesOperators(operator, []PipelineOperator{extractor}, values, condition, []string{"", "-"})
+
+	case urlParserType:
+		extractor, err := regexExtractor(operator, 0, urlRegex)
+		if err != nil {
+			return nil, err
+		}
+		port := extractor.ParseTo + ".port"
+		values := regexValues(extractor, map[string]string{
+			"port": fmt.Sprintf(`%s == "" ? nil : int(%s)`, port, port),
+		})
+		condition := fmt.Sprintf("%s != nil", extractor.ParseTo)
+		return parsedValuesOperators(operator, []PipelineOperator{extractor}, values, condition, []string{""})
+
+	case userAgentParserType:
+		browser, version := []string{}, []string{}
+		for _, b := range userAgentBrowsers {
+			browser = append(browser, fmt.Sprintf(`%s contains %s ? %s`, from, exprString(b.token), exprString(b.name)))
+			version = append(version, fmt.Sprintf(
+				`%s contains %s && %s contains %s ? trim(split(split(%s, %s)[1], " ")[0], ";)")`,
+				from, exprString(b.token), from, exprString(b.version), from, exprString(b.version),
+			))
+		}
+		os := []string{}
+		for _, o := range userAgentOS {
+			matches := []string{}
+			for _, token := range o.tokens {
+				matches = append(matches, fmt.Sprintf(`%s contains %s`, from, exprString(token)))
+			}
+			os = append(os, fmt.Sprintf(`(%s) ? %s`, strings.Join(matches, " || "), exprString(o.name)))
+		}
+		device := fmt.Sprintf(
+			`(lower(%s) contains "bot" || lower(%s) contains "spider") ? "bot" : %s contains "iPad" ? "tablet" : (%s contains "Mobi" || %s contains "iPhone" || %s contains "Android") ? "mobile" : (%s contains "Windows" || %s contains "Macintosh" || %s contains "X11" || %s contains "CrOS") ? "desktop" : nil`,
+			from, from, from, from, from, from, from, from, from, from,
+		)
+		values := fmt.Sprintf(
+			`{"user_agent.name": %s : nil, "user_agent.version": %s : nil, "user_agent.os.name": %s : nil, "user_agent.device.type": %s}`,
+			strings.Join(browser, " : "), strings.Join(version, " : "), strings.Join(os, " : "), device,
+		)
+		return parsedValuesOperators(operator, nil, values, isString, []string{""})
+	}
+
+	return nil, fmt.Errorf("unsupported format parser %s", operator.Type)
+}
+
+// regexExtractor returns a regex parser extracting the values of the format
+// parser into a temporary attribute
+func regexExtractor(operator PipelineOperator, idx int, regex string) (PipelineOperator, error) {
+	id := operator.ID
+	if idx > 0 {
+		id = fmt.Sprintf("%s-extract-%d", operator.ID, idx)
+	}
+	extractor := PipelineOperator{
+		ID:        id,
+		Type:      "regex_parser",
+		OnError:   operator.OnError,
+		ParseFrom: operator.ParseFrom,
+		ParseTo:   fmt.Sprintf("attributes.__signoz_parsed_%d__", idx),
+		Regex:     regex,
+	}
+	condition, err := regexParserCondition(extractor.ParseFrom, extractor.Regex)
+	if err != nil {
+		return PipelineOperator{}, err
+	}
+	extractor.If = condition
+	return extractor, nil
+}
+
+// regexValues returns a map expression with the capture groups of the regex
+// extractor. The expressions in overrides replace the named groups or add
+// derived values.
+func regexValues(extractor PipelineOperator, overrides map[string]string) string {
+	values := []string{}
+	for _, name := range regexp.MustCompile(extractor.Regex).SubexpNames() {
+		if _, ok := overrides[name]; name == "" || ok {
+			continue
+		}
+		values = append(values, fmt.Sprintf("%s: %s.%s", exprString(name), extractor.ParseTo, name))
+	}
+	names := maps.Keys(overrides)
+	slices.Sort(names)
+	for _, name := range names {
+		values = append(values, fmt.Sprintf("%s: %s", exprString(name), overrides[name]))
+	}
+	return "{" + strings.Join(values, ", ") + "}"
+}
+
+// parsedValuesOperators returns the extractors followed by the operators that
+// parse the values map expression into parse_to and remove the temporary
+// attributes. Values in emptyValues are left out.
+func parsedValuesOperators(
+	operator PipelineOperator, extractors []PipelineOperator, values string, condition string, emptyValues []string,
+) ([]PipelineOperator, error) {
+	keep := []string{"#[1] != nil"}
+	for _, v := range emptyValues {
+		keep = append(keep, fmt.Sprintf("#[1] != %s", exprString(v)))
+	}
+
+	parseTo := operator.ParseTo
+	if parseTo == "" {
+		parseTo = "attributes"
+	}
+
+	parsedValuesNotNilCheck, err := fieldNotNilCheck(parsedValuesField)
+	if err != nil {
+		return nil, err
+	}
+
+	id := func(suffix string) string {
+		if len(extractors) == 0 && suffix == "values" {
+			return operator.ID
+		}
+		return fmt.Sprintf("%s-%s", operator.ID, suffix)
+	}
+
+	operators := append([]PipelineOperator{}, extractors...)
+	operators = append(operators,
+		PipelineOperator{
+			ID:      id("values"),
+			Type:    "add",
+			OnError: operator.OnError,
+			Field:   parsedValuesField,
+			Value: fmt.Sprintf(
+				"EXPR(toJSON(fromPairs(filter(toPairs(%s), %s))))", values, strings.Join(keep, " && "),
+			),
+			If: condition,
+		},
+		PipelineOperator{
+			ID:        id("parse"),
+			Type:      "json_parser",
+			OnError:   operator.OnError,
+			ParseFrom: parsedValuesField,
+			ParseTo:   parseTo,
+			If:        parsedValuesNotNilCheck,
+		},
+		PipelineOperator{
+			ID:      id("cleanup"),
+			Type:    "remove",
+			OnError: operator.OnError,
+			Field:   parsedValuesField,
+			If:      parsedValuesNotNilCheck,
+		},
+	)
+	for i, extractor := range extractors {
+		notNilCheck, err := fieldNotNilCheck(extractor.ParseTo)
+		if err != nil {
+			return nil, err
+		}
+		operators = append(operators, PipelineOperator{
+			ID:      fmt.Sprintf("%s-cleanup-%d", operator.ID, i),
+			Type:    "remove",
+			OnError: operator.OnError,
+			Field:   extractor.ParseTo,
+			If:      notNilCheck,
+		})
+	}
+	return operators, nil
+}
+
+// exprString quotes s as a string literal in an expr expression
+func exprString(s string) string {
+	return strconv.Quote(s)
+}
@@ -0,0 +1,292 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestFormatParsingProcessors(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		ParserOp   string
+		Body       string
+		Attributes map[string]interface{}
+		// expected attributes after processing, nil if the log should be unchanged
+		Expected        map[string]string
+		ExpectedNumbers map[string]float64
+	}{
+		{
+			Name:     "key value",
+			ParserOp: `{"type": "key_value_parser", "parse_from": "body", "parse_to": "attributes"}`,
+			Body:     `level=info method=GET status=200 path="/api/v1" empty=`,
+			Expected: map[string]string{
+				"level": "info", "method": "GET", "status": "200", "path": "/api/v1",
+			},
+		},
+		{
+			Name:     "key value with custom delimiters",
+			ParserOp: `{"type": "key_value_parser", "parse_from": "attributes.kv", "delimiter": ":", "pair_delimiter": ";"}`,
+			Attributes: map[string]interface{}{
+				"kv": "user: alice; team: checkout",
+			},
+			Expected: map[string]string{"kv": "user: alice; team: checkout", "user": "alice", "team": "checkout"},
+		},
+		{
+			Name:     "key value without pairs",
+			ParserOp: `{"type": "key_value_parser", "parse_from": "body"}`,
+			Body:     "a plain text log",
+		},
+		{
+			Name:     "csv",
+			ParserOp: `{"type": "csv_parser", "parse_from": "body", "header": "ts,user,action"}`,
+			Body:     `2024-01-01T10:00:00Z,"alice",login`,
+			Expected: map[string]string{"ts": "2024-01-01T10:00:00Z", "user": "alice", "action": "login"},
+		},
+		{
+			Name:     "csv with mismatched columns",
+			ParserOp: `{"type": "csv_parser", "parse_from": "body", "header": "ts,user,action"}`,
+			Body:     `2024-01-01T10:00:00Z,alice`,
+		},
+		{
+			Name:     "xml",
+			ParserOp: `{"type": "xml_parser", "parse_from": "body", "fields": ["order_id", "status", "missing"]}`,
+			Body:     `<event><order_id type="int"> 42 </order_id><status>shipped</status></event>`,
+			Expected: map[string]string{"order_id": "42", "status": "shipped"},
+		},
+		{
+			Name:     "syslog rfc3164",
+			ParserOp: `{"type": "syslog_parser", "parse_from": "body", "protocol": "rfc3164"}`,
+			Body:     `<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8`,
+			Expected: map[string]string{
+				"timestamp": "Oct 11 22:14:15", "hostname": "mymachine", "appname": "su", "proc_id": "230",
+				"message": "'su root' failed for lonvick on /dev/pts/8",
+			},
+			ExpectedNumbers: map[string]float64{"priority": 34, "facility": 4, "severity": 2},
+		},
+		{
+			Name:     "syslog rfc5424",
+			ParserOp: `{"type": "syslog_parser", "parse_from": "body", "protocol": "rfc5424"}`,
+			Body:     `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event`,
+			Expected: map[string]string{
+				"version": "1", "timestamp": "2003-10-11T22:14:15.003Z", "hostname": "mymachine.example.com",
+				"appname": "evntslog", "msg_id": "ID47", "structured_data": `[exampleSDID@32473 iut="3"]`,
+				"message": "An application event",
+			},
+			ExpectedNumbers: map[string]float64{"priority": 165, "facility": 20, "severity": 5},
+		},
+		{
+			Name:     "syslog mismatch",
+			ParserOp: `{"type": "syslog_parser", "parse_from": "body", "protocol": "rfc5424"}`,
+			Body:     "not a syslog message",
+		},
+		{
+			Name:     "url",
+			ParserOp: `{"type": "url_parser", "parse_from": "attributes.url"}`,
+			Attributes: map[string]interface{}{
+				"url": "https://shop.example.com:8443/cart/items?id=7&ref=home#top",
+			},
+			Expected: map[string]string{
+				"url":    "https://shop.example.com:8443/cart/items?id=7&ref=home#top",
+				"scheme": "https", "host": "shop.example.com", "path": "/cart/items", "query": "id=7&ref=home",
+				"fragment": "top",
+			},
+			ExpectedNumbers: map[string]float64{"port": 8443},
+		},
+		{
+			Name:     "url request path",
+			ParserOp: `{"type": "url_parser", "parse_from": "attributes.url"}`,
+			Attributes: map[string]interface{}{
+				"url": "/api/v1/logs?limit=10",
+			},
+			Expected: map[string]string{"url": "/api/v1/logs?limit=10", "path": "/api/v1/logs", "query": "limit=10"},
+		},
+		{
+			Name:     "user agent",
+			ParserOp: `{"type": "user_agent_parser", "parse_from": "attributes.user_agent"}`,
+			Attributes: map[string]interface{}{
+				"user_agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36",
+			},
+			Expected: map[string]string{
+				"user_agent":             "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36",
+				"user_agent.name":        "Chrome",
+				"user_agent.version":     "120.0.6099.109",
+				"user_agent.os.name":     "Windows",
+				"user_agent.device.type": "desktop",
+			},
+		},
+		{
+			Name:     "mobile user agent",
+			ParserOp: `{"type": "user_agent_parser", "parse_from": "attributes.user_agent"}`,
+			Attributes: map[string]interface{}{
+				"user_agent": "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			},
+			Expected: map[string]string{
+				"user_agent":             "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+				"user_agent.name":        "Safari",
+				"user_agent.version":     "17.1",
+				"user_agent.os.name":     "iOS",
+				"user_agent.device.type": "mobile",
+			},
+		},
+		{
+			Name:     "missing parse from",
+			ParserOp: `{"type": "user_agent_parser", "parse_from": "attributes.user_agent"}`,
+			Body:     "request served",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			var parserOp PipelineOperator
+			require.Nil(json.Unmarshal([]byte(testCase.ParserOp), &parserOp))
+			parserOp.ID = "test-format-parser"
+			parserOp.Name = "Test format parser"
+			parserOp.OrderId = 1
+			parserOp.Enabled = true
+
+			postable := PostablePipeline{
+				OrderId: 1,
+				Name:    "pipeline1",
+				Alias:   "pipeline1",
+				Enabled: true,
+				Filter: &v3.FilterSet{
+					Operator: "AND",
+					Items: []v3.FilterItem{
+						{
+							Key: v3.AttributeKey{
+								Key:      "method",
+								DataType: v3.AttributeKeyDataTypeString,
+								Type:     v3.AttributeKeyTypeTag,
+							},
+							Operator: "=",
+							Value:    "GET",
+						},
+					},
+				},
+				Config: []PipelineOperator{parserOp},
+			}
+			require.Nil(postable.IsValid())
+
+			attributes := map[string]interface{}{"method": "GET"}
+			for k, v := range testCase.Attributes {
+				attributes[k] = v
+			}
+
+			result, collectorWarnAndErrorLogs, err := SimulatePipelinesProcessing(
+				context.Background(),
+				[]Pipeline{{
+					OrderId: postable.OrderId,
+					Name:    postable.Name,
+					Alias:   postable.Alias,
+					Enabled: postable.Enabled,
+					Filter:  postable.Filter,
+					Config:  postable.Config,
+				}},
+				[]model.SignozLog{makeTestSignozLog(testCase.Body, attributes)},
+			)
+			require.Nil(err)
+			require.Equal(0, len(collectorWarnAndErrorLogs), strings.Join(collectorWarnAndErrorLogs, "\n"))
+			require.Equal(1, len(result))
+			processed := result[0]
+
+			expected := map[string]string{"method": "GET"}
+			for k, v := range testCase.Expected {
+				expected[k] = v
+			}
+			for k, v := range testCase.Attributes {
+				if _, ok := expected[k]; !ok {
+					expected[k] = v.(string)
+				}
+			}
+			require.Equal(expected, processed.Attributes_string)
+
+			expectedNumbers := map[string]float64{}
+			for k, v := range testCase.ExpectedNumbers {
+				expectedNumbers[k] = v
+			}
+			require.Equal(expectedNumbers, processed.Attributes_float64)
+			require.Equal(testCase.Body, processed.Body)
+		})
+	}
+}
+
+func TestFormatParserValidation(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		ParserOp string
+		IsValid  bool
+	}{
+		{"key value", `{"type": "key_value_parser", "parse_from": "body"}`, true},
+		{"key value without parse from", `{"type": "key_value_parser"}`, false},
+		{"key value with same delimiters", `{"type": "key_value_parser", "parse_from": "body", "delimiter": ",", "pair_delimiter": ","}`, false},
+		{"csv", `{"type": "csv_parser", "parse_from": "body", "header": "a,b"}`, true},
+		{"csv without header", `{"type": "csv_parser", "parse_from": "body"}`, false},
+		{"csv with duplicate columns", `{"type": "csv_parser", "parse_from": "body", "header": "a,a"}`, false},
+		{"xml", `{"type": "xml_parser", "parse_from": "body", "fields": ["id"]}`, true},
+		{"xml without fields", `{"type": "xml_parser", "parse_from": "body"}`, false},
+		{"xml with invalid element", `{"type": "xml_parser", "parse_from": "body", "fields": ["a-b"]}`, false},
+		{"syslog", `{"type": "syslog_parser", "parse_from": "body", "protocol": "rfc5424"}`, true},
+		{"syslog with unknown protocol", `{"type": "syslog_parser", "parse_from": "body", "protocol": "rfc1"}`, false},
+		{"url", `{"type": "url_parser", "parse_from": "attributes.url"}`, true},
+		{"url with invalid parse from", `{"type": "url_parser", "parse_from": "url"}`, false},
+		{"user agent", `{"type": "user_agent_parser", "parse_from": "attributes.user_agent", "parse_to": "attributes"}`, true},
+		{"user agent without parse from", `{"type": "user_agent_parser"}`, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			var op PipelineOperator
+			require.Nil(t, json.Unmarshal([]byte(testCase.ParserOp), &op))
+			op.ID = "test"
+			op.Enabled = true
+
+			err := isValidOperator(op)
+			if testCase.IsValid {
+				require.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestFormatParserOperatorsChaining(t *testing.T) {
+	require := require.New(t)
+
+	ops := []PipelineOperator{
+		{ID: "before", Type: "add", Field: "attributes.a", Value: "b", Enabled: true},
+		{ID: "xml", Type: "xml_parser", ParseFrom: "body", Fields: []string{"id", "status"}, Enabled: true},
+		{ID: "after", Type: "remove", Field: "attributes.a", Enabled: true},
+	}
+	operators, err := getOperators(ops)
+	require.Nil(err)
+
+	ids := []string{}
+	for i, op := range operators {
+		ids = append(ids, op.ID)
+		if strings.HasPrefix(op.ID, "xml") {
+			require.NotEmpty(op.If, op.ID)
+		}
+		if i < len(operators)-1 {
+			require.Equal(operators[i+1].ID, op.Output)
+		}
+	}
+	require.Equal([]string{
+		"before", "xml", "xml-extract-1", "xml-values", "xml-parse", "xml-cleanup", "xml-cleanup-0", "xml-cleanup-1", "after",
+	}, ids)
+	require.Equal("", operators[len(operators)-1].Output)
+
+	// the format parser fields are not part of the generated config
+	for _, op := range operators {
+		require.Empty(op.Header)
+		require.Empty(op.Protocol)
+	}
+}
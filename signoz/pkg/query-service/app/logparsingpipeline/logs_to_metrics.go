@@ -0,0 +1,447 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// A log_to_metric operator turns the logs matching its pipeline filter into a
+// counter or a histogram of a numeric attribute, labeled with attributes of the logs.
+//
+// The collector's logs processors can't emit metrics, so the operators are
+// deployed as count and sum connectors exporting to a metrics pipeline with the
+// exporters of the collector's metrics pipeline. The connectors see the logs
+// after all pipelines have processed them, so attributes parsed by any
+// pipeline can be used as labels. Histograms are generated as cumulative
+// <name>_bucket counters labeled with le, <name>_count and <name>_sum.
+
+const (
+	logToMetricOperatorType = "log_to_metric"
+
+	counterMetricType   = "counter"
+	histogramMetricType = "histogram"
+
+	histogramBucketLabel = "le"
+
+	// pipeline of the generated metrics in service.pipelines
+	logsToMetricsPipelineName = "metrics/" + constants.LogsToMetricsPfx
+)
+
+// defaultHistogramBuckets are the bucket bounds used by the otel sdks
+var defaultHistogramBuckets = []float64{0, 5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000}
+
+var ottlComparisonOperators = map[v3.FilterOperator]string{
+	v3.FilterOperatorEqual:           "==",
+	v3.FilterOperatorNotEqual:        "!=",
+	v3.FilterOperatorLessThan:        "<",
+	v3.FilterOperatorLessThanOrEq:    "<=",
+	v3.FilterOperatorGreaterThan:     ">",
+	v3.FilterOperatorGreaterThanOrEq: ">=",
+}
+
+var metricNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+
+// ottlLogFields are the top level log fields that can be used in pipeline filters
+var ottlLogFields = map[string]string{
+	"body":            "body",
+	"severity_text":   "severity_text",
+	"severity_number": "severity_number",
+	"trace_id":        "trace_id.string",
+	"span_id":         "span_id.string",
+	"trace_flags":     "flags",
+}
+
+func isLogToMetricOperator(operatorType string) bool {
+	return operatorType == logToMetricOperatorType
+}
+
+// logAttributeKey returns the key of an attribute path like attributes.key or attributes["key"]
+func logAttributeKey(path string) (string, error) {
+	if strings.HasPrefix(path, `attributes["`) && strings.HasSuffix(path, `"]`) {
+		return strings.TrimSuffix(strings.TrimPrefix(path, `attributes["`), `"]`), nil
+	}
+	if key := strings.TrimPrefix(path, "attributes."); key != path && key != "" {
+		return key, nil
+	}
+	return "", fmt.Errorf("%s is not a log attribute", path)
+}
+
+func validateLogToMetricOperator(op PipelineOperator) error {
+	if !metricNameRegex.MatchString(op.MetricName) {
+		return fmt.Errorf(
+			"invalid metric name '%s' of log to metric processor %s, it should match %s",
+			op.MetricName, op.ID, metricNameRegex.String(),
+		)
+	}
+
+	switch op.MetricType {
+	case counterMetricType:
+	case histogramMetricType:
+		if op.Field == "" {
+			return fmt.Errorf("field of histogram log to metric processor %s cannot be empty", op.ID)
+		}
+		if _, err := logAttributeKey(op.Field); err != nil {
+			return fmt.Errorf("invalid field of log to metric processor %s: %w", op.ID, err)
+		}
+		for i := 1; i < len(op.Buckets); i++ {
+			if op.Buckets[i] <= op.Buckets[i-1] {
+				return fmt.Errorf("buckets of log to metric processor %s should be increasing", op.ID)
+			}
+		}
+	default:
+		return fmt.Errorf(
+			"invalid metric type '%s' of log to metric processor %s, use one of (%s, %s)",
+			op.MetricType, op.ID, counterMetricType, histogramMetricType,
+		)
+	}
+
+	labels := map[string]struct{}{}
+	for _, label := range op.Labels {
+		key, err := logAttributeKey(label)
+		if err != nil {
+			return fmt.Errorf("invalid label of log to metric processor %s: %w", op.ID, err)
+		}
+		if key == histogramBucketLabel && op.MetricType == histogramMetricType {
+			return fmt.Errorf("label %s of log to metric processor %s is used for histogram buckets", key, op.ID)
+		}
+		if _, ok := labels[key]; ok {
+			return fmt.Errorf("duplicate label %s in log to metric processor %s", key, op.ID)
+		}
+		labels[key] = struct{}{}
+	}
+	return nil
+}
+
+// filterToOTTL translates a pipeline filter to an OTTL condition for logs,
+// matching the logs selected by the expression generated for the pipeline router.
+func filterToOTTL(filter *v3.FilterSet) (string, error) {
+	if filter == nil || len(filter.Items) == 0 {
+		return "true", nil
+	}
+
+	conditions := []string{}
+	for _, item := range filter.Items {
+		var name string
+		switch item.Key.Type {
+		case v3.AttributeKeyTypeTag:
+			name = fmt.Sprintf("attributes[%s]", ottlString(item.Key.Key))
+		case v3.AttributeKeyTypeResource:
+			name = fmt.Sprintf("resource.attributes[%s]", ottlString(item.Key.Key))
+		default:
+			field, ok := ottlLogFields[item.Key.Key]
+			if !ok {
+				return "", fmt.Errorf("filter key %s is not supported for log to metric processors", item.Key.Key)
+			}
+			name = field
+		}
+
+		var condition string
+		switch item.Operator {
+		case v3.FilterOperatorEqual, v3.FilterOperatorNotEqual,
+			v3.FilterOperatorLessThan, v3.FilterOperatorLessThanOrEq,
+			v3.FilterOperatorGreaterThan, v3.FilterOperatorGreaterThanOrEq:
+			value, err := ottlValue(item.Value)
+			if err != nil {
+				return "", err
+			}
+			condition = fmt.Sprintf("%s %s %s", name, ottlComparisonOperators[item.Operator], value)
+		case v3.FilterOperatorContains, v3.FilterOperatorNotContains:
+			// case insensitive like the pipeline filters
+			pattern := ottlString("(?i)" + regexp.QuoteMeta(fmt.Sprint(item.Value)))
+			condition = fmt.Sprintf("IsMatch(%s, %s)", name, pattern)
+			if item.Operator == v3.FilterOperatorNotContains {
+				condition = fmt.Sprintf("%s != nil and not %s", name, condition)
+			}
+		case v3.FilterOperatorRegex, v3.FilterOperatorNotRegex:
+			condition = fmt.Sprintf("IsMatch(%s, %s)", name, ottlString(fmt.Sprint(item.Value)))
+			if item.Operator == v3.FilterOperatorNotRegex {
+				condition = fmt.Sprintf("%s != nil and not %s", name, condition)
+			}
+		case v3.FilterOperatorIn, v3.FilterOperatorNotIn:
+			values, ok := item.Value.([]interface{})
+			if !ok {
+				values = []interface{}{item.Value}
+			}
+			comparisons := []string{}
+			for _, v := range values {
+				value, err := ottlValue(v)
+				if err != nil {
+					return "", err
+				}
+				if item.Operator == v3.FilterOperatorIn {
+					comparisons = append(comparisons, fmt.Sprintf("%s == %s", name, value))
+				} else {
+					comparisons = append(comparisons, fmt.Sprintf("%s != %s", name, value))
+				}
+			}
+			if item.Operator == v3.FilterOperatorIn {
+				condition = "false"
+				if len(comparisons) > 0 {
+					condition = strings.Join(comparisons, " or ")
+				}
+			} else {
+				condition = strings.Join(append([]string{fmt.Sprintf("%s != nil", name)}, comparisons...), " and ")
+			}
+		case v3.FilterOperatorExists:
+			condition = fmt.Sprintf("%s != nil", name)
+		case v3.FilterOperatorNotExists:
+			condition = fmt.Sprintf("%s == nil", name)
+		default:
+			return "", fmt.Errorf("filter operator %s is not supported for log to metric processors", item.Operator)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s)", condition))
+	}
+
+	operator := " and "
+	if strings.ToLower(filter.Operator) == "or" {
+		operator = " or "
+	}
+	return strings.Join(conditions, operator), nil
+}
+
+func ottlString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return fmt.Sprintf(`"%s"`, s)
+}
+
+func ottlValue(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return ottlString(x), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", x), nil
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("filter value %v of type %T is not supported for log to metric processors", v, v)
+}
+
+func formatBucketBound(bound float64) string {
+	return strconv.FormatFloat(bound, 'f', -1, 64)
+}
+
+// logsToMetricConnectors returns the collector connectors generating the metric of the operator
+func logsToMetricConnectors(op PipelineOperator, filterCondition string) (map[string]interface{}, []string, error) {
+	attributes := []interface{}{}
+	for _, label := range op.Labels {
+		key, err := logAttributeKey(label)
+		if err != nil {
+			return nil, nil, err
+		}
+		// logs without the label are counted with an empty value instead of being skipped
+		attributes = append(attributes, map[string]interface{}{"key": key, "default_value": ""})
+	}
+
+	metric := func(name string, condition string, extraAttributes ...interface{}) map[string]interface{} {
+		config := map[string]interface{}{
+			"conditions": []interface{}{condition},
+			"attributes": append(append([]interface{}{}, attributes...), extraAttributes...),
+		}
+		if op.Name != "" {
+			config["description"] = op.Name
+		}
+		return map[string]interface{}{name: config}
+	}
+	connectorName := func(connectorType string, suffix string) string {
+		return fmt.Sprintf("%s/%s_%s%s", connectorType, constants.LogsToMetricsPfx, op.MetricName, suffix)
+	}
+
+	connectors := map[string]interface{}{}
+	names := []string{}
+	add := func(name string, logs map[string]interface{}) {
+		connectors[name] = map[string]interface{}{"logs": logs}
+		names = append(names, name)
+	}
+
+	if op.MetricType == counterMetricType {
+		add(connectorName("count", ""), metric(op.MetricName, filterCondition))
+		return connectors, names, nil
+	}
+
+	key, err := logAttributeKey(op.Field)
+	if err != nil {
+		return nil, nil, err
+	}
+	value := fmt.Sprintf("Double(attributes[%s])", ottlString(key))
+	condition := fmt.Sprintf("(%s) and %s != nil", filterCondition, value)
+
+	buckets := op.Buckets
+	if len(buckets) == 0 {
+		buckets = defaultHistogramBuckets
+	}
+	// each connector counts a bucket, all of them emit the same metric with a different le label
+	bucketMetric := op.MetricName + "_bucket"
+	for i, bound := range buckets {
+		add(connectorName("count", fmt.Sprintf("_le_%d", i)), metric(
+			bucketMetric,
+			fmt.Sprintf("%s and %s <= %s", condition, value, formatBucketBound(bound)),
+			map[string]interface{}{"key": histogramBucketLabel, "default_value": formatBucketBound(bound)},
+		))
+	}
+	infBucket := metric(bucketMetric, condition, map[string]interface{}{
+		"key": histogramBucketLabel, "default_value": "+Inf",
+	})
+	for name, config := range metric(op.MetricName+"_count", condition) {
+		infBucket[name] = config
+	}
+	add(connectorName("count", "_le_inf"), infBucket)
+
+	sum := metric(op.MetricName+"_sum", condition)
+	sum[op.MetricName+"_sum"].(map[string]interface{})["source_attribute"] = key
+	add(connectorName("sum", ""), sum)
+
+	return connectors, names, nil
+}
+
+// PrepareLogsToMetricsConnectors returns the collector connectors for the
+// log to metric operators of the enabled pipelines and their names
+func PrepareLogsToMetricsConnectors(pipelines []Pipeline) (map[string]interface{}, []string, error) {
+	connectors := map[string]interface{}{}
+	names := []string{}
+	metrics := map[string]string{}
+	for _, pipeline := range pipelines {
+		if !pipeline.Enabled {
+			continue
+		}
+		for _, op := range pipeline.Config {
+			if !op.Enabled || !isLogToMetricOperator(op.Type) {
+				continue
+			}
+			if other, ok := metrics[op.MetricName]; ok {
+				return nil, nil, fmt.Errorf(
+					"metric %s of pipeline %s is already generated by pipeline %s", op.MetricName, pipeline.Name, other,
+				)
+			}
+			metrics[op.MetricName] = pipeline.Name
+
+			filterCondition, err := filterToOTTL(pipeline.Filter)
+			if err != nil {
+				return nil, nil, fmt.Errorf("couldn't translate filter of pipeline %s: %w", pipeline.Name, err)
+			}
+			opConnectors, opNames, err := logsToMetricConnectors(op, filterCondition)
+			if err != nil {
+				return nil, nil, fmt.Errorf("couldn't generate connectors for %s op %s: %w", op.Type, op.Name, err)
+			}
+			for name, config := range opConnectors {
+				connectors[name] = config
+			}
+			names = append(names, opNames...)
+		}
+	}
+	return connectors, names, nil
+}
+
+func hasLogsToMetricsPrefix(componentName string) bool {
+	_, name, _ := strings.Cut(componentName, "/")
+	return strings.HasPrefix(name, constants.LogsToMetricsPfx)
+}
+
+// updateLogsToMetricsInCollectorConf replaces the generated connectors and
+// their metrics pipeline in the collector config, and returns the exporters
+// of the logs pipeline with the connectors
+func updateLogsToMetricsInCollectorConf(
+	collectorConf map[string]interface{},
+	connectors map[string]interface{},
+	connectorNames []string,
+	logsExporters []string,
+) ([]string, error) {
+	agentConnectors := map[string]interface{}{}
+	if collectorConf["connectors"] != nil {
+		agentConnectors = collectorConf["connectors"].(map[string]interface{})
+	}
+	for name := range agentConnectors {
+		if hasLogsToMetricsPrefix(name) {
+			delete(agentConnectors, name)
+		}
+	}
+	for name, config := range connectors {
+		agentConnectors[name] = config
+	}
+	if len(agentConnectors) > 0 {
+		collectorConf["connectors"] = agentConnectors
+	} else {
+		delete(collectorConf, "connectors")
+	}
+
+	exporters := []string{}
+	for _, name := range logsExporters {
+		if !hasLogsToMetricsPrefix(name) {
+			exporters = append(exporters, name)
+		}
+	}
+	exporters = append(exporters, connectorNames...)
+
+	pipelines := collectorConf["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	delete(pipelines, logsToMetricsPipelineName)
+	if len(connectorNames) == 0 {
+		return exporters, nil
+	}
+
+	// the generated metrics are exported like the other metrics of the collector
+	metricsPipelineName := "metrics"
+	if _, ok := pipelines[metricsPipelineName]; !ok {
+		names := []string{}
+		for name := range pipelines {
+			if strings.HasPrefix(name, "metrics/") {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("log to metric processors need a metrics pipeline in the collector config")
+		}
+		sort.Strings(names)
+		metricsPipelineName = names[0]
+	}
+	metricsPipeline, _ := pipelines[metricsPipelineName].(map[string]interface{})
+	metricsExporters, _ := metricsPipeline["exporters"].([]interface{})
+	if len(metricsExporters) == 0 {
+		return nil, fmt.Errorf("metrics pipeline %s of the collector config has no exporters", metricsPipelineName)
+	}
+	processors := []interface{}{}
+	metricsProcessors, _ := metricsPipeline["processors"].([]interface{})
+	for _, processor := range metricsProcessors {
+		if name, ok := processor.(string); ok && (name == "batch" || strings.HasPrefix(name, "batch/")) {
+			processors = append(processors, name)
+		}
+	}
+
+	receivers := []interface{}{}
+	for _, name := range connectorNames {
+		receivers = append(receivers, name)
+	}
+	pipelines[logsToMetricsPipelineName] = map[string]interface{}{
+		"receivers":  receivers,
+		"processors": processors,
+		"exporters":  metricsExporters,
+	}
+	return exporters, nil
+}
+
+// withoutLogToMetricOperators returns the pipelines with log to metric
+// operators disabled, these don't change the logs
+func withoutLogToMetricOperators(pipelines []Pipeline) []Pipeline {
+	result := []Pipeline{}
+	for _, pipeline := range pipelines {
+		config := []PipelineOperator{}
+		for _, op := range pipeline.Config {
+			if isLogToMetricOperator(op.Type) {
+				op.Enabled = false
+			}
+			config = append(config, op)
+		}
+		pipeline.Config = config
+		result = append(result, pipeline)
+	}
+	return result
+}
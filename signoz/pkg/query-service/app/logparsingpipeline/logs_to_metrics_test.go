@@ -0,0 +1,290 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFilterToOTTL(t *testing.T) {
+	attribute := v3.AttributeKey{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}
+	resource := v3.AttributeKey{Key: "service.name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeResource}
+	body := v3.AttributeKey{Key: "body", DataType: v3.AttributeKeyDataTypeString}
+
+	testCases := []struct {
+		Name     string
+		Filter   *v3.FilterSet
+		Expected string
+		IsValid  bool
+	}{
+		{
+			Name:     "empty",
+			Filter:   &v3.FilterSet{Operator: "AND"},
+			Expected: "true",
+			IsValid:  true,
+		},
+		{
+			Name: "and",
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: attribute, Operator: "=", Value: "GET"},
+				{Key: resource, Operator: "exists"},
+				{Key: body, Operator: "contains", Value: `a "b".`},
+			}},
+			Expected: `(attributes["method"] == "GET") and (resource.attributes["service.name"] != nil) and ` +
+				`(IsMatch(body, "(?i)a \"b\"\\."))`,
+			IsValid: true,
+		},
+		{
+			Name: "or",
+			Filter: &v3.FilterSet{Operator: "OR", Items: []v3.FilterItem{
+				{Key: attribute, Operator: "in", Value: []interface{}{"GET", "PUT"}},
+				{Key: attribute, Operator: "nin", Value: []interface{}{"POST"}},
+				{Key: v3.AttributeKey{Key: "severity_number"}, Operator: ">=", Value: 17},
+				{Key: body, Operator: "nregex", Value: "^health"},
+			}},
+			Expected: `(attributes["method"] == "GET" or attributes["method"] == "PUT") or ` +
+				`(attributes["method"] != nil and attributes["method"] != "POST") or ` +
+				`(severity_number >= 17) or (body != nil and not IsMatch(body, "^health"))`,
+			IsValid: true,
+		},
+		{
+			Name: "unsupported field",
+			Filter: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+				{Key: v3.AttributeKey{Key: "id"}, Operator: "=", Value: "1"},
+			}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			condition, err := filterToOTTL(testCase.Filter)
+			if !testCase.IsValid {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, testCase.Expected, condition)
+		})
+	}
+}
+
+func TestLogToMetricValidation(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		MetricOp string
+		IsValid  bool
+	}{
+		{"counter", `{"type": "log_to_metric", "metric_name": "checkout.orders", "metric_type": "counter", "labels": ["attributes.status", "attributes[\"http.method\"]"]}`, true},
+		{"histogram", `{"type": "log_to_metric", "metric_name": "checkout_latency", "metric_type": "histogram", "field": "attributes.latency", "buckets": [10, 100]}`, true},
+		{"invalid metric name", `{"type": "log_to_metric", "metric_name": "checkout orders", "metric_type": "counter"}`, false},
+		{"unknown metric type", `{"type": "log_to_metric", "metric_name": "orders", "metric_type": "gauge"}`, false},
+		{"histogram without field", `{"type": "log_to_metric", "metric_name": "latency", "metric_type": "histogram"}`, false},
+		{"histogram of body", `{"type": "log_to_metric", "metric_name": "latency", "metric_type": "histogram", "field": "body"}`, false},
+		{"histogram with unsorted buckets", `{"type": "log_to_metric", "metric_name": "latency", "metric_type": "histogram", "field": "attributes.latency", "buckets": [100, 10]}`, false},
+		{"histogram with le label", `{"type": "log_to_metric", "metric_name": "latency", "metric_type": "histogram", "field": "attributes.latency", "labels": ["attributes.le"]}`, false},
+		{"resource label", `{"type": "log_to_metric", "metric_name": "orders", "metric_type": "counter", "labels": ["resource.service"]}`, false},
+		{"duplicate label", `{"type": "log_to_metric", "metric_name": "orders", "metric_type": "counter", "labels": ["attributes.a", "attributes[\"a\"]"]}`, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			var op PipelineOperator
+			require.Nil(t, json.Unmarshal([]byte(testCase.MetricOp), &op))
+			op.ID = "test"
+			op.Enabled = true
+
+			err := isValidOperator(op)
+			if testCase.IsValid {
+				require.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func makeLogToMetricTestPipeline(ops ...PipelineOperator) Pipeline {
+	config := []PipelineOperator{{
+		ID:        "regex",
+		Type:      "regex_parser",
+		Enabled:   true,
+		Name:      "regex parser",
+		ParseFrom: "body",
+		ParseTo:   "attributes",
+		Regex:     `^status=(?P<status>\d+) latency=(?P<latency>\d+)$`,
+	}}
+	return Pipeline{
+		OrderId: 1,
+		Name:    "checkout",
+		Alias:   "checkout",
+		Enabled: true,
+		Filter: &v3.FilterSet{
+			Operator: "AND",
+			Items: []v3.FilterItem{
+				{
+					Key: v3.AttributeKey{
+						Key:      "method",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+					Operator: "=",
+					Value:    "GET",
+				},
+			},
+		},
+		Config: append(config, ops...),
+	}
+}
+
+func TestLogsToMetricsCollectorConfig(t *testing.T) {
+	require := require.New(t)
+
+	baseConf := []byte(`
+        receivers:
+          otlp: {}
+        processors:
+          batch: {}
+        exporters:
+          clickhouselogsexporter: {}
+          clickhousemetricswrite: {}
+        service:
+          pipelines:
+            logs:
+              receivers: [otlp]
+              processors: [batch]
+              exporters: [clickhouselogsexporter]
+            metrics:
+              receivers: [otlp]
+              processors: [batch]
+              exporters: [clickhousemetricswrite]
+      `)
+
+	pipelines := []Pipeline{makeLogToMetricTestPipeline(
+		PipelineOperator{
+			ID: "orders", Type: "log_to_metric", Enabled: true, Name: "orders by status",
+			MetricName: "checkout_orders", MetricType: "counter", Labels: []string{"attributes.status"},
+		},
+		PipelineOperator{
+			ID: "latency", Type: "log_to_metric", Enabled: true,
+			MetricName: "checkout_latency", MetricType: "histogram", Field: "attributes.latency",
+			Buckets: []float64{10, 100.5},
+		},
+	)}
+
+	confYaml, apiErr := GenerateCollectorConfigWithPipelines(baseConf, pipelines)
+	require.Nil(apiErr)
+
+	var conf map[string]interface{}
+	require.Nil(yaml.Unmarshal(confYaml, &conf))
+
+	connectors := conf["connectors"].(map[string]interface{})
+	require.Len(connectors, 5)
+
+	filter := `(attributes["method"] == "GET")`
+	counter := connectors["count/signozlogstometrics_checkout_orders"].(map[string]interface{})["logs"].(map[string]interface{})
+	require.Equal(map[string]interface{}{
+		"checkout_orders": map[string]interface{}{
+			"description": "orders by status",
+			"conditions":  []interface{}{filter},
+			"attributes":  []interface{}{map[string]interface{}{"key": "status", "default_value": ""}},
+		},
+	}, counter)
+
+	value := `Double(attributes["latency"])`
+	bucket := connectors["count/signozlogstometrics_checkout_latency_le_1"].(map[string]interface{})["logs"].(map[string]interface{})
+	require.Equal(map[string]interface{}{
+		"checkout_latency_bucket": map[string]interface{}{
+			"conditions": []interface{}{"(" + filter + ") and " + value + " != nil and " + value + " <= 100.5"},
+			"attributes": []interface{}{map[string]interface{}{"key": "le", "default_value": "100.5"}},
+		},
+	}, bucket)
+	infBucket := connectors["count/signozlogstometrics_checkout_latency_le_inf"].(map[string]interface{})["logs"].(map[string]interface{})
+	require.Contains(infBucket, "checkout_latency_bucket")
+	require.Contains(infBucket, "checkout_latency_count")
+	sum := connectors["sum/signozlogstometrics_checkout_latency"].(map[string]interface{})["logs"].(map[string]interface{})
+	require.Equal("latency", sum["checkout_latency_sum"].(map[string]interface{})["source_attribute"])
+
+	servicePipelines := conf["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	connectorNames := []interface{}{
+		"count/signozlogstometrics_checkout_orders",
+		"count/signozlogstometrics_checkout_latency_le_0",
+		"count/signozlogstometrics_checkout_latency_le_1",
+		"count/signozlogstometrics_checkout_latency_le_inf",
+		"sum/signozlogstometrics_checkout_latency",
+	}
+	require.Equal(
+		append([]interface{}{"clickhouselogsexporter"}, connectorNames...),
+		servicePipelines["logs"].(map[string]interface{})["exporters"],
+	)
+	require.Equal(map[string]interface{}{
+		"receivers":  connectorNames,
+		"processors": []interface{}{"batch"},
+		"exporters":  []interface{}{"clickhousemetricswrite"},
+	}, servicePipelines["metrics/signozlogstometrics"])
+
+	// regenerating the config doesn't change it
+	confYaml2, apiErr := GenerateCollectorConfigWithPipelines(confYaml, pipelines)
+	require.Nil(apiErr)
+	require.Equal(string(confYaml), string(confYaml2))
+
+	// the connectors are removed with the operators
+	confYaml3, apiErr := GenerateCollectorConfigWithPipelines(confYaml, []Pipeline{makeLogToMetricTestPipeline()})
+	require.Nil(apiErr)
+	var conf3 map[string]interface{}
+	require.Nil(yaml.Unmarshal(confYaml3, &conf3))
+	require.NotContains(conf3, "connectors")
+	servicePipelines = conf3["service"].(map[string]interface{})["pipelines"].(map[string]interface{})
+	require.NotContains(servicePipelines, "metrics/signozlogstometrics")
+	require.Equal([]interface{}{"clickhouselogsexporter"}, servicePipelines["logs"].(map[string]interface{})["exporters"])
+
+	// metrics can't be generated without a metrics pipeline to export them
+	_, apiErr = GenerateCollectorConfigWithPipelines([]byte(`
+        service:
+          pipelines:
+            logs:
+              receivers: [otlp]
+              exporters: [clickhouselogsexporter]
+      `), pipelines)
+	require.NotNil(apiErr)
+
+	// or with the same metric generated twice
+	_, apiErr = GenerateCollectorConfigWithPipelines(baseConf, append(pipelines, pipelines[0]))
+	require.NotNil(apiErr)
+}
+
+func TestLogToMetricPipelinePreview(t *testing.T) {
+	require := require.New(t)
+
+	pipeline := makeLogToMetricTestPipeline(PipelineOperator{
+		ID: "orders", Type: "log_to_metric", Enabled: true, OrderId: 2,
+		MetricName: "checkout_orders", MetricType: "counter", Labels: []string{"attributes.status"},
+	})
+	pipeline.Config[0].OrderId = 1
+	pipeline.Config[0].Output = "orders"
+
+	postable := PostablePipeline{
+		OrderId: pipeline.OrderId,
+		Name:    pipeline.Name,
+		Alias:   pipeline.Alias,
+		Enabled: pipeline.Enabled,
+		Filter:  pipeline.Filter,
+		Config:  pipeline.Config,
+	}
+	require.Nil(postable.IsValid())
+
+	result, collectorWarnAndErrorLogs, err := SimulatePipelinesProcessing(
+		context.Background(),
+		[]Pipeline{pipeline},
+		[]model.SignozLog{makeTestSignozLog("status=200 latency=35", map[string]interface{}{"method": "GET"})},
+	)
+	require.Nil(err)
+	require.Equal(0, len(collectorWarnAndErrorLogs), strings.Join(collectorWarnAndErrorLogs, "\n"))
+	require.Equal(1, len(result))
+	require.Equal(map[string]string{"method": "GET", "status": "200", "latency": "35"}, result[0].Attributes_string)
+}
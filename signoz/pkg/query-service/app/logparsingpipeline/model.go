@@ -70,6 +70,28 @@ type PipelineOperator struct {
 	// severity parser fields
 	SeverityMapping       map[string][]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
 	OverwriteSeverityText bool                `json:"overwrite_text,omitempty" yaml:"overwrite_text,omitempty"`
+
+	// key_value_parser and csv_parser fields.
+	// The format parsers are translated to the operators supported
+	// by the collector, so these are not needed in the final config
+	Delimiter     string `json:"delimiter,omitempty" yaml:"-"`
+	PairDelimiter string `json:"pair_delimiter,omitempty" yaml:"-"`
+	Header        string `json:"header,omitempty" yaml:"-"`
+
+	// syslog_parser fields
+	Protocol string `json:"protocol,omitempty" yaml:"-"`
+
+	// redact and pseudonymize fields, the matches of regex and the detectors
+	// in field are replaced with the mask or their salted pseudonym
+	Detectors []string `json:"detectors,omitempty" yaml:"-"`
+	Mask      string   `json:"mask,omitempty" yaml:"-"`
+	Salt      string   `json:"salt,omitempty" yaml:"-"`
+
+	// log_to_metric fields, these are deployed as collector connectors
+	MetricName string    `json:"metric_name,omitempty" yaml:"-"`
+	MetricType string    `json:"metric_type,omitempty" yaml:"-"`
+	Labels     []string  `json:"labels,omitempty" yaml:"-"`
+	Buckets    []float64 `json:"buckets,omitempty" yaml:"-"`
 }
 
 type TimestampParser struct {
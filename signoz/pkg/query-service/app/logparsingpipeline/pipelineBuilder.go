@@ -91,27 +91,36 @@ func PreparePipelineProcessor(pipelines []Pipeline) (map[string]interface{}, []s
 func getOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
 	filteredOp := []PipelineOperator{}
 	for i, operator := range ops {
-		if operator.Enabled {
+		// log to metric operators are deployed as collector connectors
+		if operator.Enabled && !isLogToMetricOperator(operator.Type) {
+			if isTranslatedOperator(operator.Type) {
+				translatedOps, err := translateOperator(operator)
+				if err != nil {
+					return nil, fmt.Errorf(
+						"couldn't generate operators for %s op %s: %w", operator.Type, operator.Name, err,
+					)
+				}
+				for _, translatedOp := range translatedOps {
+					if len(filteredOp) > 0 {
+						filteredOp[len(filteredOp)-1].Output = translatedOp.ID
+					}
+					filteredOp = append(filteredOp, translatedOp)
+				}
+				continue
+			}
+
 			if len(filteredOp) > 0 {
 				filteredOp[len(filteredOp)-1].Output = operator.ID
 			}
 
 			if operator.Type == "regex_parser" {
-				parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
+				regexCondition, err := regexParserCondition(operator.ParseFrom, operator.Regex)
 				if err != nil {
 					return nil, fmt.Errorf(
 						"couldn't generate nil check for parseFrom of regex op %s: %w", operator.Name, err,
 					)
 				}
-				operator.If = fmt.Sprintf(
-					`%s && %s matches "%s"`,
-					parseFromNotNilCheck,
-					operator.ParseFrom,
-					strings.ReplaceAll(
-						strings.ReplaceAll(operator.Regex, `\`, `\\`),
-						`"`, `\"`,
-					),
-				)
+				operator.If = regexCondition
 
 			} else if operator.Type == "grok_parser" {
 				parseFromNotNilCheck, err := fieldNotNilCheck(operator.ParseFrom)
@@ -224,6 +233,35 @@ func getOperators(ops []PipelineOperator) ([]PipelineOperator, error) {
 	return filteredOp, nil
 }
 
+// Operators not supported by the collector are translated to the ones it supports
+func isTranslatedOperator(operatorType string) bool {
+	return isFormatParser(operatorType) || isRedactionOperator(operatorType)
+}
+
+func translateOperator(operator PipelineOperator) ([]PipelineOperator, error) {
+	if isRedactionOperator(operator.Type) {
+		return redactionOperators(operator)
+	}
+	return formatParserOperators(operator)
+}
+
+// Generates the condition for a regex parser to run only on values matching the regex.
+func regexParserCondition(parseFrom string, regex string) (string, error) {
+	parseFromNotNilCheck, err := fieldNotNilCheck(parseFrom)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(
+		`%s && %s matches "%s"`,
+		parseFromNotNilCheck,
+		parseFrom,
+		strings.ReplaceAll(
+			strings.ReplaceAll(regex, `\`, `\\`),
+			`"`, `\"`,
+		),
+	), nil
+}
+
 func cleanTraceParser(operator *PipelineOperator) {
 	if operator.TraceId != nil && len(operator.TraceId.ParseFrom) < 1 {
 		operator.TraceId = nil
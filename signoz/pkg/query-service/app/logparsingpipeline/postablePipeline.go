@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 
+	"go.signoz.io/signoz/pkg/query-service/agentConf"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/queryBuilderToExpr"
 	"golang.org/x/exp/slices"
@@ -14,6 +15,10 @@ import (
 // PostablePipelines are a list of user defined pielines
 type PostablePipelines struct {
 	Pipelines []PostablePipeline `json:"pipelines"`
+
+	// Rollout deploys the pipelines to a subset of agents first
+	// and optionally rolls them back automatically on failures.
+	Rollout *agentConf.RolloutOptions `json:"rollout,omitempty"`
 }
 
 // PostablePipeline captures user inputs in setting the pipeline
@@ -87,6 +92,13 @@ func (p *PostablePipeline) IsValid() error {
 
 		idUnique[op.ID] = struct{}{}
 		outputUnique[op.Output] = struct{}{}
+
+		// metrics are generated for the logs matching the filter in the collector connectors
+		if isLogToMetricOperator(op.Type) {
+			if _, err := filterToOTTL(p.Filter); err != nil {
+				return fmt.Errorf("filter for pipeline %v is not supported with log to metric processors: %v", p.Name, err)
+			}
+		}
 	}
 	return nil
 }
@@ -210,8 +222,79 @@ func isValidOperator(op PipelineOperator) error {
 			}
 		}
 
+	case keyValueParserType:
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of key value parsing processor %s cannot be empty", op.ID)
+		}
+		delimiter, pairDelimiter := keyValueDelimiters(op)
+		if strings.Contains(pairDelimiter, delimiter) || strings.Contains(delimiter, pairDelimiter) {
+			return fmt.Errorf("delimiter and pair delimiter of key value parsing processor %s must be different", op.ID)
+		}
+
+	case csvParserType:
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of csv parsing processor %s cannot be empty", op.ID)
+		}
+		if op.Header == "" {
+			return fmt.Errorf("header of csv parsing processor %s cannot be empty", op.ID)
+		}
+		seen := map[string]bool{}
+		for _, name := range csvHeaderFields(op) {
+			if strings.TrimSpace(name) == "" {
+				return fmt.Errorf("header of csv parsing processor %s has an empty column name", op.ID)
+			}
+			if seen[name] {
+				return fmt.Errorf("header of csv parsing processor %s has duplicate column %s", op.ID, name)
+			}
+			seen[name] = true
+		}
+
+	case xmlParserType:
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of xml parsing processor %s cannot be empty", op.ID)
+		}
+		if len(op.Fields) == 0 {
+			return fmt.Errorf("fields of xml parsing processor %s cannot be empty", op.ID)
+		}
+		for _, name := range op.Fields {
+			if !xmlElementNameRegex.MatchString(name) {
+				return fmt.Errorf("invalid element name %s in xml parsing processor %s", name, op.ID)
+			}
+		}
+
+	case syslogParserType:
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of syslog parsing processor %s cannot be empty", op.ID)
+		}
+		if op.Protocol != syslogProtocolRFC3164 && op.Protocol != syslogProtocolRFC5424 {
+			return fmt.Errorf(
+				"invalid protocol '%s' of syslog parsing processor %s, use one of (%s, %s)",
+				op.Protocol, op.ID, syslogProtocolRFC3164, syslogProtocolRFC5424,
+			)
+		}
+
+	case urlParserType:
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of url parsing processor %s cannot be empty", op.ID)
+		}
+
+	case userAgentParserType:
+		if op.ParseFrom == "" {
+			return fmt.Errorf("parse from of user agent parsing processor %s cannot be empty", op.ID)
+		}
+
+	case redactOperatorType, pseudonymizeOperatorType:
+		if err := validateRedactionOperator(op); err != nil {
+			return err
+		}
+
+	case logToMetricOperatorType:
+		if err := validateLogToMetricOperator(op); err != nil {
+			return err
+		}
+
 	default:
-		return fmt.Errorf(fmt.Sprintf("operator type %s not supported for %s, use one of (grok_parser, regex_parser, copy, move, add, remove, trace_parser, retain)", op.Type, op.ID))
+		return fmt.Errorf(fmt.Sprintf("operator type %s not supported for %s, use one of (grok_parser, regex_parser, copy, move, add, remove, trace_parser, retain, key_value_parser, csv_parser, xml_parser, syslog_parser, url_parser, user_agent_parser, redact, pseudonymize, log_to_metric)", op.Type, op.ID))
 	}
 
 	if !isValidOtelValue(op.ParseFrom) ||
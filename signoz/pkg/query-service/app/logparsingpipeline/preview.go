@@ -28,6 +28,35 @@ func SimulatePipelinesProcessing(
 		return logs, nil, nil
 	}
 
+	indexedOutput, collectorWarnAndErrorLogs, apiErr := simulateIndexedPipelinesProcessing(
+		ctx, pipelines, logs,
+	)
+	if apiErr != nil {
+		return nil, collectorWarnAndErrorLogs, apiErr
+	}
+
+	output = []model.SignozLog{}
+	for _, l := range indexedOutput {
+		output = append(output, l.log)
+	}
+	return output, collectorWarnAndErrorLogs, nil
+}
+
+// indexedLog is a simulation output log along with the index of its input log.
+type indexedLog struct {
+	inputIdx int
+	log      model.SignozLog
+}
+
+// simulateIndexedPipelinesProcessing returns the simulation output sorted by the
+// order of the input logs. Logs dropped by the pipelines have no output entry.
+func simulateIndexedPipelinesProcessing(
+	ctx context.Context,
+	pipelines []Pipeline,
+	logs []model.SignozLog,
+) (
+	output []indexedLog, collectorWarnAndErrorLogs []string, apiErr *model.ApiError,
+) {
 	// Collector simulation does not guarantee that logs will come
 	// out in the same order as in the input.
 	//
@@ -59,7 +88,8 @@ func SimulatePipelinesProcessing(
 	timeout := time.Millisecond * time.Duration(len(pipelines)*100+100)
 
 	configGenerator := func(baseConf []byte) ([]byte, error) {
-		updatedConf, apiErr := GenerateCollectorConfigWithPipelines(baseConf, pipelines)
+		// log to metric operators don't change the logs
+		updatedConf, apiErr := GenerateCollectorConfigWithPipelines(baseConf, withoutLogToMetricOperators(pipelines))
 		if apiErr != nil {
 			return nil, apiErr.ToError()
 		}
@@ -94,6 +124,10 @@ func SimulatePipelinesProcessing(
 		return iIdx < jIdx
 	})
 	for _, sigLog := range outputSignozLogs {
+		output = append(output, indexedLog{
+			inputIdx: int(sigLog.Attributes_int64[inputOrderAttribute]),
+			log:      sigLog,
+		})
 		delete(sigLog.Attributes_int64, inputOrderAttribute)
 	}
 
@@ -105,7 +139,7 @@ func SimulatePipelinesProcessing(
 		collectorWarnAndErrorLogs = append(collectorWarnAndErrorLogs, log)
 	}
 
-	return outputSignozLogs, collectorWarnAndErrorLogs, nil
+	return output, collectorWarnAndErrorLogs, nil
 }
 
 // plog doesn't contain an ID field.
@@ -0,0 +1,149 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	logsV3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	logsV4 "go.signoz.io/signoz/pkg/query-service/app/logs/v4"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	defaultPreviewSampleSize = 10
+	maxPreviewSampleSize     = 100
+	maxPreviewSampleWindow   = 24 * time.Hour
+)
+
+// LogsSampler fetches the latest logs matching filter in the [start, end] window.
+// start and end are epoch milliseconds.
+type LogsSampler func(
+	ctx context.Context, filter *v3.FilterSet, start, end int64, limit int,
+) ([]model.SignozLog, *model.ApiError)
+
+// PipelinesPreviewSample selects the stored logs to preview pipelines against.
+// Start and End are epoch milliseconds.
+type PipelinesPreviewSample struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Limit int   `json:"limit"`
+}
+
+func (s *PipelinesPreviewSample) Validate() error {
+	if s.Start <= 0 || s.End <= s.Start {
+		return fmt.Errorf("sample window must have a start before its end")
+	}
+	if time.Duration(s.End-s.Start)*time.Millisecond > maxPreviewSampleWindow {
+		return fmt.Errorf("sample window can not be longer than %s", maxPreviewSampleWindow)
+	}
+	if s.Limit < 0 || s.Limit > maxPreviewSampleSize {
+		return fmt.Errorf("sample limit must be between 0 and %d", maxPreviewSampleSize)
+	}
+	return nil
+}
+
+// NewLogsSampler returns a LogsSampler reading logs from ClickHouse.
+func NewLogsSampler(reader interfaces.Reader, useLogsNewSchema bool) LogsSampler {
+	prepareLogsQuery := logsV3.PrepareLogsQuery
+	if useLogsNewSchema {
+		prepareLogsQuery = logsV4.PrepareLogsQuery
+	}
+
+	return func(
+		ctx context.Context, filter *v3.FilterSet, start, end int64, limit int,
+	) ([]model.SignozLog, *model.ApiError) {
+		query, err := prepareLogsQuery(start, end, v3.QueryTypeBuilder, v3.PanelTypeList, &v3.BuilderQuery{
+			QueryName:         "A",
+			Expression:        "A",
+			DataSource:        v3.DataSourceLogs,
+			AggregateOperator: v3.AggregateOperatorNoOp,
+			StepInterval:      60,
+			Filters:           filter,
+			Limit:             uint64(limit),
+			OrderBy: []v3.OrderBy{{
+				ColumnName: constants.TIMESTAMP,
+				Order:      v3.DirectionDesc,
+				Key:        constants.TIMESTAMP,
+				IsColumn:   true,
+			}},
+		}, v3.QBOptions{})
+		if err != nil {
+			return nil, model.BadRequest(errors.Wrap(err, "could not build query for sampling logs"))
+		}
+
+		rows, err := reader.GetListResultV3(ctx, query)
+		if err != nil {
+			return nil, model.InternalError(errors.Wrap(err, "could not query logs sample"))
+		}
+		return rowsToSignozLogs(rows), nil
+	}
+}
+
+// rowsToSignozLogs converts logs list query rows of either logs schema to logs.
+func rowsToSignozLogs(rows []*v3.Row) []model.SignozLog {
+	result := []model.SignozLog{}
+	for _, row := range rows {
+		l := model.SignozLog{
+			Timestamp:          uint64(row.Timestamp.UnixNano()),
+			Resources_string:   map[string]string{},
+			Attributes_string:  map[string]string{},
+			Attributes_int64:   map[string]int64{},
+			Attributes_float64: map[string]float64{},
+			Attributes_bool:    map[string]bool{},
+		}
+
+		for column, value := range row.Data {
+			switch v := value.(type) {
+			case *string:
+				switch column {
+				case "id":
+					l.ID = *v
+				case "trace_id":
+					l.TraceID = *v
+				case "span_id":
+					l.SpanID = *v
+				case "severity_text":
+					l.SeverityText = *v
+				case "body":
+					l.Body = *v
+				}
+			case *uint32:
+				if column == "trace_flags" {
+					l.TraceFlags = *v
+				}
+			case *uint8:
+				if column == "severity_number" {
+					l.SeverityNumber = *v
+				}
+			case *map[string]string:
+				switch column {
+				case "attributes_string":
+					l.Attributes_string = *v
+				case "resources_string":
+					l.Resources_string = *v
+				}
+			case *map[string]int64:
+				if column == "attributes_int64" {
+					l.Attributes_int64 = *v
+				}
+			case *map[string]float64:
+				// attributes_number holds all numbers in the new logs schema
+				if column == "attributes_float64" || column == "attributes_number" {
+					l.Attributes_float64 = *v
+				}
+			case *map[string]bool:
+				if column == "attributes_bool" {
+					l.Attributes_bool = *v
+				}
+			}
+		}
+
+		result = append(result, l)
+	}
+	return result
+}
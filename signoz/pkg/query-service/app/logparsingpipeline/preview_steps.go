@@ -0,0 +1,254 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// Every step of a step by step preview is a separate collector simulation,
+// so the number of operators that can be previewed that way is capped.
+const maxPreviewSteps = 50
+
+// PipelinePreviewStep is the state of the previewed logs after an operator ran.
+type PipelinePreviewStep struct {
+	PipelineId   string `json:"pipelineId"`
+	PipelineName string `json:"pipelineName"`
+	OperatorId   string `json:"operatorId"`
+	OperatorName string `json:"operatorName"`
+	OperatorType string `json:"operatorType"`
+	OnError      string `json:"onError,omitempty"`
+
+	// Logs and Diffs are aligned with the input logs.
+	// Logs dropped by this or an earlier operator are null.
+	Logs  []*model.SignozLog       `json:"logs"`
+	Diffs []PipelinePreviewLogDiff `json:"diffs"`
+
+	// ErrorCount is the number of errors the operator logged. Errors of
+	// operators with a quiet on_error mode are not logged and only show up
+	// as dropped logs when the mode drops them.
+	ErrorCount   int `json:"errorCount"`
+	DroppedCount int `json:"droppedCount"`
+}
+
+// PipelinePreviewLogDiff lists the fields of a log changed by an operator.
+// Fields are named the way pipeline operators refer to them,
+// for example `body` or `attributes.method`.
+type PipelinePreviewLogDiff struct {
+	Added   map[string]interface{}                `json:"added,omitempty"`
+	Changed map[string]PipelinePreviewFieldChange `json:"changed,omitempty"`
+	Removed map[string]interface{}                `json:"removed,omitempty"`
+	Dropped bool                                  `json:"dropped,omitempty"`
+}
+
+type PipelinePreviewFieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// previewStep identifies an operator along with the pipelines needed
+// to simulate processing up to and including it.
+type previewStep struct {
+	pipeline  Pipeline
+	operator  PipelineOperator
+	pipelines []Pipeline
+}
+
+// getPreviewSteps returns a step for every operator that can change logs.
+func getPreviewSteps(pipelines []Pipeline) ([]previewStep, *model.ApiError) {
+	steps := []previewStep{}
+	preceding := []Pipeline{}
+	for _, p := range pipelines {
+		if !p.Enabled {
+			continue
+		}
+
+		operators := []PipelineOperator{}
+		for _, op := range p.Config {
+			if op.Enabled && !isLogToMetricOperator(op.Type) {
+				operators = append(operators, op)
+			}
+		}
+
+		for i, op := range operators {
+			truncated := p
+			truncated.Config = operators[:i+1]
+			steps = append(steps, previewStep{
+				pipeline:  p,
+				operator:  op,
+				pipelines: append(slices.Clone(preceding), truncated),
+			})
+		}
+		preceding = append(preceding, p)
+	}
+
+	if len(steps) > maxPreviewSteps {
+		return nil, model.BadRequest(fmt.Errorf(
+			"step by step preview supports up to %d operators, found %d", maxPreviewSteps, len(steps),
+		))
+	}
+	return steps, nil
+}
+
+// SimulatePipelinesProcessingSteps simulates processing of logs by the pipelines
+// one operator at a time and reports what every operator did to the logs.
+func SimulatePipelinesProcessingSteps(
+	ctx context.Context,
+	pipelines []Pipeline,
+	logs []model.SignozLog,
+) ([]PipelinePreviewStep, *model.ApiError) {
+	steps, apiErr := getPreviewSteps(pipelines)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// Converting logs to plogs and back changes some of their fields (trace ids
+	// get hex encoded for example), so the state before the first operator is
+	// the input after the same round trip.
+	input := PLogsToSignozLogs(SignozLogsToPLogs(cloneSignozLogs(logs)))
+	previous := make([]*model.SignozLog, len(input))
+	for i := range input {
+		previous[i] = &input[i]
+	}
+	previousErrCount := 0
+
+	result := []PipelinePreviewStep{}
+	for _, step := range steps {
+		indexedOutput, collectorLogs, apiErr := simulateIndexedPipelinesProcessing(
+			ctx, step.pipelines, cloneSignozLogs(logs),
+		)
+		if apiErr != nil {
+			return nil, model.WrapApiError(apiErr, fmt.Sprintf(
+				"could not simulate processing up to operator %s of pipeline %s",
+				step.operator.Name, step.pipeline.Name,
+			))
+		}
+
+		current := make([]*model.SignozLog, len(logs))
+		for i := range indexedOutput {
+			current[indexedOutput[i].inputIdx] = &indexedOutput[i].log
+		}
+
+		previewStep := PipelinePreviewStep{
+			PipelineId:   step.pipeline.Id,
+			PipelineName: step.pipeline.Name,
+			OperatorId:   step.operator.ID,
+			OperatorName: step.operator.Name,
+			OperatorType: step.operator.Type,
+			OnError:      step.operator.OnError,
+			Logs:         current,
+			Diffs:        make([]PipelinePreviewLogDiff, len(logs)),
+		}
+		for i := range logs {
+			if previous[i] == nil {
+				continue
+			}
+			if current[i] == nil {
+				previewStep.Diffs[i] = PipelinePreviewLogDiff{Dropped: true}
+				previewStep.DroppedCount++
+				continue
+			}
+			previewStep.Diffs[i] = diffSignozLogs(*previous[i], *current[i])
+		}
+
+		// Simulations are deterministic, so the errors logged by an operator
+		// are the ones its simulation has over the previous one.
+		errCount := countEntryErrors(collectorLogs)
+		previewStep.ErrorCount = max(0, errCount-previousErrCount)
+
+		result = append(result, previewStep)
+		previous = current
+		previousErrCount = errCount
+	}
+
+	return result, nil
+}
+
+// Operators log this message for every log they fail to process,
+// followed by a multi line stack trace.
+const entryErrorLogMsg = "Failed to process entry"
+
+func countEntryErrors(collectorLogs []string) int {
+	count := 0
+	for _, l := range collectorLogs {
+		if strings.Contains(l, entryErrorLogMsg) {
+			count++
+		}
+	}
+	return count
+}
+
+func diffSignozLogs(before model.SignozLog, after model.SignozLog) PipelinePreviewLogDiff {
+	diff := PipelinePreviewLogDiff{
+		Added:   map[string]interface{}{},
+		Changed: map[string]PipelinePreviewFieldChange{},
+		Removed: map[string]interface{}{},
+	}
+
+	beforeFields := flattenSignozLog(before)
+	afterFields := flattenSignozLog(after)
+	for k, v := range afterFields {
+		old, exists := beforeFields[k]
+		if !exists {
+			diff.Added[k] = v
+		} else if !reflect.DeepEqual(old, v) {
+			diff.Changed[k] = PipelinePreviewFieldChange{Old: old, New: v}
+		}
+	}
+	for k, v := range beforeFields {
+		if _, exists := afterFields[k]; !exists {
+			diff.Removed[k] = v
+		}
+	}
+
+	return diff
+}
+
+// flattenSignozLog maps the fields of a log to their values using the names
+// pipeline operators use for them.
+func flattenSignozLog(l model.SignozLog) map[string]interface{} {
+	fields := map[string]interface{}{
+		"timestamp":       l.Timestamp,
+		"trace_id":        l.TraceID,
+		"span_id":         l.SpanID,
+		"trace_flags":     l.TraceFlags,
+		"severity_text":   l.SeverityText,
+		"severity_number": l.SeverityNumber,
+		"body":            l.Body,
+	}
+	for k, v := range l.Attributes_string {
+		fields["attributes."+k] = v
+	}
+	for k, v := range l.Attributes_int64 {
+		fields["attributes."+k] = v
+	}
+	for k, v := range l.Attributes_float64 {
+		fields["attributes."+k] = v
+	}
+	for k, v := range l.Attributes_bool {
+		fields["attributes."+k] = v
+	}
+	for k, v := range l.Resources_string {
+		fields["resource."+k] = v
+	}
+	return fields
+}
+
+// cloneSignozLogs copies logs so that simulations can annotate them freely.
+func cloneSignozLogs(logs []model.SignozLog) []model.SignozLog {
+	result := make([]model.SignozLog, len(logs))
+	for i, l := range logs {
+		l.Resources_string = maps.Clone(l.Resources_string)
+		l.Attributes_string = maps.Clone(l.Attributes_string)
+		l.Attributes_int64 = maps.Clone(l.Attributes_int64)
+		l.Attributes_float64 = maps.Clone(l.Attributes_float64)
+		l.Attributes_bool = maps.Clone(l.Attributes_bool)
+		result[i] = l
+	}
+	return result
+}
@@ -0,0 +1,233 @@
+package logparsingpipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestPipelinePreviewSteps(t *testing.T) {
+	require := require.New(t)
+
+	testPipelines := []Pipeline{
+		{
+			OrderId: 1,
+			Name:    "pipeline1",
+			Alias:   "pipeline1",
+			Enabled: true,
+			Filter: &v3.FilterSet{
+				Operator: "AND",
+				Items: []v3.FilterItem{
+					{
+						Key: v3.AttributeKey{
+							Key:      "method",
+							DataType: v3.AttributeKeyDataTypeString,
+							Type:     v3.AttributeKeyTypeTag,
+						},
+						Operator: "=",
+						Value:    "GET",
+					},
+				},
+			},
+			Config: []PipelineOperator{
+				{ID: "add", Type: "add", Name: "add", Field: "attributes.team", Value: "payments", Enabled: true},
+				{ID: "disabled", Type: "remove", Name: "disabled", Field: "attributes.method", Enabled: false},
+				{ID: "move", Type: "move", Name: "move", From: "attributes.method", To: "attributes.http_method", Enabled: true},
+				{
+					ID: "json", Type: "json_parser", Name: "json", OnError: "send",
+					ParseFrom: "body", ParseTo: "attributes", Enabled: true,
+				},
+			},
+		},
+	}
+
+	matchingLog := makeTestSignozLog("{not json}", map[string]interface{}{"method": "GET"})
+	nonMatchingLog := makeTestSignozLog("{not json}", map[string]interface{}{"method": "POST"})
+
+	steps, err := SimulatePipelinesProcessingSteps(
+		context.Background(), testPipelines, []model.SignozLog{matchingLog, nonMatchingLog},
+	)
+	require.Nil(err)
+	require.Equal(3, len(steps))
+	require.Equal([]string{"add", "move", "json"}, []string{
+		steps[0].OperatorId, steps[1].OperatorId, steps[2].OperatorId,
+	})
+
+	// the first step adds the attribute to the matching log only
+	require.Equal(2, len(steps[0].Logs))
+	require.Equal("payments", steps[0].Logs[0].Attributes_string["team"])
+	require.Equal(map[string]interface{}{"attributes.team": "payments"}, steps[0].Diffs[0].Added)
+	require.Empty(steps[0].Diffs[0].Changed)
+	require.Empty(steps[0].Diffs[0].Removed)
+	require.Empty(steps[0].Diffs[1].Added)
+	require.Equal(0, steps[0].ErrorCount)
+
+	// the second step only sees the changes made by the move operator
+	require.Equal(map[string]interface{}{"attributes.http_method": "GET"}, steps[1].Diffs[0].Added)
+	require.Equal(map[string]interface{}{"attributes.method": "GET"}, steps[1].Diffs[0].Removed)
+	require.Equal("payments", steps[1].Logs[0].Attributes_string["team"])
+
+	// parsing the body fails and the log is sent on
+	require.Equal("send", steps[2].OnError)
+	require.Equal(1, steps[2].ErrorCount)
+	require.Equal(0, steps[2].DroppedCount)
+	require.NotNil(steps[2].Logs[0])
+}
+
+func TestPipelinePreviewStepsLimit(t *testing.T) {
+	require := require.New(t)
+
+	operators := []PipelineOperator{}
+	for i := 0; i <= maxPreviewSteps; i++ {
+		operators = append(operators, PipelineOperator{
+			ID: fmt.Sprintf("add%d", i), Type: "add", Field: "attributes.a", Value: "b", Enabled: true,
+		})
+	}
+	testPipelines := []Pipeline{
+		{Name: "pipeline1", Alias: "pipeline1", Enabled: true, Filter: getMethodFilter("GET"), Config: operators},
+	}
+
+	_, err := SimulatePipelinesProcessingSteps(context.Background(), testPipelines, []model.SignozLog{})
+	require.NotNil(err)
+	require.Equal(model.ErrorBadData, err.Type())
+}
+
+func TestDiffSignozLogs(t *testing.T) {
+	require := require.New(t)
+
+	before := makeTestSignozLog("body", map[string]interface{}{
+		"kept":    "a",
+		"changed": "b",
+		"removed": 1,
+	})
+	after := before
+	after.SeverityText = "ERROR"
+	after.Attributes_string = map[string]string{"kept": "a", "changed": "c"}
+	after.Attributes_int64 = map[string]int64{}
+	after.Attributes_float64 = map[string]float64{"added": 1.5}
+	after.Resources_string = map[string]string{"service.name": "api"}
+
+	diff := diffSignozLogs(before, after)
+	require.Equal(map[string]interface{}{
+		"attributes.added":      1.5,
+		"resource.service.name": "api",
+	}, diff.Added)
+	require.Equal(map[string]PipelinePreviewFieldChange{
+		"attributes.changed": {Old: "b", New: "c"},
+		"severity_text":      {Old: before.SeverityText, New: "ERROR"},
+	}, diff.Changed)
+	require.Equal(map[string]interface{}{"attributes.removed": int64(1)}, diff.Removed)
+	require.False(diff.Dropped)
+}
+
+func TestPreviewLogsPipelinesWithSample(t *testing.T) {
+	require := require.New(t)
+
+	sampledLog := makeTestSignozLog("sampled", map[string]interface{}{"method": "GET"})
+	sampledLog.ID = "sampled"
+	filters := []*v3.FilterSet{}
+	controller := &LogParsingPipelineController{
+		SampleLogs: func(
+			ctx context.Context, filter *v3.FilterSet, start, end int64, limit int,
+		) ([]model.SignozLog, *model.ApiError) {
+			filters = append(filters, filter)
+			require.Equal(defaultPreviewSampleSize, limit)
+			return []model.SignozLog{sampledLog}, nil
+		},
+	}
+
+	filter := getMethodFilter("GET")
+	testPipelines := []Pipeline{
+		{
+			Name: "pipeline1", Alias: "pipeline1", Enabled: true, Filter: filter,
+			Config: []PipelineOperator{
+				{ID: "add", Type: "add", Field: "attributes.team", Value: "payments", Enabled: true},
+			},
+		},
+		{
+			Name: "pipeline2", Alias: "pipeline2", Enabled: true, Filter: filter,
+			Config: []PipelineOperator{
+				{ID: "add", Type: "add", Field: "attributes.owner", Value: "platform", Enabled: true},
+			},
+		},
+	}
+
+	now := time.Now().UnixMilli()
+	response, err := controller.PreviewLogsPipelines(context.Background(), &PipelinesPreviewRequest{
+		Pipelines:    testPipelines,
+		Logs:         []model.SignozLog{makeTestSignozLog("sent", map[string]interface{}{"method": "GET"})},
+		Sample:       &PipelinesPreviewSample{Start: now - time.Hour.Milliseconds(), End: now},
+		IncludeSteps: true,
+	})
+	require.Nil(err)
+
+	// every pipeline gets sampled and duplicate logs are previewed once
+	require.Equal([]*v3.FilterSet{filter, filter}, filters)
+	require.Equal(2, len(response.InputLogs))
+	require.Equal("sampled", response.InputLogs[1].ID)
+	require.Equal(2, len(response.OutputLogs))
+	require.Equal("platform", response.OutputLogs[1].Attributes_string["owner"])
+	require.Equal(2, len(response.Steps))
+
+	// invalid sample windows are rejected
+	_, err = controller.PreviewLogsPipelines(context.Background(), &PipelinesPreviewRequest{
+		Pipelines: testPipelines,
+		Sample:    &PipelinesPreviewSample{Start: now, End: now - 1},
+	})
+	require.NotNil(err)
+	require.Equal(model.ErrorBadData, err.Type())
+}
+
+func TestRowsToSignozLogs(t *testing.T) {
+	require := require.New(t)
+
+	id := "log-id"
+	body := "body"
+	severityNumber := uint8(9)
+	attributesNumber := map[string]float64{"status": 200}
+	resources := map[string]string{"service.name": "api"}
+	ts := time.Unix(0, 1700000000000000000)
+
+	logs := rowsToSignozLogs([]*v3.Row{
+		{
+			Timestamp: ts,
+			Data: map[string]interface{}{
+				"id":                &id,
+				"body":              &body,
+				"severity_number":   &severityNumber,
+				"attributes_number": &attributesNumber,
+				"resources_string":  &resources,
+			},
+		},
+	})
+	require.Equal(1, len(logs))
+	require.Equal(uint64(ts.UnixNano()), logs[0].Timestamp)
+	require.Equal(id, logs[0].ID)
+	require.Equal(body, logs[0].Body)
+	require.Equal(severityNumber, logs[0].SeverityNumber)
+	require.Equal(attributesNumber, logs[0].Attributes_float64)
+	require.Equal(resources, logs[0].Resources_string)
+	require.NotNil(logs[0].Attributes_string)
+}
+
+func getMethodFilter(method string) *v3.FilterSet {
+	return &v3.FilterSet{
+		Operator: "AND",
+		Items: []v3.FilterItem{
+			{
+				Key: v3.AttributeKey{
+					Key:      "method",
+					DataType: v3.AttributeKeyDataTypeString,
+					Type:     v3.AttributeKeyTypeTag,
+				},
+				Operator: "=",
+				Value:    method,
+			},
+		},
+	}
+}
@@ -0,0 +1,281 @@
+package logparsingpipeline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operators for removing sensitive data from logs. A redact operator replaces
+// the matches of its regex and detectors in a field with a mask, a pseudonymize
+// operator replaces them with a salted pseudonym so that the same value can
+// still be correlated across logs. Without a regex or detectors the whole value
+// of the field is replaced.
+//
+// The pseudonyms are not a cryptographic hash: the collector expressions have
+// no hash functions, so they are computed with polynomial hashes. Anyone who
+// knows a value and its pseudonym can link the other pseudonyms produced with
+// the same salt to their values by guessing them, so pseudonymize only hides
+// values from casual readers. Use redact for values that must not be recovered.
+//
+// The collector has no operator for replacing substrings, so the field is
+// scanned left to right by a bounded sequence of regex parsers and add
+// operators. Whatever still matches after the last pass is replaced entirely.
+
+const (
+	redactOperatorType       = "redact"
+	pseudonymizeOperatorType = "pseudonymize"
+
+	defaultRedactionMask = "[REDACTED]"
+
+	// matches replaced one by one in each field, the rest of the field
+	// is replaced entirely
+	maxRedactionPasses = 10
+
+	// temporary attributes used by the translated operators
+	redactionRestField  = "attributes.__signoz_redact_rest__"
+	redactionDoneField  = "attributes.__signoz_redact_done__"
+	redactionMatchField = "attributes.__signoz_redact_match__"
+
+	// the base64 alphabet, used as character codes by the pseudonyms
+	pseudonymAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/="
+)
+
+const (
+	detectorEmail      = "email"
+	detectorIPv4       = "ipv4"
+	detectorIPv6       = "ipv6"
+	detectorCreditCard = "credit_card"
+	detectorJWT        = "jwt"
+	detectorAWSKey     = "aws_access_key"
+)
+
+// redactionDetectors are the built in patterns, each is used as a named
+// capture group in the scanning regex
+var redactionDetectors = map[string]string{
+	detectorEmail: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	detectorIPv4:  `\b(?:(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\b`,
+	detectorIPv6: `(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}` +
+		`|(?:[0-9A-Fa-f]{1,4}:){1,6}(?::[0-9A-Fa-f]{1,4}){1,6}` +
+		`|(?:[0-9A-Fa-f]{1,4}:){1,7}:` +
+		`|::(?:[0-9A-Fa-f]{1,4}:){0,6}[0-9A-Fa-f]{1,4}`,
+	// matches are replaced only if they pass the luhn check
+	detectorCreditCard: `\b(?:[0-9][ -]?){12,18}[0-9]\b`,
+	detectorJWT:        `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	detectorAWSKey:     `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+}
+
+func isRedactionOperator(operatorType string) bool {
+	return operatorType == redactOperatorType || operatorType == pseudonymizeOperatorType
+}
+
+// redactionGroups returns the names of the capture groups of the scanning
+// regex and the pattern matching any of them
+func redactionGroups(operator PipelineOperator) ([]string, string) {
+	names, patterns := []string{}, []string{}
+	for _, detector := range operator.Detectors {
+		names = append(names, detector)
+		patterns = append(patterns, fmt.Sprintf("(?P<%s>%s)", detector, redactionDetectors[detector]))
+	}
+	if operator.Regex != "" {
+		names = append(names, "custom")
+		patterns = append(patterns, fmt.Sprintf("(?P<custom>%s)", operator.Regex))
+	}
+	return names, strings.Join(patterns, "|")
+}
+
+// redactionScanRegex splits a value at the first match into pre, the match groups and post
+func redactionScanRegex(pattern string) string {
+	return fmt.Sprintf(`(?s)^(?P<pre>.*?)(?:%s)(?P<post>.*)$`, pattern)
+}
+
+func validateRedactionOperator(op PipelineOperator) error {
+	if op.Field == "" {
+		return fmt.Errorf("field of %s processor %s cannot be empty", op.Type, op.ID)
+	}
+	if op.Type == pseudonymizeOperatorType && op.Salt == "" {
+		return fmt.Errorf("salt of pseudonymize processor %s cannot be empty", op.ID)
+	}
+	for _, detector := range op.Detectors {
+		if _, ok := redactionDetectors[detector]; !ok {
+			return fmt.Errorf(
+				"unknown detector %s in %s processor %s, use one of (%s, %s, %s, %s, %s, %s)",
+				detector, op.Type, op.ID,
+				detectorEmail, detectorIPv4, detectorIPv6, detectorCreditCard, detectorJWT, detectorAWSKey,
+			)
+		}
+	}
+	if op.Regex != "" {
+		r, err := regexp.Compile(op.Regex)
+		if err != nil {
+			return fmt.Errorf("error compiling regex expression of %s processor %s: %w", op.Type, op.ID, err)
+		}
+		if r.MatchString("") {
+			return fmt.Errorf("regex expression of %s processor %s must not match empty values", op.Type, op.ID)
+		}
+		// named groups of the regex must not conflict with the ones used for scanning
+		for _, name := range r.SubexpNames() {
+			if _, ok := redactionDetectors[name]; ok || name == "pre" || name == "post" || name == "custom" {
+				return fmt.Errorf("regex expression of %s processor %s cannot use the group name %s", op.Type, op.ID, name)
+			}
+		}
+	}
+	return nil
+}
+
+// redactionReplacement returns the expression replacing the value
+func redactionReplacement(operator PipelineOperator, value string) string {
+	if operator.Type == pseudonymizeOperatorType {
+		return pseudonymExpr(value, operator.Salt)
+	}
+	mask := operator.Mask
+	if mask == "" {
+		mask = defaultRedactionMask
+	}
+	return exprString(mask)
+}
+
+// pseudonymExpr returns an expression for a salted pseudonym of the value, made
+// of two polynomial hashes of the character codes. The value is base64 encoded
+// first so that every byte of non ascii characters contributes to the codes.
+func pseudonymExpr(value string, salt string) string {
+	codes := fmt.Sprintf(`map(split(toBase64(%s + %s), ""), indexOf(%s, #) + 1)`, exprString(salt), value, exprString(pseudonymAlphabet))
+	return fmt.Sprintf(
+		`"pseudonym_" + string(reduce(%s, (#acc * 131 + #) %% 1000000007, 7)) + "_" + string(reduce(%s, (#acc * 137 + #) %% 998244353, 11))`,
+		codes, codes,
+	)
+}
+
+// luhnExpr returns an expression checking the digits of the value with the luhn algorithm
+func luhnExpr(value string) string {
+	return fmt.Sprintf(
+		`reduce(reverse(filter(split(%s, ""), # matches "^[0-9]$")), #acc + (#index %% 2 == 1 ? (int(#) * 2 > 9 ? int(#) * 2 - 9 : int(#) * 2) : int(#)), 0) %% 10 == 0`,
+		value,
+	)
+}
+
+// Generates the collector operators for a redact or pseudonymize operator
+func redactionOperators(operator PipelineOperator) ([]PipelineOperator, error) {
+	field := operator.Field
+	fieldNotNilCheck, err := fieldNotNilCheck(field)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't generate nil check for field: %w", err)
+	}
+
+	names, pattern := redactionGroups(operator)
+	if pattern == "" {
+		// the whole value is replaced
+		return []PipelineOperator{{
+			ID:      operator.ID,
+			Type:    "add",
+			OnError: operator.OnError,
+			Field:   field,
+			Value:   fmt.Sprintf("EXPR(%s)", redactionReplacement(operator, fmt.Sprintf("string(%s)", field))),
+			If:      fieldNotNilCheck,
+		}}, nil
+	}
+
+	restNotNilCheck, err := fieldNotNilCheckOrErr(redactionRestField)
+	if err != nil {
+		return nil, err
+	}
+	matchNotNilCheck, err := fieldNotNilCheckOrErr(redactionMatchField)
+	if err != nil {
+		return nil, err
+	}
+	doneNotNilCheck, err := fieldNotNilCheckOrErr(redactionDoneField)
+	if err != nil {
+		return nil, err
+	}
+	fieldMatches, err := regexParserCondition(field, pattern)
+	if err != nil {
+		return nil, err
+	}
+	restMatches, err := regexParserCondition(redactionRestField, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	newOp := func(id string, opType string) PipelineOperator {
+		return PipelineOperator{ID: id, Type: opType, OnError: operator.OnError}
+	}
+
+	// the matched text is the one non empty group, credit card numbers
+	// failing the luhn check are kept
+	groups := []string{}
+	for _, name := range names {
+		groups = append(groups, fmt.Sprintf("%s.%s", redactionMatchField, name))
+	}
+	matched := strings.Join(groups, " + ")
+	replacement := redactionReplacement(operator, fmt.Sprintf("(%s)", matched))
+	for _, name := range names {
+		if name == detectorCreditCard {
+			card := fmt.Sprintf("%s.%s", redactionMatchField, name)
+			replacement = fmt.Sprintf(`(%s != "" && !(%s) ? %s : %s)`, card, luhnExpr(card), card, replacement)
+		}
+	}
+
+	start := newOp(operator.ID, "add")
+	start.Field = redactionRestField
+	start.Value = fmt.Sprintf("EXPR(%s)", field)
+	start.If = fmt.Sprintf(`%s && type(%s) == "string" && %s`, fieldNotNilCheck, field, fieldMatches)
+
+	done := newOp(operator.ID+"-init", "add")
+	done.Field = redactionDoneField
+	done.Value = `EXPR("")`
+	done.If = restNotNilCheck
+
+	operators := []PipelineOperator{start, done}
+	for i := 0; i < maxRedactionPasses; i++ {
+		scan := newOp(fmt.Sprintf("%s-scan-%d", operator.ID, i), "regex_parser")
+		scan.ParseFrom = redactionRestField
+		scan.ParseTo = redactionMatchField
+		scan.Regex = redactionScanRegex(pattern)
+		scan.If = fmt.Sprintf("%s && %s", restNotNilCheck, restMatches)
+
+		replace := newOp(fmt.Sprintf("%s-replace-%d", operator.ID, i), "add")
+		replace.Field = redactionDoneField
+		replace.Value = fmt.Sprintf("EXPR(%s + %s.pre + %s)", redactionDoneField, redactionMatchField, replacement)
+		replace.If = matchNotNilCheck
+
+		rest := newOp(fmt.Sprintf("%s-rest-%d", operator.ID, i), "add")
+		rest.Field = redactionRestField
+		rest.Value = fmt.Sprintf("EXPR(%s.post)", redactionMatchField)
+		rest.If = matchNotNilCheck
+
+		cleanup := newOp(fmt.Sprintf("%s-scan-cleanup-%d", operator.ID, i), "remove")
+		cleanup.Field = redactionMatchField
+		cleanup.If = matchNotNilCheck
+
+		operators = append(operators, scan, replace, rest, cleanup)
+	}
+
+	// matches beyond the passes are not left in the field
+	overflow := newOp(operator.ID+"-overflow", "add")
+	overflow.Field = redactionRestField
+	overflow.Value = fmt.Sprintf("EXPR(%s)", redactionReplacement(operator, redactionRestField))
+	overflow.If = fmt.Sprintf("%s && %s", restNotNilCheck, restMatches)
+
+	result := newOp(operator.ID+"-result", "add")
+	result.Field = field
+	result.Value = fmt.Sprintf("EXPR(%s + %s)", redactionDoneField, redactionRestField)
+	result.If = fmt.Sprintf("%s && %s", restNotNilCheck, doneNotNilCheck)
+
+	cleanupDone := newOp(operator.ID+"-cleanup-done", "remove")
+	cleanupDone.Field = redactionDoneField
+	cleanupDone.If = doneNotNilCheck
+
+	cleanupRest := newOp(operator.ID+"-cleanup-rest", "remove")
+	cleanupRest.Field = redactionRestField
+	cleanupRest.If = restNotNilCheck
+
+	return append(operators, overflow, result, cleanupDone, cleanupRest), nil
+}
+
+func fieldNotNilCheckOrErr(field string) (string, error) {
+	check, err := fieldNotNilCheck(field)
+	if err != nil {
+		return "", fmt.Errorf("couldn't generate nil check for %s: %w", field, err)
+	}
+	return check, nil
+}
@@ -260,6 +260,9 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	timeFilter := fmt.Sprintf("(timestamp >= %d AND timestamp <= %d)", utils.GetEpochNanoSecs(start), utils.GetEpochNanoSecs(end))
 
 	selectLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy)
+	if panelType == v3.PanelTypeHeatmap {
+		selectLabels += fmt.Sprintf(" %s as `%s`,", utils.HeatmapBucketExpression(getClickhouseColumnName(mq.AggregateAttribute), mq.HeatmapBuckets), utils.HeatmapBucketLabel)
+	}
 
 	having := Having(mq.Having)
 	if having != "" {
@@ -274,7 +277,7 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 			"SELECT now() as ts,"
 		// step or aggregate interval is whole time period in case of table panel
 		step = (utils.GetEpochNanoSecs(end) - utils.GetEpochNanoSecs(start)) / 1000000000
-	} else if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue {
+	} else if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue || panelType == v3.PanelTypeHeatmap {
 		// Select the aggregate value for interval
 		queryTmpl =
 			fmt.Sprintf("SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL %d SECOND) AS ts,", step)
@@ -298,6 +301,9 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	if panelType != v3.PanelTypeList && groupBy != "" {
 		groupBy = " group by " + groupBy
 	}
+	if panelType == v3.PanelTypeHeatmap {
+		groupBy += fmt.Sprintf(",`%s`", utils.HeatmapBucketLabel)
+	}
 	orderBy := orderByAttributeKeyTags(panelType, mq.OrderBy, mq.GroupBy)
 	if panelType != v3.PanelTypeList && orderBy != "" {
 		orderBy = " order by " + orderBy
@@ -391,7 +397,7 @@ func buildLogsLiveTailQuery(mq *v3.BuilderQuery) (string, error) {
 // groupBy returns a string of comma separated tags for group by clause
 // `ts` is always added to the group by clause
 func groupBy(panelType v3.PanelType, graphLimitQtype string, tags ...string) string {
-	if (graphLimitQtype != constants.FirstQueryGraphLimit) && (panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue) {
+	if (graphLimitQtype != constants.FirstQueryGraphLimit) && (panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue || panelType == v3.PanelTypeHeatmap) {
 		tags = append(tags, "ts")
 	}
 	return strings.Join(tags, ",")
@@ -505,6 +511,13 @@ func PrepareLogsQuery(start, end int64, queryType v3.QueryType, panelType v3.Pan
 	// 	end = end - (end % (mq.StepInterval * 1000))
 	// }
 
+	if panelType == v3.PanelTypeHeatmap {
+		// the heatmap counts the logs in the bucket of the value of the aggregate attribute
+		heatmapQuery := *mq
+		heatmapQuery.AggregateOperator = v3.AggregateOperatorCount
+		mq = &heatmapQuery
+	}
+
 	if options.IsLivetailQuery {
 		query, err := buildLogsLiveTailQuery(mq)
 		if err != nil {
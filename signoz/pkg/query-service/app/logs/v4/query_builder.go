@@ -366,6 +366,10 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	if err != nil {
 		return "", err
 	}
+	if panelType == v3.PanelTypeHeatmap && filterSubQuery == "" && mq.AggregateAttribute.Type != v3.AttributeKeyTypeResource {
+		// the heatmap only buckets the logs having the aggregate attribute, even without filters
+		filterSubQuery = getExistsNexistsFilter(v3.FilterOperatorExists, v3.FilterItem{Key: mq.AggregateAttribute})
+	}
 	if filterSubQuery != "" {
 		filterSubQuery = " AND " + filterSubQuery
 	}
@@ -382,6 +386,9 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 
 	// get the select labels
 	selectLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy)
+	if panelType == v3.PanelTypeHeatmap {
+		selectLabels += fmt.Sprintf(" %s as `%s`,", utils.HeatmapBucketExpression(getClickhouseKey(mq.AggregateAttribute), mq.HeatmapBuckets), utils.HeatmapBucketLabel)
+	}
 
 	// get the order by clause
 	orderBy := orderByAttributeKeyTags(panelType, mq.OrderBy, mq.GroupBy)
@@ -412,6 +419,9 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	if panelType != v3.PanelTypeList && groupBy != "" {
 		groupBy = " group by " + groupBy
 	}
+	if panelType == v3.PanelTypeHeatmap {
+		groupBy += fmt.Sprintf(",`%s`", utils.HeatmapBucketLabel)
+	}
 
 	// get the aggregation key
 	aggregationKey := ""
@@ -436,7 +446,7 @@ func buildLogsQuery(panelType v3.PanelType, start, end, step int64, mq *v3.Build
 	} else if panelType == v3.PanelTypeTable {
 		queryTmplPrefix =
 			"SELECT"
-	} else if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue {
+	} else if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue || panelType == v3.PanelTypeHeatmap {
 		// Select the aggregate value for interval
 		queryTmplPrefix =
 			fmt.Sprintf("SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL %d SECOND) AS ts,", step)
@@ -497,6 +507,13 @@ func PrepareLogsQuery(start, end int64, queryType v3.QueryType, panelType v3.Pan
 	// 	end = end - (end % (mq.StepInterval * 1000))
 	// }
 
+	if panelType == v3.PanelTypeHeatmap {
+		// the heatmap counts the logs in the bucket of the value of the aggregate attribute
+		heatmapQuery := *mq
+		heatmapQuery.AggregateOperator = v3.AggregateOperatorCount
+		mq = &heatmapQuery
+	}
+
 	if options.IsLivetailQuery {
 		query, err := buildLogsLiveTailQuery(mq)
 		if err != nil {
@@ -433,6 +433,38 @@ func Test_buildLogsTimeSeriesFilterQuery(t *testing.T) {
 			want: "attributes_string['service.name'] = 'test' AND mapContains(attributes_string, 'service.name') " +
 				"AND mapContains(attributes_string, 'user_name') AND `attribute_string_method_exists`=true AND mapContains(attributes_string, 'test')",
 		},
+		{
+			name: "build logs time series filter query without filters",
+			args: args{
+				fs: &v3.FilterSet{},
+				groupBy: []v3.AttributeKey{
+					{
+						Key:      "user_name",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+				},
+				aggregateAttribute: v3.AttributeKey{
+					Key:      "test",
+					DataType: v3.AttributeKeyDataTypeString,
+					Type:     v3.AttributeKeyTypeTag,
+				},
+			},
+			want: "",
+		},
+		{
+			name: "build logs time series filter query with nil filters",
+			args: args{
+				groupBy: []v3.AttributeKey{
+					{
+						Key:      "user_name",
+						DataType: v3.AttributeKeyDataTypeString,
+						Type:     v3.AttributeKeyTypeTag,
+					},
+				},
+			},
+			want: "",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1093,6 +1125,48 @@ func TestPrepareLogsQuery(t *testing.T) {
 				"signoz_logs.distributed_logs_v2 where (timestamp >= 1680066360726000000 AND timestamp <= 1680066458000000000) AND (ts_bucket_start >= 1680064560 AND ts_bucket_start <= 1680066458) AND " +
 				"id < '2TNh4vp2TpiWyLt3SzuadLJF2s4' order by attributes_string['method'] desc LIMIT 50 OFFSET 50",
 		},
+		{
+			name: "GRAPH: Test count distinct without filters doesn't filter on group by and aggregate attributes",
+			args: args{
+				start:     1680066360726,
+				end:       1680066458000,
+				queryType: v3.QueryTypeBuilder,
+				panelType: v3.PanelTypeGraph,
+				mq: &v3.BuilderQuery{
+					QueryName:          "A",
+					StepInterval:       60,
+					AggregateOperator:  v3.AggregateOperatorCountDistinct,
+					AggregateAttribute: v3.AttributeKey{Key: "user", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag},
+					Expression:         "A",
+					Filters:            &v3.FilterSet{},
+					GroupBy:            []v3.AttributeKey{{Key: "method", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}},
+				},
+			},
+			want: "SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL 60 SECOND) AS ts, attributes_string['method'] as `method`, " +
+				"toFloat64(count(distinct(attributes_string['user']))) as value from signoz_logs.distributed_logs_v2 where (timestamp >= 1680066360726000000 AND timestamp <= 1680066458000000000) " +
+				"AND (ts_bucket_start >= 1680064560 AND ts_bucket_start <= 1680066458) group by `method`,ts order by value DESC",
+		},
+		{
+			name: "HEATMAP: Test count per bucket of a numeric attribute",
+			args: args{
+				start:     1680066360726,
+				end:       1680066458000,
+				queryType: v3.QueryTypeBuilder,
+				panelType: v3.PanelTypeHeatmap,
+				mq: &v3.BuilderQuery{
+					QueryName:          "A",
+					StepInterval:       60,
+					AggregateOperator:  v3.AggregateOperatorNoOp,
+					AggregateAttribute: v3.AttributeKey{Key: "bytes", DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag},
+					Expression:         "A",
+					Filters:            &v3.FilterSet{},
+				},
+			},
+			want: "SELECT toStartOfInterval(fromUnixTimestamp64Nano(timestamp), INTERVAL 60 SECOND) AS ts, " +
+				"if(toFloat64(attributes_number['bytes']) <= 0, '0', toString(pow(2, ceil(log2(toFloat64(attributes_number['bytes'])))))) as `le`, " +
+				"toFloat64(count(*)) as value from signoz_logs.distributed_logs_v2 where (timestamp >= 1680066360726000000 AND timestamp <= 1680066458000000000) " +
+				"AND (ts_bucket_start >= 1680064560 AND ts_bucket_start <= 1680066458) AND mapContains(attributes_number, 'bytes') group by ts,`le` order by value DESC",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -339,6 +339,10 @@ func reduceQuery(query string, reduceTo v3.ReduceToOperator, aggregateOperator v
 // step is in seconds
 func PrepareMetricQuery(start, end int64, queryType v3.QueryType, panelType v3.PanelType, mq *v3.BuilderQuery, options Options) (string, error) {
 
+	if panelType == v3.PanelTypeHeatmap {
+		return "", fmt.Errorf("heatmap panel type is only supported in v4 query range")
+	}
+
 	start, end = common.AdjustedMetricTimeRange(start, end, mq.StepInterval, *mq)
 
 	if valFilter := metrics.AddMetricValueFilter(mq); valFilter != nil {
@@ -2,6 +2,7 @@ package v4
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
 	"go.signoz.io/signoz/pkg/query-service/app/metrics"
@@ -29,7 +30,29 @@ func PrepareMetricQuery(start, end int64, queryType v3.QueryType, panelType v3.P
 
 	percentileOperator := mq.SpaceAggregation
 
-	if v3.IsPercentileOperator(mq.SpaceAggregation) &&
+	if panelType == v3.PanelTypeHeatmap {
+		// The heatmap shows the number of observations in each bucket
+		// so we group by le and take the increase of the bucket counts,
+		// the counts are de-cumulated in the post processing
+		heatmapQuery := *mq
+		heatmapQuery.TimeAggregation = v3.TimeAggregationIncrease
+		heatmapQuery.SpaceAggregation = v3.SpaceAggregationSum
+		leFound := false
+		for _, groupBy := range mq.GroupBy {
+			if groupBy.Key == "le" {
+				leFound = true
+				break
+			}
+		}
+		if !leFound {
+			heatmapQuery.GroupBy = append(slices.Clone(mq.GroupBy), v3.AttributeKey{
+				Key:      "le",
+				Type:     v3.AttributeKeyTypeTag,
+				DataType: v3.AttributeKeyDataTypeString,
+			})
+		}
+		mq = &heatmapQuery
+	} else if v3.IsPercentileOperator(mq.SpaceAggregation) &&
 		mq.AggregateAttribute.Type != v3.AttributeKeyType(v3.MetricTypeExponentialHistogram) {
 		quantile = v3.GetPercentileFromOperator(mq.SpaceAggregation)
 		// If quantile is set, we need to group by le
@@ -760,3 +760,37 @@ func TestPrepareMetricQueryValueTypePanelWithGroupBY(t *testing.T) {
 		})
 	}
 }
+
+func TestPrepareMetricQueryHeatmap(t *testing.T) {
+	t.Setenv("USE_METRICS_PRE_AGGREGATION", "false")
+	builderQuery := &v3.BuilderQuery{
+		QueryName:    "A",
+		StepInterval: 60,
+		DataSource:   v3.DataSourceMetrics,
+		AggregateAttribute: v3.AttributeKey{
+			Key:  "signoz_latency_bucket",
+			Type: v3.AttributeKeyType(v3.MetricTypeHistogram),
+		},
+		Temporality: v3.Cumulative,
+		Filters:     &v3.FilterSet{Operator: "AND"},
+		GroupBy: []v3.AttributeKey{{
+			Key:      "service_name",
+			DataType: v3.AttributeKeyDataTypeString,
+			Type:     v3.AttributeKeyTypeTag,
+		}},
+		Expression:       "A",
+		TimeAggregation:  v3.TimeAggregationRate,
+		SpaceAggregation: v3.SpaceAggregationPercentile99,
+	}
+
+	query, err := PrepareMetricQuery(1650991982000, 1651078382000, v3.QueryTypeBuilder, v3.PanelTypeHeatmap, builderQuery, metricsV3.Options{})
+	assert.Nil(t, err)
+	// the buckets are returned as the increase of their counts in each interval
+	assert.Contains(t, query, "SELECT service_name, le, ts, sum(per_series_value) as value")
+	assert.Contains(t, query, "GROUP BY service_name, le, ts ORDER BY service_name ASC, le ASC, ts ASC")
+	assert.NotContains(t, query, "histogramQuantile")
+	// the query of the caller is left as is
+	assert.Equal(t, v3.TimeAggregationRate, builderQuery.TimeAggregation)
+	assert.Equal(t, v3.SpaceAggregationPercentile99, builderQuery.SpaceAggregation)
+	assert.Len(t, builderQuery.GroupBy, 1)
+}
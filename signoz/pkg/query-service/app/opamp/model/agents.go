@@ -121,7 +121,7 @@ func (agents *Agents) RecommendLatestConfigToAll(
 ) error {
 	for _, agent := range agents.GetAllAgents() {
 		newConfig, confId, err := provider.RecommendAgentConfig(
-			[]byte(agent.EffectiveConfig),
+			agent.ID, []byte(agent.EffectiveConfig),
 		)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf(
@@ -134,7 +134,7 @@ func (agents *Agents) RecommendLatestConfigToAll(
 			zap.L().Info(
 				"Recommended config same as current effective config for agent", zap.String("agentID", agent.ID),
 			)
-			return nil
+			continue
 		}
 
 		newRemoteConfig := &protobufs.AgentRemoteConfig{
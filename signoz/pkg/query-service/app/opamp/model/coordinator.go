@@ -21,10 +21,16 @@ type OnChangeCallback func(agentId string, hash string, err error)
 type Coordinator struct {
 	mutex sync.Mutex
 
-	// hash wise list of subscribers
+	// agent and hash wise list of subscribers
 	subscribers map[string][]OnChangeCallback
 }
 
+// Agents getting the same config share its hash,
+// so subscriptions are tracked per agent.
+func subscriptionKey(agentId string, hash string) string {
+	return agentId + "/" + hash
+}
+
 func onConfigSuccess(agentId string, hash string) {
 	notifySubscribers(agentId, hash, nil)
 }
@@ -35,10 +41,17 @@ func onConfigFailure(agentId string, hash string, errorMessage string) {
 
 // OnSuccess listens to config changes and notifies subscribers
 func notifySubscribers(agentId string, hash string, err error) {
-	// this method currently does not handle multi-agent scenario.
 	// as soon as a message is delivered, we release all the subscribers
-	// for a given hash
-	subs, ok := coordinator.subscribers[hash]
+	// for the agent and hash
+	key := subscriptionKey(agentId, hash)
+
+	coordinator.mutex.Lock()
+	subs, ok := coordinator.subscribers[key]
+	// delete all subscribers for this agent and hash, assume future
+	// notifies will be disabled. the first response is processed
+	delete(coordinator.subscribers, key)
+	coordinator.mutex.Unlock()
+
 	if !ok {
 		return
 	}
@@ -46,10 +59,6 @@ func notifySubscribers(agentId string, hash string, err error) {
 	for _, s := range subs {
 		s(agentId, hash, err)
 	}
-
-	// delete all subscribers for this hash, assume future
-	// notifies will be disabled. the first response is processed
-	delete(coordinator.subscribers, hash)
 }
 
 // callers subscribe to this function to listen on config change requests
@@ -57,10 +66,11 @@ func ListenToConfigUpdate(agentId string, hash string, ss OnChangeCallback) {
 	coordinator.mutex.Lock()
 	defer coordinator.mutex.Unlock()
 
-	if subs, ok := coordinator.subscribers[hash]; ok {
+	key := subscriptionKey(agentId, hash)
+	if subs, ok := coordinator.subscribers[key]; ok {
 		subs = append(subs, ss)
-		coordinator.subscribers[hash] = subs
+		coordinator.subscribers[key] = subs
 	} else {
-		coordinator.subscribers[hash] = []OnChangeCallback{ss}
+		coordinator.subscribers[key] = []OnChangeCallback{ss}
 	}
 }
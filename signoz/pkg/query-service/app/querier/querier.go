@@ -10,6 +10,7 @@ import (
 	logsV4 "go.signoz.io/signoz/pkg/query-service/app/logs/v4"
 	metricsV3 "go.signoz.io/signoz/pkg/query-service/app/metrics/v3"
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimiter"
 	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
 	tracesV4 "go.signoz.io/signoz/pkg/query-service/app/traces/v4"
 	"go.signoz.io/signoz/pkg/query-service/common"
@@ -22,6 +23,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/querylimit"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
@@ -44,6 +46,7 @@ type querier struct {
 
 	builder       *queryBuilder.QueryBuilder
 	featureLookUp interfaces.FeatureLookup
+	limiter       *querylimiter.Limiter
 
 	// used for testing
 	// TODO(srikanthccv): remove this once we have a proper mock
@@ -64,6 +67,7 @@ type QuerierOptions struct {
 	KeyGenerator  cache.KeyGenerator
 	FluxInterval  time.Duration
 	FeatureLookup interfaces.FeatureLookup
+	QueryLimits   querylimit.Config
 
 	// used for testing
 	TestingMode       bool
@@ -85,6 +89,12 @@ func NewQuerier(opts QuerierOptions) interfaces.Querier {
 
 	qc := querycache.NewQueryCache(querycache.WithCache(opts.Cache), querycache.WithFluxInterval(opts.FluxInterval))
 
+	builder := queryBuilder.NewQueryBuilder(queryBuilder.QueryBuilderOptions{
+		BuildTraceQuery:  tracesQueryBuilder,
+		BuildLogQuery:    logsQueryBuilder,
+		BuildMetricQuery: metricsV3.PrepareMetricQuery,
+	}, opts.FeatureLookup)
+
 	return &querier{
 		cache:        opts.Cache,
 		queryCache:   qc,
@@ -92,11 +102,8 @@ func NewQuerier(opts QuerierOptions) interfaces.Querier {
 		keyGenerator: opts.KeyGenerator,
 		fluxInterval: opts.FluxInterval,
 
-		builder: queryBuilder.NewQueryBuilder(queryBuilder.QueryBuilderOptions{
-			BuildTraceQuery:  tracesQueryBuilder,
-			BuildLogQuery:    logsQueryBuilder,
-			BuildMetricQuery: metricsV3.PrepareMetricQuery,
-		}, opts.FeatureLookup),
+		builder:       builder,
+		limiter:       querylimiter.New(opts.Reader, builder, opts.QueryLimits),
 		featureLookUp: opts.FeatureLookup,
 
 		testingMode:       opts.TestingMode,
@@ -524,6 +531,12 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3)
 	var results []*v3.Result
 	var err error
 	var errQueriesByName map[string]error
+
+	estimates, errQueriesByName, err := q.limiter.Apply(ctx, params)
+	if err != nil {
+		return nil, errQueriesByName, err
+	}
+
 	if params.CompositeQuery != nil {
 		switch params.CompositeQuery.QueryType {
 		case v3.QueryTypeBuilder:
@@ -562,6 +575,10 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3)
 		}
 	}
 
+	for _, result := range results {
+		result.CostEstimate = estimates[result.QueryName]
+	}
+
 	return results, errQueriesByName, err
 }
 
@@ -19,6 +19,16 @@ import (
 	"go.uber.org/zap"
 )
 
+// builderPanelType returns the panel type the query of the builder query is
+// built for. The logs and traces queries reduced by the query service are
+// built as time series so that their gaps are filled before they are reduced.
+func builderPanelType(params *v3.QueryRangeParamsV3, builderQuery *v3.BuilderQuery) v3.PanelType {
+	if builderQuery.DataSource != v3.DataSourceMetrics && params.CompositeQuery.ReducedByQueryService(builderQuery.QueryName) {
+		return v3.PanelTypeGraph
+	}
+	return params.CompositeQuery.PanelType
+}
+
 func prepareLogsQuery(_ context.Context,
 	useLogsNewSchema bool,
 	start,
@@ -69,7 +79,7 @@ func prepareLogsQuery(_ context.Context,
 		start,
 		end,
 		params.CompositeQuery.QueryType,
-		params.CompositeQuery.PanelType,
+		builderPanelType(params, builderQuery),
 		builderQuery,
 		v3.QBOptions{PreferRPM: preferRPM},
 	)
@@ -195,7 +205,7 @@ func (q *querier) runBuilderQuery(
 			query, err = tracesQueryBuilder(
 				start,
 				end,
-				params.CompositeQuery.PanelType,
+				builderPanelType(params, builderQuery),
 				builderQuery,
 				v3.QBOptions{PreferRPM: preferRPM},
 			)
@@ -10,6 +10,7 @@ import (
 	logsV4 "go.signoz.io/signoz/pkg/query-service/app/logs/v4"
 	metricsV4 "go.signoz.io/signoz/pkg/query-service/app/metrics/v4"
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/app/querylimiter"
 	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
 	tracesV4 "go.signoz.io/signoz/pkg/query-service/app/traces/v4"
 	"go.signoz.io/signoz/pkg/query-service/common"
@@ -22,6 +23,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/querylimit"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
@@ -44,6 +46,7 @@ type querier struct {
 
 	builder       *queryBuilder.QueryBuilder
 	featureLookUp interfaces.FeatureLookup
+	limiter       *querylimiter.Limiter
 
 	// used for testing
 	// TODO(srikanthccv): remove this once we have a proper mock
@@ -63,6 +66,7 @@ type QuerierOptions struct {
 	KeyGenerator  cache.KeyGenerator
 	FluxInterval  time.Duration
 	FeatureLookup interfaces.FeatureLookup
+	QueryLimits   querylimit.Config
 
 	// used for testing
 	TestingMode       bool
@@ -85,6 +89,12 @@ func NewQuerier(opts QuerierOptions) interfaces.Querier {
 
 	qc := querycache.NewQueryCache(querycache.WithCache(opts.Cache), querycache.WithFluxInterval(opts.FluxInterval))
 
+	builder := queryBuilder.NewQueryBuilder(queryBuilder.QueryBuilderOptions{
+		BuildTraceQuery:  tracesQueryBuilder,
+		BuildLogQuery:    logsQueryBuilder,
+		BuildMetricQuery: metricsV4.PrepareMetricQuery,
+	}, opts.FeatureLookup)
+
 	return &querier{
 		cache:        opts.Cache,
 		queryCache:   qc,
@@ -92,11 +102,8 @@ func NewQuerier(opts QuerierOptions) interfaces.Querier {
 		keyGenerator: opts.KeyGenerator,
 		fluxInterval: opts.FluxInterval,
 
-		builder: queryBuilder.NewQueryBuilder(queryBuilder.QueryBuilderOptions{
-			BuildTraceQuery:  tracesQueryBuilder,
-			BuildLogQuery:    logsQueryBuilder,
-			BuildMetricQuery: metricsV4.PrepareMetricQuery,
-		}, opts.FeatureLookup),
+		builder:       builder,
+		limiter:       querylimiter.New(opts.Reader, builder, opts.QueryLimits),
 		featureLookUp: opts.FeatureLookup,
 
 		testingMode:       opts.TestingMode,
@@ -165,6 +172,33 @@ func (q *querier) execPromQuery(ctx context.Context, params *model.QueryRangePar
 	return seriesList, nil
 }
 
+// execListQuery executes the list query for the time window and returns the rows
+// the rows are served from the cache when the same query was already run for the window
+func (q *querier) execListQuery(ctx context.Context, dataSource v3.DataSource, query string, start, end int64, noCache bool) ([]*v3.Row, error) {
+	if q.queryCache == nil || noCache {
+		return q.reader.GetListResultV3(ctx, query)
+	}
+
+	cacheKey := querycache.ListCacheKey(dataSource, query, start, end)
+	if rowList, ok := q.queryCache.RetrieveListData(cacheKey); ok {
+		zap.L().Debug("cache hit for list query", zap.String("cacheKey", cacheKey), zap.Int("rows", len(rowList)))
+		return rowList, nil
+	}
+
+	rowList, err := q.reader.GetListResultV3(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// the windows can be in either milliseconds or nanoseconds
+	q.queryCache.StoreListData(cacheKey, querycache.CachedListData{
+		Start: utils.GetEpochNanoSecs(start) / int64(time.Millisecond),
+		End:   utils.GetEpochNanoSecs(end) / int64(time.Millisecond),
+		Rows:  rowList,
+	})
+	return rowList, nil
+}
+
 func (q *querier) runBuilderQueries(ctx context.Context, params *v3.QueryRangeParamsV3) ([]*v3.Result, map[string]error, error) {
 
 	cacheKeys := q.keyGenerator.GenerateKeys(params)
@@ -353,7 +387,7 @@ func (q *querier) runWindowBasedListQuery(ctx context.Context, params *v3.QueryR
 				return nil, nil, err
 			}
 			for name, query := range queries {
-				rowList, err := q.reader.GetListResultV3(ctx, query)
+				rowList, err := q.execListQuery(ctx, v3.DataSourceLogs, query, v.Start, v.End, params.NoCache)
 				if err != nil {
 					errs := []error{err}
 					errQueriesByName := map[string]error{
@@ -409,7 +443,7 @@ func (q *querier) runWindowBasedListQuery(ctx context.Context, params *v3.QueryR
 				return nil, nil, err
 			}
 			for name, query := range queries {
-				rowList, err := q.reader.GetListResultV3(ctx, query)
+				rowList, err := q.execListQuery(ctx, v3.DataSourceTraces, query, v.Start, v.End, params.NoCache)
 				if err != nil {
 					errs := []error{err}
 					errQueriesByName := map[string]error{
@@ -487,7 +521,14 @@ func (q *querier) runBuilderListQueries(ctx context.Context, params *v3.QueryRan
 		wg.Add(1)
 		go func(name, query string) {
 			defer wg.Done()
-			rowList, err := q.reader.GetListResultV3(ctx, query)
+			var rowList []*v3.Row
+			var err error
+			// only the builder queries are cached as the clickhouse queries can be relative to now()
+			if builderQuery, ok := params.CompositeQuery.BuilderQueries[name]; ok && params.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+				rowList, err = q.execListQuery(ctx, builderQuery.DataSource, query, params.Start, params.End, params.NoCache)
+			} else {
+				rowList, err = q.reader.GetListResultV3(ctx, query)
+			}
 
 			if err != nil {
 				ch <- channelResult{Err: err, Name: name, Query: query}
@@ -527,6 +568,12 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3)
 	var results []*v3.Result
 	var err error
 	var errQueriesByName map[string]error
+
+	estimates, errQueriesByName, err := q.limiter.Apply(ctx, params)
+	if err != nil {
+		return nil, errQueriesByName, err
+	}
+
 	if params.CompositeQuery != nil {
 		switch params.CompositeQuery.QueryType {
 		case v3.QueryTypeBuilder:
@@ -565,6 +612,10 @@ func (q *querier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3)
 		}
 	}
 
+	for _, result := range results {
+		result.CostEstimate = estimates[result.QueryName]
+	}
+
 	return results, errQueriesByName, err
 }
 
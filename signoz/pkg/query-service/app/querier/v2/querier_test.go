@@ -825,6 +825,59 @@ func TestV2QueryRangeValueType(t *testing.T) {
 	}
 }
 
+func TestV2QueryRangeValueTypeFillGaps(t *testing.T) {
+	cases := []struct {
+		name     string
+		fillGaps bool
+		reduced  bool
+	}{
+		{name: "reduced by the query", fillGaps: false, reduced: true},
+		{name: "reduced after the gaps are filled", fillGaps: true, reduced: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			param := &v3.QueryRangeParamsV3{
+				Start:   1675115596722,
+				End:     1675115596722 + 120*60*1000,
+				Step:    60,
+				Version: "v4",
+				CompositeQuery: &v3.CompositeQuery{
+					QueryType: v3.QueryTypeBuilder,
+					PanelType: v3.PanelTypeValue,
+					FillGaps:  c.fillGaps,
+					BuilderQueries: map[string]*v3.BuilderQuery{
+						"A": {
+							QueryName:         "A",
+							StepInterval:      60,
+							DataSource:        v3.DataSourceTraces,
+							AggregateOperator: v3.AggregateOperatorCount,
+							Expression:        "A",
+							ReduceTo:          v3.ReduceToOperatorSum,
+						},
+					},
+				},
+			}
+			q := NewQuerier(QuerierOptions{
+				Cache:        inmemory.New(&inmemory.Options{TTL: 60 * time.Minute, CleanupInterval: 10 * time.Minute}),
+				FluxInterval: 5 * time.Minute,
+				KeyGenerator: queryBuilder.NewKeyGenerator(),
+				TestingMode:  true,
+			})
+
+			tracesV3.Enrich(param, map[string]v3.AttributeKey{})
+			_, _, err := q.QueryRange(context.Background(), param)
+			if err != nil {
+				t.Errorf("expected no error, got %s", err)
+			}
+			query := q.QueriesExecuted()[0]
+			if reduced := strings.Contains(query, "sum(value) as value, now() as ts"); reduced != c.reduced {
+				t.Errorf("expected the query to be reduced: %v, got %s", c.reduced, query)
+			}
+		})
+	}
+}
+
 // test timeshift
 func TestV2QueryRangeTimeShift(t *testing.T) {
 	params := []*v3.QueryRangeParamsV3{
@@ -1488,3 +1541,96 @@ func Test_querier_runWindowBasedListQuery(t *testing.T) {
 		})
 	}
 }
+
+func Test_querier_runWindowBasedListQueryWithCache(t *testing.T) {
+	params := &v3.QueryRangeParamsV3{
+		Start: 1722171576000000000, // July 28, 2024 6:29:36 PM
+		End:   1722262800000000000, // July 29, 2024 7:50:00 PM
+		CompositeQuery: &v3.CompositeQuery{
+			PanelType: v3.PanelTypeList,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:         "A",
+					Expression:        "A",
+					DataSource:        v3.DataSourceTraces,
+					PageSize:          10,
+					Limit:             2,
+					StepInterval:      60,
+					AggregateOperator: v3.AggregateOperatorNoOp,
+					SelectColumns:     []v3.AttributeKey{{Key: "serviceName"}},
+					Filters: &v3.FilterSet{
+						Operator: "AND",
+						Items:    []v3.FilterItem{},
+					},
+				},
+			},
+		},
+	}
+
+	tsRanges := []utils.LogsListTsRange{
+		{
+			Start: 1722259200000000000, // July 29, 2024 6:50:00 PM
+			End:   1722262800000000000, // July 29, 2024 7:50:00 PM
+		},
+	}
+
+	cols := []cmock.ColumnType{
+		{Name: "timestamp", Type: "UInt64"},
+		{Name: "name", Type: "String"},
+	}
+	testName := "name"
+	timestamps := []uint64{1722259300000000000, 1722259400000000000}
+
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &regexMatcher{})
+	require.NoError(t, err, "Failed to create ClickHouse mock")
+
+	// the query is expected only once, the second run must be served from the cache
+	values := make([][]any, 0, len(timestamps))
+	for _, ts := range timestamps {
+		values = append(values, []any{&ts, &testName})
+	}
+	mock.ExpectQuery(".*(timestamp >= '1722259200000000000' AND timestamp <= '1722262800000000000').* DESC LIMIT 2").WillReturnRows(
+		cmock.NewRows(cols, values),
+	)
+
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(
+		mock,
+		clickhouseReader.NewOptions("", "", "archiveNamespace"),
+		nil,
+		"",
+		featureManager.StartManager(),
+		"",
+		true,
+		true,
+		time.Duration(time.Second),
+		nil,
+	)
+
+	c := inmemory.New(&inmemory.Options{TTL: 5 * time.Minute, CleanupInterval: 10 * time.Minute})
+	q := &querier{
+		reader:     reader,
+		queryCache: querycache.NewQueryCache(querycache.WithCache(c)),
+		builder: queryBuilder.NewQueryBuilder(
+			queryBuilder.QueryBuilderOptions{
+				BuildTraceQuery: tracesV3.PrepareTracesQuery,
+			},
+			featureManager.StartManager(),
+		),
+	}
+
+	for i := 0; i < 2; i++ {
+		params.CompositeQuery.BuilderQueries["A"].Limit = 2
+		params.CompositeQuery.BuilderQueries["A"].Offset = 0
+
+		results, errMap, err := q.runWindowBasedListQuery(context.Background(), params, tsRanges)
+		require.NoError(t, err, "Query execution failed")
+		require.Nil(t, errMap, "Unexpected error map in results")
+		require.Len(t, results, 1, "Expected exactly one result set")
+		require.Len(t, results[0].List, len(timestamps))
+		for idx, expected := range timestamps {
+			require.Equal(t, int64(expected), results[0].List[idx].Timestamp.UnixNano())
+		}
+	}
+
+	require.NoError(t, mock.ExpectationsWereMet(), "Mock expectations were not met")
+}
@@ -252,6 +252,302 @@ func median(values []float64) float64 {
 	return values[medianIndex]
 }
 
+// funcDerivative returns the per second rate of change between each point and the previous point
+func funcDerivative(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		if len(series.Points) == 0 {
+			continue
+		}
+		for idx := len(series.Points) - 1; idx > 0; idx-- {
+			series.Points[idx].Value = perSecond(series.Points[idx-1], series.Points[idx], series.Points[idx].Value-series.Points[idx-1].Value)
+		}
+		// remove the first point
+		// the timerange is already adjusted in the query range
+		series.Points = series.Points[1:]
+	}
+	return result
+}
+
+// funcRatePerSecond returns the per second rate of increase of a counter,
+// a decrease is treated as a counter reset
+func funcRatePerSecond(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		if len(series.Points) == 0 {
+			continue
+		}
+		for idx := len(series.Points) - 1; idx > 0; idx-- {
+			increase := series.Points[idx].Value - series.Points[idx-1].Value
+			if increase < 0 {
+				// the counter was reset, the current value is the increase since the reset
+				increase = series.Points[idx].Value
+			}
+			series.Points[idx].Value = perSecond(series.Points[idx-1], series.Points[idx], increase)
+		}
+		// remove the first point
+		// the timerange is already adjusted in the query range
+		series.Points = series.Points[1:]
+	}
+	return result
+}
+
+// perSecond divides the delta by the seconds elapsed between the points
+func perSecond(prev, curr v3.Point, delta float64) float64 {
+	seconds := float64(curr.Timestamp-prev.Timestamp) / 1000
+	if seconds <= 0 || math.IsNaN(delta) {
+		return math.NaN()
+	}
+	return delta / seconds
+}
+
+// funcIntegral returns the cumulative area under the series using the trapezoidal rule,
+// the values are treated as per second rates
+func funcIntegral(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		var sum float64
+		prev := math.NaN()
+		var prevTimestamp int64
+		for idx, point := range series.Points {
+			if !math.IsNaN(point.Value) && !math.IsNaN(prev) {
+				sum += (prev + point.Value) / 2 * float64(point.Timestamp-prevTimestamp) / 1000
+			}
+			if !math.IsNaN(point.Value) {
+				prev = point.Value
+				prevTimestamp = point.Timestamp
+			}
+			series.Points[idx].Value = sum
+		}
+	}
+	return result
+}
+
+// funcFillForward replaces NaN values with the previous non NaN value
+func funcFillForward(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		last := math.NaN()
+		for idx, point := range series.Points {
+			if math.IsNaN(point.Value) {
+				series.Points[idx].Value = last
+				continue
+			}
+			last = point.Value
+		}
+	}
+	return result
+}
+
+// funcFillZero replaces NaN values with zero
+func funcFillZero(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		for idx, point := range series.Points {
+			if math.IsNaN(point.Value) {
+				series.Points[idx].Value = 0
+			}
+		}
+	}
+	return result
+}
+
+// funcFillLinear replaces NaN values with the linear interpolation of the surrounding non NaN values,
+// the NaN values at the start and the end of the series are left as is
+func funcFillLinear(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		prevIdx := -1
+		for idx, point := range series.Points {
+			if math.IsNaN(point.Value) {
+				continue
+			}
+			if prevIdx >= 0 && idx-prevIdx > 1 {
+				prev := series.Points[prevIdx]
+				slope := (point.Value - prev.Value) / float64(point.Timestamp-prev.Timestamp)
+				for gapIdx := prevIdx + 1; gapIdx < idx; gapIdx++ {
+					series.Points[gapIdx].Value = prev.Value + slope*float64(series.Points[gapIdx].Timestamp-prev.Timestamp)
+				}
+			}
+			prevIdx = idx
+		}
+	}
+	return result
+}
+
+// funcMovingWindow replaces each point with the aggregate of the non NaN values
+// of the window of points ending at the point
+func funcMovingWindow(result *v3.Result, window int, aggregate func(values []float64) float64) *v3.Result {
+	for _, series := range result.Series {
+		values := make([]float64, len(series.Points))
+		for idx, point := range series.Points {
+			values[idx] = point.Value
+		}
+		windowValues := make([]float64, 0, window)
+		for idx := range series.Points {
+			windowValues = windowValues[:0]
+			for _, value := range values[max(0, idx-window+1) : idx+1] {
+				if !math.IsNaN(value) {
+					windowValues = append(windowValues, value)
+				}
+			}
+			if len(windowValues) == 0 {
+				series.Points[idx].Value = math.NaN()
+				continue
+			}
+			series.Points[idx].Value = aggregate(windowValues)
+		}
+	}
+	return result
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+func mean(values []float64) float64 {
+	return sum(values) / float64(len(values))
+}
+
+// percentile returns the percentile of the values using linear interpolation between the closest ranks
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+}
+
+// funcZScore returns the number of standard deviations each point is away from the mean of the series
+func funcZScore(result *v3.Result) *v3.Result {
+	for _, series := range result.Series {
+		values := make([]float64, 0, len(series.Points))
+		for _, point := range series.Points {
+			if !math.IsNaN(point.Value) {
+				values = append(values, point.Value)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		avg := mean(values)
+		var variance float64
+		for _, value := range values {
+			variance += (value - avg) * (value - avg)
+		}
+		stddev := math.Sqrt(variance / float64(len(values)))
+
+		for idx, point := range series.Points {
+			if math.IsNaN(point.Value) {
+				continue
+			}
+			if stddev == 0 {
+				series.Points[idx].Value = 0
+				continue
+			}
+			series.Points[idx].Value = (point.Value - avg) / stddev
+		}
+	}
+	return result
+}
+
+// funcHoltWinters returns the double exponential smoothing of the series,
+// the smoothing factor weighs the recent values and the trend factor weighs the recent trend
+func funcHoltWinters(result *v3.Result, smoothingFactor, trendFactor float64) *v3.Result {
+	for _, series := range result.Series {
+		var level, trend, prevValue float64
+		// seen is the number of non NaN values seen so far
+		seen := 0
+		for idx, point := range series.Points {
+			if math.IsNaN(point.Value) {
+				continue
+			}
+			switch seen {
+			case 0:
+				level = point.Value
+			case 1:
+				// the initial trend is the difference between the first two values
+				trend = point.Value - prevValue
+				fallthrough
+			default:
+				prevLevel := level
+				level = smoothingFactor*point.Value + (1-smoothingFactor)*(level+trend)
+				trend = trendFactor*(level-prevLevel) + (1-trendFactor)*trend
+			}
+			prevValue = point.Value
+			seen++
+			series.Points[idx].Value = level
+		}
+	}
+	return result
+}
+
+// funcTopKByWindow splits the time range into consecutive windows of points and
+// keeps the values of the k series with the highest average in each window, the
+// values of the other series are replaced with NaN. The series that are not in
+// the top k of any window are removed.
+func funcTopKByWindow(result *v3.Result, k, window int) *v3.Result {
+	timestamps := []int64{}
+	seen := make(map[int64]struct{})
+	for _, series := range result.Series {
+		for _, point := range series.Points {
+			if _, ok := seen[point.Timestamp]; !ok {
+				seen[point.Timestamp] = struct{}{}
+				timestamps = append(timestamps, point.Timestamp)
+			}
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	// windowOf is the index of the window of each timestamp
+	windowOf := make(map[int64]int, len(timestamps))
+	for idx, ts := range timestamps {
+		windowOf[ts] = idx / window
+	}
+	windows := (len(timestamps) + window - 1) / window
+
+	// kept holds the windows in which each series is in the top k
+	kept := make([][]bool, len(result.Series))
+	for windowIdx := 0; windowIdx < windows; windowIdx++ {
+		type seriesAvg struct {
+			idx int
+			avg float64
+		}
+		avgs := []seriesAvg{}
+		for seriesIdx, series := range result.Series {
+			values := []float64{}
+			for _, point := range series.Points {
+				if windowOf[point.Timestamp] == windowIdx && !math.IsNaN(point.Value) {
+					values = append(values, point.Value)
+				}
+			}
+			if len(values) > 0 {
+				avgs = append(avgs, seriesAvg{idx: seriesIdx, avg: mean(values)})
+			}
+		}
+		sort.SliceStable(avgs, func(i, j int) bool { return avgs[i].avg > avgs[j].avg })
+		for _, avg := range avgs[:min(k, len(avgs))] {
+			if kept[avg.idx] == nil {
+				kept[avg.idx] = make([]bool, windows)
+			}
+			kept[avg.idx][windowIdx] = true
+		}
+	}
+
+	filtered := make([]*v3.Series, 0, len(result.Series))
+	for seriesIdx, series := range result.Series {
+		if kept[seriesIdx] == nil {
+			continue
+		}
+		for idx, point := range series.Points {
+			if !kept[seriesIdx][windowOf[point.Timestamp]] {
+				series.Points[idx].Value = math.NaN()
+			}
+		}
+		filtered = append(filtered, series)
+	}
+	result.Series = filtered
+	return result
+}
+
 func ApplyFunction(fn v3.Function, result *v3.Result) *v3.Result {
 
 	switch fn.Name {
@@ -305,6 +601,73 @@ func ApplyFunction(fn v3.Function, result *v3.Result) *v3.Result {
 			return result
 		}
 		return funcTimeShift(result, shift)
+	case v3.FunctionNameDerivative:
+		return funcDerivative(result)
+	case v3.FunctionNameRatePerSecond:
+		return funcRatePerSecond(result)
+	case v3.FunctionNameIntegral:
+		return funcIntegral(result)
+	case v3.FunctionNameFillForward:
+		return funcFillForward(result)
+	case v3.FunctionNameFillZero:
+		return funcFillZero(result)
+	case v3.FunctionNameFillLinear:
+		return funcFillLinear(result)
+	case v3.FunctionNameMovingAverage, v3.FunctionNameMovingSum:
+		if len(fn.Args) < 1 {
+			return result
+		}
+		window, ok := fn.Args[0].(float64)
+		if !ok || window < 1 {
+			return result
+		}
+		if fn.Name == v3.FunctionNameMovingAverage {
+			return funcMovingWindow(result, int(window), mean)
+		}
+		return funcMovingWindow(result, int(window), sum)
+	case v3.FunctionNamePercentileOverTime:
+		if len(fn.Args) < 2 {
+			return result
+		}
+		p, ok := fn.Args[0].(float64)
+		if !ok {
+			return result
+		}
+		window, ok := fn.Args[1].(float64)
+		if !ok || window < 1 {
+			return result
+		}
+		return funcMovingWindow(result, int(window), func(values []float64) float64 {
+			return percentile(values, p)
+		})
+	case v3.FunctionNameZScore:
+		return funcZScore(result)
+	case v3.FunctionNameHoltWinters:
+		if len(fn.Args) < 2 {
+			return result
+		}
+		smoothingFactor, ok := fn.Args[0].(float64)
+		if !ok {
+			return result
+		}
+		trendFactor, ok := fn.Args[1].(float64)
+		if !ok {
+			return result
+		}
+		return funcHoltWinters(result, smoothingFactor, trendFactor)
+	case v3.FunctionNameTopKByWindow:
+		if len(fn.Args) < 2 {
+			return result
+		}
+		k, ok := fn.Args[0].(float64)
+		if !ok || k < 1 {
+			return result
+		}
+		window, ok := fn.Args[1].(float64)
+		if !ok || window < 1 {
+			return result
+		}
+		return funcTopKByWindow(result, int(k), int(window))
 	}
 	return result
 }
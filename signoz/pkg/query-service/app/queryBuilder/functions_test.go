@@ -669,3 +669,201 @@ func TestFuncRunningDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyFunctionSeriesTransforms(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name   string
+		fn     v3.Function
+		points []v3.Point
+		want   []v3.Point
+	}{
+		{
+			name:   "derivative",
+			fn:     v3.Function{Name: v3.FunctionNameDerivative},
+			points: []v3.Point{{Timestamp: 0, Value: 10}, {Timestamp: 60000, Value: 70}, {Timestamp: 120000, Value: 40}},
+			want:   []v3.Point{{Timestamp: 60000, Value: 1}, {Timestamp: 120000, Value: -0.5}},
+		},
+		{
+			name:   "rate per second with counter reset",
+			fn:     v3.Function{Name: v3.FunctionNameRatePerSecond},
+			points: []v3.Point{{Timestamp: 0, Value: 10}, {Timestamp: 10000, Value: 30}, {Timestamp: 20000, Value: 5}},
+			want:   []v3.Point{{Timestamp: 10000, Value: 2}, {Timestamp: 20000, Value: 0.5}},
+		},
+		{
+			name:   "integral",
+			fn:     v3.Function{Name: v3.FunctionNameIntegral},
+			points: []v3.Point{{Timestamp: 0, Value: 1}, {Timestamp: 10000, Value: 3}, {Timestamp: 20000, Value: nan}, {Timestamp: 30000, Value: 3}},
+			want:   []v3.Point{{Timestamp: 0, Value: 0}, {Timestamp: 10000, Value: 20}, {Timestamp: 20000, Value: 20}, {Timestamp: 30000, Value: 80}},
+		},
+		{
+			name:   "fill forward",
+			fn:     v3.Function{Name: v3.FunctionNameFillForward},
+			points: []v3.Point{{Timestamp: 0, Value: nan}, {Timestamp: 1, Value: 2}, {Timestamp: 2, Value: nan}, {Timestamp: 3, Value: 4}},
+			want:   []v3.Point{{Timestamp: 0, Value: nan}, {Timestamp: 1, Value: 2}, {Timestamp: 2, Value: 2}, {Timestamp: 3, Value: 4}},
+		},
+		{
+			name:   "fill zero",
+			fn:     v3.Function{Name: v3.FunctionNameFillZero},
+			points: []v3.Point{{Timestamp: 0, Value: nan}, {Timestamp: 1, Value: 2}},
+			want:   []v3.Point{{Timestamp: 0, Value: 0}, {Timestamp: 1, Value: 2}},
+		},
+		{
+			name:   "fill linear",
+			fn:     v3.Function{Name: v3.FunctionNameFillLinear},
+			points: []v3.Point{{Timestamp: 0, Value: nan}, {Timestamp: 1, Value: 2}, {Timestamp: 2, Value: nan}, {Timestamp: 3, Value: nan}, {Timestamp: 4, Value: 8}, {Timestamp: 5, Value: nan}},
+			want:   []v3.Point{{Timestamp: 0, Value: nan}, {Timestamp: 1, Value: 2}, {Timestamp: 2, Value: 4}, {Timestamp: 3, Value: 6}, {Timestamp: 4, Value: 8}, {Timestamp: 5, Value: nan}},
+		},
+		{
+			name:   "moving average",
+			fn:     v3.Function{Name: v3.FunctionNameMovingAverage, Args: []interface{}{float64(2)}},
+			points: []v3.Point{{Timestamp: 0, Value: 2}, {Timestamp: 1, Value: 4}, {Timestamp: 2, Value: nan}, {Timestamp: 3, Value: 8}},
+			want:   []v3.Point{{Timestamp: 0, Value: 2}, {Timestamp: 1, Value: 3}, {Timestamp: 2, Value: 4}, {Timestamp: 3, Value: 8}},
+		},
+		{
+			name:   "moving sum",
+			fn:     v3.Function{Name: v3.FunctionNameMovingSum, Args: []interface{}{float64(3)}},
+			points: []v3.Point{{Timestamp: 0, Value: 1}, {Timestamp: 1, Value: 2}, {Timestamp: 2, Value: 3}, {Timestamp: 3, Value: 4}},
+			want:   []v3.Point{{Timestamp: 0, Value: 1}, {Timestamp: 1, Value: 3}, {Timestamp: 2, Value: 6}, {Timestamp: 3, Value: 9}},
+		},
+		{
+			name:   "percentile over time",
+			fn:     v3.Function{Name: v3.FunctionNamePercentileOverTime, Args: []interface{}{float64(50), float64(3)}},
+			points: []v3.Point{{Timestamp: 0, Value: 5}, {Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 3}, {Timestamp: 3, Value: 10}},
+			want:   []v3.Point{{Timestamp: 0, Value: 5}, {Timestamp: 1, Value: 3}, {Timestamp: 2, Value: 3}, {Timestamp: 3, Value: 3}},
+		},
+		{
+			name:   "z score",
+			fn:     v3.Function{Name: v3.FunctionNameZScore},
+			points: []v3.Point{{Timestamp: 0, Value: 2}, {Timestamp: 1, Value: 4}, {Timestamp: 2, Value: nan}, {Timestamp: 3, Value: 6}},
+			want:   []v3.Point{{Timestamp: 0, Value: -math.Sqrt(1.5)}, {Timestamp: 1, Value: 0}, {Timestamp: 2, Value: nan}, {Timestamp: 3, Value: math.Sqrt(1.5)}},
+		},
+		{
+			name:   "holt winters follows a linear trend",
+			fn:     v3.Function{Name: v3.FunctionNameHoltWinters, Args: []interface{}{0.5, 0.5}},
+			points: []v3.Point{{Timestamp: 0, Value: 1}, {Timestamp: 1, Value: 2}, {Timestamp: 2, Value: nan}, {Timestamp: 3, Value: 3}, {Timestamp: 4, Value: 4}},
+			want:   []v3.Point{{Timestamp: 0, Value: 1}, {Timestamp: 1, Value: 2}, {Timestamp: 2, Value: nan}, {Timestamp: 3, Value: 3}, {Timestamp: 4, Value: 4}},
+		},
+		{
+			name:   "holt winters smooths a spike",
+			fn:     v3.Function{Name: v3.FunctionNameHoltWinters, Args: []interface{}{0.5, 0.5}},
+			points: []v3.Point{{Timestamp: 0, Value: 1}, {Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 5}},
+			want:   []v3.Point{{Timestamp: 0, Value: 1}, {Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &v3.Result{Series: []*v3.Series{{Points: tt.points}}}
+			got := ApplyFunction(tt.fn, result)
+			points := got.Series[0].Points
+			if len(points) != len(tt.want) {
+				t.Fatalf("ApplyFunction(%s) = %d points, want %d", tt.fn.Name, len(points), len(tt.want))
+			}
+			for idx, point := range points {
+				want := tt.want[idx]
+				if point.Timestamp != want.Timestamp {
+					t.Errorf("ApplyFunction(%s) timestamp = %v, want %v", tt.fn.Name, point.Timestamp, want.Timestamp)
+				}
+				if math.IsNaN(want.Value) != math.IsNaN(point.Value) ||
+					(!math.IsNaN(want.Value) && math.Abs(point.Value-want.Value) > 1e-9) {
+					t.Errorf("ApplyFunction(%s) value at %d = %v, want %v", tt.fn.Name, idx, point.Value, want.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestFuncTopKByWindow(t *testing.T) {
+	nan := math.NaN()
+	series := func(name string, values ...float64) *v3.Series {
+		points := make([]v3.Point, len(values))
+		for idx, value := range values {
+			points[idx] = v3.Point{Timestamp: int64(idx) * 60000, Value: value}
+		}
+		return &v3.Series{Labels: map[string]string{"service_name": name}, Points: points}
+	}
+	result := &v3.Result{Series: []*v3.Series{
+		series("frontend", 10, 10, 1, 1, 5),
+		series("redis", 5, 5, 8, 8, 1),
+		series("mysql", 1, 1, 9, nan, 3),
+		series("kafka", 2, 2, 2, 2, 2),
+	}}
+
+	got := ApplyFunction(v3.Function{Name: v3.FunctionNameTopKByWindow, Args: []interface{}{float64(2), float64(2)}}, result)
+
+	// the windows are [0, 1], [2, 3] and [4], kafka is never in the top 2
+	want := map[string][]float64{
+		"frontend": {10, 10, nan, nan, 5},
+		"redis":    {5, 5, 8, 8, nan},
+		"mysql":    {nan, nan, 9, nan, 3},
+	}
+	if len(got.Series) != len(want) {
+		t.Fatalf("funcTopKByWindow() = %d series, want %d", len(got.Series), len(want))
+	}
+	for _, s := range got.Series {
+		name := s.Labels["service_name"]
+		wantValues, ok := want[name]
+		if !ok {
+			t.Fatalf("funcTopKByWindow() kept the series %s", name)
+		}
+		for idx, point := range s.Points {
+			if math.IsNaN(wantValues[idx]) != math.IsNaN(point.Value) ||
+				(!math.IsNaN(wantValues[idx]) && point.Value != wantValues[idx]) {
+				t.Errorf("funcTopKByWindow() %s value at %d = %v, want %v", name, idx, point.Value, wantValues[idx])
+			}
+		}
+	}
+}
+
+func TestValidateFunctionArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      v3.Function
+		wantErr string
+	}{
+		{name: "derivative", fn: v3.Function{Name: v3.FunctionNameDerivative}},
+		{name: "moving average window as string", fn: v3.Function{Name: v3.FunctionNameMovingAverage, Args: []interface{}{"5"}}},
+		{name: "moving sum window missing", fn: v3.Function{Name: v3.FunctionNameMovingSum}, wantErr: "window param missing in query"},
+		{name: "moving sum fractional window", fn: v3.Function{Name: v3.FunctionNameMovingSum, Args: []interface{}{2.5}}, wantErr: "window param should be a positive integer"},
+		{name: "percentile out of range", fn: v3.Function{Name: v3.FunctionNamePercentileOverTime, Args: []interface{}{float64(101), float64(5)}}, wantErr: "percentile param should be between 0 and 100"},
+		{name: "percentile window missing", fn: v3.Function{Name: v3.FunctionNamePercentileOverTime, Args: []interface{}{float64(90)}}, wantErr: "window param missing in query"},
+		{name: "holt winters", fn: v3.Function{Name: v3.FunctionNameHoltWinters, Args: []interface{}{"0.3", 0.1}}},
+		{name: "holt winters trend factor out of range", fn: v3.Function{Name: v3.FunctionNameHoltWinters, Args: []interface{}{0.3, float64(1)}}, wantErr: "trendFactor param should be between 0 and 1"},
+		{name: "topk by window", fn: v3.Function{Name: v3.FunctionNameTopKByWindow, Args: []interface{}{"2", float64(5)}}},
+		{name: "topk by window k missing", fn: v3.Function{Name: v3.FunctionNameTopKByWindow}, wantErr: "k param missing in query"},
+		{name: "topk by window zero k", fn: v3.Function{Name: v3.FunctionNameTopKByWindow, Args: []interface{}{float64(0), float64(5)}}, wantErr: "k param should be a positive integer"},
+		{name: "unknown function", fn: v3.Function{Name: "topk"}, wantErr: "function name is invalid: invalid function name: topk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &v3.BuilderQuery{
+				QueryName:         "A",
+				DataSource:        v3.DataSourceMetrics,
+				AggregateOperator: v3.AggregateOperatorSum,
+				AggregateAttribute: v3.AttributeKey{
+					Key: "signoz_calls_total",
+				},
+				Expression: "A",
+				Functions:  []v3.Function{tt.fn},
+			}
+			err := query.Validate(v3.PanelTypeGraph)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want no error", err)
+				}
+				for _, arg := range query.Functions[0].Args {
+					if _, ok := arg.(float64); !ok {
+						t.Errorf("Validate() did not convert the arg %v to a number", arg)
+					}
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -407,6 +407,11 @@ func (c *cacheKeyGenerator) GenerateKeys(params *v3.QueryRangeParamsV3) map[stri
 			parts = append(parts, fmt.Sprintf("timeAggregation=%s", query.TimeAggregation))
 			parts = append(parts, fmt.Sprintf("spaceAggregation=%s", query.SpaceAggregation))
 
+			// the heatmap is built from the buckets of the histogram instead of the aggregated series
+			if params.CompositeQuery.PanelType == v3.PanelTypeHeatmap {
+				parts = append(parts, fmt.Sprintf("panelType=%s", params.CompositeQuery.PanelType))
+			}
+
 			if query.ShiftBy != 0 {
 				parts = append(parts, fmt.Sprintf("shiftBy=%d", query.ShiftBy))
 			}
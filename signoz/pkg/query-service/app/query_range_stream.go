@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/postprocess"
+	"go.uber.org/zap"
+)
+
+// queryRangeStreamContentType is the media type requested by the clients
+// that consume the query range results as a stream of series
+const queryRangeStreamContentType = "application/x-ndjson"
+
+// streamingRequested returns true if the client accepts the streamed query
+// range response and the results of the query can be streamed
+func streamingRequested(r *http.Request, queryRangeParams *v3.QueryRangeParamsV3) bool {
+	if !strings.Contains(r.Header.Get("Accept"), queryRangeStreamContentType) {
+		return false
+	}
+	switch queryRangeParams.CompositeQuery.PanelType {
+	case v3.PanelTypeList, v3.PanelTypeTrace:
+		return false
+	case v3.PanelTypeTable:
+		// the table for the web is built from all the results at once
+		return !queryRangeParams.FormatForWeb
+	default:
+		return true
+	}
+}
+
+// seriesStreamWriter writes every series as a json line and flushes it to
+// the client. The writes block while the client is not reading, which keeps
+// the post processing from running ahead of a slow client.
+type seriesStreamWriter struct {
+	encoder *json.Encoder
+	flusher http.Flusher
+}
+
+func newSeriesStreamWriter(w http.ResponseWriter) *seriesStreamWriter {
+	flusher, _ := w.(http.Flusher)
+	return &seriesStreamWriter{
+		encoder: json.NewEncoder(w),
+		flusher: flusher,
+	}
+}
+
+func (s *seriesStreamWriter) write(msg v3.QueryRangeStreamMessage) error {
+	if err := s.encoder.Encode(msg); err != nil {
+		return err
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+func (s *seriesStreamWriter) WriteSeries(queryName string, series *v3.Series) error {
+	return s.write(v3.QueryRangeStreamMessage{
+		Type:      v3.QueryRangeStreamMessageTypeSeries,
+		QueryName: queryName,
+		Series:    series,
+	})
+}
+
+// streamQueryRangeResult post processes the results of the query range and
+// streams their series to the client
+func (aH *APIHandler) streamQueryRangeResult(ctx context.Context, result []*v3.Result, queryRangeParams *v3.QueryRangeParamsV3, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", queryRangeStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	writer := newSeriesStreamWriter(w)
+
+	var err error
+	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+		err = postprocess.StreamPostProcessResult(ctx, result, queryRangeParams, writer)
+	} else {
+		err = writeResultSeries(ctx, result, writer)
+	}
+
+	if err != nil {
+		zap.L().Error("error while streaming the query range result", zap.Error(err))
+		if err := writer.write(v3.QueryRangeStreamMessage{Type: v3.QueryRangeStreamMessageTypeError, Error: err.Error()}); err != nil {
+			zap.L().Error("error while writing the query range stream error", zap.Error(err))
+		}
+		return
+	}
+
+	if err := writer.write(v3.QueryRangeStreamMessage{Type: v3.QueryRangeStreamMessageTypeEnd}); err != nil {
+		zap.L().Error("error while ending the query range stream", zap.Error(err))
+	}
+}
+
+// writeResultSeries writes the series of the results as they are and
+// releases them once written
+func writeResultSeries(ctx context.Context, result []*v3.Result, writer postprocess.SeriesWriter) error {
+	for _, res := range result {
+		for idx, series := range res.Series {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := writer.WriteSeries(res.QueryName, series); err != nil {
+				return err
+			}
+			res.Series[idx] = nil
+		}
+		res.Series = nil
+	}
+	return nil
+}
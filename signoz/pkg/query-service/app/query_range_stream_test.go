@@ -0,0 +1,67 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestStreamingRequested(t *testing.T) {
+	testCases := []struct {
+		name         string
+		accept       string
+		panelType    v3.PanelType
+		formatForWeb bool
+		expected     bool
+	}{
+		{name: "graph", accept: "application/x-ndjson", panelType: v3.PanelTypeGraph, expected: true},
+		{name: "json client", accept: "application/json", panelType: v3.PanelTypeGraph, expected: false},
+		{name: "list", accept: "application/x-ndjson", panelType: v3.PanelTypeList, expected: false},
+		{name: "table", accept: "application/x-ndjson", panelType: v3.PanelTypeTable, expected: true},
+		{name: "table for web", accept: "application/x-ndjson", panelType: v3.PanelTypeTable, formatForWeb: true, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v4/query_range", nil)
+			r.Header.Set("Accept", tc.accept)
+			params := &v3.QueryRangeParamsV3{
+				FormatForWeb:   tc.formatForWeb,
+				CompositeQuery: &v3.CompositeQuery{PanelType: tc.panelType},
+			}
+			assert.Equal(t, tc.expected, streamingRequested(r, params))
+		})
+	}
+}
+
+func TestStreamQueryRangeResult(t *testing.T) {
+	result := []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"job": "api"}, Points: []v3.Point{{Timestamp: 1, Value: 1.5}}},
+				{Labels: map[string]string{"job": "db"}, Points: []v3.Point{{Timestamp: 1, Value: 2}}},
+			},
+		},
+	}
+	params := &v3.QueryRangeParamsV3{
+		CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypePromQL, PanelType: v3.PanelTypeGraph},
+	}
+
+	w := httptest.NewRecorder()
+	(&APIHandler{}).streamQueryRangeResult(context.Background(), result, params, w)
+
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Equal(t, []string{
+		`{"type":"series","queryName":"A","series":{"labels":{"job":"api"},"labelsArray":null,"values":[{"timestamp":1,"value":"1.5"}]}}`,
+		`{"type":"series","queryName":"A","series":{"labels":{"job":"db"},"labelsArray":null,"values":[{"timestamp":1,"value":"2"}]}}`,
+		`{"type":"end"}`,
+	}, lines)
+	assert.True(t, w.Flushed)
+}
@@ -0,0 +1,157 @@
+package querylimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/querylimit"
+	"go.uber.org/zap"
+)
+
+// ErrQueryLimitsExceeded is returned when queries exceed the limits and can not
+// be downgraded, it is a resource limit error
+var ErrQueryLimitsExceeded = chErrors.NewResourceLimitError(errors.New("queries exceed the query limits"))
+
+// Limiter estimates the cost of the builder and clickhouse queries before they
+// are run and enforces the limits configured for the role of the requester, or
+// for the rules when the queries are run without a user.
+type Limiter struct {
+	reader  interfaces.Reader
+	builder *queryBuilder.QueryBuilder
+	config  querylimit.Config
+}
+
+func New(reader interfaces.Reader, builder *queryBuilder.QueryBuilder, config querylimit.Config) *Limiter {
+	return &Limiter{
+		reader:  reader,
+		builder: builder,
+		config:  config,
+	}
+}
+
+// Apply estimates the cost of the queries and enforces the limits. It returns
+// the estimates by query name. When the queries are downgraded, the start of the
+// time range of the params is moved forward so that their cost fits the limits.
+// The queries exceeding the limits that can not be downgraded are returned as
+// resource limit errors by query name.
+func (l *Limiter) Apply(ctx context.Context, params *v3.QueryRangeParamsV3) (map[string]*v3.QueryCostEstimate, map[string]error, error) {
+	if l == nil || !l.config.Enabled || params.CompositeQuery == nil {
+		return nil, nil, nil
+	}
+
+	// the queries without a user, such as the rule evaluations, get the limits
+	// of the rules and are never downgraded. They are not estimated when the
+	// rules are not limited.
+	limit := l.config.Rules
+	canDowngrade := false
+	if user := common.GetUserFromContext(ctx); user != nil {
+		limit = l.config.LimitForRole(user.Role)
+		canDowngrade = l.config.Action == querylimit.ActionDowngrade && params.CompositeQuery.QueryType == v3.QueryTypeBuilder
+	} else if limit == (querylimit.Limit{}) {
+		return nil, nil, nil
+	}
+
+	queries, err := l.prepareQueries(params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	estimates := make(map[string]*v3.QueryCostEstimate)
+	errQueriesByName := make(map[string]error)
+	// fraction is the share of the time range that fits the limits for every query
+	fraction := 1.0
+
+	for name, query := range queries {
+		estimate, err := l.reader.EstimateQueryCost(ctx, query)
+		if err != nil {
+			// the estimation is best effort and must not prevent the query from running
+			zap.L().Error("error while estimating query cost", zap.String("queryName", name), zap.Error(err))
+			continue
+		}
+		estimates[name] = estimate
+
+		if !limit.Exceeds(estimate.Rows, estimate.Bytes) {
+			continue
+		}
+
+		if canDowngrade {
+			fraction = math.Min(fraction, fitFraction(limit, estimate))
+			continue
+		}
+
+		errQueriesByName[name] = chErrors.NewResourceLimitError(fmt.Errorf(
+			"estimated cost of query %s (%d rows, %d bytes) exceeds the query limits, try applying filters or reducing the time range",
+			name, estimate.Rows, estimate.Bytes,
+		))
+	}
+
+	if len(errQueriesByName) > 0 {
+		return estimates, errQueriesByName, ErrQueryLimitsExceeded
+	}
+
+	if fraction < 1 {
+		l.downgrade(params, estimates, fraction)
+	}
+
+	return estimates, nil, nil
+}
+
+func (l *Limiter) prepareQueries(params *v3.QueryRangeParamsV3) (map[string]string, error) {
+	switch params.CompositeQuery.QueryType {
+	case v3.QueryTypeBuilder:
+		return l.builder.PrepareQueries(params)
+	case v3.QueryTypeClickHouseSQL:
+		queries := make(map[string]string)
+		for name, chQuery := range params.CompositeQuery.ClickHouseQueries {
+			if chQuery.Disabled {
+				continue
+			}
+			queries[name] = chQuery.Query
+		}
+		return queries, nil
+	default:
+		// promql queries are not run against clickhouse directly and can not be estimated
+		return nil, nil
+	}
+}
+
+// downgrade narrows the time range of the params to the given fraction, keeping the end of the range
+func (l *Limiter) downgrade(params *v3.QueryRangeParamsV3, estimates map[string]*v3.QueryCostEstimate, fraction float64) {
+	start := params.End - int64(float64(params.End-params.Start)*fraction)
+	// align the start to the step so that the time series points remain aligned
+	if stepMillis := params.Step * 1000; stepMillis > 0 && start%stepMillis != 0 {
+		start = start - start%stepMillis + stepMillis
+	}
+	start = min(start, params.End)
+
+	zap.L().Info("downgrading queries exceeding the query limits",
+		zap.Int64("start", params.Start), zap.Int64("downgradedStart", start), zap.Int64("end", params.End))
+
+	params.Start = start
+	for _, estimate := range estimates {
+		estimate.Rows = uint64(float64(estimate.Rows) * fraction)
+		estimate.Bytes = uint64(float64(estimate.Bytes) * fraction)
+		estimate.Downgraded = true
+		estimate.Start = start
+	}
+}
+
+// fitFraction returns the share of the time range of the query that fits the limit
+// assuming the data is evenly distributed over time
+func fitFraction(limit querylimit.Limit, estimate *v3.QueryCostEstimate) float64 {
+	fraction := 1.0
+	if limit.MaxRows > 0 && estimate.Rows > limit.MaxRows {
+		fraction = math.Min(fraction, float64(limit.MaxRows)/float64(estimate.Rows))
+	}
+	if limit.MaxBytes > 0 && estimate.Bytes > limit.MaxBytes {
+		fraction = math.Min(fraction, float64(limit.MaxBytes)/float64(estimate.Bytes))
+	}
+	return fraction
+}
@@ -0,0 +1,159 @@
+package querylimiter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	chErrors "go.signoz.io/signoz/pkg/query-service/errors"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/querylimit"
+)
+
+// estimateReader returns the same estimate for every query
+type estimateReader struct {
+	interfaces.Reader
+	estimate v3.QueryCostEstimate
+	queries  int
+}
+
+func (r *estimateReader) EstimateQueryCost(_ context.Context, _ string) (*v3.QueryCostEstimate, error) {
+	r.queries++
+	estimate := r.estimate
+	return &estimate, nil
+}
+
+func TestApplyDisabled(t *testing.T) {
+	params := &v3.QueryRangeParamsV3{
+		Start:          1000,
+		End:            2000,
+		CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder},
+	}
+
+	var nilLimiter *Limiter
+	estimates, errs, err := nilLimiter.Apply(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Nil(t, estimates)
+	assert.Nil(t, errs)
+
+	limiter := New(nil, nil, querylimit.Config{Enabled: false})
+	estimates, errs, err = limiter.Apply(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Nil(t, estimates)
+	assert.Nil(t, errs)
+	assert.Equal(t, int64(1000), params.Start)
+}
+
+func TestApplyWithoutUser(t *testing.T) {
+	userCtx := context.WithValue(context.Background(), constants.ContextUserKey, &model.UserPayload{Role: "VIEWER"})
+
+	testCases := []struct {
+		name          string
+		ctx           context.Context
+		rules         querylimit.Limit
+		expectedErr   bool
+		expectedCalls int
+	}{
+		{name: "rules are not limited by default", ctx: context.Background()},
+		{name: "rules within their limits", ctx: context.Background(), rules: querylimit.Limit{MaxRows: 2000}, expectedCalls: 1},
+		{name: "rules exceeding their limits are rejected", ctx: context.Background(), rules: querylimit.Limit{MaxRows: 500}, expectedErr: true, expectedCalls: 1},
+		{name: "users get the limits of their role", ctx: userCtx, expectedErr: true, expectedCalls: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			params := &v3.QueryRangeParamsV3{
+				Start: 0,
+				End:   3_600_000,
+				Step:  60,
+				CompositeQuery: &v3.CompositeQuery{
+					QueryType: v3.QueryTypeClickHouseSQL,
+					ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+						"A": {Query: "SELECT 1"},
+					},
+				},
+			}
+			reader := &estimateReader{estimate: v3.QueryCostEstimate{Rows: 1000}}
+			limiter := New(reader, nil, querylimit.Config{
+				Enabled: true,
+				Action:  querylimit.ActionDowngrade,
+				Default: querylimit.Limit{MaxRows: 100},
+				Roles:   map[string]querylimit.Limit{"VIEWER": {MaxRows: 100}},
+				Rules:   tc.rules,
+			})
+
+			_, errs, err := limiter.Apply(tc.ctx, params)
+			if tc.expectedErr {
+				assert.ErrorIs(t, err, ErrQueryLimitsExceeded)
+				assert.Contains(t, errs, "A")
+			} else {
+				assert.NoError(t, err)
+				assert.Empty(t, errs)
+			}
+			assert.Equal(t, tc.expectedCalls, reader.queries)
+			// the clickhouse queries are never downgraded
+			assert.Equal(t, int64(0), params.Start)
+		})
+	}
+}
+
+func TestFitFraction(t *testing.T) {
+	testCases := []struct {
+		name     string
+		limit    querylimit.Limit
+		estimate *v3.QueryCostEstimate
+		expected float64
+	}{
+		{
+			name:     "no limit",
+			limit:    querylimit.Limit{},
+			estimate: &v3.QueryCostEstimate{Rows: 1000, Bytes: 1000},
+			expected: 1,
+		},
+		{
+			name:     "rows exceeded",
+			limit:    querylimit.Limit{MaxRows: 250},
+			estimate: &v3.QueryCostEstimate{Rows: 1000, Bytes: 1000},
+			expected: 0.25,
+		},
+		{
+			name:     "bytes more restrictive than rows",
+			limit:    querylimit.Limit{MaxRows: 500, MaxBytes: 100},
+			estimate: &v3.QueryCostEstimate{Rows: 1000, Bytes: 1000},
+			expected: 0.1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, fitFraction(tc.limit, tc.estimate))
+		})
+	}
+}
+
+func TestDowngrade(t *testing.T) {
+	params := &v3.QueryRangeParamsV3{
+		Start: 0,
+		End:   3_600_000,
+		Step:  60,
+	}
+	estimates := map[string]*v3.QueryCostEstimate{
+		"A": {Rows: 1000, Bytes: 4000},
+	}
+
+	(&Limiter{}).downgrade(params, estimates, 0.25)
+
+	// the start is moved to the last quarter of the range and aligned to the step
+	assert.Equal(t, int64(2_700_000), params.Start)
+	assert.Equal(t, int64(3_600_000), params.End)
+	assert.Equal(t, &v3.QueryCostEstimate{Rows: 250, Bytes: 1000, Downgraded: true, Start: 2_700_000}, estimates["A"])
+}
+
+func TestQueryLimitsExceededError(t *testing.T) {
+	// the handlers rely on the error being a resource limit error to
+	// respond with a 4xx instead of an internal error
+	assert.True(t, chErrors.IsResourceLimitError(ErrQueryLimitsExceeded))
+}
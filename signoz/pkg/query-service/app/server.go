@@ -158,7 +158,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		if err != nil {
 			return nil, err
 		}
-		c = cache.NewCache(cacheOpts)
+		c = cache.NewCache(cacheOpts, serverOptions.SigNoz.Cache)
 	}
 
 	<-readerReady
@@ -187,6 +187,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 
 	logParsingPipelineController, err := logparsingpipeline.NewLogParsingPipelinesController(
 		serverOptions.SigNoz.SQLStore.SQLxDB(), integrationsController.GetPipelinesForInstalledIntegrations,
+		logparsingpipeline.NewLogsSampler(reader, serverOptions.UseLogsNewSchema),
 	)
 	if err != nil {
 		return nil, err
@@ -205,6 +206,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		LogsParsingPipelineController: logParsingPipelineController,
 		Cache:                         c,
 		FluxInterval:                  fluxInterval,
+		QueryLimits:                   serverOptions.Config.QueryLimit,
 		UseLogsNewSchema:              serverOptions.UseLogsNewSchema,
 		UseTraceNewSchema:             serverOptions.UseTraceNewSchema,
 	})
@@ -245,6 +247,7 @@ func NewServer(serverOptions *ServerOptions) (*Server, error) {
 		AgentFeatures: []agentConf.AgentFeature{
 			logParsingPipelineController,
 		},
+		IngestionErrorRate: agentConf.NewIngestionErrorRate(reader),
 	})
 	if err != nil {
 		return nil, err
@@ -243,6 +243,9 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, _ string, pan
 	spanIndexTableTimeFilter := fmt.Sprintf("(timestamp >= '%d' AND timestamp <= '%d')", start*getZerosForEpochNano(start), end*getZerosForEpochNano(end))
 
 	selectLabels := getSelectLabels(mq.AggregateOperator, mq.GroupBy)
+	if panelType == v3.PanelTypeHeatmap {
+		selectLabels += fmt.Sprintf(" %s as `%s`,", utils.HeatmapBucketExpression(getColumnName(mq.AggregateAttribute), mq.HeatmapBuckets), utils.HeatmapBucketLabel)
+	}
 
 	having := Having(mq.Having)
 	if having != "" {
@@ -257,7 +260,7 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, _ string, pan
 			"SELECT now() as ts,"
 		// step or aggregate interval is whole time period in case of table panel
 		step = (end*getZerosForEpochNano(end) - start*getZerosForEpochNano(start)) / 1000000000
-	} else if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue {
+	} else if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue || panelType == v3.PanelTypeHeatmap {
 		// Select the aggregate value for interval
 		queryTmpl =
 			fmt.Sprintf("SELECT toStartOfInterval(timestamp, INTERVAL %d SECOND) AS ts,", step)
@@ -285,6 +288,9 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, _ string, pan
 	if groupBy != "" {
 		groupBy = " group by " + groupBy
 	}
+	if panelType == v3.PanelTypeHeatmap {
+		groupBy += fmt.Sprintf(",`%s`", utils.HeatmapBucketLabel)
+	}
 	orderBy := orderByAttributeKeyTags(panelType, mq.OrderBy, mq.GroupBy)
 	if orderBy != "" {
 		orderBy = " order by " + orderBy
@@ -397,7 +403,7 @@ func enrichOrderBy(items []v3.OrderBy, keys map[string]v3.AttributeKey) []v3.Ord
 // groupBy returns a string of comma separated tags for group by clause
 // `ts` is always added to the group by clause
 func groupBy(panelType v3.PanelType, graphLimitQtype string, tags ...string) string {
-	if (graphLimitQtype != constants.FirstQueryGraphLimit) && (panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue) {
+	if (graphLimitQtype != constants.FirstQueryGraphLimit) && (panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue || panelType == v3.PanelTypeHeatmap) {
 		tags = append(tags, "ts")
 	}
 	return strings.Join(tags, ",")
@@ -500,8 +506,14 @@ func AddOffsetToQuery(query string, offset uint64) string {
 // start and end are in epoch millisecond
 // step is in seconds
 func PrepareTracesQuery(start, end int64, panelType v3.PanelType, mq *v3.BuilderQuery, options v3.QBOptions) (string, error) {
+	if panelType == v3.PanelTypeHeatmap {
+		// the heatmap counts the spans in the bucket of the value of the aggregate attribute
+		heatmapQuery := *mq
+		heatmapQuery.AggregateOperator = v3.AggregateOperatorCount
+		mq = &heatmapQuery
+	}
 	// adjust the start and end time to the step interval
-	if panelType == v3.PanelTypeGraph {
+	if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeHeatmap {
 		// adjust the start and end time to the step interval for graph panel types
 		start = start - (start % (mq.StepInterval * 1000))
 		end = end - (end % (mq.StepInterval * 1000))
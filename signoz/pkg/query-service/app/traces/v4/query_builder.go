@@ -283,6 +283,9 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, panelType v3.
 	if selectLabels != "" {
 		selectLabels = selectLabels + ","
 	}
+	if panelType == v3.PanelTypeHeatmap {
+		selectLabels += fmt.Sprintf(" %s as `%s`,", utils.HeatmapBucketExpression(getColumnName(mq.AggregateAttribute), mq.HeatmapBuckets), utils.HeatmapBucketLabel)
+	}
 
 	orderBy := orderByAttributeKeyTags(panelType, mq.OrderBy, mq.GroupBy)
 	if orderBy != "" {
@@ -324,6 +327,9 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, panelType v3.
 	if groupBy != "" {
 		groupBy = " group by " + groupBy
 	}
+	if panelType == v3.PanelTypeHeatmap {
+		groupBy += fmt.Sprintf(",`%s`", utils.HeatmapBucketLabel)
+	}
 
 	aggregationKey := ""
 	if mq.AggregateAttribute.Key != "" {
@@ -336,7 +342,7 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, panelType v3.
 	} else if panelType == v3.PanelTypeTable {
 		queryTmpl =
 			"SELECT "
-	} else if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue {
+	} else if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeValue || panelType == v3.PanelTypeHeatmap {
 		// Select the aggregate value for interval
 		queryTmpl =
 			fmt.Sprintf("SELECT toStartOfInterval(timestamp, INTERVAL %d SECOND) AS ts,", step)
@@ -419,8 +425,14 @@ func buildTracesQuery(start, end, step int64, mq *v3.BuilderQuery, panelType v3.
 // start and end are in epoch millisecond
 // step is in seconds
 func PrepareTracesQuery(start, end int64, panelType v3.PanelType, mq *v3.BuilderQuery, options v3.QBOptions) (string, error) {
+	if panelType == v3.PanelTypeHeatmap {
+		// the heatmap counts the spans in the bucket of the value of the aggregate attribute
+		heatmapQuery := *mq
+		heatmapQuery.AggregateOperator = v3.AggregateOperatorCount
+		mq = &heatmapQuery
+	}
 	// adjust the start and end time to the step interval
-	if panelType == v3.PanelTypeGraph {
+	if panelType == v3.PanelTypeGraph || panelType == v3.PanelTypeHeatmap {
 		// adjust the start and end time to the step interval for graph panel types
 		start = start - (start % (mq.StepInterval * 1000))
 		end = end - (end % (mq.StepInterval * 1000))
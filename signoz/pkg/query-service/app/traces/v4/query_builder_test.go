@@ -834,6 +834,26 @@ func TestPrepareTracesQuery(t *testing.T) {
 				"AND (resource_fingerprint GLOBAL IN (SELECT fingerprint FROM signoz_traces.distributed_traces_v3_resource WHERE (seen_at_ts_bucket_start >= 1680064560) AND (seen_at_ts_bucket_start <= 1680066458) " +
 				"AND simpleJSONExtractString(labels, 'hostname') = 'server1' AND labels like '%hostname%server1%')) AND (`function`,`serviceName`) GLOBAL IN (#LIMIT_PLACEHOLDER) group by `function`,`serviceName` order by value DESC",
 		},
+		{
+			name: "test heatmap of duration",
+			args: args{
+				start:     1680066360726,
+				end:       1680066458000,
+				panelType: v3.PanelTypeHeatmap,
+				mq: &v3.BuilderQuery{
+					StepInterval:       60,
+					AggregateOperator:  v3.AggregateOperatorP99,
+					Filters:            &v3.FilterSet{},
+					AggregateAttribute: v3.AttributeKey{Key: "durationNano", IsColumn: true, DataType: v3.AttributeKeyDataTypeFloat64, Type: v3.AttributeKeyTypeTag},
+					GroupBy:            []v3.AttributeKey{{Key: "serviceName", IsColumn: true, DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag}},
+					HeatmapBuckets:     []float64{1000000, 5000000.5},
+				},
+			},
+			want: "SELECT toStartOfInterval(timestamp, INTERVAL 60 SECOND) AS ts, serviceName as `serviceName`, " +
+				"arrayElement(['1000000', '5000000.5', '+Inf'], arrayFirstIndex(x -> toFloat64(durationNano) <= x, [1000000, 5000000.5, inf])) as `le`, " +
+				"toFloat64(count()) as value from signoz_traces.distributed_signoz_index_v3 where (timestamp >= '1680066360000000000' AND timestamp <= '1680066420000000000') " +
+				"AND (ts_bucket_start >= 1680064560 AND ts_bucket_start <= 1680066420) group by `serviceName`,ts,`le` order by value DESC",
+		},
 	}
 
 	for _, tt := range tests {
@@ -4,8 +4,10 @@ import (
 	"os"
 	"time"
 
+	pkgcache "go.signoz.io/signoz/pkg/cache"
 	inmemory "go.signoz.io/signoz/pkg/query-service/cache/inmemory"
 	redis "go.signoz.io/signoz/pkg/query-service/cache/redis"
+	"go.signoz.io/signoz/pkg/query-service/cache/signozcache"
 	"go.signoz.io/signoz/pkg/query-service/cache/status"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"gopkg.in/yaml.v2"
@@ -56,13 +58,20 @@ func LoadFromYAMLCacheConfigFile(configFile string) (*Options, error) {
 	return LoadFromYAMLCacheConfig(bytes)
 }
 
-// NewCache creates a new cache based on the given options
-func NewCache(options *Options) Cache {
+// NewCache creates a new cache based on the given options. The "signoz" provider
+// uses signozCache, the cache configured with the cache::provider setting, which
+// also supports the disk and tiered providers.
+func NewCache(options *Options, signozCache pkgcache.Cache) Cache {
 	switch options.Provider {
 	case "redis":
 		return redis.New(options.Redis)
 	case "inmemory":
 		return inmemory.New(options.InMemory)
+	case "signoz":
+		if signozCache == nil {
+			return nil
+		}
+		return signozcache.New(signozCache)
 	default:
 		return nil
 	}
@@ -1,12 +1,19 @@
 package cache
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	pkgcache "go.signoz.io/signoz/pkg/cache"
+	"go.signoz.io/signoz/pkg/cache/memorycache"
+	"go.signoz.io/signoz/pkg/factory/providertest"
+)
 
 func TestNewCacheUnKnownProvider(t *testing.T) {
 	c := NewCache(&Options{
 		Name:     "test",
 		Provider: "unknown",
-	})
+	}, nil)
 
 	if c != nil {
 		t.Fatalf("expected nil, got %v", c)
@@ -17,7 +24,7 @@ func TestNewCacheInMemory(t *testing.T) {
 	c := NewCache(&Options{
 		Name:     "test",
 		Provider: "inmemory",
-	})
+	}, nil)
 
 	if c == nil {
 		t.Fatalf("expected non-nil, got nil")
@@ -28,7 +35,31 @@ func TestNewCacheRedis(t *testing.T) {
 	c := NewCache(&Options{
 		Name:     "test",
 		Provider: "redis",
-	})
+	}, nil)
+
+	if c == nil {
+		t.Fatalf("expected non-nil, got nil")
+	}
+}
+
+func TestNewCacheSigNoz(t *testing.T) {
+	c := NewCache(&Options{
+		Name:     "test",
+		Provider: "signoz",
+	}, nil)
+
+	if c != nil {
+		t.Fatalf("expected nil without a signoz cache, got %v", c)
+	}
+
+	signozCache, err := memorycache.New(context.Background(), providertest.NewSettings(), pkgcache.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	c = NewCache(&Options{
+		Name:     "test",
+		Provider: "signoz",
+	}, signozCache)
 
 	if c == nil {
 		t.Fatalf("expected non-nil, got nil")
@@ -0,0 +1,79 @@
+package signozcache
+
+import (
+	"context"
+	"time"
+
+	pkgcache "go.signoz.io/signoz/pkg/cache"
+	"go.signoz.io/signoz/pkg/query-service/cache/status"
+)
+
+// entry wraps the raw bytes stored by the query service caches so that they
+// can be stored in any of the signoz cache providers
+type entry struct {
+	Data []byte
+}
+
+func (e *entry) MarshalBinary() ([]byte, error) {
+	return e.Data, nil
+}
+
+func (e *entry) UnmarshalBinary(data []byte) error {
+	e.Data = append([]byte(nil), data...)
+	return nil
+}
+
+// cache implements the Cache interface on top of the cache configured for
+// signoz, which makes the memory, redis, disk and tiered providers available
+// to the query service
+type cache struct {
+	c pkgcache.Cache
+}
+
+// New creates a new cache backed by the given signoz cache
+func New(c pkgcache.Cache) *cache {
+	return &cache{c: c}
+}
+
+// Connect connects the underlying cache
+func (c *cache) Connect() error {
+	return c.c.Connect(context.Background())
+}
+
+// Store stores the data in the cache
+func (c *cache) Store(cacheKey string, data []byte, ttl time.Duration) error {
+	return c.c.Store(context.Background(), cacheKey, &entry{Data: data}, ttl)
+}
+
+// Retrieve retrieves the data from the cache
+func (c *cache) Retrieve(cacheKey string, allowExpired bool) ([]byte, status.RetrieveStatus, error) {
+	e := &entry{}
+	retrieveStatus, err := c.c.Retrieve(context.Background(), cacheKey, e, allowExpired)
+	if err != nil {
+		return nil, status.RetrieveStatusError, err
+	}
+	if retrieveStatus != pkgcache.RetrieveStatusHit {
+		return nil, status.RetrieveStatus(retrieveStatus), nil
+	}
+	return e.Data, status.RetrieveStatusHit, nil
+}
+
+// SetTTL sets the TTL for the cache entry
+func (c *cache) SetTTL(cacheKey string, ttl time.Duration) {
+	c.c.SetTTL(context.Background(), cacheKey, ttl)
+}
+
+// Remove removes the cache entry
+func (c *cache) Remove(cacheKey string) {
+	c.c.Remove(context.Background(), cacheKey)
+}
+
+// BulkRemove removes the cache entries
+func (c *cache) BulkRemove(cacheKeys []string) {
+	c.c.BulkRemove(context.Background(), cacheKeys)
+}
+
+// Close closes the underlying cache
+func (c *cache) Close() error {
+	return c.c.Close(context.Background())
+}
@@ -0,0 +1,68 @@
+package signozcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pkgcache "go.signoz.io/signoz/pkg/cache"
+	"go.signoz.io/signoz/pkg/cache/diskcache"
+	"go.signoz.io/signoz/pkg/cache/memorycache"
+	"go.signoz.io/signoz/pkg/factory"
+	"go.signoz.io/signoz/pkg/factory/providertest"
+	"go.signoz.io/signoz/pkg/query-service/cache/status"
+)
+
+func newTestConfig(t *testing.T) pkgcache.Config {
+	return pkgcache.Config{
+		Memory: pkgcache.Memory{TTL: time.Minute, CleanupInterval: time.Minute},
+		Disk:   pkgcache.Disk{Path: t.TempDir(), MaxSize: 1024, TTL: time.Minute},
+	}
+}
+
+func TestStoreAndRetrieve(t *testing.T) {
+	config := newTestConfig(t)
+	for name, newProvider := range map[string]func(context.Context, factory.ProviderSettings, pkgcache.Config) (pkgcache.Cache, error){
+		"memory": memorycache.New,
+		"disk":   diskcache.New,
+	} {
+		t.Run(name, func(t *testing.T) {
+			provider, err := newProvider(context.Background(), providertest.NewSettings(), config)
+			require.NoError(t, err)
+			c := New(provider)
+
+			data, retrieveStatus, err := c.Retrieve("key", false)
+			require.NoError(t, err)
+			assert.Equal(t, status.RetrieveStatusKeyMiss, retrieveStatus)
+			assert.Nil(t, data)
+
+			require.NoError(t, c.Store("key", []byte("value"), time.Minute))
+			data, retrieveStatus, err = c.Retrieve("key", false)
+			require.NoError(t, err)
+			assert.Equal(t, status.RetrieveStatusHit, retrieveStatus)
+			assert.Equal(t, []byte("value"), data)
+
+			c.Remove("key")
+			_, retrieveStatus, err = c.Retrieve("key", false)
+			require.NoError(t, err)
+			assert.Equal(t, status.RetrieveStatusKeyMiss, retrieveStatus)
+		})
+	}
+}
+
+func TestDiskEntriesSurviveRestarts(t *testing.T) {
+	config := newTestConfig(t)
+
+	provider, err := diskcache.New(context.Background(), providertest.NewSettings(), config)
+	require.NoError(t, err)
+	require.NoError(t, New(provider).Store("key", []byte("value"), time.Minute))
+
+	provider, err = diskcache.New(context.Background(), providertest.NewSettings(), config)
+	require.NoError(t, err)
+	data, retrieveStatus, err := New(provider).Retrieve("key", false)
+	require.NoError(t, err)
+	assert.Equal(t, status.RetrieveStatusHit, retrieveStatus)
+	assert.Equal(t, []byte("value"), data)
+}
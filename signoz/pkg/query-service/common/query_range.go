@@ -19,7 +19,10 @@ func AdjustedMetricTimeRange(start, end, step int64, mq v3.BuilderQuery) (int64,
 	// so that we can calculate the rate for the first data point
 	hasRunningDiff := false
 	for _, fn := range mq.Functions {
-		if fn.Name == v3.FunctionNameRunningDiff {
+		// the functions that drop the first point need the data of one more step
+		if fn.Name == v3.FunctionNameRunningDiff ||
+			fn.Name == v3.FunctionNameDerivative ||
+			fn.Name == v3.FunctionNameRatePerSecond {
 			hasRunningDiff = true
 			break
 		}
@@ -73,6 +73,15 @@ var InviteEmailTemplate = GetOrDefaultEnv("INVITE_EMAIL_TEMPLATE", "/root/templa
 // Alert manager channel subpath
 var AmChannelApiPath = GetOrDefaultEnv("ALERTMANAGER_API_CHANNEL_PATH", "v1/routes")
 
+// IsEmbeddedAlertManagerEnabled returns true when the notifications of the alerts
+// are dispatched by query service instead of an external alert manager
+func IsEmbeddedAlertManagerEnabled() bool {
+	return GetOrDefaultEnv("ALERTMANAGER_EMBEDDED", "false") == "true"
+}
+
+// Path to the yaml file with the routing tree and the inhibit rules of the embedded alert manager
+var AmConfigPath = GetOrDefaultEnv("ALERTMANAGER_CONFIG_PATH", "")
+
 var OTLPTarget = GetOrDefaultEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
 var LogExportBatchSize = GetOrDefaultEnv("OTEL_BLRP_MAX_EXPORT_BATCH_SIZE", "512")
 
@@ -162,6 +171,51 @@ func GetEvalDelay() time.Duration {
 	return evalDelayDuration
 }
 
+// GetAlertsProvisioningPath returns the directory with the rules, notification
+// channels and downtime schedules provisioned from files, provisioning is
+// disabled when it is empty
+func GetAlertsProvisioningPath() string {
+	return GetOrDefaultEnv("ALERTS_PROVISIONING_PATH", "")
+}
+
+// GetAlertsProvisioningInterval returns how often the provisioned files are reconciled
+func GetAlertsProvisioningInterval() time.Duration {
+	intervalStr := GetOrDefaultEnv("ALERTS_PROVISIONING_INTERVAL", "1m")
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil || interval <= 0 {
+		return time.Minute
+	}
+	return interval
+}
+
+// IsRulesHAEnabled returns true when the rules are distributed across the
+// query service replicas sharing the rules db
+func IsRulesHAEnabled() bool {
+	return GetOrDefaultEnv("RULES_HA_ENABLED", "false") == "true"
+}
+
+// GetRulesReplicaID returns the id of the replica in the rule leases, the host name by default
+func GetRulesReplicaID() string {
+	if id := os.Getenv("RULES_REPLICA_ID"); id != "" {
+		return id
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "query-service"
+	}
+	return hostname
+}
+
+// GetRulesLeaseDuration returns how long a rule lease is valid without renewal
+func GetRulesLeaseDuration() time.Duration {
+	durationStr := GetOrDefaultEnv("RULES_LEASE_DURATION", "30s")
+	duration, err := time.ParseDuration(durationStr)
+	if err != nil || duration <= 0 {
+		return 30 * time.Second
+	}
+	return duration
+}
+
 const (
 	TraceID                        = "traceID"
 	ServiceName                    = "serviceName"
@@ -349,8 +403,23 @@ var ReservedColumnTargetAliases = map[string]struct{}{
 const LogsPPLPfx = "signozlogspipeline/pipeline_"
 const OldLogsPPLPfx = "logstransform/pipeline_"
 
+// LogsToMetricsPfx is the name prefix of the collector connectors and
+// metrics pipeline generated for log to metric operators
+const LogsToMetricsPfx = "signozlogstometrics"
+
 const IntegrationPipelineIdPrefix = "integration"
 
+// IngestionErrorMetrics are the collector metrics counting telemetry
+// that failed to get processed or exported
+var IngestionErrorMetrics = []string{
+	"otelcol_processor_dropped_log_records",
+	"otelcol_processor_dropped_spans",
+	"otelcol_processor_dropped_metric_points",
+	"otelcol_exporter_send_failed_log_records",
+	"otelcol_exporter_send_failed_spans",
+	"otelcol_exporter_send_failed_metric_points",
+}
+
 // The datatype present here doesn't represent the actual datatype of column in the logs table.
 
 var StaticFieldsLogsV3 = map[string]v3.AttributeKey{
@@ -0,0 +1,282 @@
+package alertManager
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultGroupWait      = 30 * time.Second
+	defaultGroupInterval  = 5 * time.Minute
+	defaultRepeatInterval = 4 * time.Hour
+)
+
+// Config is the configuration of the embedded alert manager. The receivers are
+// the notification channels stored in the sql store, so only the routing tree
+// and the inhibit rules are configured here.
+type Config struct {
+	Route        *Route         `yaml:"route,omitempty" json:"route,omitempty"`
+	InhibitRules []*InhibitRule `yaml:"inhibit_rules,omitempty" json:"inhibit_rules,omitempty"`
+}
+
+// Route is a node of the routing tree. An alert is dispatched by the deepest
+// matching routes, the children of a route inherit the grouping and the timing
+// options of their parent unless they set them.
+type Route struct {
+	// Receiver is the name of the channel of the route. The alerts of a route
+	// without receiver are sent to their preferred channels, or to all the
+	// channels when the rule has none.
+	Receiver string   `yaml:"receiver,omitempty" json:"receiver,omitempty"`
+	Matchers Matchers `yaml:"matchers,omitempty" json:"matchers,omitempty"`
+	GroupBy  []string `yaml:"group_by,omitempty" json:"group_by,omitempty"`
+	// Continue makes the alerts matching the route try the next sibling routes
+	Continue bool `yaml:"continue,omitempty" json:"continue,omitempty"`
+
+	GroupWait      *model.Duration `yaml:"group_wait,omitempty" json:"group_wait,omitempty"`
+	GroupInterval  *model.Duration `yaml:"group_interval,omitempty" json:"group_interval,omitempty"`
+	RepeatInterval *model.Duration `yaml:"repeat_interval,omitempty" json:"repeat_interval,omitempty"`
+
+	Routes []*Route `yaml:"routes,omitempty" json:"routes,omitempty"`
+
+	// id identifies the route in the group keys, it is the path of the route in the tree
+	id string
+}
+
+// InhibitRule mutes the alerts matching the target matchers while an alert
+// matching the source matchers is firing and has the same values for the
+// equal labels.
+type InhibitRule struct {
+	SourceMatchers Matchers `yaml:"source_matchers,omitempty" json:"source_matchers,omitempty"`
+	TargetMatchers Matchers `yaml:"target_matchers,omitempty" json:"target_matchers,omitempty"`
+	Equal          []string `yaml:"equal,omitempty" json:"equal,omitempty"`
+}
+
+// DefaultConfig returns the configuration used when no configuration file is
+// provided, all the alerts are grouped by the alert name.
+func DefaultConfig() *Config {
+	c := &Config{Route: &Route{GroupBy: []string{labels.AlertNameLabel}}}
+	c.init()
+	return c
+}
+
+// LoadConfig reads the configuration of the embedded alert manager from a yaml file
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		return DefaultConfig(), nil
+	}
+	c := &Config{}
+	if err := readYaml(path, c); err != nil {
+		return nil, fmt.Errorf("failed to read alert manager config %s: %w", path, err)
+	}
+	if c.Route == nil {
+		c.Route = &Route{GroupBy: []string{labels.AlertNameLabel}}
+	}
+	if len(c.Route.Matchers) > 0 {
+		return nil, fmt.Errorf("root route must not have any matchers")
+	}
+	c.init()
+	return c, nil
+}
+
+// init sets the defaults of the root route and the ids of the routes
+func (c *Config) init() {
+	root := c.Route
+	if root.GroupWait == nil {
+		d := model.Duration(defaultGroupWait)
+		root.GroupWait = &d
+	}
+	if root.GroupInterval == nil {
+		d := model.Duration(defaultGroupInterval)
+		root.GroupInterval = &d
+	}
+	if root.RepeatInterval == nil {
+		d := model.Duration(defaultRepeatInterval)
+		root.RepeatInterval = &d
+	}
+	root.init(nil, "0")
+}
+
+func (r *Route) init(parent *Route, id string) {
+	r.id = id
+	if parent != nil {
+		if r.GroupBy == nil {
+			r.GroupBy = parent.GroupBy
+		}
+		if r.GroupWait == nil {
+			r.GroupWait = parent.GroupWait
+		}
+		if r.GroupInterval == nil {
+			r.GroupInterval = parent.GroupInterval
+		}
+		if r.RepeatInterval == nil {
+			r.RepeatInterval = parent.RepeatInterval
+		}
+		if r.Receiver == "" {
+			r.Receiver = parent.Receiver
+		}
+	}
+	for idx, child := range r.Routes {
+		child.init(r, fmt.Sprintf("%s/%d", id, idx))
+	}
+}
+
+// Match returns the deepest routes matching the labels of the alert
+func (r *Route) Match(lbls map[string]string) []*Route {
+	if !r.Matchers.Matches(lbls) {
+		return nil
+	}
+	var all []*Route
+	for _, child := range r.Routes {
+		matches := child.Match(lbls)
+		all = append(all, matches...)
+		if matches != nil && !child.Continue {
+			break
+		}
+	}
+	if len(all) == 0 {
+		all = append(all, r)
+	}
+	return all
+}
+
+// groupLabels returns the labels of the alert the route groups by
+func (r *Route) groupLabels(lbls map[string]string) map[string]string {
+	group := make(map[string]string, len(r.GroupBy))
+	for _, name := range r.GroupBy {
+		if name == "..." {
+			// group by all the labels, this disables the aggregation
+			for k, v := range lbls {
+				group[k] = v
+			}
+			return group
+		}
+		if v, ok := lbls[name]; ok {
+			group[name] = v
+		}
+	}
+	return group
+}
+
+// MatchType is the comparison of a label matcher
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// Matcher matches the value of a label
+type Matcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+
+	re *regexp.Regexp
+}
+
+// ParseMatcher parses a matcher of the form name="value", the supported
+// operators are =, !=, =~ and !~ and the quotes around the value are optional.
+func ParseMatcher(s string) (*Matcher, error) {
+	s = strings.TrimSpace(s)
+	idx := strings.IndexAny(s, "=!")
+	if idx <= 0 {
+		return nil, fmt.Errorf("invalid matcher %q", s)
+	}
+	name := strings.TrimSpace(s[:idx])
+	rest := s[idx:]
+	var typ MatchType
+	for _, op := range []MatchType{MatchNotRegexp, MatchRegexp, MatchNotEqual, MatchEqual} {
+		if strings.HasPrefix(rest, string(op)) {
+			typ = op
+			break
+		}
+	}
+	if typ == "" {
+		return nil, fmt.Errorf("invalid operator in matcher %q", s)
+	}
+	value := strings.TrimSpace(rest[len(typ):])
+	if len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		value = value[1 : len(value)-1]
+	}
+	m := &Matcher{Type: typ, Name: name, Value: value}
+	if typ == MatchRegexp || typ == MatchNotRegexp {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in matcher %q: %w", s, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Matches returns true if the value of the label satisfies the matcher
+func (m *Matcher) Matches(lbls map[string]string) bool {
+	v := lbls[m.Name]
+	switch m.Type {
+	case MatchEqual:
+		return v == m.Value
+	case MatchNotEqual:
+		return v != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(v)
+	case MatchNotRegexp:
+		return !m.re.MatchString(v)
+	}
+	return false
+}
+
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// Matchers is a list of matchers which all have to match
+type Matchers []*Matcher
+
+func (ms Matchers) Matches(lbls map[string]string) bool {
+	for _, m := range ms {
+		if !m.Matches(lbls) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ms *Matchers) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw []string
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	for _, s := range raw {
+		m, err := ParseMatcher(s)
+		if err != nil {
+			return err
+		}
+		*ms = append(*ms, m)
+	}
+	return nil
+}
+
+func (ms Matchers) MarshalYAML() (interface{}, error) {
+	raw := make([]string, 0, len(ms))
+	for _, m := range ms {
+		raw = append(raw, m.String())
+	}
+	return raw, nil
+}
+
+func readYaml(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(data, v)
+}
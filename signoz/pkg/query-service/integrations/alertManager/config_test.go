@@ -0,0 +1,52 @@
+package alertManager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMatcher(t *testing.T) {
+	cases := []struct {
+		matcher string
+		labels  map[string]string
+		matches bool
+		err     bool
+	}{
+		{matcher: `severity="critical"`, labels: map[string]string{"severity": "critical"}, matches: true},
+		{matcher: `severity=critical`, labels: map[string]string{"severity": "warning"}, matches: false},
+		{matcher: `severity!="critical"`, labels: map[string]string{"severity": "warning"}, matches: true},
+		{matcher: `service=~"cart|checkout"`, labels: map[string]string{"service": "checkout"}, matches: true},
+		{matcher: `service=~"cart"`, labels: map[string]string{"service": "cartservice"}, matches: false},
+		{matcher: `service!~"cart.*"`, labels: map[string]string{"service": "frontend"}, matches: true},
+		{matcher: `env=""`, labels: map[string]string{}, matches: true},
+		{matcher: `severity`, err: true},
+		{matcher: `service=~"("`, err: true},
+	}
+	for _, c := range cases {
+		t.Run(c.matcher, func(t *testing.T) {
+			m, err := ParseMatcher(c.matcher)
+			if c.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.matches, m.Matches(c.labels))
+		})
+	}
+}
+
+func TestRouteInheritsParentOptions(t *testing.T) {
+	c := DefaultConfig()
+	c.Route.Routes = []*Route{{Receiver: "slack", Routes: []*Route{{GroupBy: []string{"service"}}}}}
+	c.init()
+
+	child := c.Route.Routes[0].Routes[0]
+	assert.Equal(t, "slack", child.Receiver)
+	assert.Equal(t, []string{"service"}, child.GroupBy)
+	assert.Equal(t, c.Route.GroupWait, child.GroupWait)
+	assert.Equal(t, c.Route.RepeatInterval, child.RepeatInterval)
+	assert.Equal(t, "0/0/0", child.id)
+	assert.Equal(t, []*Route{child}, c.Route.Match(map[string]string{"alertname": "A"}))
+}
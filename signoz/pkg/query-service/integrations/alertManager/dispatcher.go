@@ -0,0 +1,496 @@
+package alertManager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+// flushInterval is how often the dispatcher checks the groups that are due
+const flushInterval = time.Second
+
+// AlertNotifier hands the alerts of the rules over to the notification system,
+// it is implemented by the Notifier of the external alert manager and by the
+// embedded Dispatcher.
+type AlertNotifier interface {
+	Run()
+	Send(alerts ...*Alert)
+	Stop()
+}
+
+// Dispatcher is the embedded alert manager. It routes the alerts through the
+// routing tree, aggregates them in groups, mutes the inhibited alerts and sends
+// a notification of each group to its receiver when the group changes or when
+// the repeat interval of the route elapses.
+type Dispatcher struct {
+	mtx       sync.Mutex
+	config    *Config
+	receivers map[string][]integration
+	alerts    map[uint64]*Alert
+	groups    map[string]*aggrGroup
+
+	client  *http.Client
+	timeout time.Duration
+	now     func() time.Time
+
+	ctx    context.Context
+	cancel func()
+}
+
+// aggrGroup is a group of alerts of a route sent to one receiver. It records the
+// alerts of the last notification to deduplicate the notifications.
+type aggrGroup struct {
+	key      string
+	route    *Route
+	receiver string
+	labels   map[string]string
+	alerts   map[uint64]struct{}
+	// next is the time of the next flush of the group
+	next time.Time
+
+	notifiedAt time.Time
+	// firing are the firing alerts of the last notification
+	firing map[uint64]struct{}
+}
+
+// GettableAlert is an alert of the dispatcher with its notification status
+type GettableAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+	Receivers    []string          `json:"receivers"`
+	Status       AlertStatus       `json:"status"`
+}
+
+// AlertStatus is the notification status of an alert
+type AlertStatus struct {
+	State       string   `json:"state"`
+	InhibitedBy []string `json:"inhibitedBy"`
+	SilencedBy  []string `json:"silencedBy"`
+}
+
+// NewDispatcher creates a dispatcher with the given routing tree and inhibit
+// rules, the receivers are added with SetReceiver.
+func NewDispatcher(config *Config, timeout time.Duration) *Dispatcher {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		config:    config,
+		receivers: make(map[string][]integration),
+		alerts:    make(map[uint64]*Alert),
+		groups:    make(map[string]*aggrGroup),
+		client:    &http.Client{},
+		timeout:   timeout,
+		now:       time.Now,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// SetReceiver adds or replaces the receiver with the name of the given receiver
+func (d *Dispatcher) SetReceiver(receiver *Receiver) error {
+	ints, err := integrations(receiver)
+	if err != nil {
+		return err
+	}
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.receivers[receiver.Name] = ints
+	return nil
+}
+
+// RemoveReceiver removes the receiver, the groups of the receiver are dropped
+// on the next flush
+func (d *Dispatcher) RemoveReceiver(name string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	delete(d.receivers, name)
+}
+
+// ReceiverNames returns the names of the receivers of the dispatcher
+func (d *Dispatcher) ReceiverNames() []string {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	names := make([]string, 0, len(d.receivers))
+	for name := range d.receivers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Run flushes the groups that are due until the dispatcher is stopped
+func (d *Dispatcher) Run() {
+	zap.L().Info("Starting embedded alert manager dispatcher")
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.flush(d.now())
+		}
+	}
+}
+
+// Stop stops the dispatcher
+func (d *Dispatcher) Stop() {
+	zap.L().Info("Stopping embedded alert manager dispatcher")
+	d.cancel()
+}
+
+// Send adds the alerts to the groups of the routes they match
+func (d *Dispatcher) Send(alerts ...*Alert) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	now := d.now()
+	for _, a := range alerts {
+		alert := *a
+		fp := alert.Hash()
+		if old, ok := d.alerts[fp]; ok && !old.ResolvedAt(now) && old.StartsAt.Before(alert.StartsAt) {
+			alert.StartsAt = old.StartsAt
+		}
+		d.alerts[fp] = &alert
+
+		lbls := alert.Labels.Map()
+		for _, route := range d.config.Route.Match(lbls) {
+			groupLabels := route.groupLabels(lbls)
+			for _, receiver := range d.routeReceivers(route, &alert) {
+				key := fmt.Sprintf("%s:%s:%s", route.id, receiver, labels.FromMap(groupLabels).String())
+				g, ok := d.groups[key]
+				if !ok {
+					g = &aggrGroup{
+						key:      key,
+						route:    route,
+						receiver: receiver,
+						labels:   groupLabels,
+						alerts:   make(map[uint64]struct{}),
+						next:     now.Add(time.Duration(*route.GroupWait)),
+					}
+					d.groups[key] = g
+				}
+				g.alerts[fp] = struct{}{}
+			}
+		}
+	}
+}
+
+// routeReceivers returns the receivers of an alert matching the route. The
+// alerts of the routes without receiver go to the preferred channels of the
+// rule, or to all the channels when the rule has none.
+func (d *Dispatcher) routeReceivers(route *Route, alert *Alert) []string {
+	if route.Receiver != "" {
+		return []string{route.Receiver}
+	}
+	if len(alert.Receivers) > 0 {
+		return alert.Receivers
+	}
+	names := make([]string, 0, len(d.receivers))
+	for name := range d.receivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// notification is a notification of a group due to be sent
+type notification struct {
+	group  *aggrGroup
+	ints   []integration
+	alerts []*Alert
+	firing map[uint64]struct{}
+}
+
+// flush sends the notifications of the groups that are due
+func (d *Dispatcher) flush(now time.Time) {
+	notifications := d.dueNotifications(now)
+
+	for _, n := range notifications {
+		err := d.notify(n, now)
+
+		d.mtx.Lock()
+		if err != nil {
+			zap.L().Error("failed to send alert notification", zap.String("receiver", n.group.receiver), zap.String("group", n.group.key), zap.Error(err))
+		} else {
+			n.group.notifiedAt = now
+			n.group.firing = n.firing
+			// the resolved alerts have been notified, they leave the group
+			for _, a := range n.alerts {
+				if a.ResolvedAt(now) {
+					delete(n.group.alerts, a.Hash())
+				}
+			}
+			if len(n.group.alerts) == 0 {
+				delete(d.groups, n.group.key)
+			}
+		}
+		d.mtx.Unlock()
+	}
+
+	d.gc(now)
+}
+
+// dueNotifications returns the notifications of the groups that are due and
+// have changed since their last notification or have to be repeated
+func (d *Dispatcher) dueNotifications(now time.Time) []*notification {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	var notifications []*notification
+	for key, g := range d.groups {
+		if g.next.After(now) {
+			continue
+		}
+		g.next = now.Add(time.Duration(*g.route.GroupInterval))
+
+		ints, ok := d.receivers[g.receiver]
+		if !ok {
+			zap.L().Warn("dropping alert group of unknown receiver", zap.String("receiver", g.receiver), zap.String("group", key))
+			delete(d.groups, key)
+			continue
+		}
+
+		var alerts []*Alert
+		firing := make(map[uint64]struct{})
+		resolved := make(map[uint64]struct{})
+		for fp := range g.alerts {
+			a, ok := d.alerts[fp]
+			if !ok {
+				delete(g.alerts, fp)
+				continue
+			}
+			if a.ResolvedAt(now) {
+				resolved[fp] = struct{}{}
+			} else if len(d.inhibitedBy(a, now)) > 0 {
+				continue
+			} else {
+				firing[fp] = struct{}{}
+			}
+			alerts = append(alerts, a)
+		}
+		if len(g.alerts) == 0 {
+			delete(d.groups, key)
+			continue
+		}
+
+		if !g.needsUpdate(firing, resolved, time.Duration(*g.route.RepeatInterval), now) {
+			// the resolved alerts that were never notified as firing leave the group
+			for fp := range resolved {
+				delete(g.alerts, fp)
+			}
+			continue
+		}
+
+		sort.Slice(alerts, func(i, j int) bool {
+			return alerts[i].Labels.String() < alerts[j].Labels.String()
+		})
+		notifications = append(notifications, &notification{group: g, ints: ints, alerts: alerts, firing: firing})
+	}
+	return notifications
+}
+
+// needsUpdate returns true if the group has to be notified. A group is notified
+// when it has new firing alerts, when some of the notified alerts are resolved
+// and when the repeat interval has elapsed since the last notification.
+func (g *aggrGroup) needsUpdate(firing, resolved map[uint64]struct{}, repeatInterval time.Duration, now time.Time) bool {
+	if g.notifiedAt.IsZero() {
+		return len(firing) > 0
+	}
+	for fp := range firing {
+		if _, ok := g.firing[fp]; !ok {
+			return true
+		}
+	}
+	for fp := range resolved {
+		if _, ok := g.firing[fp]; ok {
+			return true
+		}
+	}
+	if len(firing) == 0 {
+		return false
+	}
+	return !g.notifiedAt.Add(repeatInterval).After(now)
+}
+
+// notify sends the notification to all the integrations of the receiver
+func (d *Dispatcher) notify(n *notification, now time.Time) error {
+	ctx, cancel := context.WithTimeout(d.ctx, d.timeout)
+	defer cancel()
+
+	data := newTemplateData(n.group.receiver, n.group.labels, n.alerts, now)
+	var firingData *TemplateData
+	var errs []error
+	for _, i := range n.ints {
+		td := data
+		if !i.SendResolved() {
+			if len(n.firing) == 0 {
+				continue
+			}
+			if firingData == nil {
+				var firing []*Alert
+				for _, a := range n.alerts {
+					if !a.ResolvedAt(now) {
+						firing = append(firing, a)
+					}
+				}
+				firingData = newTemplateData(n.group.receiver, n.group.labels, firing, now)
+			}
+			td = firingData
+		}
+		if err := i.Notify(ctx, d.client, n.group.key, td); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// inhibitedBy returns the fingerprints of the firing alerts inhibiting the alert
+func (d *Dispatcher) inhibitedBy(a *Alert, now time.Time) []string {
+	var by []string
+	lbls := a.Labels.Map()
+	fp := a.Hash()
+	for _, rule := range d.config.InhibitRules {
+		if !rule.TargetMatchers.Matches(lbls) {
+			continue
+		}
+		alsoSource := rule.SourceMatchers.Matches(lbls)
+		for sfp, source := range d.alerts {
+			if sfp == fp || source.ResolvedAt(now) {
+				continue
+			}
+			sourceLbls := source.Labels.Map()
+			if !rule.SourceMatchers.Matches(sourceLbls) {
+				continue
+			}
+			// an alert matching both sides can't be muted by another such alert
+			if alsoSource && rule.TargetMatchers.Matches(sourceLbls) {
+				continue
+			}
+			equal := true
+			for _, name := range rule.Equal {
+				if lbls[name] != sourceLbls[name] {
+					equal = false
+					break
+				}
+			}
+			if equal {
+				by = append(by, fmt.Sprintf("%016x", sfp))
+			}
+		}
+	}
+	return by
+}
+
+// gc removes the resolved alerts which are not in any group
+func (d *Dispatcher) gc(now time.Time) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	inGroup := make(map[uint64]struct{}, len(d.alerts))
+	for _, g := range d.groups {
+		for fp := range g.alerts {
+			inGroup[fp] = struct{}{}
+		}
+	}
+	for fp, a := range d.alerts {
+		if _, ok := inGroup[fp]; !ok && a.ResolvedAt(now) {
+			delete(d.alerts, fp)
+		}
+	}
+}
+
+// Alerts returns the active alerts of the dispatcher
+func (d *Dispatcher) Alerts() []*GettableAlert {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	now := d.now()
+	receivers := make(map[uint64]map[string]struct{})
+	for _, g := range d.groups {
+		for fp := range g.alerts {
+			if receivers[fp] == nil {
+				receivers[fp] = make(map[string]struct{})
+			}
+			receivers[fp][g.receiver] = struct{}{}
+		}
+	}
+
+	res := make([]*GettableAlert, 0, len(d.alerts))
+	for fp, a := range d.alerts {
+		if a.ResolvedAt(now) {
+			continue
+		}
+		alert := &GettableAlert{
+			Labels:       a.Labels.Map(),
+			Annotations:  map[string]string{},
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  fmt.Sprintf("%016x", fp),
+			Receivers:    []string{},
+			Status:       AlertStatus{State: "active", InhibitedBy: []string{}, SilencedBy: []string{}},
+		}
+		if a.Annotations != nil {
+			alert.Annotations = a.Annotations.Map()
+		}
+		for name := range receivers[fp] {
+			alert.Receivers = append(alert.Receivers, name)
+		}
+		sort.Strings(alert.Receivers)
+		if by := d.inhibitedBy(a, now); len(by) > 0 {
+			alert.Status.State = "suppressed"
+			alert.Status.InhibitedBy = by
+		}
+		res = append(res, alert)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Fingerprint < res[j].Fingerprint
+	})
+	return res
+}
+
+// TestReceiver sends a test notification to all the configs of the receiver
+func TestReceiver(ctx context.Context, receiver *Receiver) error {
+	ints, err := integrations(receiver)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	alert := &Alert{
+		Labels: labels.FromMap(map[string]string{
+			labels.AlertNameLabel: "Test Alert",
+			"severity":            "critical",
+		}),
+		Annotations: labels.FromMap(map[string]string{
+			"summary":     "This is a test alert to verify the notification channel",
+			"description": "The notification channel is configured correctly if this message is received",
+		}),
+		StartsAt: now,
+	}
+	data := newTemplateData(receiver.Name, map[string]string{labels.AlertNameLabel: "Test Alert"}, []*Alert{alert}, now)
+	client := &http.Client{Timeout: 10 * time.Second}
+	for _, i := range ints {
+		if err := i.Notify(ctx, client, "test", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
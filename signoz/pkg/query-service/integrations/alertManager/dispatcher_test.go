@@ -0,0 +1,331 @@
+package alertManager
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+type fakeIntegration struct {
+	resolved bool
+	sent     []*TemplateData
+}
+
+func (f *fakeIntegration) SendResolved() bool { return f.resolved }
+
+func (f *fakeIntegration) Notify(ctx context.Context, client *http.Client, groupKey string, data *TemplateData) error {
+	f.sent = append(f.sent, data)
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, config string, receivers ...string) (*Dispatcher, map[string]*fakeIntegration, *time.Time) {
+	c := DefaultConfig()
+	if config != "" {
+		path := t.TempDir() + "/config.yaml"
+		require.NoError(t, os.WriteFile(path, []byte(config), 0o644))
+		var err error
+		c, err = LoadConfig(path)
+		require.NoError(t, err)
+	}
+	d := NewDispatcher(c, time.Second)
+	now := time.Unix(1700000000, 0)
+	d.now = func() time.Time { return now }
+	fakes := make(map[string]*fakeIntegration)
+	for _, name := range receivers {
+		fakes[name] = &fakeIntegration{resolved: true}
+		d.receivers[name] = []integration{fakes[name]}
+	}
+	return d, fakes, &now
+}
+
+func testAlert(now time.Time, lbls ...string) *Alert {
+	return &Alert{
+		Labels:   labels.FromStrings(lbls...),
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	}
+}
+
+func resolvedAlert(now time.Time, lbls ...string) *Alert {
+	a := testAlert(now.Add(-time.Hour), lbls...)
+	a.EndsAt = now.Add(-time.Second)
+	return a
+}
+
+func TestDispatcherGroupsAlerts(t *testing.T) {
+	d, fakes, now := newTestDispatcher(t, "", "slack")
+
+	d.Send(
+		testAlert(*now, "alertname", "HighLatency", "service", "frontend"),
+		testAlert(*now, "alertname", "HighLatency", "service", "cart"),
+		testAlert(*now, "alertname", "HighErrorRate", "service", "cart"),
+	)
+
+	// nothing is sent before the group wait
+	d.flush(now.Add(10 * time.Second))
+	assert.Empty(t, fakes["slack"].sent)
+
+	d.flush(now.Add(defaultGroupWait))
+	require.Len(t, fakes["slack"].sent, 2)
+	byName := map[string]*TemplateData{}
+	for _, data := range fakes["slack"].sent {
+		byName[data.GroupLabels["alertname"]] = data
+	}
+	assert.Len(t, byName["HighLatency"].Alerts, 2)
+	assert.Equal(t, KV{"alertname": "HighLatency"}, byName["HighLatency"].CommonLabels)
+	assert.Len(t, byName["HighErrorRate"].Alerts, 1)
+	assert.Equal(t, statusFiring, byName["HighErrorRate"].Status)
+}
+
+func TestDispatcherDeduplicatesAndRepeats(t *testing.T) {
+	d, fakes, now := newTestDispatcher(t, "", "slack")
+	alert := testAlert(*now, "alertname", "HighLatency")
+
+	d.Send(alert)
+	d.flush(now.Add(defaultGroupWait))
+	require.Len(t, fakes["slack"].sent, 1)
+
+	// the rule sends the same alert again on every evaluation
+	next := now.Add(defaultGroupWait + defaultGroupInterval)
+	*now = next
+	d.Send(testAlert(next, "alertname", "HighLatency"))
+	d.flush(next)
+	assert.Len(t, fakes["slack"].sent, 1, "unchanged group must not be notified again")
+
+	// the start of the alert is kept from the first notification
+	assert.Equal(t, alert.StartsAt, d.alerts[alert.Hash()].StartsAt)
+
+	// a new alert in the group is notified at the next group interval
+	d.Send(testAlert(next, "alertname", "HighLatency", "service", "cart"))
+	d.flush(next)
+	assert.Len(t, fakes["slack"].sent, 1)
+	d.flush(next.Add(defaultGroupInterval))
+	require.Len(t, fakes["slack"].sent, 2)
+	assert.Len(t, fakes["slack"].sent[1].Alerts, 2)
+
+	// the group is notified again after the repeat interval
+	repeat := next.Add(defaultGroupInterval + defaultRepeatInterval)
+	*now = repeat
+	d.Send(testAlert(repeat, "alertname", "HighLatency"), testAlert(repeat, "alertname", "HighLatency", "service", "cart"))
+	d.flush(repeat)
+	assert.Len(t, fakes["slack"].sent, 3)
+}
+
+func TestDispatcherResolvedAlerts(t *testing.T) {
+	d, fakes, now := newTestDispatcher(t, "", "slack", "webhook")
+	fakes["webhook"].resolved = false
+
+	d.Send(testAlert(*now, "alertname", "HighLatency"))
+	d.flush(now.Add(defaultGroupWait))
+	require.Len(t, fakes["slack"].sent, 1)
+	require.Len(t, fakes["webhook"].sent, 1)
+
+	*now = now.Add(defaultGroupWait + defaultGroupInterval)
+	d.Send(resolvedAlert(*now, "alertname", "HighLatency"))
+	d.flush(*now)
+	require.Len(t, fakes["slack"].sent, 2)
+	assert.Equal(t, statusResolved, fakes["slack"].sent[1].Status)
+	assert.Len(t, fakes["webhook"].sent, 1, "resolved alerts are only sent to the configs with send resolved")
+
+	// the notified resolved alerts are removed
+	assert.Empty(t, d.groups)
+	assert.Empty(t, d.alerts)
+
+	// the alerts resolved before their first notification are never notified
+	d.Send(resolvedAlert(*now, "alertname", "Flapping"))
+	d.flush(now.Add(defaultGroupWait))
+	assert.Len(t, fakes["slack"].sent, 2)
+	assert.Empty(t, d.alerts)
+}
+
+func TestDispatcherRouting(t *testing.T) {
+	config := `
+route:
+  group_by: [alertname]
+  routes:
+    - matchers: ['severity="critical"']
+      receiver: pagerduty
+      group_wait: 0s
+      continue: true
+    - matchers: ['service=~"cart|checkout"']
+      receiver: slack
+`
+	d, fakes, now := newTestDispatcher(t, config, "pagerduty", "slack", "email", "webhook")
+
+	d.Send(
+		testAlert(*now, "alertname", "A", "severity", "critical", "service", "cart"),
+		testAlert(*now, "alertname", "B", "severity", "warning", "service", "checkout"),
+		&Alert{Labels: labels.FromStrings("alertname", "C"), StartsAt: *now, EndsAt: now.Add(time.Hour), Receivers: []string{"email"}},
+		testAlert(*now, "alertname", "D"),
+	)
+
+	d.flush(*now)
+	require.Len(t, fakes["pagerduty"].sent, 1)
+	assert.Equal(t, "A", fakes["pagerduty"].sent[0].CommonLabels["alertname"])
+	assert.Empty(t, fakes["slack"].sent)
+
+	d.flush(now.Add(defaultGroupWait))
+	names := func(f *fakeIntegration) []string {
+		var res []string
+		for _, data := range f.sent {
+			res = append(res, data.GroupLabels["alertname"])
+		}
+		return res
+	}
+	// the alerts of the root route go to the preferred channels of the rule or to all the channels
+	assert.ElementsMatch(t, []string{"A", "B", "D"}, names(fakes["slack"]))
+	assert.ElementsMatch(t, []string{"C", "D"}, names(fakes["email"]))
+	assert.ElementsMatch(t, []string{"D"}, names(fakes["webhook"]))
+	assert.ElementsMatch(t, []string{"A", "D"}, names(fakes["pagerduty"]))
+}
+
+func TestDispatcherInhibition(t *testing.T) {
+	config := `
+inhibit_rules:
+  - source_matchers: [severity=critical]
+    target_matchers: [severity=warning]
+    equal: [service]
+`
+	d, fakes, now := newTestDispatcher(t, config, "slack")
+
+	d.Send(
+		testAlert(*now, "alertname", "HighErrorRate", "severity", "critical", "service", "cart"),
+		testAlert(*now, "alertname", "HighLatency", "severity", "warning", "service", "cart"),
+		testAlert(*now, "alertname", "HighLatency", "severity", "warning", "service", "frontend"),
+	)
+	d.flush(now.Add(defaultGroupWait))
+
+	var notified []string
+	for _, data := range fakes["slack"].sent {
+		for _, a := range data.Alerts {
+			notified = append(notified, a.Labels["alertname"]+"/"+a.Labels["service"])
+		}
+	}
+	assert.ElementsMatch(t, []string{"HighErrorRate/cart", "HighLatency/frontend"}, notified)
+
+	alerts := d.Alerts()
+	require.Len(t, alerts, 3)
+	suppressed := 0
+	for _, a := range alerts {
+		if a.Status.State == "suppressed" {
+			suppressed++
+			assert.Equal(t, "cart", a.Labels["service"])
+			assert.Len(t, a.Status.InhibitedBy, 1)
+		}
+	}
+	assert.Equal(t, 1, suppressed)
+}
+
+func TestDispatcherDropsGroupsOfRemovedReceivers(t *testing.T) {
+	d, fakes, now := newTestDispatcher(t, "", "slack")
+
+	d.Send(testAlert(*now, "alertname", "HighLatency"))
+	d.RemoveReceiver("slack")
+	d.flush(now.Add(defaultGroupWait))
+
+	assert.Empty(t, fakes["slack"].sent)
+	assert.Empty(t, d.groups)
+}
+
+func TestWebhookNotification(t *testing.T) {
+	var body map[string]interface{}
+	var user, password string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ = r.BasicAuth()
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &body)
+	}))
+	defer server.Close()
+
+	receiver := &Receiver{
+		Name: "webhook",
+		WebhookConfigs: []interface{}{map[string]interface{}{
+			"url":           server.URL,
+			"send_resolved": true,
+			"http_config": map[string]interface{}{
+				"basic_auth": map[string]interface{}{"username": "user", "password": "secret"},
+			},
+		}},
+	}
+	d, _, now := newTestDispatcher(t, "")
+	require.NoError(t, d.SetReceiver(receiver))
+	d.client = server.Client()
+
+	d.Send(testAlert(*now, "alertname", "HighLatency", "service", "cart"))
+	d.flush(now.Add(defaultGroupWait))
+
+	assert.Equal(t, "user", user)
+	assert.Equal(t, "secret", password)
+	assert.Equal(t, "webhook", body["receiver"])
+	assert.Equal(t, "firing", body["status"])
+	assert.Equal(t, map[string]interface{}{"alertname": "HighLatency"}, body["groupLabels"])
+	assert.Len(t, body["alerts"], 1)
+}
+
+func TestIntegrationsValidation(t *testing.T) {
+	cases := []struct {
+		name     string
+		receiver *Receiver
+		err      string
+	}{
+		{
+			name:     "slack",
+			receiver: &Receiver{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{"api_url": "https://hooks.slack.com/services/x", "channel": "#alerts"}}},
+		},
+		{
+			name:     "missing slack url",
+			receiver: &Receiver{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{"channel": "#alerts"}}},
+			err:      "slack api_url is required",
+		},
+		{
+			name:     "unsupported",
+			receiver: &Receiver{Name: "opsgenie", OpsGenieConfigs: []interface{}{map[string]interface{}{"api_key": "x"}}},
+			err:      "opsgenie channels are not supported",
+		},
+		{
+			name:     "no configs",
+			receiver: &Receiver{Name: "empty"},
+			err:      "has no configs",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := integrations(c.receiver)
+			if c.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), c.err)
+		})
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	now := time.Now()
+	a := testAlert(now, "alertname", "HighLatency", "service", "cart")
+	a.Annotations = labels.FromStrings("summary", "latency is high")
+	data := newTemplateData("slack", map[string]string{"alertname": "HighLatency"}, []*Alert{a}, now)
+
+	title, err := executeTextTemplate("", defaultTitleTemplate, data)
+	require.NoError(t, err)
+	assert.Equal(t, "[FIRING:1] HighLatency", title)
+
+	text, err := executeTextTemplate("", defaultTextTemplate, data)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(text, "latency is high"))
+
+	custom, err := executeTextTemplate(`{{ range .Alerts }}{{ .Labels.service | toUpper }}{{ end }}`, defaultTextTemplate, data)
+	require.NoError(t, err)
+	assert.Equal(t, "CART", custom)
+}
@@ -0,0 +1,69 @@
+package alertManager
+
+import (
+	"context"
+	neturl "net/url"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// embeddedManager manages the channels of the embedded alert manager, the
+// channels are stored in the sql store and loaded in the dispatcher
+type embeddedManager struct {
+	dispatcher *Dispatcher
+}
+
+// NewEmbeddedManager returns the manager of the channels of the dispatcher. The
+// manager only validates and tests the channels when the dispatcher is nil.
+func NewEmbeddedManager(dispatcher *Dispatcher) Manager {
+	return &embeddedManager{dispatcher: dispatcher}
+}
+
+func (m *embeddedManager) URL() *neturl.URL {
+	return nil
+}
+
+func (m *embeddedManager) URLPath(path string) *neturl.URL {
+	return nil
+}
+
+func (m *embeddedManager) AddRoute(receiver *Receiver) *model.ApiError {
+	return m.setReceiver(receiver)
+}
+
+func (m *embeddedManager) EditRoute(receiver *Receiver) *model.ApiError {
+	return m.setReceiver(receiver)
+}
+
+func (m *embeddedManager) setReceiver(receiver *Receiver) *model.ApiError {
+	if m.dispatcher == nil {
+		if _, err := integrations(receiver); err != nil {
+			return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+		return nil
+	}
+	if err := m.dispatcher.SetReceiver(receiver); err != nil {
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	return nil
+}
+
+func (m *embeddedManager) DeleteRoute(name string) *model.ApiError {
+	if m.dispatcher != nil {
+		m.dispatcher.RemoveReceiver(name)
+	}
+	return nil
+}
+
+func (m *embeddedManager) TestReceiver(receiver *Receiver) *model.ApiError {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := TestReceiver(ctx, receiver); err != nil {
+		zap.L().Error("failed to send test notification", zap.String("receiver", receiver.Name), zap.Error(err))
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	return nil
+}
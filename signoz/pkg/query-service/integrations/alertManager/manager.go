@@ -34,6 +34,10 @@ func defaultOptions() []ManagerOptions {
 type ManagerOptions func(m *manager) error
 
 func New(opts ...ManagerOptions) (Manager, error) {
+	if constants.IsEmbeddedAlertManagerEnabled() {
+		return NewEmbeddedManager(nil), nil
+	}
+
 	m := &manager{}
 
 	newOpts := defaultOptions()
@@ -0,0 +1,382 @@
+package alertManager
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	smtpservice "go.signoz.io/signoz/pkg/query-service/utils/smtpService"
+)
+
+const pagerdutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// integration sends the notifications of a group of alerts to one config of a receiver
+type integration interface {
+	// Notify sends the notification, groupKey identifies the group of the alerts
+	Notify(ctx context.Context, client *http.Client, groupKey string, data *TemplateData) error
+	// SendResolved returns true if the resolved alerts are notified
+	SendResolved() bool
+}
+
+type slackConfig struct {
+	APIURL    string `json:"api_url"`
+	Channel   string `json:"channel,omitempty"`
+	Username  string `json:"username,omitempty"`
+	IconEmoji string `json:"icon_emoji,omitempty"`
+	IconURL   string `json:"icon_url,omitempty"`
+	Title     string `json:"title,omitempty"`
+	TitleLink string `json:"title_link,omitempty"`
+	Text      string `json:"text,omitempty"`
+	Resolved  bool   `json:"send_resolved,omitempty"`
+}
+
+func (c *slackConfig) SendResolved() bool { return c.Resolved }
+
+func (c *slackConfig) Notify(ctx context.Context, client *http.Client, groupKey string, data *TemplateData) error {
+	title, err := executeTextTemplate(c.Title, defaultTitleTemplate, data)
+	if err != nil {
+		return fmt.Errorf("failed to render slack title: %w", err)
+	}
+	text, err := executeTextTemplate(c.Text, defaultTextTemplate, data)
+	if err != nil {
+		return fmt.Errorf("failed to render slack text: %w", err)
+	}
+	titleLink, err := executeTextTemplate(c.TitleLink, "{{ .ExternalURL }}", data)
+	if err != nil {
+		return fmt.Errorf("failed to render slack title link: %w", err)
+	}
+	color := "danger"
+	if data.Status == statusResolved {
+		color = "good"
+	}
+	msg := map[string]interface{}{
+		"channel":    c.Channel,
+		"username":   c.Username,
+		"icon_emoji": c.IconEmoji,
+		"icon_url":   c.IconURL,
+		"attachments": []map[string]interface{}{{
+			"title":      title,
+			"title_link": titleLink,
+			"text":       text,
+			"color":      color,
+			"mrkdwn_in":  []string{"fallback", "pretext", "text"},
+		}},
+	}
+	return postJSON(ctx, client, c.APIURL, msg, nil)
+}
+
+type webhookConfig struct {
+	URL        string `json:"url"`
+	Resolved   bool   `json:"send_resolved,omitempty"`
+	MaxAlerts  int    `json:"max_alerts,omitempty"`
+	HttpConfig struct {
+		BasicAuth *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"basic_auth,omitempty"`
+		Authorization *struct {
+			Type        string `json:"type"`
+			Credentials string `json:"credentials"`
+		} `json:"authorization,omitempty"`
+	} `json:"http_config,omitempty"`
+}
+
+func (c *webhookConfig) SendResolved() bool { return c.Resolved }
+
+func (c *webhookConfig) Notify(ctx context.Context, client *http.Client, groupKey string, data *TemplateData) error {
+	alerts := data.Alerts
+	truncated := 0
+	if c.MaxAlerts > 0 && len(alerts) > c.MaxAlerts {
+		truncated = len(alerts) - c.MaxAlerts
+		alerts = alerts[:c.MaxAlerts]
+	}
+	msg := map[string]interface{}{
+		"version":           "4",
+		"groupKey":          groupKey,
+		"truncatedAlerts":   truncated,
+		"receiver":          data.Receiver,
+		"status":            data.Status,
+		"alerts":            alerts,
+		"groupLabels":       data.GroupLabels,
+		"commonLabels":      data.CommonLabels,
+		"commonAnnotations": data.CommonAnnotations,
+		"externalURL":       data.ExternalURL,
+	}
+	headers := map[string]string{}
+	if auth := c.HttpConfig.Authorization; auth != nil && auth.Credentials != "" {
+		typ := auth.Type
+		if typ == "" {
+			typ = "Bearer"
+		}
+		headers["Authorization"] = typ + " " + auth.Credentials
+	}
+	return postJSON(ctx, client, c.URL, msg, func(req *http.Request) {
+		if auth := c.HttpConfig.BasicAuth; auth != nil && auth.Username != "" {
+			req.SetBasicAuth(auth.Username, auth.Password)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	})
+}
+
+type pagerdutyConfig struct {
+	RoutingKey  string            `json:"routing_key"`
+	ServiceKey  string            `json:"service_key,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	Client      string            `json:"client,omitempty"`
+	ClientURL   string            `json:"client_url,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Severity    string            `json:"severity,omitempty"`
+	Class       string            `json:"class,omitempty"`
+	Component   string            `json:"component,omitempty"`
+	Group       string            `json:"group,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Resolved    bool              `json:"send_resolved,omitempty"`
+}
+
+func (c *pagerdutyConfig) SendResolved() bool { return c.Resolved }
+
+func (c *pagerdutyConfig) Notify(ctx context.Context, client *http.Client, groupKey string, data *TemplateData) error {
+	routingKey := c.RoutingKey
+	if routingKey == "" {
+		routingKey = c.ServiceKey
+	}
+	url := c.URL
+	if url == "" {
+		url = pagerdutyEventsURL
+	}
+	action := "trigger"
+	if data.Status == statusResolved {
+		action = "resolve"
+	}
+
+	render := func(text, defaultText string) string {
+		out, err := executeTextTemplate(text, defaultText, data)
+		if err != nil {
+			return text
+		}
+		return out
+	}
+	severity := render(c.Severity, "error")
+	switch severity {
+	case "critical", "error", "warning", "info":
+	default:
+		severity = "error"
+	}
+	details := map[string]string{
+		"num_firing":   fmt.Sprintf("%d", len(data.Alerts.Firing())),
+		"num_resolved": fmt.Sprintf("%d", len(data.Alerts.Resolved())),
+	}
+	for k, v := range c.Details {
+		details[k] = render(v, "")
+	}
+
+	msg := map[string]interface{}{
+		"routing_key":  routingKey,
+		"event_action": action,
+		"dedup_key":    fmt.Sprintf("%x", sha256.Sum256([]byte(groupKey))),
+		"client":       render(c.Client, "SigNoz Alert Manager"),
+		"client_url":   render(c.ClientURL, "{{ .ExternalURL }}"),
+		"payload": map[string]interface{}{
+			"summary":        truncate(render(c.Description, defaultTitleTemplate), 1024),
+			"source":         render(c.Client, "SigNoz Alert Manager"),
+			"severity":       severity,
+			"class":          render(c.Class, ""),
+			"component":      render(c.Component, ""),
+			"group":          render(c.Group, ""),
+			"custom_details": details,
+		},
+	}
+	return postJSON(ctx, client, url, msg, nil)
+}
+
+type emailConfig struct {
+	To       string            `json:"to"`
+	HTML     string            `json:"html,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Resolved bool              `json:"send_resolved,omitempty"`
+}
+
+func (c *emailConfig) SendResolved() bool { return c.Resolved }
+
+func (c *emailConfig) Notify(ctx context.Context, client *http.Client, groupKey string, data *TemplateData) error {
+	subject, err := executeTextTemplate(c.Headers["Subject"], defaultTitleTemplate, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email subject: %w", err)
+	}
+	body, err := executeHTMLTemplate(c.HTML, defaultEmailTemplate, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email body: %w", err)
+	}
+	return smtpservice.GetInstance().SendEmail(c.To, subject, body)
+}
+
+type msteamsConfig struct {
+	WebhookURL string `json:"webhook_url"`
+	Title      string `json:"title,omitempty"`
+	Text       string `json:"text,omitempty"`
+	Resolved   bool   `json:"send_resolved,omitempty"`
+}
+
+func (c *msteamsConfig) SendResolved() bool { return c.Resolved }
+
+func (c *msteamsConfig) Notify(ctx context.Context, client *http.Client, groupKey string, data *TemplateData) error {
+	title, err := executeTextTemplate(c.Title, defaultTitleTemplate, data)
+	if err != nil {
+		return fmt.Errorf("failed to render msteams title: %w", err)
+	}
+	text, err := executeTextTemplate(c.Text, defaultTextTemplate, data)
+	if err != nil {
+		return fmt.Errorf("failed to render msteams text: %w", err)
+	}
+	color := "8C1A1A"
+	if data.Status == statusResolved {
+		color = "2DC72D"
+	}
+	msg := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "http://schema.org/extensions",
+		"themeColor": color,
+		"title":      title,
+		"summary":    title,
+		"text":       text,
+	}
+	return postJSON(ctx, client, c.WebhookURL, msg, nil)
+}
+
+// integrations returns the integrations of the configs of the receiver, the
+// receivers with configs the embedded alert manager doesn't support are rejected
+func integrations(receiver *Receiver) ([]integration, error) {
+	if receiver.Name == "" {
+		return nil, fmt.Errorf("receiver name is required")
+	}
+	for typ, configs := range map[string]interface{}{
+		"opsgenie":  receiver.OpsGenieConfigs,
+		"wechat":    receiver.WechatConfigs,
+		"pushover":  receiver.PushoverConfigs,
+		"victorops": receiver.VictorOpsConfigs,
+		"sns":       receiver.SNSConfigs,
+	} {
+		if configs != nil {
+			return nil, fmt.Errorf("%s channels are not supported by the embedded alert manager", typ)
+		}
+	}
+
+	var all []integration
+	var slack []*slackConfig
+	if err := decodeConfigs(receiver.SlackConfigs, &slack); err != nil {
+		return nil, fmt.Errorf("invalid slack config: %w", err)
+	}
+	for _, c := range slack {
+		if c.APIURL == "" {
+			return nil, fmt.Errorf("slack api_url is required")
+		}
+		all = append(all, c)
+	}
+	var webhook []*webhookConfig
+	if err := decodeConfigs(receiver.WebhookConfigs, &webhook); err != nil {
+		return nil, fmt.Errorf("invalid webhook config: %w", err)
+	}
+	for _, c := range webhook {
+		if c.URL == "" {
+			return nil, fmt.Errorf("webhook url is required")
+		}
+		all = append(all, c)
+	}
+	var pagerduty []*pagerdutyConfig
+	if err := decodeConfigs(receiver.PagerdutyConfigs, &pagerduty); err != nil {
+		return nil, fmt.Errorf("invalid pagerduty config: %w", err)
+	}
+	for _, c := range pagerduty {
+		if c.RoutingKey == "" && c.ServiceKey == "" {
+			return nil, fmt.Errorf("pagerduty routing_key is required")
+		}
+		all = append(all, c)
+	}
+	var email []*emailConfig
+	if err := decodeConfigs(receiver.EmailConfigs, &email); err != nil {
+		return nil, fmt.Errorf("invalid email config: %w", err)
+	}
+	for _, c := range email {
+		if c.To == "" {
+			return nil, fmt.Errorf("email to is required")
+		}
+		all = append(all, c)
+	}
+	var msteams []*msteamsConfig
+	if err := decodeConfigs(receiver.MSTeamsConfigs, &msteams); err != nil {
+		return nil, fmt.Errorf("invalid msteams config: %w", err)
+	}
+	for _, c := range msteams {
+		if c.WebhookURL == "" {
+			return nil, fmt.Errorf("msteams webhook_url is required")
+		}
+		all = append(all, c)
+	}
+
+	if len(all) == 0 {
+		return nil, fmt.Errorf("receiver %s has no configs", receiver.Name)
+	}
+	return all, nil
+}
+
+// decodeConfigs decodes the loosely typed configs of a receiver
+func decodeConfigs(configs interface{}, v interface{}) error {
+	if configs == nil {
+		return nil
+	}
+	b, err := json.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, msg interface{}, prepare func(req *http.Request)) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	if prepare != nil {
+		prepare(req)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d from %s: %s", resp.StatusCode, redactURL(url), strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// redactURL removes the path of the url, the webhook urls of the channels
+// have their secret in the path
+func redactURL(url string) string {
+	if idx := strings.Index(url, "://"); idx >= 0 {
+		if end := strings.Index(url[idx+3:], "/"); end >= 0 {
+			return url[:idx+3+end]
+		}
+	}
+	return url
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
@@ -0,0 +1,283 @@
+package alertManager
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"sort"
+	"strings"
+	textTemplate "text/template"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+const (
+	statusFiring   = "firing"
+	statusResolved = "resolved"
+)
+
+// KV is a set of key/value string pairs
+type KV map[string]string
+
+// Pair is a key/value string pair
+type Pair struct {
+	Name, Value string
+}
+
+// Pairs is a list of key/value string pairs
+type Pairs []Pair
+
+// Names returns the names of the pairs
+func (ps Pairs) Names() []string {
+	ns := make([]string, 0, len(ps))
+	for _, p := range ps {
+		ns = append(ns, p.Name)
+	}
+	return ns
+}
+
+// Values returns the values of the pairs
+func (ps Pairs) Values() []string {
+	vs := make([]string, 0, len(ps))
+	for _, p := range ps {
+		vs = append(vs, p.Value)
+	}
+	return vs
+}
+
+// SortedPairs returns the pairs sorted by name, the alertname comes first
+func (kv KV) SortedPairs() Pairs {
+	var (
+		pairs     = make([]Pair, 0, len(kv))
+		keys      = make([]string, 0, len(kv))
+		sortStart = 0
+	)
+	for k := range kv {
+		if k == "alertname" {
+			keys = append([]string{k}, keys...)
+			sortStart = 1
+		} else {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[sortStart:])
+
+	for _, k := range keys {
+		pairs = append(pairs, Pair{k, kv[k]})
+	}
+	return pairs
+}
+
+// Remove returns a copy of the key/value set without the given keys
+func (kv KV) Remove(keys []string) KV {
+	keySet := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		keySet[k] = struct{}{}
+	}
+
+	res := KV{}
+	for k, v := range kv {
+		if _, ok := keySet[k]; !ok {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// Names returns the sorted names of the key/value set
+func (kv KV) Names() []string {
+	return kv.SortedPairs().Names()
+}
+
+// Values returns the values of the key/value set sorted by name
+func (kv KV) Values() []string {
+	return kv.SortedPairs().Values()
+}
+
+// TemplateAlert is an alert in the data of the notification templates. The
+// data has the same shape as the one of the Prometheus Alertmanager, so the
+// templates of the existing channels keep working.
+type TemplateAlert struct {
+	Status       string    `json:"status"`
+	Labels       KV        `json:"labels"`
+	Annotations  KV        `json:"annotations"`
+	StartsAt     time.Time `json:"startsAt"`
+	EndsAt       time.Time `json:"endsAt"`
+	GeneratorURL string    `json:"generatorURL"`
+	Fingerprint  string    `json:"fingerprint"`
+}
+
+// TemplateAlerts is a list of alerts in the data of the notification templates
+type TemplateAlerts []TemplateAlert
+
+// Firing returns the firing alerts
+func (as TemplateAlerts) Firing() []TemplateAlert {
+	res := []TemplateAlert{}
+	for _, a := range as {
+		if a.Status == statusFiring {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// Resolved returns the resolved alerts
+func (as TemplateAlerts) Resolved() []TemplateAlert {
+	res := []TemplateAlert{}
+	for _, a := range as {
+		if a.Status == statusResolved {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// TemplateData is the data the notification templates are executed with
+type TemplateData struct {
+	Receiver string         `json:"receiver"`
+	Status   string         `json:"status"`
+	Alerts   TemplateAlerts `json:"alerts"`
+
+	GroupLabels       KV `json:"groupLabels"`
+	CommonLabels      KV `json:"commonLabels"`
+	CommonAnnotations KV `json:"commonAnnotations"`
+
+	ExternalURL string `json:"externalURL"`
+}
+
+// newTemplateData builds the template data of the alerts of a group
+func newTemplateData(receiver string, groupLabels map[string]string, alerts []*Alert, now time.Time) *TemplateData {
+	data := &TemplateData{
+		Receiver:          receiver,
+		Status:            statusResolved,
+		Alerts:            make(TemplateAlerts, 0, len(alerts)),
+		GroupLabels:       KV{},
+		CommonLabels:      KV{},
+		CommonAnnotations: KV{},
+	}
+	for k, v := range groupLabels {
+		data.GroupLabels[k] = v
+	}
+
+	for idx, a := range alerts {
+		alert := TemplateAlert{
+			Status:       statusFiring,
+			Labels:       KV(a.Labels.Map()),
+			Annotations:  KV{},
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  fmt.Sprintf("%016x", a.Hash()),
+		}
+		if a.Annotations != nil {
+			alert.Annotations = KV(a.Annotations.Map())
+		}
+		if a.ResolvedAt(now) {
+			alert.Status = statusResolved
+		} else {
+			data.Status = statusFiring
+		}
+		data.Alerts = append(data.Alerts, alert)
+
+		if idx == 0 {
+			for k, v := range alert.Labels {
+				data.CommonLabels[k] = v
+			}
+			for k, v := range alert.Annotations {
+				data.CommonAnnotations[k] = v
+			}
+			continue
+		}
+		for k, v := range data.CommonLabels {
+			if alert.Labels[k] != v {
+				delete(data.CommonLabels, k)
+			}
+		}
+		for k, v := range data.CommonAnnotations {
+			if alert.Annotations[k] != v {
+				delete(data.CommonAnnotations, k)
+			}
+		}
+	}
+	if len(alerts) > 0 {
+		data.ExternalURL = alerts[0].GeneratorURL
+	}
+	return data
+}
+
+var templateFuncs = map[string]interface{}{
+	"toUpper": strings.ToUpper,
+	"toLower": strings.ToLower,
+	"title":   cases.Title(language.AmericanEnglish).String,
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+	"match": regexp.MatchString,
+	"safeHtml": func(text string) template.HTML {
+		return template.HTML(text)
+	},
+	"reReplaceAll": func(pattern, repl, text string) string {
+		re := regexp.MustCompile(pattern)
+		return re.ReplaceAllString(text, repl)
+	},
+	"stringSlice": func(s ...string) []string {
+		return s
+	},
+}
+
+// executeTextTemplate renders a text template of a channel, the empty templates
+// render the default value
+func executeTextTemplate(text, defaultText string, data *TemplateData) (string, error) {
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := textTemplate.New("").Option("missingkey=zero").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// executeHTMLTemplate renders a html template of a channel, the empty templates
+// render the default value
+func executeHTMLTemplate(text, defaultText string, data *TemplateData) (string, error) {
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := template.New("").Option("missingkey=zero").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+const (
+	defaultTitleTemplate = `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ .Alerts.Firing | len }}{{ end }}] {{ .CommonLabels.alertname }}`
+
+	defaultTextTemplate = `{{ range .Alerts }}{{ if .Annotations.summary }}*Summary:* {{ .Annotations.summary }}
+{{ end }}{{ if .Annotations.description }}*Description:* {{ .Annotations.description }}
+{{ end }}*Details:*
+{{ range .Labels.SortedPairs }}  • *{{ .Name }}:* {{ .Value }}
+{{ end }}
+{{ end }}`
+
+	defaultEmailTemplate = `<html><body>
+<h3>{{ .Alerts | len }} alert{{ if gt (len .Alerts) 1 }}s{{ end }} for {{ range .GroupLabels.SortedPairs }}{{ .Name }}={{ .Value }} {{ end }}</h3>
+{{ range .Alerts }}<p><b>[{{ .Status | toUpper }}]</b> {{ .Labels.alertname }}<br/>
+{{ if .Annotations.summary }}{{ .Annotations.summary }}<br/>{{ end }}
+{{ if .Annotations.description }}{{ .Annotations.description }}<br/>{{ end }}
+{{ range .Labels.SortedPairs }}{{ .Name }} = {{ .Value }}<br/>{{ end }}
+{{ if .GeneratorURL }}<a href="{{ .GeneratorURL }}">Source</a>{{ end }}</p>
+{{ end }}</body></html>`
+)
@@ -54,9 +54,13 @@ type Reader interface {
 	// Returns `MetricStatus` for latest received metric among `metricNames`. Useful for status calculations
 	GetLatestReceivedMetric(ctx context.Context, metricNames []string) (*model.MetricStatus, *model.ApiError)
 
+	// Returns the increase of the `metricNames` counters between start and end epoch milliseconds
+	GetIngestionErrorCount(ctx context.Context, metricNames []string, start, end int64) (float64, error)
+
 	// QB V3 metrics/traces/logs
 	GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error)
 	GetListResultV3(ctx context.Context, query string) ([]*v3.Row, error)
+	EstimateQueryCost(ctx context.Context, query string) (*v3.QueryCostEstimate, error)
 	LiveTailLogsV3(ctx context.Context, query string, timestampStart uint64, idStart string, client *model.LogsLiveTailClient)
 	LiveTailLogsV4(ctx context.Context, query string, timestampStart uint64, idStart string, client *model.LogsLiveTailClientV2)
 
@@ -91,6 +95,7 @@ type Reader interface {
 	CheckClickHouse(ctx context.Context) error
 
 	GetMetricMetadata(context.Context, string, string) (*v3.MetricMetadataResponse, error)
+	GetHistogramBuckets(ctx context.Context, metricName, serviceName string) ([]float64, error)
 
 	AddRuleStateHistory(ctx context.Context, ruleStateHistory []model.RuleStateHistory) error
 	GetOverallStateTransitions(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) ([]model.ReleStateItem, error)
@@ -102,6 +107,9 @@ type Reader interface {
 	ReadRuleStateHistoryTopContributorsByRuleID(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) ([]model.RuleStateHistoryContributor, error)
 	GetLastSavedRuleStateHistory(ctx context.Context, ruleID string) ([]model.RuleStateHistory, error)
 
+	WriteRecordedMetric(ctx context.Context, metric *model.RecordedMetric) error
+	GetLastRecordedSampleTime(ctx context.Context, metricName string, since int64) (int64, error)
+
 	GetMinAndMaxTimestampForTraceID(ctx context.Context, traceID []string) (int64, int64, error)
 
 	// Query Progress tracking helpers.
@@ -126,4 +134,6 @@ type Querier interface {
 type QueryCache interface {
 	FindMissingTimeRanges(start, end int64, step int64, cacheKey string) []querycache.MissInterval
 	MergeWithCachedSeriesData(cacheKey string, newData []querycache.CachedSeriesData) []querycache.CachedSeriesData
+	RetrieveListData(cacheKey string) ([]*v3.Row, bool)
+	StoreListData(cacheKey string, data querycache.CachedListData)
 }
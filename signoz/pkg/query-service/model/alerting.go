@@ -18,6 +18,9 @@ const (
 	StateFiring
 	StateNoData
 	StateDisabled
+	// StateSuppressed is recorded in the rule state history when the
+	// notifications of a firing alert are suppressed by a dependency
+	StateSuppressed
 )
 
 func (s AlertState) String() string {
@@ -32,6 +35,8 @@ func (s AlertState) String() string {
 		return "nodata"
 	case StateDisabled:
 		return "disabled"
+	case StateSuppressed:
+		return "suppressed"
 	}
 	panic(errors.Errorf("unknown alert state: %d", s))
 }
@@ -58,6 +63,8 @@ func (s *AlertState) UnmarshalJSON(b []byte) error {
 			*s = StateNoData
 		case "disabled":
 			*s = StateDisabled
+		case "suppressed":
+			*s = StateSuppressed
 		default:
 			*s = StateInactive
 		}
@@ -83,6 +90,8 @@ func (s *AlertState) Scan(value interface{}) error {
 		*s = StateNoData
 	case "disabled":
 		*s = StateDisabled
+	case "suppressed":
+		*s = StateSuppressed
 	}
 	return nil
 }
@@ -191,3 +200,20 @@ type Stats struct {
 	CurrentAvgResolutionTimeSeries *v3.Series `json:"currentAvgResolutionTimeSeries"`
 	PastAvgResolutionTimeSeries    *v3.Series `json:"pastAvgResolutionTimeSeries"`
 }
+
+// RecordedMetric is a metric materialized by a recording rule
+type RecordedMetric struct {
+	Name        string
+	Description string
+	Unit        string
+	Samples     []RecordedSample
+}
+
+// RecordedSample is a sample of a series of a recorded metric
+type RecordedSample struct {
+	Fingerprint uint64
+	// Labels is the json of the labels of the series, including the metric name
+	Labels    string
+	UnixMilli int64
+	Value     float64
+}
@@ -166,6 +166,8 @@ type ChannelItem struct {
 	Name      string    `json:"name" db:"name"`
 	Type      string    `json:"type" db:"type"`
 	Data      string    `json:"data" db:"data"`
+	// Managed is true when the channel is provisioned from a file and can not be changed in the API
+	Managed bool `json:"managed,omitempty" db:"-"`
 }
 
 // AlertDiscovery has info for all active alerts.
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -176,6 +177,29 @@ func (r ReduceToOperator) Validate() error {
 	}
 }
 
+// FillGapsStrategy is how the missing points of a time series are filled
+type FillGapsStrategy string
+
+const (
+	// FillGapsStrategyZero fills the missing points with zero
+	FillGapsStrategyZero FillGapsStrategy = "zero"
+	// FillGapsStrategyPrevious fills the missing points with the last known value
+	FillGapsStrategyPrevious FillGapsStrategy = "previous"
+	// FillGapsStrategyLinear interpolates the missing points between the known values
+	FillGapsStrategyLinear FillGapsStrategy = "linear"
+	// FillGapsStrategyNull fills the missing points with NaN
+	FillGapsStrategyNull FillGapsStrategy = "null"
+)
+
+func (f FillGapsStrategy) Validate() error {
+	switch f {
+	case FillGapsStrategyZero, FillGapsStrategyPrevious, FillGapsStrategyLinear, FillGapsStrategyNull:
+		return nil
+	default:
+		return fmt.Errorf("invalid fill gaps strategy: %s", f)
+	}
+}
+
 type QueryType string
 
 const (
@@ -202,11 +226,16 @@ const (
 	PanelTypeTable PanelType = "table"
 	PanelTypeList  PanelType = "list"
 	PanelTypeTrace PanelType = "trace"
+	// PanelTypeHeatmap shows the distribution of the values over time, each
+	// series of the result is a bucket identified by its upper bound label `le`.
+	// The bounds are the histogram buckets for metrics, and the HeatmapBuckets of
+	// the query or powers of two for logs and traces
+	PanelTypeHeatmap PanelType = "heatmap"
 )
 
 func (p PanelType) Validate() error {
 	switch p {
-	case PanelTypeValue, PanelTypeGraph, PanelTypeTable, PanelTypeList, PanelTypeTrace:
+	case PanelTypeValue, PanelTypeGraph, PanelTypeTable, PanelTypeList, PanelTypeTrace, PanelTypeHeatmap:
 		return nil
 	default:
 		return fmt.Errorf("invalid panel type: %s", p)
@@ -468,6 +497,9 @@ type CompositeQuery struct {
 	Unit string `json:"unit,omitempty"`
 	// FillGaps is used to fill the gaps in the time series data
 	FillGaps bool `json:"fillGaps,omitempty"`
+	// FillGapsStrategy is the strategy used to fill the gaps of the queries
+	// that don't set their own, zero if not set
+	FillGapsStrategy FillGapsStrategy `json:"fillGapsStrategy,omitempty"`
 }
 
 func (c *CompositeQuery) Clone() *CompositeQuery {
@@ -503,6 +535,7 @@ func (c *CompositeQuery) Clone() *CompositeQuery {
 		QueryType:         c.QueryType,
 		Unit:              c.Unit,
 		FillGaps:          c.FillGaps,
+		FillGapsStrategy:  c.FillGapsStrategy,
 	}
 
 }
@@ -544,6 +577,61 @@ func (c *CompositeQuery) Sanitize() {
 	}
 }
 
+// ShouldFillGaps returns true if the gaps of any of the queries have to be filled
+func (c *CompositeQuery) ShouldFillGaps() bool {
+	if c.FillGaps {
+		return true
+	}
+	for _, query := range c.BuilderQueries {
+		if query.FillGapsStrategy != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// FillGapsStrategyFor returns the strategy used to fill the gaps of the given query
+// and false if its gaps are not filled
+func (c *CompositeQuery) FillGapsStrategyFor(queryName string) (FillGapsStrategy, bool) {
+	if query, ok := c.BuilderQueries[queryName]; ok && query.FillGapsStrategy != "" {
+		return query.FillGapsStrategy, true
+	}
+	if !c.FillGaps {
+		return "", false
+	}
+	if c.FillGapsStrategy != "" {
+		return c.FillGapsStrategy, true
+	}
+	return FillGapsStrategyZero, true
+}
+
+// ReducedByQueryService returns true if the series of the query are reduced to
+// a single point by the query service instead of the ClickHouse query. The
+// series of the metrics queries of the table and value panels are always
+// reduced by the query service. The logs and traces queries of the value panel
+// are reduced by the query service when their gaps are filled, so that the
+// reduced value honors the fill gaps strategy, unless they are grouped as the
+// ClickHouse query reduces all the groups to a single value. The logs and traces
+// queries of the table panel are aggregated over the whole time range and have
+// no gaps to fill.
+func (c *CompositeQuery) ReducedByQueryService(queryName string) bool {
+	query, ok := c.BuilderQueries[queryName]
+	if !ok || query == nil {
+		return false
+	}
+	switch query.DataSource {
+	case DataSourceMetrics:
+		return c.PanelType == PanelTypeTable || c.PanelType == PanelTypeValue
+	case DataSourceLogs, DataSourceTraces:
+		if c.PanelType != PanelTypeValue || query.QueryName != query.Expression || len(query.GroupBy) > 0 {
+			return false
+		}
+		_, fillGaps := c.FillGapsStrategyFor(queryName)
+		return fillGaps
+	}
+	return false
+}
+
 func (c *CompositeQuery) Validate() error {
 	if c == nil {
 		return fmt.Errorf("composite query is required")
@@ -585,6 +673,16 @@ func (c *CompositeQuery) Validate() error {
 		return fmt.Errorf("query type is invalid: %w", err)
 	}
 
+	if c.PanelType == PanelTypeHeatmap && c.QueryType != QueryTypeBuilder {
+		return fmt.Errorf("heatmap panel type is only supported for builder queries")
+	}
+
+	if c.FillGapsStrategy != "" {
+		if err := c.FillGapsStrategy.Validate(); err != nil {
+			return fmt.Errorf("fill gaps strategy is invalid: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -755,6 +853,19 @@ const (
 	FunctionNameMedian7     FunctionName = "median7"
 	FunctionNameTimeShift   FunctionName = "timeShift"
 	FunctionNameAnomaly     FunctionName = "anomaly"
+
+	FunctionNameDerivative         FunctionName = "derivative"
+	FunctionNameRatePerSecond      FunctionName = "ratePerSecond"
+	FunctionNameIntegral           FunctionName = "integral"
+	FunctionNameFillForward        FunctionName = "fillForward"
+	FunctionNameFillZero           FunctionName = "fillZero"
+	FunctionNameFillLinear         FunctionName = "fillLinear"
+	FunctionNameMovingAverage      FunctionName = "movingAverage"
+	FunctionNameMovingSum          FunctionName = "movingSum"
+	FunctionNamePercentileOverTime FunctionName = "percentileOverTime"
+	FunctionNameZScore             FunctionName = "zScore"
+	FunctionNameHoltWinters        FunctionName = "holtWinters"
+	FunctionNameTopKByWindow       FunctionName = "topKByWindow"
 )
 
 func (f FunctionName) Validate() error {
@@ -775,7 +886,19 @@ func (f FunctionName) Validate() error {
 		FunctionNameMedian5,
 		FunctionNameMedian7,
 		FunctionNameTimeShift,
-		FunctionNameAnomaly:
+		FunctionNameAnomaly,
+		FunctionNameDerivative,
+		FunctionNameRatePerSecond,
+		FunctionNameIntegral,
+		FunctionNameFillForward,
+		FunctionNameFillZero,
+		FunctionNameFillLinear,
+		FunctionNameMovingAverage,
+		FunctionNameMovingSum,
+		FunctionNamePercentileOverTime,
+		FunctionNameZScore,
+		FunctionNameHoltWinters,
+		FunctionNameTopKByWindow:
 		return nil
 	default:
 		return fmt.Errorf("invalid function name: %s", f)
@@ -829,6 +952,9 @@ type BuilderQuery struct {
 	SpaceAggregation     SpaceAggregation     `json:"spaceAggregation,omitempty"`
 	SecondaryAggregation SecondaryAggregation `json:"seriesAggregation,omitempty"`
 	Functions            []Function           `json:"functions,omitempty"`
+	VectorMatching       *VectorMatching      `json:"vectorMatching,omitempty"`
+	FillGapsStrategy     FillGapsStrategy     `json:"fillGapsStrategy,omitempty"`
+	HeatmapBuckets       []float64            `json:"heatmapBuckets,omitempty"`
 	ShiftBy              int64
 	IsAnomaly            bool
 	QueriesUsedInFormula []string
@@ -836,6 +962,47 @@ type BuilderQuery struct {
 	MetricValueFilter    *MetricValueFilter `json:"-"`
 }
 
+// VectorMatching controls how the series of the queries in a formula are joined,
+// similar to the vector matching of PromQL. Without On or Ignoring the series
+// are joined when their label sets are equal or one is a subset of the other.
+type VectorMatching struct {
+	// LabelMapping renames the labels of the series of a query before they are joined.
+	// It maps the query name to the mapping of the label names to their new names,
+	// e.g. {"A": {"service.name": "service_name"}}
+	LabelMapping map[string]map[string]string `json:"labelMapping,omitempty"`
+	// On joins the series on the values of the given labels only
+	On []string `json:"on,omitempty"`
+	// Ignoring joins the series on the values of all the labels except the given ones
+	Ignoring []string `json:"ignoring,omitempty"`
+	// GroupLeft allows many series of the first query of the formula to be joined
+	// with one series of each of the other queries
+	GroupLeft *GroupLeft `json:"groupLeft,omitempty"`
+}
+
+// GroupLeft is the many-to-one matching of a formula
+type GroupLeft struct {
+	// Include are the labels copied from the matched series of the other queries
+	// to the series of the result
+	Include []string `json:"include,omitempty"`
+}
+
+func (v *VectorMatching) Validate() error {
+	if len(v.On) > 0 && len(v.Ignoring) > 0 {
+		return fmt.Errorf("on and ignoring can not be used together")
+	}
+	if v.GroupLeft != nil && len(v.On) == 0 && len(v.Ignoring) == 0 {
+		return fmt.Errorf("group left requires on or ignoring")
+	}
+	for queryName, mapping := range v.LabelMapping {
+		for from, to := range mapping {
+			if from == "" || to == "" {
+				return fmt.Errorf("label mapping of query %s has an empty label name", queryName)
+			}
+		}
+	}
+	return nil
+}
+
 func (b *BuilderQuery) SetShiftByFromFunc() {
 	// Remove the time shift function from the list of functions and set the shift by value
 	var timeShiftBy int64
@@ -894,6 +1061,9 @@ func (b *BuilderQuery) Clone() *BuilderQuery {
 		TimeAggregation:      b.TimeAggregation,
 		SpaceAggregation:     b.SpaceAggregation,
 		Functions:            b.Functions,
+		VectorMatching:       b.VectorMatching,
+		FillGapsStrategy:     b.FillGapsStrategy,
+		HeatmapBuckets:       b.HeatmapBuckets,
 		ShiftBy:              b.ShiftBy,
 		IsAnomaly:            b.IsAnomaly,
 		QueriesUsedInFormula: b.QueriesUsedInFormula,
@@ -929,6 +1099,44 @@ func (b *BuilderQuery) CanDefaultZero() bool {
 	return false
 }
 
+// validateHeatmap validates the query for the heatmap panel, the metrics are
+// bucketed by their histogram buckets and the logs and traces by the value of
+// the aggregate attribute
+func (b *BuilderQuery) validateHeatmap() error {
+	if b.QueryName != b.Expression {
+		return fmt.Errorf("formulas are not supported")
+	}
+	switch b.DataSource {
+	case DataSourceMetrics:
+		if b.AggregateAttribute.Type != AttributeKeyType(MetricTypeHistogram) {
+			return fmt.Errorf("metric %s is not a histogram", b.AggregateAttribute.Key)
+		}
+	case DataSourceLogs, DataSourceTraces:
+		if b.AggregateAttribute.Key == "" {
+			return fmt.Errorf("aggregate attribute is required")
+		}
+		if b.AggregateAttribute.DataType != AttributeKeyDataTypeUnspecified &&
+			b.AggregateAttribute.DataType != AttributeKeyDataTypeInt64 &&
+			b.AggregateAttribute.DataType != AttributeKeyDataTypeFloat64 {
+			return fmt.Errorf("aggregate attribute %s is not numeric", b.AggregateAttribute.Key)
+		}
+		for _, groupBy := range b.GroupBy {
+			if groupBy.Key == "le" {
+				return fmt.Errorf("group by le is not supported")
+			}
+		}
+	}
+	for idx, bound := range b.HeatmapBuckets {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) {
+			return fmt.Errorf("bucket bound %v is not finite", bound)
+		}
+		if idx > 0 && bound <= b.HeatmapBuckets[idx-1] {
+			return fmt.Errorf("bucket bounds must be increasing")
+		}
+	}
+	return nil
+}
+
 func (b *BuilderQuery) Validate(panelType PanelType) error {
 	if b == nil {
 		return nil
@@ -938,6 +1146,21 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 		return fmt.Errorf("query name is required")
 	}
 
+	if b.FillGapsStrategy != "" {
+		if err := b.FillGapsStrategy.Validate(); err != nil {
+			return fmt.Errorf("fill gaps strategy is invalid: %w", err)
+		}
+	}
+
+	if b.VectorMatching != nil {
+		if b.QueryName == b.Expression {
+			return fmt.Errorf("vector matching is only supported for formulas")
+		}
+		if err := b.VectorMatching.Validate(); err != nil {
+			return fmt.Errorf("vector matching is invalid: %w", err)
+		}
+	}
+
 	// if expression is same as query name, it's a simple builder query and not a formula
 	// formula involves more than one data source, aggregate operator, etc.
 	if b.QueryName == b.Expression {
@@ -1009,6 +1232,12 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 		}
 	}
 
+	if panelType == PanelTypeHeatmap {
+		if err := b.validateHeatmap(); err != nil {
+			return fmt.Errorf("heatmap is invalid: %w", err)
+		}
+	}
+
 	for _, selectColumn := range b.SelectColumns {
 		if err := selectColumn.Validate(); err != nil {
 			return fmt.Errorf("select column is invalid %w", err)
@@ -1071,6 +1300,48 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 					}
 					function.Args[0] = threshold
 				}
+			} else if function.Name == FunctionNameMovingAverage ||
+				function.Name == FunctionNameMovingSum {
+				args, err := numericFunctionArgs(function, "window")
+				if err != nil {
+					return err
+				}
+				if !isPositiveInteger(args[0]) {
+					return fmt.Errorf("window param should be a positive integer")
+				}
+			} else if function.Name == FunctionNamePercentileOverTime {
+				args, err := numericFunctionArgs(function, "percentile", "window")
+				if err != nil {
+					return err
+				}
+				if args[0] < 0 || args[0] > 100 {
+					return fmt.Errorf("percentile param should be between 0 and 100")
+				}
+				if !isPositiveInteger(args[1]) {
+					return fmt.Errorf("window param should be a positive integer")
+				}
+			} else if function.Name == FunctionNameHoltWinters {
+				args, err := numericFunctionArgs(function, "smoothingFactor", "trendFactor")
+				if err != nil {
+					return err
+				}
+				if args[0] <= 0 || args[0] >= 1 {
+					return fmt.Errorf("smoothingFactor param should be between 0 and 1")
+				}
+				if args[1] <= 0 || args[1] >= 1 {
+					return fmt.Errorf("trendFactor param should be between 0 and 1")
+				}
+			} else if function.Name == FunctionNameTopKByWindow {
+				args, err := numericFunctionArgs(function, "k", "window")
+				if err != nil {
+					return err
+				}
+				if !isPositiveInteger(args[0]) {
+					return fmt.Errorf("k param should be a positive integer")
+				}
+				if !isPositiveInteger(args[1]) {
+					return fmt.Errorf("window param should be a positive integer")
+				}
 			}
 		}
 	}
@@ -1078,6 +1349,35 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 	return nil
 }
 
+// numericFunctionArgs converts the leading args of the function to floats in place,
+// the args can be sent as numbers or as numeric strings
+func numericFunctionArgs(function Function, names ...string) ([]float64, error) {
+	values := make([]float64, len(names))
+	for idx, name := range names {
+		if len(function.Args) <= idx {
+			return nil, fmt.Errorf("%s param missing in query", name)
+		}
+		switch arg := function.Args[idx].(type) {
+		case float64:
+			values[idx] = arg
+		case string:
+			value, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s param should be a number", name)
+			}
+			values[idx] = value
+		default:
+			return nil, fmt.Errorf("%s param should be a number", name)
+		}
+		function.Args[idx] = values[idx]
+	}
+	return values, nil
+}
+
+func isPositiveInteger(value float64) bool {
+	return value >= 1 && value == math.Trunc(value)
+}
+
 type FilterSet struct {
 	Operator string       `json:"op,omitempty"`
 	Items    []FilterItem `json:"items"`
@@ -1231,6 +1531,24 @@ type QueryRangeResponse struct {
 	Result                []*Result `json:"result"`
 }
 
+type QueryRangeStreamMessageType string
+
+const (
+	QueryRangeStreamMessageTypeSeries QueryRangeStreamMessageType = "series"
+	QueryRangeStreamMessageTypeError  QueryRangeStreamMessageType = "error"
+	QueryRangeStreamMessageTypeEnd    QueryRangeStreamMessageType = "end"
+)
+
+// QueryRangeStreamMessage is a line of the streamed query range response.
+// The series are sent one per message, followed by an end message once
+// all the series are sent or an error message if the stream failed.
+type QueryRangeStreamMessage struct {
+	Type      QueryRangeStreamMessageType `json:"type"`
+	QueryName string                      `json:"queryName,omitempty"`
+	Series    *Series                     `json:"series,omitempty"`
+	Error     string                      `json:"error,omitempty"`
+}
+
 type TableColumn struct {
 	Name string `json:"name"`
 	// QueryName is the name of the query that this column belongs to
@@ -1259,6 +1577,20 @@ type Result struct {
 	AnomalyScores    []*Series `json:"anomalyScores,omitempty"`
 	List             []*Row    `json:"list,omitempty"`
 	Table            *Table    `json:"table,omitempty"`
+	// CostEstimate is the estimated cost of the query, only set when the query limits are enabled
+	CostEstimate *QueryCostEstimate `json:"costEstimate,omitempty"`
+}
+
+// QueryCostEstimate is the cost of a query estimated before running it
+type QueryCostEstimate struct {
+	// Rows is the estimated number of rows read by the query
+	Rows uint64 `json:"rows"`
+	// Bytes is the estimated number of uncompressed bytes read by the query
+	Bytes uint64 `json:"bytes"`
+	// Downgraded is true if the time range of the query was narrowed to fit the limits
+	Downgraded bool `json:"downgraded,omitempty"`
+	// Start is the start of the narrowed time range in milliseconds, only set when the query was downgraded
+	Start int64 `json:"start,omitempty"`
 }
 
 type Series struct {
@@ -70,34 +70,47 @@ func joinAndCalculate(
 	canDefaultZero map[string]bool,
 ) (*v3.Series, error) {
 
-	uniqueTimestamps := make(map[int64]struct{})
-	// map[queryName]map[timestamp]value
-	seriesMap := make(map[string]map[int64]float64)
+	// map[queryName]series
+	matchingSeries := make(map[string]*v3.Series)
 	for _, result := range results {
-		var matchingSeries *v3.Series
 		// We try to find a series that matches the label set from the current query result
 		for _, series := range result.Series {
 			if isSubset(uniqueLabelSet, series.Labels) {
-				matchingSeries = series
+				matchingSeries[result.QueryName] = series
 				break
 			}
 		}
+	}
 
+	return calculate(matchingSeries, uniqueLabelSet, expression, canDefaultZero)
+}
+
+// calculate joins the matching series of the queries on timestamp and evaluates
+// the expression for each timestamp, the resulting series has the given labels
+func calculate(
+	matchingSeries map[string]*v3.Series,
+	labels map[string]string,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+) (*v3.Series, error) {
+
+	uniqueTimestamps := make(map[int64]struct{})
+	// map[queryName]map[timestamp]value
+	seriesMap := make(map[string]map[int64]float64)
+	for queryName, series := range matchingSeries {
 		// Prepare the seriesMap for quick lookup during evaluation
 		// seriesMap[queryName][timestamp]value contains the value of the series with the given queryName at the given timestamp
-		if matchingSeries != nil {
-			for _, point := range matchingSeries.Points {
-				if _, ok := seriesMap[result.QueryName]; !ok {
-					seriesMap[result.QueryName] = make(map[int64]float64)
-				}
-				seriesMap[result.QueryName][point.Timestamp] = point.Value
-				uniqueTimestamps[point.Timestamp] = struct{}{}
+		for _, point := range series.Points {
+			if _, ok := seriesMap[queryName]; !ok {
+				seriesMap[queryName] = make(map[int64]float64)
 			}
+			seriesMap[queryName][point.Timestamp] = point.Value
+			uniqueTimestamps[point.Timestamp] = struct{}{}
 		}
 	}
 
 	resultSeries := &v3.Series{
-		Labels: uniqueLabelSet,
+		Labels: labels,
 		Points: make([]v3.Point, 0),
 	}
 	timestamps := make([]int64, 0)
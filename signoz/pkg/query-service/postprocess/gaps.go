@@ -1,6 +1,8 @@
 package postprocess
 
 import (
+	"math"
+
 	"github.com/SigNoz/govaluate"
 	"go.signoz.io/signoz/pkg/query-service/common"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
@@ -19,39 +21,121 @@ func StepIntervalForFunction(params *v3.QueryRangeParamsV3, query string) int64
 	return q.StepInterval
 }
 
-func fillGap(series *v3.Series, start, end, step int64) *v3.Series {
+// fillGap returns a copy of the series with a point at every step from start to end,
+// the missing points are filled according to the strategy
+func fillGap(series *v3.Series, start, end, step int64, strategy v3.FillGapsStrategy) *v3.Series {
 	v := make(map[int64]float64)
 	for _, point := range series.Points {
 		v[point.Timestamp] = point.Value
 	}
 
-	// For all the values from start to end, find the timestamps
-	// that don't have value and add zero point
 	start = start - (start % (step * 1000))
-	for i := start; i <= end; i += step * 1000 {
-		if _, ok := v[i]; !ok {
-			v[i] = 0
-		}
-	}
 	newSeries := &v3.Series{
 		Labels:      series.Labels,
 		LabelsArray: series.LabelsArray,
 		Points:      make([]v3.Point, 0),
 	}
+	// missing holds the indexes of the points that are not in the series
+	var missing []int
 	for i := start; i <= end; i += step * 1000 {
-		newSeries.Points = append(newSeries.Points, v3.Point{Timestamp: i, Value: v[i]})
+		value, ok := v[i]
+		if !ok {
+			missing = append(missing, len(newSeries.Points))
+		}
+		newSeries.Points = append(newSeries.Points, v3.Point{Timestamp: i, Value: value})
+	}
+
+	switch strategy {
+	case v3.FillGapsStrategyNull:
+		for _, idx := range missing {
+			newSeries.Points[idx].Value = math.NaN()
+		}
+	case v3.FillGapsStrategyPrevious:
+		fillPrevious(newSeries.Points, missing)
+	case v3.FillGapsStrategyLinear:
+		fillLinear(newSeries.Points, missing)
 	}
 	return newSeries
 }
 
+// fillPrevious fills the missing points with the value of the last point before them,
+// the missing points before the first known point are NaN
+func fillPrevious(points []v3.Point, missing []int) {
+	isMissing := make(map[int]bool, len(missing))
+	for _, idx := range missing {
+		isMissing[idx] = true
+	}
+	previous := math.NaN()
+	for idx := range points {
+		if isMissing[idx] {
+			points[idx].Value = previous
+		} else {
+			previous = points[idx].Value
+		}
+	}
+}
+
+// fillLinear interpolates the missing points between the known points around them,
+// the missing points before the first and after the last known point are NaN
+func fillLinear(points []v3.Point, missing []int) {
+	isMissing := make(map[int]bool, len(missing))
+	for _, idx := range missing {
+		isMissing[idx] = true
+	}
+	previous := -1
+	for idx := range points {
+		if isMissing[idx] {
+			continue
+		}
+		for gap := previous + 1; gap < idx; gap++ {
+			if previous == -1 {
+				points[gap].Value = math.NaN()
+				continue
+			}
+			from, to := points[previous], points[idx]
+			ratio := float64(points[gap].Timestamp-from.Timestamp) / float64(to.Timestamp-from.Timestamp)
+			points[gap].Value = from.Value + (to.Value-from.Value)*ratio
+		}
+		previous = idx
+	}
+	for gap := previous + 1; gap < len(points); gap++ {
+		points[gap].Value = math.NaN()
+	}
+}
+
 // TODO(srikanthccv): can WITH FILL be perfect substitute for all cases https://clickhouse.com/docs/en/sql-reference/statements/select/order-by#order-by-expr-with-fill-modifier
 func FillGaps(results []*v3.Result, params *v3.QueryRangeParamsV3) {
-	if params.CompositeQuery.PanelType != v3.PanelTypeGraph {
+	if params.CompositeQuery.PanelType != v3.PanelTypeGraph && params.CompositeQuery.PanelType != v3.PanelTypeHeatmap {
 		return
 	}
+	fillGaps(results, params)
+}
+
+// fillGapsBeforeReduce fills the gaps of the results of the table and value
+// panels that are reduced to a single point, so the reduced value honors the
+// fill gaps strategy
+func fillGapsBeforeReduce(results []*v3.Result, params *v3.QueryRangeParamsV3) {
+	var reduced []*v3.Result
 	for _, result := range results {
+		if params.CompositeQuery.ReducedByQueryService(result.QueryName) {
+			reduced = append(reduced, result)
+		}
+	}
+	fillGaps(reduced, params)
+}
+
+func fillGaps(results []*v3.Result, params *v3.QueryRangeParamsV3) {
+	for _, result := range results {
+		strategy, ok := params.CompositeQuery.FillGapsStrategyFor(result.QueryName)
+		if !ok {
+			// when only some of the queries set a strategy, the others are left as is
+			if params.CompositeQuery.ShouldFillGaps() {
+				continue
+			}
+			strategy = v3.FillGapsStrategyZero
+		}
 		// A `result` item in `results` contains the query result for individual query.
-		// If there are no series in the result, we add empty series and `fillGap` adds all zeros
+		// If there are no series in the result, we add empty series and `fillGap` fills all the points
 		if len(result.Series) == 0 {
 			result.Series = []*v3.Series{
 				{
@@ -66,7 +150,7 @@ func FillGaps(results []*v3.Result, params *v3.QueryRangeParamsV3) {
 			// The values should be added at the intervals of `step`
 			step := StepIntervalForFunction(params, result.QueryName)
 			for idx := range result.Series {
-				result.Series[idx] = fillGap(result.Series[idx], params.Start, params.End, step)
+				result.Series[idx] = fillGap(result.Series[idx], params.Start, params.End, step, strategy)
 			}
 		}
 	}
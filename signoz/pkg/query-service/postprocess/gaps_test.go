@@ -1,6 +1,7 @@
 package postprocess
 
 import (
+	"math"
 	"testing"
 
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
@@ -200,3 +201,239 @@ func TestFillGaps(t *testing.T) {
 		})
 	}
 }
+
+func TestFillGapsStrategies(t *testing.T) {
+	nan := math.NaN()
+	newResults := func() []*v3.Result {
+		return []*v3.Result{
+			{
+				QueryName: "A",
+				Series: []*v3.Series{
+					{
+						Points: []v3.Point{
+							{Timestamp: 2000, Value: 2.0},
+							{Timestamp: 5000, Value: 8.0},
+						},
+					},
+				},
+			},
+		}
+	}
+	newParams := func(fillGaps bool, composite, query v3.FillGapsStrategy) *v3.QueryRangeParamsV3 {
+		return &v3.QueryRangeParamsV3{
+			Start: 1000,
+			End:   6000,
+			CompositeQuery: &v3.CompositeQuery{
+				PanelType:        v3.PanelTypeGraph,
+				FillGaps:         fillGaps,
+				FillGapsStrategy: composite,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:        "A",
+						Expression:       "A",
+						StepInterval:     1,
+						FillGapsStrategy: query,
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		params   *v3.QueryRangeParamsV3
+		expected []float64
+	}{
+		{
+			name:     "zero by default",
+			params:   newParams(true, "", ""),
+			expected: []float64{0, 2, 0, 0, 8, 0},
+		},
+		{
+			name:     "previous value",
+			params:   newParams(true, v3.FillGapsStrategyPrevious, ""),
+			expected: []float64{nan, 2, 2, 2, 8, 8},
+		},
+		{
+			name:     "linear interpolation",
+			params:   newParams(true, v3.FillGapsStrategyLinear, ""),
+			expected: []float64{nan, 2, 4, 6, 8, nan},
+		},
+		{
+			name:     "null",
+			params:   newParams(true, v3.FillGapsStrategyNull, ""),
+			expected: []float64{nan, 2, nan, nan, 8, nan},
+		},
+		{
+			name:     "query strategy overrides the composite strategy",
+			params:   newParams(true, v3.FillGapsStrategyNull, v3.FillGapsStrategyPrevious),
+			expected: []float64{nan, 2, 2, 2, 8, 8},
+		},
+		{
+			name:     "query strategy without fill gaps",
+			params:   newParams(false, "", v3.FillGapsStrategyLinear),
+			expected: []float64{nan, 2, 4, 6, 8, nan},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := newResults()
+			FillGaps(results, tt.params)
+			points := results[0].Series[0].Points
+			if len(points) != len(tt.expected) {
+				t.Fatalf("expected %d points, got %d", len(tt.expected), len(points))
+			}
+			for idx, point := range points {
+				if point.Timestamp != int64(idx+1)*1000 {
+					t.Errorf("expected timestamp %d, got %d", (idx+1)*1000, point.Timestamp)
+				}
+				if math.IsNaN(tt.expected[idx]) != math.IsNaN(point.Value) ||
+					(!math.IsNaN(point.Value) && point.Value != tt.expected[idx]) {
+					t.Errorf("expected value %v at %d, got %v", tt.expected[idx], point.Timestamp, point.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestFillGapsOnlyQueriesWithStrategy(t *testing.T) {
+	results := []*v3.Result{
+		{QueryName: "A", Series: []*v3.Series{{Points: []v3.Point{{Timestamp: 1000, Value: 1.0}}}}},
+		{QueryName: "B", Series: []*v3.Series{{Points: []v3.Point{{Timestamp: 1000, Value: 1.0}}}}},
+	}
+	params := &v3.QueryRangeParamsV3{
+		Start: 1000,
+		End:   3000,
+		CompositeQuery: &v3.CompositeQuery{
+			PanelType: v3.PanelTypeGraph,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {QueryName: "A", Expression: "A", StepInterval: 1, FillGapsStrategy: v3.FillGapsStrategyPrevious},
+				"B": {QueryName: "B", Expression: "B", StepInterval: 1},
+			},
+		},
+	}
+
+	FillGaps(results, params)
+
+	if len(results[0].Series[0].Points) != 3 {
+		t.Errorf("expected the gaps of A to be filled, got %d points", len(results[0].Series[0].Points))
+	}
+	if len(results[1].Series[0].Points) != 1 {
+		t.Errorf("expected the gaps of B not to be filled, got %d points", len(results[1].Series[0].Points))
+	}
+}
+
+func TestPostProcessResultFillsValuePanelBeforeReduce(t *testing.T) {
+	results := []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{
+					Points: []v3.Point{
+						{Timestamp: 1000, Value: 10.0},
+						{Timestamp: 4000, Value: 10.0},
+					},
+				},
+			},
+		},
+	}
+	params := &v3.QueryRangeParamsV3{
+		Start: 1000,
+		End:   4000,
+		CompositeQuery: &v3.CompositeQuery{
+			PanelType:        v3.PanelTypeValue,
+			QueryType:        v3.QueryTypeBuilder,
+			FillGaps:         true,
+			FillGapsStrategy: v3.FillGapsStrategyPrevious,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:    "A",
+					Expression:   "A",
+					DataSource:   v3.DataSourceMetrics,
+					StepInterval: 1,
+					ReduceTo:     v3.ReduceToOperatorAvg,
+				},
+			},
+		},
+	}
+
+	results, err := PostProcessResult(results, params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// with zeros the average would be 5
+	if len(results[0].Series[0].Points) != 1 || results[0].Series[0].Points[0].Value != 10.0 {
+		t.Errorf("expected a single point with value 10, got %v", results[0].Series[0].Points)
+	}
+}
+
+func TestPostProcessResultFillsGapsBeforeReduce(t *testing.T) {
+	cases := []struct {
+		name       string
+		panelType  v3.PanelType
+		dataSource v3.DataSource
+		groupBy    []v3.AttributeKey
+		expected   float64
+	}{
+		{name: "metrics table", panelType: v3.PanelTypeTable, dataSource: v3.DataSourceMetrics, expected: 10.0},
+		{name: "logs value", panelType: v3.PanelTypeValue, dataSource: v3.DataSourceLogs, expected: 10.0},
+		{name: "traces value", panelType: v3.PanelTypeValue, dataSource: v3.DataSourceTraces, expected: 10.0},
+		// the grouped logs are reduced by the ClickHouse query
+		{name: "grouped logs value", panelType: v3.PanelTypeValue, dataSource: v3.DataSourceLogs, groupBy: []v3.AttributeKey{{Key: "service"}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			results := []*v3.Result{
+				{
+					QueryName: "A",
+					Series: []*v3.Series{
+						{
+							Points: []v3.Point{
+								{Timestamp: 1000, Value: 10.0},
+								{Timestamp: 4000, Value: 10.0},
+							},
+						},
+					},
+				},
+			}
+			params := &v3.QueryRangeParamsV3{
+				Start: 1000,
+				End:   4000,
+				CompositeQuery: &v3.CompositeQuery{
+					PanelType:        c.panelType,
+					QueryType:        v3.QueryTypeBuilder,
+					FillGaps:         true,
+					FillGapsStrategy: v3.FillGapsStrategyPrevious,
+					BuilderQueries: map[string]*v3.BuilderQuery{
+						"A": {
+							QueryName:    "A",
+							Expression:   "A",
+							DataSource:   c.dataSource,
+							StepInterval: 1,
+							GroupBy:      c.groupBy,
+							ReduceTo:     v3.ReduceToOperatorAvg,
+						},
+					},
+				},
+			}
+
+			results, err := PostProcessResult(results, params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			points := results[0].Series[0].Points
+			if c.groupBy != nil {
+				// neither filled nor reduced
+				if len(points) != 2 {
+					t.Errorf("expected the points to be left as is, got %v", points)
+				}
+				return
+			}
+			if len(points) != 1 || points[0].Value != c.expected {
+				t.Errorf("expected a single point with value %v, got %v", c.expected, points)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package postprocess
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.uber.org/zap"
+)
+
+// heatmapBucket is a series of a heatmap with the upper bound of its bucket
+type heatmapBucket struct {
+	bound  float64
+	series *v3.Series
+}
+
+// ApplyHeatmap turns the series of the heatmap panel into a series per bucket for
+// each combination of the group by labels, ordered by the upper bound of the buckets.
+// The histogram buckets of the metrics are cumulative, so they are de-cumulated to
+// get the number of observations in each bucket. The buckets of the query that
+// have no observations are added with zero points.
+func ApplyHeatmap(results []*v3.Result, params *v3.QueryRangeParamsV3) {
+	if params.CompositeQuery.PanelType != v3.PanelTypeHeatmap {
+		return
+	}
+	for _, result := range results {
+		query, ok := params.CompositeQuery.BuilderQueries[result.QueryName]
+		if !ok {
+			continue
+		}
+		result.Series = heatmapSeries(result.Series, query.HeatmapBuckets, query.DataSource == v3.DataSourceMetrics)
+	}
+}
+
+func heatmapSeries(series []*v3.Series, bounds []float64, cumulative bool) []*v3.Series {
+	ignoreBucket := &v3.VectorMatching{Ignoring: []string{utils.HeatmapBucketLabel}}
+	groups := make(map[string][]heatmapBucket)
+	var keys []string
+	for _, s := range series {
+		bound, err := strconv.ParseFloat(s.Labels[utils.HeatmapBucketLabel], 64)
+		if err != nil {
+			zap.L().Warn("skipping heatmap series with invalid bucket", zap.String("le", s.Labels[utils.HeatmapBucketLabel]))
+			continue
+		}
+		key := matchingKey(s.Labels, ignoreBucket)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], heatmapBucket{bound: bound, series: s})
+	}
+	sort.Strings(keys)
+
+	newSeries := make([]*v3.Series, 0, len(series))
+	for _, key := range keys {
+		buckets := groups[key]
+		sort.Slice(buckets, func(i, j int) bool {
+			return buckets[i].bound < buckets[j].bound
+		})
+		if cumulative {
+			decumulate(buckets)
+		}
+		buckets = addMissingBuckets(buckets, bounds)
+		for _, bucket := range buckets {
+			newSeries = append(newSeries, bucket.series)
+		}
+	}
+	return newSeries
+}
+
+// decumulate subtracts the count of the previous bucket at each timestamp from the
+// count of the bucket, the buckets are sorted by their upper bound
+func decumulate(buckets []heatmapBucket) {
+	previous := make(map[int64]float64)
+	for _, bucket := range buckets {
+		current := make(map[int64]float64, len(bucket.series.Points))
+		for idx, point := range bucket.series.Points {
+			current[point.Timestamp] = point.Value
+			// the counts of the higher buckets can be lower because of the counter resets
+			bucket.series.Points[idx].Value = math.Max(point.Value-previous[point.Timestamp], 0)
+		}
+		previous = current
+	}
+}
+
+// addMissingBuckets adds the bounds and the +Inf bucket that don't have a series
+// with zero points at the timestamps of the other buckets
+func addMissingBuckets(buckets []heatmapBucket, bounds []float64) []heatmapBucket {
+	if len(bounds) == 0 || len(buckets) == 0 {
+		return buckets
+	}
+	found := make(map[float64]bool, len(buckets))
+	timestamps := make(map[int64]bool)
+	for _, bucket := range buckets {
+		found[bucket.bound] = true
+		for _, point := range bucket.series.Points {
+			timestamps[point.Timestamp] = true
+		}
+	}
+	points := make([]v3.Point, 0, len(timestamps))
+	for ts := range timestamps {
+		points = append(points, v3.Point{Timestamp: ts})
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp < points[j].Timestamp
+	})
+
+	missing := make([]float64, 0, len(bounds)+1)
+	missing = append(missing, bounds...)
+	missing = append(missing, math.Inf(1))
+	for _, bound := range missing {
+		if found[bound] {
+			continue
+		}
+		le := "+Inf"
+		if !math.IsInf(bound, 1) {
+			le = utils.FormatHeatmapBucket(bound)
+		}
+		series := &v3.Series{
+			Labels:      make(map[string]string, len(buckets[0].series.Labels)),
+			LabelsArray: make([]map[string]string, 0, len(buckets[0].series.Labels)),
+			Points:      append([]v3.Point(nil), points...),
+		}
+		for k, v := range buckets[0].series.Labels {
+			if k == utils.HeatmapBucketLabel {
+				v = le
+			}
+			series.Labels[k] = v
+			series.LabelsArray = append(series.LabelsArray, map[string]string{k: v})
+		}
+		buckets = append(buckets, heatmapBucket{bound: bound, series: series})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].bound < buckets[j].bound
+	})
+	return buckets
+}
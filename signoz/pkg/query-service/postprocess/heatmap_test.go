@@ -0,0 +1,94 @@
+package postprocess
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestApplyHeatmap(t *testing.T) {
+	bucket := func(service, le string, values ...float64) *v3.Series {
+		points := make([]v3.Point, 0, len(values))
+		for idx, value := range values {
+			points = append(points, v3.Point{Timestamp: int64(idx+1) * 60000, Value: value})
+		}
+		return &v3.Series{Labels: map[string]string{"service_name": service, "le": le}, Points: points}
+	}
+	values := func(series *v3.Series) []float64 {
+		var values []float64
+		for _, point := range series.Points {
+			values = append(values, point.Value)
+		}
+		return values
+	}
+
+	t.Run("metrics buckets are de-cumulated and completed", func(t *testing.T) {
+		results := []*v3.Result{
+			{
+				QueryName: "A",
+				Series: []*v3.Series{
+					bucket("redis", "+Inf", 4, 4),
+					bucket("frontend", "+Inf", 10, 12),
+					bucket("frontend", "0.1", 6, 2),
+					bucket("frontend", "0.05", 3, 2),
+				},
+			},
+		}
+		params := &v3.QueryRangeParamsV3{
+			CompositeQuery: &v3.CompositeQuery{
+				PanelType: v3.PanelTypeHeatmap,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {QueryName: "A", DataSource: v3.DataSourceMetrics, HeatmapBuckets: []float64{0.05, 0.1}},
+				},
+			},
+		}
+
+		ApplyHeatmap(results, params)
+
+		series := results[0].Series
+		require.Len(t, series, 6)
+		var les []string
+		for _, s := range series {
+			les = append(les, s.Labels["service_name"]+"/"+s.Labels["le"])
+		}
+		assert.Equal(t, []string{"frontend/0.05", "frontend/0.1", "frontend/+Inf", "redis/0.05", "redis/0.1", "redis/+Inf"}, les)
+		assert.Equal(t, []float64{3, 2}, values(series[0]))
+		// the count of the bucket can't be negative
+		assert.Equal(t, []float64{3, 0}, values(series[1]))
+		assert.Equal(t, []float64{4, 10}, values(series[2]))
+		assert.Equal(t, []float64{0, 0}, values(series[3]))
+		assert.Equal(t, []float64{4, 4}, values(series[5]))
+		assert.Len(t, series[3].LabelsArray, 2)
+	})
+
+	t.Run("logs buckets are counts", func(t *testing.T) {
+		results := []*v3.Result{
+			{
+				QueryName: "A",
+				Series: []*v3.Series{
+					bucket("frontend", "1024", 1),
+					bucket("frontend", "8", 5),
+				},
+			},
+		}
+		params := &v3.QueryRangeParamsV3{
+			CompositeQuery: &v3.CompositeQuery{
+				PanelType: v3.PanelTypeHeatmap,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {QueryName: "A", DataSource: v3.DataSourceLogs},
+				},
+			},
+		}
+
+		ApplyHeatmap(results, params)
+
+		series := results[0].Series
+		require.Len(t, series, 2)
+		assert.Equal(t, "8", series[0].Labels["le"])
+		assert.Equal(t, []float64{5}, values(series[0]))
+		assert.Equal(t, "1024", series[1].Labels["le"])
+		assert.Equal(t, []float64{1}, values(series[1]))
+	})
+}
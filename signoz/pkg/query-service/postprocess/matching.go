@@ -0,0 +1,245 @@
+package postprocess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SigNoz/govaluate"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// processMatchedResults evaluates the formula joining the series of the queries
+// as specified by the vector matching. The label mapping is applied first, so
+// that the series of queries from different data sources, such as the
+// `service.name` of traces and the `service_name` of metrics, can be joined.
+func processMatchedResults(
+	results []*v3.Result,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+	matching *v3.VectorMatching,
+) (*v3.Result, error) {
+	if matching == nil {
+		return processResults(results, expression, canDefaultZero)
+	}
+
+	results = applyLabelMapping(results, matching.LabelMapping)
+	if len(matching.On) == 0 && len(matching.Ignoring) == 0 {
+		return processResults(results, expression, canDefaultZero)
+	}
+
+	// the queries of the expression in the order they appear, the first one is the left hand side
+	queryNames := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, v := range expression.Vars() {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			queryNames = append(queryNames, v)
+		}
+	}
+
+	// map[queryName]map[matchingKey]series
+	groups := make(map[string]map[string][]*v3.Series)
+	for _, result := range results {
+		if _, ok := seen[result.QueryName]; !ok {
+			continue
+		}
+		groups[result.QueryName] = make(map[string][]*v3.Series)
+		for _, series := range result.Series {
+			key := matchingKey(series.Labels, matching)
+			groups[result.QueryName][key] = append(groups[result.QueryName][key], series)
+		}
+	}
+
+	var newSeries []*v3.Series
+	var err error
+	if matching.GroupLeft != nil {
+		newSeries, err = matchManyToOne(queryNames, groups, expression, canDefaultZero, matching)
+	} else {
+		newSeries, err = matchOneToOne(queryNames, groups, expression, canDefaultZero, matching)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &v3.Result{
+		Series: newSeries,
+	}, nil
+}
+
+// matchOneToOne joins the series of all the queries that have the same matching key,
+// every query can have at most one series for a matching key
+func matchOneToOne(
+	queryNames []string,
+	groups map[string]map[string][]*v3.Series,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+	matching *v3.VectorMatching,
+) ([]*v3.Series, error) {
+	keys := make([]string, 0)
+	seenKeys := make(map[string]struct{})
+	for _, queryName := range queryNames {
+		for key := range groups[queryName] {
+			if _, ok := seenKeys[key]; !ok {
+				seenKeys[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	newSeries := make([]*v3.Series, 0)
+	for _, key := range keys {
+		matchingSeries := make(map[string]*v3.Series)
+		var labels map[string]string
+		for _, queryName := range queryNames {
+			group := groups[queryName][key]
+			if len(group) > 1 {
+				return nil, fmt.Errorf("found duplicate series for the match group {%s} of query %s, many-to-one matching must be explicit with group left", key, queryName)
+			}
+			if len(group) == 1 {
+				matchingSeries[queryName] = group[0]
+				if labels == nil {
+					labels = matchingLabels(group[0].Labels, matching)
+				}
+			}
+		}
+
+		series, err := calculate(matchingSeries, labels, expression, canDefaultZero)
+		if err != nil {
+			return nil, err
+		}
+		newSeries = appendSeries(newSeries, series)
+	}
+	return newSeries, nil
+}
+
+// matchManyToOne joins every series of the first query with the series of the
+// other queries that have the same matching key, the other queries can have at
+// most one series for a matching key
+func matchManyToOne(
+	queryNames []string,
+	groups map[string]map[string][]*v3.Series,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+	matching *v3.VectorMatching,
+) ([]*v3.Series, error) {
+	if len(queryNames) == 0 {
+		return []*v3.Series{}, nil
+	}
+	left := queryNames[0]
+
+	keys := make([]string, 0, len(groups[left]))
+	for key := range groups[left] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	newSeries := make([]*v3.Series, 0)
+	for _, key := range keys {
+		for _, leftSeries := range groups[left][key] {
+			matchingSeries := map[string]*v3.Series{left: leftSeries}
+			labels := make(map[string]string, len(leftSeries.Labels))
+			for k, v := range leftSeries.Labels {
+				labels[k] = v
+			}
+
+			for _, queryName := range queryNames[1:] {
+				group := groups[queryName][key]
+				if len(group) > 1 {
+					return nil, fmt.Errorf("found duplicate series for the match group {%s} of query %s, the queries other than %s must have at most one series for every match group", key, queryName, left)
+				}
+				if len(group) == 0 {
+					continue
+				}
+				matchingSeries[queryName] = group[0]
+				for _, label := range matching.GroupLeft.Include {
+					if v, ok := group[0].Labels[label]; ok {
+						labels[label] = v
+					}
+				}
+			}
+
+			series, err := calculate(matchingSeries, labels, expression, canDefaultZero)
+			if err != nil {
+				return nil, err
+			}
+			newSeries = appendSeries(newSeries, series)
+		}
+	}
+	return newSeries, nil
+}
+
+func appendSeries(newSeries []*v3.Series, series *v3.Series) []*v3.Series {
+	if series == nil || len(series.Points) == 0 {
+		return newSeries
+	}
+	labelsArray := make([]map[string]string, 0)
+	for k, v := range series.Labels {
+		labelsArray = append(labelsArray, map[string]string{k: v})
+	}
+	series.LabelsArray = labelsArray
+	return append(newSeries, series)
+}
+
+// applyLabelMapping returns the results with the labels of the series renamed as per the mapping of their query
+func applyLabelMapping(results []*v3.Result, labelMapping map[string]map[string]string) []*v3.Result {
+	if len(labelMapping) == 0 {
+		return results
+	}
+	mapped := make([]*v3.Result, 0, len(results))
+	for _, result := range results {
+		mapping, ok := labelMapping[result.QueryName]
+		if !ok {
+			mapped = append(mapped, result)
+			continue
+		}
+		series := make([]*v3.Series, 0, len(result.Series))
+		for _, s := range result.Series {
+			labels := make(map[string]string, len(s.Labels))
+			for k, v := range s.Labels {
+				if to, ok := mapping[k]; ok {
+					k = to
+				}
+				labels[k] = v
+			}
+			series = append(series, &v3.Series{Labels: labels, Points: s.Points})
+		}
+		mapped = append(mapped, &v3.Result{QueryName: result.QueryName, Series: series})
+	}
+	return mapped
+}
+
+// matchingLabels returns the labels the series are matched on
+func matchingLabels(labels map[string]string, matching *v3.VectorMatching) map[string]string {
+	matched := make(map[string]string)
+	if len(matching.On) > 0 {
+		for _, label := range matching.On {
+			if v, ok := labels[label]; ok {
+				matched[label] = v
+			}
+		}
+		return matched
+	}
+	ignoring := make(map[string]struct{}, len(matching.Ignoring))
+	for _, label := range matching.Ignoring {
+		ignoring[label] = struct{}{}
+	}
+	for k, v := range labels {
+		if _, ok := ignoring[k]; !ok {
+			matched[k] = v
+		}
+	}
+	return matched
+}
+
+// matchingKey returns the signature of the matching labels of the series
+func matchingKey(labels map[string]string, matching *v3.VectorMatching) string {
+	matched := matchingLabels(labels, matching)
+	pairs := make([]string, 0, len(matched))
+	for k, v := range matched {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,169 @@
+package postprocess
+
+import (
+	"testing"
+
+	"github.com/SigNoz/govaluate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestProcessMatchedResults(t *testing.T) {
+	// A is the count of error spans from traces, B the request count from metrics
+	results := []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service.name": "frontend"}, Points: []v3.Point{{Timestamp: 1, Value: 5}}},
+				{Labels: map[string]string{"service.name": "redis"}, Points: []v3.Point{{Timestamp: 1, Value: 1}}},
+			},
+		},
+		{
+			QueryName: "B",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service_name": "frontend", "env": "prod"}, Points: []v3.Point{{Timestamp: 1, Value: 100}}},
+				{Labels: map[string]string{"service_name": "redis", "env": "prod"}, Points: []v3.Point{{Timestamp: 1, Value: 10}}},
+			},
+		},
+	}
+	// C is the request count by operation, D the error budget by service
+	manyToOneResults := []*v3.Result{
+		{
+			QueryName: "C",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service_name": "frontend", "operation": "GET /"}, Points: []v3.Point{{Timestamp: 1, Value: 10}}},
+				{Labels: map[string]string{"service_name": "frontend", "operation": "POST /cart"}, Points: []v3.Point{{Timestamp: 1, Value: 30}}},
+				{Labels: map[string]string{"service_name": "redis", "operation": "GET"}, Points: []v3.Point{{Timestamp: 1, Value: 4}}},
+			},
+		},
+		{
+			QueryName: "D",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service_name": "frontend", "team": "web"}, Points: []v3.Point{{Timestamp: 1, Value: 2}}},
+				{Labels: map[string]string{"service_name": "redis", "team": "storage"}, Points: []v3.Point{{Timestamp: 1, Value: 4}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		results    []*v3.Result
+		expression string
+		matching   *v3.VectorMatching
+		want       []*v3.Series
+		wantErr    string
+	}{
+		{
+			name:       "label mapping with subset matching",
+			results:    results,
+			expression: "A/B",
+			matching: &v3.VectorMatching{
+				LabelMapping: map[string]map[string]string{"A": {"service.name": "service_name"}},
+			},
+			want: []*v3.Series{
+				{Labels: map[string]string{"service_name": "frontend", "env": "prod"}, Points: []v3.Point{{Timestamp: 1, Value: 0.05}}},
+				{Labels: map[string]string{"service_name": "redis", "env": "prod"}, Points: []v3.Point{{Timestamp: 1, Value: 0.1}}},
+			},
+		},
+		{
+			name:       "label mapping with on",
+			results:    results,
+			expression: "A/B",
+			matching: &v3.VectorMatching{
+				LabelMapping: map[string]map[string]string{"A": {"service.name": "service_name"}},
+				On:           []string{"service_name"},
+			},
+			want: []*v3.Series{
+				{Labels: map[string]string{"service_name": "frontend"}, Points: []v3.Point{{Timestamp: 1, Value: 0.05}}},
+				{Labels: map[string]string{"service_name": "redis"}, Points: []v3.Point{{Timestamp: 1, Value: 0.1}}},
+			},
+		},
+		{
+			name:       "ignoring",
+			results:    results,
+			expression: "A/B",
+			matching: &v3.VectorMatching{
+				LabelMapping: map[string]map[string]string{"A": {"service.name": "service_name"}},
+				Ignoring:     []string{"env"},
+			},
+			want: []*v3.Series{
+				{Labels: map[string]string{"service_name": "frontend"}, Points: []v3.Point{{Timestamp: 1, Value: 0.05}}},
+				{Labels: map[string]string{"service_name": "redis"}, Points: []v3.Point{{Timestamp: 1, Value: 0.1}}},
+			},
+		},
+		{
+			name:       "many to one without group left",
+			results:    manyToOneResults,
+			expression: "C/D",
+			matching:   &v3.VectorMatching{On: []string{"service_name"}},
+			wantErr:    "found duplicate series for the match group {service_name=frontend} of query C, many-to-one matching must be explicit with group left",
+		},
+		{
+			name:       "group left",
+			results:    manyToOneResults,
+			expression: "C/D",
+			matching: &v3.VectorMatching{
+				On:        []string{"service_name"},
+				GroupLeft: &v3.GroupLeft{Include: []string{"team"}},
+			},
+			want: []*v3.Series{
+				{Labels: map[string]string{"service_name": "frontend", "operation": "GET /", "team": "web"}, Points: []v3.Point{{Timestamp: 1, Value: 5}}},
+				{Labels: map[string]string{"service_name": "frontend", "operation": "POST /cart", "team": "web"}, Points: []v3.Point{{Timestamp: 1, Value: 15}}},
+				{Labels: map[string]string{"service_name": "redis", "operation": "GET", "team": "storage"}, Points: []v3.Point{{Timestamp: 1, Value: 1}}},
+			},
+		},
+		{
+			name:       "group left with duplicates on the right",
+			results:    manyToOneResults,
+			expression: "D/C",
+			matching: &v3.VectorMatching{
+				On:        []string{"service_name"},
+				GroupLeft: &v3.GroupLeft{},
+			},
+			wantErr: "found duplicate series for the match group {service_name=frontend} of query C, the queries other than D must have at most one series for every match group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expression, err := govaluate.NewEvaluableExpressionWithFunctions(tt.expression, EvalFuncs())
+			require.NoError(t, err)
+
+			got, err := processMatchedResults(tt.results, expression, map[string]bool{}, tt.matching)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got.Series, len(tt.want))
+			for _, want := range tt.want {
+				found := false
+				for _, series := range got.Series {
+					if assert.ObjectsAreEqual(want.Labels, series.Labels) {
+						found = true
+						assert.InDeltaSlice(t, pointValues(want.Points), pointValues(series.Points), 1e-9)
+					}
+				}
+				assert.True(t, found, "series with labels %v not found", want.Labels)
+			}
+		})
+	}
+
+	// the label mapping does not modify the results of the queries
+	assert.Equal(t, map[string]string{"service.name": "frontend"}, results[0].Series[0].Labels)
+}
+
+func pointValues(points []v3.Point) []float64 {
+	values := make([]float64, len(points))
+	for idx, point := range points {
+		values[idx] = point.Value
+	}
+	return values
+}
+
+func TestVectorMatchingValidate(t *testing.T) {
+	assert.NoError(t, (&v3.VectorMatching{On: []string{"service_name"}, GroupLeft: &v3.GroupLeft{}}).Validate())
+	assert.EqualError(t, (&v3.VectorMatching{On: []string{"a"}, Ignoring: []string{"b"}}).Validate(), "on and ignoring can not be used together")
+	assert.EqualError(t, (&v3.VectorMatching{GroupLeft: &v3.GroupLeft{}}).Validate(), "group left requires on or ignoring")
+}
@@ -1,6 +1,9 @@
 package postprocess
 
 import (
+	"context"
+	"sort"
+
 	"github.com/SigNoz/govaluate"
 	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
@@ -14,6 +17,60 @@ import (
 // 1. Effective use of caching
 // 2. Easier to add new functions
 func PostProcessResult(result []*v3.Result, queryRangeParams *v3.QueryRangeParamsV3) ([]*v3.Result, error) {
+	w := &bufferedResultWriter{}
+	if err := postProcess(context.Background(), result, queryRangeParams, w); err != nil {
+		return nil, err
+	}
+
+	// the results of the queries are returned in the order they were given,
+	// followed by the results of the formulas
+	order := make(map[string]int, len(result))
+	for idx, res := range result {
+		order[res.QueryName] = idx
+	}
+	position := func(res *v3.Result) int {
+		if idx, ok := order[res.QueryName]; ok {
+			return idx
+		}
+		return len(result)
+	}
+	result = w.results
+	sort.SliceStable(result, func(i, j int) bool {
+		return position(result[i]) < position(result[j])
+	})
+
+	if queryRangeParams.FormatForWeb &&
+		queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder &&
+		queryRangeParams.CompositeQuery.PanelType == v3.PanelTypeTable {
+		result = TransformToTableForBuilderQueries(result, queryRangeParams)
+	}
+
+	return result, nil
+}
+
+// resultWriter receives the final result of every enabled query and formula,
+// the results of the disabled queries are skipped once the formulas are done
+type resultWriter interface {
+	writeResult(ctx context.Context, res *v3.Result) error
+	skipResult(res *v3.Result)
+}
+
+// bufferedResultWriter keeps the results to return them all at once
+type bufferedResultWriter struct {
+	results []*v3.Result
+}
+
+func (w *bufferedResultWriter) writeResult(_ context.Context, res *v3.Result) error {
+	w.results = append(w.results, res)
+	return nil
+}
+
+func (w *bufferedResultWriter) skipResult(_ *v3.Result) {}
+
+// postProcess processes the results and writes the result of every enabled
+// query and formula to the writer as soon as it is final. The results that are
+// not used by any formula are written before the formulas are evaluated.
+func postProcess(ctx context.Context, result []*v3.Result, queryRangeParams *v3.QueryRangeParamsV3, w resultWriter) error {
 	// Having clause is not part of the clickhouse query, so we need to apply it here
 	// It's not included in the query because it doesn't work nicely with caching
 	// With this change, if you have a query with a having clause, and then you change the having clause
@@ -30,9 +87,15 @@ func PostProcessResult(result []*v3.Result, queryRangeParams *v3.QueryRangeParam
 	ApplyMetricLimit(result, queryRangeParams)
 	// We apply the functions here it's easier to add new functions
 	ApplyFunctions(result, queryRangeParams)
+	// The heatmap shows a series for each bucket of the distribution
+	ApplyHeatmap(result, queryRangeParams)
 	// Each series in the result produces N number of points, where N is (end - start) / step
 	// For the panel type table, we need to show one point for each series in the row
 	// We do that by applying a reduce function to each series
+	// The gaps are filled before that for the reduced value to honor the fill gaps strategy
+	if queryRangeParams.CompositeQuery.ShouldFillGaps() {
+		fillGapsBeforeReduce(result, queryRangeParams)
+	}
 	applyReduceTo(result, queryRangeParams)
 
 	// expressions are executed at query serivce so the value of time.now in the invdividual
@@ -42,58 +105,82 @@ func PostProcessResult(result []*v3.Result, queryRangeParams *v3.QueryRangeParam
 	}
 
 	canDefaultZero := make(map[string]bool)
+	expressions := make(map[string]*govaluate.EvaluableExpression)
+	matchings := make(map[string]*v3.VectorMatching)
+	// usedByFormula holds the queries whose results are needed to evaluate the formulas
+	usedByFormula := make(map[string]bool)
 
 	for _, query := range queryRangeParams.CompositeQuery.BuilderQueries {
 		canDefaultZero[query.QueryName] = query.CanDefaultZero()
-	}
-
-	for _, query := range queryRangeParams.CompositeQuery.BuilderQueries {
 		// The way we distinguish between a formula and a query is by checking if the expression
 		// is the same as the query name
 		// TODO(srikanthccv): Update the UI to send a flag to distinguish between a formula and a query
-		if query.Expression != query.QueryName {
-			expression, err := govaluate.NewEvaluableExpressionWithFunctions(query.Expression, EvalFuncs())
-			// This shouldn't happen here, because it should have been caught earlier in validation
-			if err != nil {
-				zap.L().Error("error in expression", zap.Error(err))
-				return nil, err
-			}
-			formulaResult, err := processResults(result, expression, canDefaultZero)
-			if err != nil {
-				zap.L().Error("error in expression", zap.Error(err))
-				return nil, err
-			}
-			formulaResult.QueryName = query.QueryName
-			ApplyHavingClause([]*v3.Result{formulaResult}, queryRangeParams)
-			ApplyMetricLimit([]*v3.Result{formulaResult}, queryRangeParams)
-			result = append(result, formulaResult)
+		if query.Expression == query.QueryName {
+			continue
 		}
-	}
-	// we are done with the formula calculations, only send the results for enabled queries
-	removeDisabledQueries := func(result []*v3.Result) []*v3.Result {
-		var newResult []*v3.Result
-		for _, res := range result {
-			if queryRangeParams.CompositeQuery.BuilderQueries[res.QueryName].Disabled {
-				continue
-			}
-			newResult = append(newResult, res)
+		expression, err := govaluate.NewEvaluableExpressionWithFunctions(query.Expression, EvalFuncs())
+		// This shouldn't happen here, because it should have been caught earlier in validation
+		if err != nil {
+			zap.L().Error("error in expression", zap.Error(err))
+			return err
+		}
+		expressions[query.QueryName] = expression
+		matchings[query.QueryName] = query.VectorMatching
+		for _, v := range expression.Vars() {
+			usedByFormula[v] = true
 		}
-		return newResult
 	}
-	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
-		result = removeDisabledQueries(result)
+
+	var retained []*v3.Result
+	for _, res := range result {
+		if usedByFormula[res.QueryName] {
+			retained = append(retained, res)
+			continue
+		}
+		if err := writeFinalResult(ctx, res, queryRangeParams, w); err != nil {
+			return err
+		}
 	}
-	if queryRangeParams.CompositeQuery.FillGaps {
-		FillGaps(result, queryRangeParams)
+
+	for queryName, expression := range expressions {
+		formulaResult, err := processMatchedResults(retained, expression, canDefaultZero, matchings[queryName])
+		if err != nil {
+			zap.L().Error("error in expression", zap.Error(err))
+			return err
+		}
+		formulaResult.QueryName = queryName
+		ApplyHavingClause([]*v3.Result{formulaResult}, queryRangeParams)
+		ApplyMetricLimit([]*v3.Result{formulaResult}, queryRangeParams)
+		if usedByFormula[queryName] {
+			retained = append(retained, formulaResult)
+			continue
+		}
+		if err := writeFinalResult(ctx, formulaResult, queryRangeParams, w); err != nil {
+			return err
+		}
 	}
 
-	if queryRangeParams.FormatForWeb &&
-		queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder &&
-		queryRangeParams.CompositeQuery.PanelType == v3.PanelTypeTable {
-		result = TransformToTableForBuilderQueries(result, queryRangeParams)
+	for _, res := range retained {
+		if err := writeFinalResult(ctx, res, queryRangeParams, w); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return result, nil
+// writeFinalResult fills the gaps of the result and writes it, the results of
+// the disabled queries are only used by the formulas and are not written
+func writeFinalResult(ctx context.Context, res *v3.Result, queryRangeParams *v3.QueryRangeParamsV3, w resultWriter) error {
+	if queryRangeParams.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+		if query, ok := queryRangeParams.CompositeQuery.BuilderQueries[res.QueryName]; ok && query.Disabled {
+			w.skipResult(res)
+			return nil
+		}
+	}
+	if queryRangeParams.CompositeQuery.ShouldFillGaps() {
+		FillGaps([]*v3.Result{res}, queryRangeParams)
+	}
+	return w.writeResult(ctx, res)
 }
 
 // ApplyFunctions applies functions for each query in the composite query
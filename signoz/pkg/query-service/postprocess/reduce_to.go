@@ -1,6 +1,8 @@
 package postprocess
 
 import (
+	"math"
+
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
@@ -12,27 +14,38 @@ import (
 // - avg
 // - min
 // - max
+// The NaN points, such as the gaps filled with null, are skipped
 func applyReduceTo(result []*v3.Result, queryRangeParams *v3.QueryRangeParamsV3) {
 	for _, result := range result {
 		builderQueries := queryRangeParams.CompositeQuery.BuilderQueries
 
-		// reduceTo is only applicable for metrics data source
-		// and for table and value panels
-		if builderQueries[result.QueryName] != nil && (builderQueries[result.QueryName].DataSource == v3.DataSourceMetrics &&
-			(queryRangeParams.CompositeQuery.PanelType == v3.PanelTypeTable || queryRangeParams.CompositeQuery.PanelType == v3.PanelTypeValue)) {
+		// reduceTo is only applicable for the table and value panels, to the
+		// series that are not already reduced by the ClickHouse query
+		if queryRangeParams.CompositeQuery.ReducedByQueryService(result.QueryName) {
 			reduceTo := builderQueries[result.QueryName].ReduceTo
 
 			switch reduceTo {
 			case v3.ReduceToOperatorLast:
 				for i := 0; i < len(result.Series); i++ {
 					if len(result.Series[i].Points) > 0 {
-						result.Series[i].Points = []v3.Point{result.Series[i].Points[len(result.Series[i].Points)-1]}
+						last := len(result.Series[i].Points) - 1
+						// NaN points are the gaps filled with null, skip them if there is a value
+						for j := last; j >= 0; j-- {
+							if !math.IsNaN(result.Series[i].Points[j].Value) {
+								last = j
+								break
+							}
+						}
+						result.Series[i].Points = []v3.Point{result.Series[i].Points[last]}
 					}
 				}
 			case v3.ReduceToOperatorSum:
 				for i := 0; i < len(result.Series); i++ {
 					var sum float64
 					for j := 0; j < len(result.Series[i].Points); j++ {
+						if math.IsNaN(result.Series[i].Points[j].Value) {
+							continue
+						}
 						sum += result.Series[i].Points[j].Value
 					}
 					result.Series[i].Points = []v3.Point{{Value: sum}}
@@ -40,16 +53,24 @@ func applyReduceTo(result []*v3.Result, queryRangeParams *v3.QueryRangeParamsV3)
 			case v3.ReduceToOperatorAvg:
 				for i := 0; i < len(result.Series); i++ {
 					var sum float64
+					var count int
 					for j := 0; j < len(result.Series[i].Points); j++ {
+						if math.IsNaN(result.Series[i].Points[j].Value) {
+							continue
+						}
 						sum += result.Series[i].Points[j].Value
+						count++
 					}
-					result.Series[i].Points = []v3.Point{{Value: sum / float64(len(result.Series[i].Points))}}
+					result.Series[i].Points = []v3.Point{{Value: sum / float64(count)}}
 				}
 			case v3.ReduceToOperatorMin:
 				for i := 0; i < len(result.Series); i++ {
-					var min float64
+					min := math.NaN()
 					for j := 0; j < len(result.Series[i].Points); j++ {
-						if j == 0 || result.Series[i].Points[j].Value < min {
+						if math.IsNaN(result.Series[i].Points[j].Value) {
+							continue
+						}
+						if math.IsNaN(min) || result.Series[i].Points[j].Value < min {
 							min = result.Series[i].Points[j].Value
 						}
 					}
@@ -57,9 +78,12 @@ func applyReduceTo(result []*v3.Result, queryRangeParams *v3.QueryRangeParamsV3)
 				}
 			case v3.ReduceToOperatorMax:
 				for i := 0; i < len(result.Series); i++ {
-					var max float64
+					max := math.NaN()
 					for j := 0; j < len(result.Series[i].Points); j++ {
-						if j == 0 || result.Series[i].Points[j].Value > max {
+						if math.IsNaN(result.Series[i].Points[j].Value) {
+							continue
+						}
+						if math.IsNaN(max) || result.Series[i].Points[j].Value > max {
 							max = result.Series[i].Points[j].Value
 						}
 					}
@@ -1,6 +1,7 @@
 package postprocess
 
 import (
+	"math"
 	"testing"
 
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
@@ -97,3 +98,37 @@ func TestApplyReduceTo(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyReduceToSkipsNaN(t *testing.T) {
+	points := func() []v3.Point {
+		return []v3.Point{{Value: 1}, {Value: math.NaN()}, {Value: 3}, {Value: math.NaN()}}
+	}
+	expected := map[v3.ReduceToOperator]float64{
+		v3.ReduceToOperatorLast: 3,
+		v3.ReduceToOperatorSum:  4,
+		v3.ReduceToOperatorAvg:  2,
+		v3.ReduceToOperatorMin:  1,
+		v3.ReduceToOperatorMax:  3,
+	}
+
+	for reduceTo, want := range expected {
+		t.Run(string(reduceTo), func(t *testing.T) {
+			results := []*v3.Result{{QueryName: "A", Series: []*v3.Series{{Points: points()}}}}
+			params := &v3.QueryRangeParamsV3{
+				CompositeQuery: &v3.CompositeQuery{
+					PanelType: v3.PanelTypeValue,
+					BuilderQueries: map[string]*v3.BuilderQuery{
+						"A": {QueryName: "A", DataSource: v3.DataSourceMetrics, ReduceTo: reduceTo},
+					},
+				},
+			}
+
+			applyReduceTo(results, params)
+
+			got := results[0].Series[0].Points[0].Value
+			if got != want {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
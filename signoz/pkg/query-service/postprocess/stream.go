@@ -0,0 +1,53 @@
+package postprocess
+
+import (
+	"context"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// SeriesWriter receives the post processed series one at a time.
+// WriteSeries is expected to block until the series is consumed, which
+// propagates the backpressure of slow consumers to the post processing.
+type SeriesWriter interface {
+	WriteSeries(queryName string, series *v3.Series) error
+}
+
+// StreamPostProcessResult applies the same processing as PostProcessResult and
+// writes the series of every enabled query and formula to the writer as soon
+// as its result is final. The series are released once written, and the
+// results that are not used by any formula are written before the formulas
+// are evaluated.
+//
+// The results of the queries are still fetched and held in memory in full
+// before the processing starts, since the limits, functions and formulas need
+// all the series of a query. Streaming only avoids building and holding the
+// whole response. The table panel formatted for the web is built from all
+// the results at once and is not supported.
+func StreamPostProcessResult(ctx context.Context, result []*v3.Result, queryRangeParams *v3.QueryRangeParamsV3, w SeriesWriter) error {
+	return postProcess(ctx, result, queryRangeParams, &seriesResultWriter{w: w})
+}
+
+// seriesResultWriter writes the series of the results one at a time and
+// releases them
+type seriesResultWriter struct {
+	w SeriesWriter
+}
+
+func (s *seriesResultWriter) writeResult(ctx context.Context, res *v3.Result) error {
+	for idx, series := range res.Series {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.w.WriteSeries(res.QueryName, series); err != nil {
+			return err
+		}
+		res.Series[idx] = nil
+	}
+	res.Series = nil
+	return nil
+}
+
+func (s *seriesResultWriter) skipResult(res *v3.Result) {
+	res.Series = nil
+}
@@ -0,0 +1,126 @@
+package postprocess
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+type streamedSeries struct {
+	queryName string
+	series    *v3.Series
+}
+
+type recordingSeriesWriter struct {
+	written []streamedSeries
+	err     error
+}
+
+func (w *recordingSeriesWriter) WriteSeries(queryName string, series *v3.Series) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.written = append(w.written, streamedSeries{queryName: queryName, series: series})
+	return nil
+}
+
+func streamTestResults() []*v3.Result {
+	return []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service_name": "redis"}, Points: []v3.Point{{Timestamp: 1, Value: 10}}},
+			},
+		},
+		{
+			QueryName: "B",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service_name": "redis"}, Points: []v3.Point{{Timestamp: 1, Value: 5}}},
+			},
+		},
+		{
+			QueryName: "C",
+			Series: []*v3.Series{
+				{Labels: map[string]string{"service_name": "frontend"}, Points: []v3.Point{{Timestamp: 1, Value: 1}}},
+			},
+		},
+	}
+}
+
+func streamTestParams() *v3.QueryRangeParamsV3 {
+	return &v3.QueryRangeParamsV3{
+		Start: 0,
+		End:   60000,
+		Step:  60,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypeBuilder,
+			PanelType: v3.PanelTypeGraph,
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A":  {QueryName: "A", Expression: "A", DataSource: v3.DataSourceMetrics},
+				"B":  {QueryName: "B", Expression: "B", DataSource: v3.DataSourceMetrics, Disabled: true},
+				"C":  {QueryName: "C", Expression: "C", DataSource: v3.DataSourceMetrics},
+				"F1": {QueryName: "F1", Expression: "A/B"},
+			},
+		},
+	}
+}
+
+func TestStreamPostProcessResult(t *testing.T) {
+	results := streamTestResults()
+	writer := &recordingSeriesWriter{}
+
+	err := StreamPostProcessResult(context.Background(), results, streamTestParams(), writer)
+	require.NoError(t, err)
+
+	queryNames := []string{}
+	for _, written := range writer.written {
+		queryNames = append(queryNames, written.queryName)
+	}
+	// C is not used by the formula and is written first, the disabled B is not written
+	assert.Equal(t, []string{"C", "F1", "A"}, queryNames)
+	assert.Equal(t, []v3.Point{{Timestamp: 1, Value: 2}}, writer.written[1].series.Points)
+
+	// the series are released once written
+	for _, result := range results {
+		assert.Nil(t, result.Series, result.QueryName)
+	}
+}
+
+func TestStreamPostProcessResultMatchesPostProcessResult(t *testing.T) {
+	want, err := PostProcessResult(streamTestResults(), streamTestParams())
+	require.NoError(t, err)
+	// the buffered results keep the order of the queries, followed by the formulas
+	wantNames := []string{}
+	for _, result := range want {
+		wantNames = append(wantNames, result.QueryName)
+	}
+	assert.Equal(t, []string{"A", "C", "F1"}, wantNames)
+
+	writer := &recordingSeriesWriter{}
+	err = StreamPostProcessResult(context.Background(), streamTestResults(), streamTestParams(), writer)
+	require.NoError(t, err)
+
+	got := map[string][]*v3.Series{}
+	for _, written := range writer.written {
+		got[written.queryName] = append(got[written.queryName], written.series)
+	}
+	require.Len(t, got, len(want))
+	for _, result := range want {
+		assert.Equal(t, result.Series, got[result.QueryName], result.QueryName)
+	}
+}
+
+func TestStreamPostProcessResultStopsOnError(t *testing.T) {
+	writer := &recordingSeriesWriter{err: errors.New("client went away")}
+	err := StreamPostProcessResult(context.Background(), streamTestResults(), streamTestParams(), writer)
+	assert.EqualError(t, err, "client went away")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = StreamPostProcessResult(ctx, streamTestResults(), streamTestParams(), &recordingSeriesWriter{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -97,7 +97,8 @@ func TransformToTableForBuilderQueries(results []*v3.Result, params *v3.QueryRan
 
 			// Add the value for this query
 			for _, col := range columns {
-				if col.Name == result.QueryName {
+				// a NaN value is a gap filled with null and is shown as n/a
+				if col.Name == result.QueryName && !math.IsNaN(series.Points[0].Value) {
 					row.Data[col.Name] = roundToTwoDecimal(series.Points[0].Value)
 					break
 				}
@@ -0,0 +1,169 @@
+package querycache
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/cache"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// InvalidationEvent describes a change that alters the results of the queries
+// over a data source, which makes the cached results for those queries stale.
+type InvalidationEvent struct {
+	// DataSource is the data source whose cached results are affected
+	DataSource v3.DataSource
+	// AttributeKeys restricts the invalidation to the cached results of the
+	// queries referring to any of these attributes. Every cached result of the
+	// data source is invalidated when it is empty.
+	AttributeKeys []string
+	// Reason is the change that triggered the invalidation, used for logging
+	Reason string
+}
+
+// Cached results are not evicted on invalidation. Instead, every data source
+// and every invalidated attribute has a generation which is part of the keys
+// of the affected cached results. Publishing an event bumps the generations
+// so that the results are cached under new keys, and the stale ones are left
+// to expire. The generations are kept in the cache itself without expiry so
+// that they are shared with the other replicas using the same cache and
+// survive restarts.
+const generationKeyPrefix = "querycache-generation:"
+
+// generationTTL stores the generations without expiry, a negative ttl never
+// expires in any of the cache providers
+const generationTTL = -1
+
+// generations are the generations of a data source and of its attributes
+type generations struct {
+	DataSource int64            `json:"dataSource"`
+	Attributes map[string]int64 `json:"attributes,omitempty"`
+}
+
+func generationKey(dataSource v3.DataSource) string {
+	return generationKeyPrefix + string(dataSource)
+}
+
+// dataSourceOf returns the data source of the cache key generated by the
+// query builder. PromQL queries use the query itself as the cache key.
+func dataSourceOf(cacheKey string) v3.DataSource {
+	_, source, found := strings.Cut(cacheKey, "source=")
+	if !found {
+		return v3.DataSourceMetrics
+	}
+	source, _, _ = strings.Cut(source, "&")
+	return v3.DataSource(source)
+}
+
+// refersTo returns true if the cache key generated by the query builder
+// belongs to a query referring to the attribute
+func refersTo(cacheKey string, attributeKey string) bool {
+	// attributes are part of the key as `<part>=<key>-...` for aggregate attributes
+	// and group by, and as `key:<key>-...` for filters
+	return strings.Contains(cacheKey, "="+attributeKey+"-") || strings.Contains(cacheKey, "key:"+attributeKey+"-")
+}
+
+func getGenerations(c cache.Cache, dataSource v3.DataSource) generations {
+	var gens generations
+	data, _, err := c.Retrieve(generationKey(dataSource), false)
+	if err != nil || data == nil {
+		return gens
+	}
+	if err := json.Unmarshal(data, &gens); err != nil {
+		return generations{}
+	}
+	return gens
+}
+
+// nextGeneration returns the generation following the current one. The
+// generations are taken from the clock so that a generation is never reused,
+// even when the stored generations were lost, e.g. evicted by the cache.
+func nextGeneration(current int64, now time.Time) int64 {
+	return max(current+1, now.UnixNano())
+}
+
+// generationCacheKey returns the key under which the results for the cache
+// key are stored for the current generations of its data source and of the
+// attributes it refers to. The key is left as is until it is first invalidated.
+func generationCacheKey(c cache.Cache, cacheKey string) string {
+	gens := getGenerations(c, dataSourceOf(cacheKey))
+
+	var prefix strings.Builder
+	if gens.DataSource != 0 {
+		prefix.WriteString("generation=" + strconv.FormatInt(gens.DataSource, 10) + "&")
+	}
+	attributeKeys := make([]string, 0, len(gens.Attributes))
+	for attributeKey := range gens.Attributes {
+		attributeKeys = append(attributeKeys, attributeKey)
+	}
+	sort.Strings(attributeKeys)
+	for _, attributeKey := range attributeKeys {
+		if refersTo(cacheKey, attributeKey) {
+			prefix.WriteString("generation-" + attributeKey + ":" + strconv.FormatInt(gens.Attributes[attributeKey], 10) + "&")
+		}
+	}
+	return prefix.String() + cacheKey
+}
+
+// invalidator keeps track of the caches used by the query caches so that the
+// generations can be bumped in each of them.
+type invalidator struct {
+	mtx    sync.Mutex
+	caches map[cache.Cache]struct{}
+}
+
+var defaultInvalidator = &invalidator{caches: make(map[cache.Cache]struct{})}
+
+func (i *invalidator) register(c cache.Cache) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	i.caches[c] = struct{}{}
+}
+
+func (i *invalidator) publish(event InvalidationEvent) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	now := time.Now()
+	for c := range i.caches {
+		gens := getGenerations(c, event.DataSource)
+		if len(event.AttributeKeys) == 0 {
+			// the generation of the data source covers all of its attributes
+			gens = generations{DataSource: nextGeneration(gens.DataSource, now)}
+		} else {
+			if gens.Attributes == nil {
+				gens.Attributes = make(map[string]int64)
+			}
+			for _, attributeKey := range event.AttributeKeys {
+				gens.Attributes[attributeKey] = nextGeneration(gens.Attributes[attributeKey], now)
+			}
+		}
+
+		data, err := json.Marshal(gens)
+		if err != nil {
+			zap.L().Error("error marshalling cache generations", zap.String("dataSource", string(event.DataSource)), zap.Error(err))
+			continue
+		}
+		if err := c.Store(generationKey(event.DataSource), data, generationTTL); err != nil {
+			zap.L().Error("error storing cache generations", zap.String("dataSource", string(event.DataSource)), zap.Error(err))
+		}
+	}
+
+	zap.L().Info("invalidated cached query results",
+		zap.String("dataSource", string(event.DataSource)),
+		zap.Strings("attributeKeys", event.AttributeKeys),
+		zap.String("reason", event.Reason),
+	)
+}
+
+// PublishInvalidation invalidates the cached query results affected by the
+// event in every cache used by the query caches.
+func PublishInvalidation(event InvalidationEvent) {
+	defaultInvalidator.publish(event)
+}
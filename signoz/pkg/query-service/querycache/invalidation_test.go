@@ -0,0 +1,115 @@
+package querycache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/cache/inmemory"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/querycache"
+)
+
+func TestPublishInvalidation(t *testing.T) {
+	logsByMethod := "source=logs&step=60&aggregate=count&limit=0&groupBy-0=method-string-tag-false"
+	metricsByMethod := "source=metrics&step=60&aggregate=sum_rate&timeAggregation=rate&spaceAggregation=sum&groupBy-0=method-string-tag-false"
+	promQL := "sum(rate(signoz_calls_total[5m]))"
+	logsList := querycache.ListCacheKey(v3.DataSourceLogs, "SELECT body FROM logs", 1000, 2000)
+
+	testCases := []struct {
+		name            string
+		event           querycache.InvalidationEvent
+		expectedInvalid []string
+	}{
+		{
+			name:            "logs data source",
+			event:           querycache.InvalidationEvent{DataSource: v3.DataSourceLogs},
+			expectedInvalid: []string{logsByMethod, logsList},
+		},
+		{
+			name:            "metrics data source",
+			event:           querycache.InvalidationEvent{DataSource: v3.DataSourceMetrics},
+			expectedInvalid: []string{metricsByMethod, promQL},
+		},
+		{
+			name:            "logs attribute",
+			event:           querycache.InvalidationEvent{DataSource: v3.DataSourceLogs, AttributeKeys: []string{"method"}},
+			expectedInvalid: []string{logsByMethod},
+		},
+		{
+			name:            "attribute of another data source",
+			event:           querycache.InvalidationEvent{DataSource: v3.DataSourceTraces, AttributeKeys: []string{"method"}},
+			expectedInvalid: []string{},
+		},
+		{
+			name:            "traces data source",
+			event:           querycache.InvalidationEvent{DataSource: v3.DataSourceTraces},
+			expectedInvalid: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := inmemory.New(&inmemory.Options{TTL: 5 * time.Minute, CleanupInterval: 10 * time.Minute})
+			q := querycache.NewQueryCache(querycache.WithCache(c))
+
+			seriesKeys := []string{logsByMethod, metricsByMethod, promQL}
+			for _, key := range seriesKeys {
+				q.MergeWithCachedSeriesData(key, []querycache.CachedSeriesData{{Start: 1000, End: 2000, Data: []*v3.Series{}}})
+			}
+			q.StoreListData(logsList, querycache.CachedListData{Start: 1000, End: 2000, Rows: []*v3.Row{}})
+
+			querycache.PublishInvalidation(tc.event)
+
+			// the results are invalidated for every query cache using the
+			// cache, including the ones created after the invalidation
+			for _, qc := range []interfaces.QueryCache{q, querycache.NewQueryCache(querycache.WithCache(c))} {
+				for _, key := range seriesKeys {
+					missing := qc.FindMissingTimeRanges(1000, 2000, 60, key)
+					if contains(tc.expectedInvalid, key) {
+						assert.Equal(t, []querycache.MissInterval{{Start: 1000, End: 2000}}, missing, key)
+					} else {
+						assert.Empty(t, missing, key)
+					}
+				}
+				_, cached := qc.RetrieveListData(logsList)
+				assert.Equal(t, !contains(tc.expectedInvalid, logsList), cached)
+			}
+		})
+	}
+}
+
+func TestPublishInvalidationGenerationExpiry(t *testing.T) {
+	key := "source=traces&step=60&aggregate=count&limit=0&groupBy-0=method-string-tag-false"
+	// the entries expire quickly unless stored without expiry
+	c := inmemory.New(&inmemory.Options{TTL: 50 * time.Millisecond, CleanupInterval: 10 * time.Millisecond})
+	q := querycache.NewQueryCache(querycache.WithCache(c))
+	cached := func() bool {
+		return len(q.FindMissingTimeRanges(1000, 2000, 60, key)) == 0
+	}
+
+	querycache.PublishInvalidation(querycache.InvalidationEvent{DataSource: v3.DataSourceTraces})
+	time.Sleep(100 * time.Millisecond)
+	data, _, err := c.Retrieve("querycache-generation:traces", false)
+	assert.NoError(t, err)
+	assert.NotNil(t, data, "the generations must not expire")
+
+	q.MergeWithCachedSeriesData(key, []querycache.CachedSeriesData{{Start: 1000, End: 2000, Data: []*v3.Series{}}})
+	assert.True(t, cached())
+
+	// the generations are lost between the invalidations, the results cached
+	// before the first invalidation or since must not be served again
+	c.Remove("querycache-generation:traces")
+	querycache.PublishInvalidation(querycache.InvalidationEvent{DataSource: v3.DataSourceTraces})
+	assert.False(t, cached())
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package querycache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// CachedListData holds the rows returned by a list query for a time window
+type CachedListData struct {
+	Start int64     `json:"start"`
+	End   int64     `json:"end"`
+	Rows  []*v3.Row `json:"rows"`
+}
+
+// ListCacheKey returns the cache key for the rows returned by the list query
+// for the time window. The data source is part of the key so that the rows are
+// invalidated along with the rest of the cached results of the data source.
+func ListCacheKey(dataSource v3.DataSource, query string, start, end int64) string {
+	return fmt.Sprintf("source=%s&list=%x&start=%d&end=%d", dataSource, sha256.Sum256([]byte(query)), start, end)
+}
+
+// RetrieveListData returns the cached rows for the cache key, the second return
+// value is false if the rows are not cached
+func (q *queryCache) RetrieveListData(cacheKey string) ([]*v3.Row, bool) {
+	if q.cache == nil || cacheKey == "" {
+		return nil, false
+	}
+
+	cachedData, _, err := q.cache.Retrieve(generationCacheKey(q.cache, cacheKey), false)
+	if err != nil || cachedData == nil {
+		return nil, false
+	}
+
+	var cachedListData CachedListData
+	// use numbers to preserve the precision of the 64-bit integer columns such as timestamps
+	decoder := json.NewDecoder(bytes.NewReader(cachedData))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cachedListData); err != nil {
+		return nil, false
+	}
+	return cachedListData.Rows, true
+}
+
+// StoreListData caches the rows of a list query for the time window in
+// milliseconds. Windows ending inside the flux interval are not cached
+// because the data might not be fully ingested yet.
+func (q *queryCache) StoreListData(cacheKey string, data CachedListData) {
+	if q.cache == nil || cacheKey == "" {
+		return
+	}
+
+	if data.End > time.Now().UnixMilli()-q.fluxInterval.Milliseconds() {
+		return
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		zap.L().Error("error marshalling list data", zap.Error(err))
+		return
+	}
+	if err := q.cache.Store(generationCacheKey(q.cache, cacheKey), dataJSON, 0); err != nil {
+		zap.L().Error("error storing list data", zap.Error(err))
+	}
+}
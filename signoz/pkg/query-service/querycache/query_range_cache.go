@@ -40,6 +40,9 @@ func NewQueryCache(opts ...QueryCacheOption) *queryCache {
 func WithCache(cache cache.Cache) QueryCacheOption {
 	return func(q *queryCache) {
 		q.cache = cache
+		if cache != nil {
+			defaultInvalidator.register(cache)
+		}
 	}
 }
 
@@ -116,7 +119,7 @@ func (q *queryCache) FindMissingTimeRanges(start, end, step int64, cacheKey stri
 }
 
 func (q *queryCache) getCachedSeriesData(cacheKey string) []*CachedSeriesData {
-	cachedData, _, _ := q.cache.Retrieve(cacheKey, true)
+	cachedData, _, _ := q.cache.Retrieve(generationCacheKey(q.cache, cacheKey), true)
 	var cachedSeriesDataList []*CachedSeriesData
 	if err := json.Unmarshal(cachedData, &cachedSeriesDataList); err != nil {
 		return nil
@@ -170,6 +173,7 @@ func (q *queryCache) storeMergedData(cacheKey string, mergedData []CachedSeriesD
 	err = q.cache.Store(cacheKey, mergedDataJSON, 0)
 	if err != nil {
 		zap.L().Error("error storing merged data", zap.Error(err))
+		return
 	}
 }
 
@@ -179,6 +183,9 @@ func (q *queryCache) MergeWithCachedSeriesData(cacheKey string, newData []Cached
 		return newData
 	}
 
+	// the generation is resolved once so that the merged data is stored
+	// under the key it was read from
+	cacheKey = generationCacheKey(q.cache, cacheKey)
 	cachedData, _, _ := q.cache.Retrieve(cacheKey, true)
 	var existingData []CachedSeriesData
 	if err := json.Unmarshal(cachedData, &existingData); err != nil {
@@ -0,0 +1,232 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// StoredAlertState is the persisted state of an active alert of a rule. The
+// states are restored when the rule is loaded so that a restart neither
+// resets the hold duration of the pending alerts nor sends the notifications
+// of the firing alerts again.
+type StoredAlertState struct {
+	RuleID            string           `db:"rule_id"`
+	Fingerprint       string           `db:"fingerprint"`
+	State             model.AlertState `db:"state"`
+	Labels            string           `db:"labels"`
+	QueryResultLabels string           `db:"query_result_labels"`
+	Annotations       string           `db:"annotations"`
+	GeneratorURL      string           `db:"generator_url"`
+	Receivers         string           `db:"receivers"`
+	Value             float64          `db:"value"`
+	// the times are in unix milliseconds, 0 if not set
+	ActiveAt   int64     `db:"active_at"`
+	FiredAt    int64     `db:"fired_at"`
+	ResolvedAt int64     `db:"resolved_at"`
+	LastSentAt int64     `db:"last_sent_at"`
+	ValidUntil int64     `db:"valid_until"`
+	Missing    bool      `db:"missing"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+// statefulRule is a rule whose active alerts are persisted across restarts
+type statefulRule interface {
+	alertStates() ([]StoredAlertState, bool)
+	setAlertStatesSaved(saved bool)
+	restoreAlertStates(states []StoredAlertState) int
+	dropAlerts()
+}
+
+func toUnixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func labelsJSON(l labels.BaseLabels) string {
+	if l == nil {
+		return "{}"
+	}
+	data, _ := json.Marshal(l.Map())
+	return string(data)
+}
+
+func labelsFromJSON(s string) (labels.Labels, error) {
+	m := map[string]string{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		return nil, err
+	}
+	return labels.FromMap(m), nil
+}
+
+func newStoredAlertState(ruleID string, fp uint64, a *Alert) StoredAlertState {
+	receivers, _ := json.Marshal(a.Receivers)
+	return StoredAlertState{
+		RuleID:            ruleID,
+		Fingerprint:       strconv.FormatUint(fp, 10),
+		State:             a.State,
+		Labels:            labelsJSON(a.Labels),
+		QueryResultLabels: labelsJSON(a.QueryResultLables),
+		Annotations:       labelsJSON(a.Annotations),
+		GeneratorURL:      a.GeneratorURL,
+		Receivers:         string(receivers),
+		Value:             a.Value,
+		ActiveAt:          toUnixMilli(a.ActiveAt),
+		FiredAt:           toUnixMilli(a.FiredAt),
+		ResolvedAt:        toUnixMilli(a.ResolvedAt),
+		LastSentAt:        toUnixMilli(a.LastSentAt),
+		ValidUntil:        toUnixMilli(a.ValidUntil),
+		Missing:           a.Missing,
+	}
+}
+
+// alert returns the fingerprint and the alert of the persisted state
+func (s StoredAlertState) alert() (uint64, *Alert, error) {
+	fp, err := strconv.ParseUint(s.Fingerprint, 10, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	lbls, err := labelsFromJSON(s.Labels)
+	if err != nil {
+		return 0, nil, err
+	}
+	queryResultLabels, err := labelsFromJSON(s.QueryResultLabels)
+	if err != nil {
+		return 0, nil, err
+	}
+	annotations, err := labelsFromJSON(s.Annotations)
+	if err != nil {
+		return 0, nil, err
+	}
+	var receivers []string
+	if err := json.Unmarshal([]byte(s.Receivers), &receivers); err != nil {
+		return 0, nil, err
+	}
+
+	return fp, &Alert{
+		State:             s.State,
+		Labels:            lbls,
+		QueryResultLables: queryResultLabels,
+		Annotations:       annotations,
+		GeneratorURL:      s.GeneratorURL,
+		Receivers:         receivers,
+		Value:             s.Value,
+		ActiveAt:          fromUnixMilli(s.ActiveAt),
+		FiredAt:           fromUnixMilli(s.FiredAt),
+		ResolvedAt:        fromUnixMilli(s.ResolvedAt),
+		LastSentAt:        fromUnixMilli(s.LastSentAt),
+		ValidUntil:        fromUnixMilli(s.ValidUntil),
+		Missing:           s.Missing,
+	}, nil
+}
+
+// alertStates returns the persisted form of the active alerts of the rule, and
+// whether they need to be saved. The states of a rule without active alerts are
+// only saved when they replace previously saved states.
+func (r *BaseRule) alertStates() ([]StoredAlertState, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.Active) == 0 && !r.alertStatesSaved {
+		return nil, false
+	}
+
+	states := make([]StoredAlertState, 0, len(r.Active))
+	for fp, a := range r.Active {
+		states = append(states, newStoredAlertState(r.id, fp, a))
+	}
+	return states, true
+}
+
+func (r *BaseRule) setAlertStatesSaved(saved bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.alertStatesSaved = saved
+}
+
+// restoreAlertStates sets the active alerts of the rule from the persisted
+// states, unless the rule already has active alerts copied from the rule it
+// replaces. It returns the number of restored alerts.
+func (r *BaseRule) restoreAlertStates(states []StoredAlertState) int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.Active) > 0 || len(states) == 0 {
+		return 0
+	}
+
+	for _, s := range states {
+		fp, a, err := s.alert()
+		if err != nil {
+			zap.L().Error("failed to restore the alert state", zap.String("ruleid", r.id), zap.String("fingerprint", s.Fingerprint), zap.Error(err))
+			continue
+		}
+		r.Active[fp] = a
+	}
+	r.alertStatesSaved = true
+	// the state history continues from the restored states
+	r.handledRestart = true
+	return len(r.Active)
+}
+
+// dropAlerts clears the active alerts of the rule without saving, the saved
+// states are kept for the replica that evaluates the rule next
+func (r *BaseRule) dropAlerts() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.Active = map[uint64]*Alert{}
+	r.alertStatesSaved = false
+}
+
+// restoreAlertStates restores the persisted active alerts of the rules of a task
+func restoreAlertStates(ctx context.Context, ruleDB RuleDB, rules []Rule) {
+	if ruleDB == nil {
+		return
+	}
+	for _, rule := range rules {
+		sr, ok := rule.(statefulRule)
+		if !ok {
+			continue
+		}
+		states, err := ruleDB.GetAlertStates(ctx, rule.ID())
+		if err != nil {
+			zap.L().Error("failed to get the alert states of the rule", zap.String("ruleid", rule.ID()), zap.Error(err))
+			continue
+		}
+		if n := sr.restoreAlertStates(states); n > 0 {
+			zap.L().Info("restored the alert states of the rule", zap.String("ruleid", rule.ID()), zap.Int("alerts", n))
+		}
+	}
+}
+
+// saveAlertStates persists the active alerts of the rule
+func saveAlertStates(ctx context.Context, ruleDB RuleDB, rule Rule) {
+	sr, ok := rule.(statefulRule)
+	if !ok || ruleDB == nil {
+		return
+	}
+	states, save := sr.alertStates()
+	if !save {
+		return
+	}
+	if err := ruleDB.SaveAlertStates(ctx, rule.ID(), states); err != nil {
+		zap.L().Error("failed to save the alert states of the rule", zap.String("ruleid", rule.ID()), zap.Error(err))
+		return
+	}
+	sr.setAlertStatesSaved(len(states) > 0)
+}
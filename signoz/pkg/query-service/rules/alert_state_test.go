@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func newAlertStateTestRule(t *testing.T) *ThresholdRule {
+	target := 90.0
+	rule, err := NewThresholdRule("42", &PostableRule{
+		AlertName:  "High CPU",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {QueryName: "A", Expression: "A", DataSource: v3.DataSourceMetrics},
+				},
+			},
+			CompareOp: ValueIsAbove,
+			MatchType: AtleastOnce,
+			Target:    &target,
+		},
+	}, nil, nil, true, true)
+	require.NoError(t, err)
+	return rule
+}
+
+func TestAlertStatePersistence(t *testing.T) {
+	ctx := context.Background()
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), am.NewEmbeddedManager(nil))
+
+	activeAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	firing := &Alert{
+		State:             model.StateFiring,
+		Labels:            labels.FromStrings("alertname", "High CPU", "host", "a"),
+		QueryResultLables: labels.FromStrings("host", "a"),
+		Annotations:       labels.FromStrings("summary", "cpu is 95"),
+		GeneratorURL:      "http://localhost/alerts/edit?ruleId=42",
+		Receivers:         []string{"ops"},
+		Value:             95,
+		ActiveAt:          activeAt,
+		FiredAt:           activeAt.Add(5 * time.Minute),
+		LastSentAt:        activeAt.Add(5 * time.Minute),
+		ValidUntil:        activeAt.Add(9 * time.Minute),
+	}
+	pending := &Alert{
+		State:             model.StatePending,
+		Labels:            labels.FromStrings("alertname", "High CPU", "host", "b"),
+		QueryResultLables: labels.FromStrings("host", "b"),
+		Value:             91,
+		ActiveAt:          activeAt.Add(3 * time.Minute),
+	}
+
+	rule := newAlertStateTestRule(t)
+	rule.Active[firing.Labels.(labels.Labels).Hash()] = firing
+	rule.Active[pending.Labels.(labels.Labels).Hash()] = pending
+	saveAlertStates(ctx, ruleDB, rule)
+
+	states, err := ruleDB.GetAlertStates(ctx, "42")
+	require.NoError(t, err)
+	require.Len(t, states, 2)
+
+	// a new instance of the rule continues from the saved states
+	restored := newAlertStateTestRule(t)
+	restoreAlertStates(ctx, ruleDB, []Rule{restored})
+	require.Len(t, restored.Active, 2)
+	assert.True(t, restored.handledRestart)
+	assert.Equal(t, model.StateFiring, restored.State())
+
+	got := restored.Active[firing.Labels.(labels.Labels).Hash()]
+	require.NotNil(t, got)
+	assert.Equal(t, firing.State, got.State)
+	assert.Equal(t, firing.Labels.Map(), got.Labels.Map())
+	assert.Equal(t, firing.QueryResultLables.Map(), got.QueryResultLables.Map())
+	assert.Equal(t, firing.Annotations.Map(), got.Annotations.Map())
+	assert.Equal(t, firing.Receivers, got.Receivers)
+	assert.Equal(t, firing.Value, got.Value)
+	assert.True(t, firing.ActiveAt.Equal(got.ActiveAt))
+	assert.True(t, firing.FiredAt.Equal(got.FiredAt))
+	assert.True(t, firing.LastSentAt.Equal(got.LastSentAt))
+	assert.True(t, got.ResolvedAt.IsZero())
+
+	// the notification of the restored firing alert is not sent again
+	assert.False(t, got.needsSending(firing.LastSentAt.Add(time.Minute), 5*time.Minute))
+
+	gotPending := restored.Active[pending.Labels.(labels.Labels).Hash()]
+	require.NotNil(t, gotPending)
+	assert.Equal(t, model.StatePending, gotPending.State)
+	assert.True(t, pending.ActiveAt.Equal(gotPending.ActiveAt))
+
+	// the alerts copied from a replaced rule are kept
+	copied := newAlertStateTestRule(t)
+	copied.Active[1] = &Alert{State: model.StatePending}
+	restoreAlertStates(ctx, ruleDB, []Rule{copied})
+	assert.Len(t, copied.Active, 1)
+
+	// the saved states are cleared once the alerts are gone
+	restored.Active = map[uint64]*Alert{}
+	saveAlertStates(ctx, ruleDB, restored)
+	states, err = ruleDB.GetAlertStates(ctx, "42")
+	require.NoError(t, err)
+	assert.Empty(t, states)
+
+	_, save := restored.alertStates()
+	assert.False(t, save)
+}
@@ -30,6 +30,8 @@ const (
 	RuleTypeThreshold = "threshold_rule"
 	RuleTypeProm      = "promql_rule"
 	RuleTypeAnomaly   = "anomaly_rule"
+	RuleTypeSLO       = "slo_rule"
+	RuleTypeRecording = "recording_rule"
 )
 
 type RuleHealth string
@@ -106,18 +108,20 @@ const (
 )
 
 type RuleCondition struct {
-	CompositeQuery    *v3.CompositeQuery `json:"compositeQuery,omitempty" yaml:"compositeQuery,omitempty"`
-	CompareOp         CompareOp          `yaml:"op,omitempty" json:"op,omitempty"`
-	Target            *float64           `yaml:"target,omitempty" json:"target,omitempty"`
-	AlertOnAbsent     bool               `yaml:"alertOnAbsent,omitempty" json:"alertOnAbsent,omitempty"`
-	AbsentFor         uint64             `yaml:"absentFor,omitempty" json:"absentFor,omitempty"`
-	MatchType         MatchType          `json:"matchType,omitempty"`
-	TargetUnit        string             `json:"targetUnit,omitempty"`
-	Algorithm         string             `json:"algorithm,omitempty"`
-	Seasonality       string             `json:"seasonality,omitempty"`
-	SelectedQuery     string             `json:"selectedQueryName,omitempty"`
-	RequireMinPoints  bool               `yaml:"requireMinPoints,omitempty" json:"requireMinPoints,omitempty"`
-	RequiredNumPoints int                `yaml:"requiredNumPoints,omitempty" json:"requiredNumPoints,omitempty"`
+	CompositeQuery    *v3.CompositeQuery  `json:"compositeQuery,omitempty" yaml:"compositeQuery,omitempty"`
+	CompareOp         CompareOp           `yaml:"op,omitempty" json:"op,omitempty"`
+	Target            *float64            `yaml:"target,omitempty" json:"target,omitempty"`
+	AlertOnAbsent     bool                `yaml:"alertOnAbsent,omitempty" json:"alertOnAbsent,omitempty"`
+	AbsentFor         uint64              `yaml:"absentFor,omitempty" json:"absentFor,omitempty"`
+	MatchType         MatchType           `json:"matchType,omitempty"`
+	TargetUnit        string              `json:"targetUnit,omitempty"`
+	Algorithm         string              `json:"algorithm,omitempty"`
+	Seasonality       string              `json:"seasonality,omitempty"`
+	SelectedQuery     string              `json:"selectedQueryName,omitempty"`
+	RequireMinPoints  bool                `yaml:"requireMinPoints,omitempty" json:"requireMinPoints,omitempty"`
+	RequiredNumPoints int                 `yaml:"requiredNumPoints,omitempty" json:"requiredNumPoints,omitempty"`
+	SLO               *SLOCondition       `yaml:"slo,omitempty" json:"slo,omitempty"`
+	Recording         *RecordingCondition `yaml:"recording,omitempty" json:"recording,omitempty"`
 }
 
 func (rc *RuleCondition) GetSelectedQueryName() string {
@@ -137,6 +141,10 @@ func (rc *RuleCondition) GetSelectedQueryName() string {
 				for name := range rc.CompositeQuery.ClickHouseQueries {
 					queryNames[name] = struct{}{}
 				}
+			} else if rc.QueryType() == v3.QueryTypePromQL {
+				for name := range rc.CompositeQuery.PromQueries {
+					queryNames[name] = struct{}{}
+				}
 			}
 		}
 
@@ -155,6 +163,9 @@ func (rc *RuleCondition) GetSelectedQueryName() string {
 			keys = append(keys, k)
 		}
 		sort.Strings(keys)
+		if len(keys) == 0 {
+			return ""
+		}
 		return keys[len(keys)-1]
 	}
 	// This should never happen
@@ -163,10 +174,20 @@ func (rc *RuleCondition) GetSelectedQueryName() string {
 
 func (rc *RuleCondition) IsValid() bool {
 
+	if rc.SLO != nil {
+		// slo rules evaluate the queries of the slo
+		return rc.SLO.SLOId != ""
+	}
+
 	if rc.CompositeQuery == nil {
 		return false
 	}
 
+	if rc.Recording != nil {
+		// recording rules have no threshold
+		return rc.Recording.MetricName != ""
+	}
+
 	if rc.QueryType() == v3.QueryTypeBuilder {
 		if rc.Target == nil {
 			return false
@@ -62,6 +62,9 @@ type PostableRule struct {
 
 	PreferredChannels []string `json:"preferredChannels,omitempty"`
 
+	// Dependencies suppress the notifications of the rule while they are firing
+	Dependencies []RuleDependency `yaml:"dependencies,omitempty" json:"dependencies,omitempty"`
+
 	Version string `json:"version,omitempty"`
 
 	// legacy
@@ -121,7 +124,11 @@ func parseIntoRule(initRule PostableRule, content []byte, kind RuleDataKind) (*P
 		rule.Frequency = Duration(1 * time.Minute)
 	}
 
-	if rule.RuleCondition != nil {
+	if rule.RuleCondition != nil && rule.RuleCondition.SLO != nil {
+		rule.RuleType = RuleTypeSLO
+	} else if rule.RuleCondition != nil && rule.RuleCondition.Recording != nil {
+		rule.RuleType = RuleTypeRecording
+	} else if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
 		if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 			if rule.RuleType == "" {
 				rule.RuleType = RuleTypeThreshold
@@ -206,12 +213,26 @@ func (r *PostableRule) Validate() error {
 	if r.RuleCondition == nil {
 		// will get panic if we try to access CompositeQuery, so return here
 		return errors.Errorf("rule condition is required")
+	} else if r.RuleType == RuleTypeSLO {
+		if r.RuleCondition.SLO == nil {
+			errs = append(errs, errors.Errorf("rule condition missing the slo"))
+		} else if err := r.RuleCondition.SLO.Validate(); err != nil {
+			errs = append(errs, err)
+		}
 	} else {
 		if r.RuleCondition.CompositeQuery == nil {
 			errs = append(errs, errors.Errorf("composite metric query is required"))
 		}
 	}
 
+	if r.RuleType == RuleTypeRecording {
+		if r.RuleCondition.Recording == nil {
+			errs = append(errs, errors.Errorf("rule condition missing the recording"))
+		} else if err := r.RuleCondition.Recording.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	if isAllQueriesDisabled(r.RuleCondition.CompositeQuery) {
 		errs = append(errs, errors.Errorf("all queries are disabled in rule condition"))
 	}
@@ -244,6 +265,12 @@ func (r *PostableRule) Validate() error {
 		}
 	}
 
+	for _, dep := range r.Dependencies {
+		if err := dep.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	errs = append(errs, testTemplateParsing(r)...)
 	return multierr.Combine(errs...)
 }
@@ -302,4 +329,6 @@ type GettableRule struct {
 	CreatedBy *string    `json:"createBy"`
 	UpdatedAt *time.Time `json:"updateAt"`
 	UpdatedBy *string    `json:"updateBy"`
+	// Managed is true when the rule is provisioned from a file and can not be changed in the API
+	Managed bool `json:"managed,omitempty"`
 }
@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// maxBackfillEvaluations limits the evaluations of a backfill, it is a week
+// of evaluations at the default frequency
+const maxBackfillEvaluations = 7 * 24 * 60
+
+// BackfillRequest evaluates the rule at its frequency between start and end
+type BackfillRequest struct {
+	Rule json.RawMessage `json:"rule"`
+	// Start and End are epoch millis
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// HoldDuration is the time an alert stays pending before it fires
+	HoldDuration Duration `json:"holdDuration,omitempty"`
+}
+
+func (r *BackfillRequest) Validate() error {
+	if len(r.Rule) == 0 {
+		return fmt.Errorf("rule is required")
+	}
+	if r.Start <= 0 || r.End <= 0 {
+		return fmt.Errorf("start and end are required")
+	}
+	if r.Start >= r.End {
+		return fmt.Errorf("start must be before end")
+	}
+	if r.End > time.Now().UnixMilli() {
+		return fmt.Errorf("end must not be in the future")
+	}
+	if r.HoldDuration < 0 {
+		return fmt.Errorf("hold duration must not be negative")
+	}
+	return nil
+}
+
+// BackfillResult has the state transitions the rule would have recorded
+type BackfillResult struct {
+	Evaluations int `json:"evaluations"`
+	// AlertCount is the number of times an alert started firing
+	AlertCount  int                      `json:"alertCount"`
+	Transitions []model.RuleStateHistory `json:"transitions"`
+}
+
+// defaultPrepareBackfillRule creates the rule to evaluate in a backfill
+func defaultPrepareBackfillRule(opts PrepareTestRuleOptions, ruleOpts ...RuleOption) (Rule, error) {
+	switch opts.Rule.RuleType {
+	case RuleTypeThreshold:
+		return NewThresholdRule(
+			backfillRuleID,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			ruleOpts...,
+		)
+	case RuleTypeProm:
+		return NewPromRule(
+			backfillRuleID,
+			opts.Rule,
+			opts.Logger,
+			opts.Reader,
+			opts.ManagerOpts.PqlEngine,
+			ruleOpts...,
+		)
+	case RuleTypeSLO:
+		return NewSLORule(
+			backfillRuleID,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.RuleDB,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			ruleOpts...,
+		)
+	}
+	return nil, fmt.Errorf("unsupported rule type %s", opts.Rule.RuleType)
+}
+
+// backfillRuleID is the id of the rules evaluated in a backfill
+const backfillRuleID = "backfill"
+
+// runBackfill evaluates the rule at each step between start and end, the
+// transitions are recorded by the dry run option of the rule
+func runBackfill(ctx context.Context, rule Rule, start, end time.Time, step time.Duration, transitions *[]model.RuleStateHistory) (*BackfillResult, *model.ApiError) {
+	result := &BackfillResult{}
+	for ts := start; !ts.After(end); ts = ts.Add(step) {
+		if err := ctx.Err(); err != nil {
+			return nil, model.InternalError(fmt.Errorf("backfill stopped at %s: %w", ts.Format(time.RFC3339), err))
+		}
+		if _, err := rule.Eval(ctx, ts); err != nil {
+			zap.L().Error("evaluating rule failed in backfill", zap.String("rule", rule.Name()), zap.Time("ts", ts), zap.Error(err))
+			return nil, model.InternalError(fmt.Errorf("rule evaluation failed at %s: %w", ts.Format(time.RFC3339), err))
+		}
+		result.Evaluations++
+	}
+
+	result.Transitions = *transitions
+	for _, item := range result.Transitions {
+		if item.State == model.StateFiring || item.State == model.StateNoData {
+			result.AlertCount++
+		}
+	}
+	return result, nil
+}
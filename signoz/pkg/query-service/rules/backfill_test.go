@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	cmock "github.com/srikanthccv/ClickHouse-go-mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func backfillTestRule(t *testing.T) json.RawMessage {
+	target := 1.0
+	postableRule := PostableRule{
+		AlertName:  "Backfill test",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						Temporality:       v3.Delta,
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						DataSource:        v3.DataSourceMetrics,
+						Expression:        "A",
+					},
+				},
+			},
+			CompareOp: ValueIsAbove,
+			MatchType: AtleastOnce,
+			Target:    &target,
+		},
+	}
+	data, err := json.Marshal(postableRule)
+	require.NoError(t, err)
+	return data
+}
+
+func TestBackfill(t *testing.T) {
+	fm := featureManager.StartManager()
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &queryMatcherAny{})
+	require.NoError(t, err)
+
+	cols := []cmock.ColumnType{
+		{Name: "value", Type: "Float64"},
+		{Name: "attr", Type: "String"},
+		{Name: "timestamp", Type: "String"},
+	}
+	start := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	// the value of each evaluation, the rule alerts above 1
+	for _, v := range []float64{2, 2, 0.5, 2} {
+		mock.ExpectQuery("SELECT any").WillReturnRows(cmock.NewRows(cols, [][]interface{}{{v, "attr", start}}))
+	}
+
+	options := clickhouseReader.NewOptions("", "", "archiveNamespace")
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(mock, options, nil, "", fm, "", true, true, time.Duration(time.Second), nil)
+
+	m := &Manager{
+		opts:                    &ManagerOptions{},
+		logger:                  zap.L(),
+		reader:                  reader,
+		featureFlags:            fm,
+		prepareBackfillRuleFunc: defaultPrepareBackfillRule,
+	}
+
+	result, apiErr := m.Backfill(context.Background(), &BackfillRequest{
+		Rule:         backfillTestRule(t),
+		Start:        start.UnixMilli(),
+		End:          start.Add(3 * time.Minute).UnixMilli(),
+		HoldDuration: Duration(time.Minute),
+	})
+	require.Nil(t, apiErr)
+
+	// pending, firing after the hold duration, resolved, pending again
+	assert.Equal(t, 4, result.Evaluations)
+	assert.Equal(t, 1, result.AlertCount)
+	require.Len(t, result.Transitions, 2)
+	assert.Equal(t, model.StateFiring, result.Transitions[0].State)
+	assert.Equal(t, start.Add(time.Minute).UnixMilli(), result.Transitions[0].UnixMilli)
+	assert.Equal(t, model.StateInactive, result.Transitions[1].State)
+	assert.Equal(t, start.Add(2*time.Minute).UnixMilli(), result.Transitions[1].UnixMilli)
+}
+
+func TestBackfillValidate(t *testing.T) {
+	m := &Manager{opts: &ManagerOptions{}, prepareBackfillRuleFunc: defaultPrepareBackfillRule}
+	now := time.Now()
+
+	cases := []struct {
+		name string
+		req  BackfillRequest
+	}{
+		{name: "missing rule", req: BackfillRequest{Start: now.Add(-time.Hour).UnixMilli(), End: now.UnixMilli()}},
+		{name: "start after end", req: BackfillRequest{Rule: backfillTestRule(t), Start: now.UnixMilli(), End: now.Add(-time.Hour).UnixMilli()}},
+		{name: "future end", req: BackfillRequest{Rule: backfillTestRule(t), Start: now.UnixMilli(), End: now.Add(time.Hour).UnixMilli()}},
+		{name: "too many evaluations", req: BackfillRequest{Rule: backfillTestRule(t), Start: now.Add(-30 * 24 * time.Hour).UnixMilli(), End: now.Add(-time.Minute).UnixMilli()}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, apiErr := m.Backfill(context.Background(), &c.req)
+			require.NotNil(t, apiErr)
+			assert.Equal(t, model.ErrorBadData, apiErr.Type())
+		})
+	}
+}
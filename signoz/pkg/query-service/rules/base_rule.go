@@ -22,6 +22,8 @@ type BaseRule struct {
 	name           string
 	source         string
 	handledRestart bool
+	// alertStatesSaved is set when the persisted alert states of the rule are not empty
+	alertStatesSaved bool
 
 	// Type of the rule
 	typ AlertType
@@ -45,7 +47,9 @@ type BaseRule struct {
 	// preferredChannels is the list of channels to send the alert to
 	// if the rule is triggered
 	preferredChannels []string
-	mtx               sync.Mutex
+	// dependencies suppress the notifications of the rule while they fire
+	dependencies []RuleDependency
+	mtx          sync.Mutex
 	// the time it took to evaluate the rule (most recent evaluation)
 	evaluationDuration time.Duration
 	// the timestamp of the last evaluation
@@ -73,6 +77,10 @@ type BaseRule struct {
 	// or other params
 	sendAlways bool
 
+	// recordHistory receives the state history instead of the store when
+	// the rule is evaluated in a dry run
+	recordHistory func(items []model.RuleStateHistory)
+
 	// TemporalityMap is a map of metric name to temporality
 	// to avoid fetching temporality for the same metric multiple times
 	// querying the v4 table on low cardinal temporality column
@@ -100,6 +108,20 @@ func WithEvalDelay(dur time.Duration) RuleOption {
 	}
 }
 
+func WithHoldDuration(dur time.Duration) RuleOption {
+	return func(r *BaseRule) {
+		r.holdDuration = dur
+	}
+}
+
+// WithDryRun passes the state transitions of the rule to record instead
+// of storing them in the rule state history
+func WithDryRun(record func(items []model.RuleStateHistory)) RuleOption {
+	return func(r *BaseRule) {
+		r.recordHistory = record
+	}
+}
+
 func WithLogger(logger *zap.Logger) RuleOption {
 	return func(r *BaseRule) {
 		r.logger = logger
@@ -121,6 +143,7 @@ func NewBaseRule(id string, p *PostableRule, reader interfaces.Reader, opts ...R
 		labels:            qslabels.FromMap(p.Labels),
 		annotations:       qslabels.FromMap(p.Annotations),
 		preferredChannels: p.PreferredChannels,
+		dependencies:      p.Dependencies,
 		health:            HealthUnknown,
 		Active:            map[uint64]*Alert{},
 		reader:            reader,
@@ -213,6 +236,7 @@ func (r *BaseRule) Condition() *RuleCondition        { return r.ruleCondition }
 func (r *BaseRule) Labels() qslabels.BaseLabels      { return r.labels }
 func (r *BaseRule) Annotations() qslabels.BaseLabels { return r.annotations }
 func (r *BaseRule) PreferredChannels() []string      { return r.preferredChannels }
+func (r *BaseRule) Dependencies() []RuleDependency   { return r.dependencies }
 
 func (r *BaseRule) GeneratorURL() string {
 	return prepareRuleGeneratorURL(r.ID(), r.source)
@@ -562,6 +586,11 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 
 func (r *BaseRule) RecordRuleStateHistory(ctx context.Context, prevState, currentState model.AlertState, itemsToAdd []model.RuleStateHistory) error {
 	zap.L().Debug("recording rule state history", zap.String("ruleid", r.ID()), zap.Any("prevState", prevState), zap.Any("currentState", currentState), zap.Any("itemsToAdd", itemsToAdd))
+	if r.recordHistory != nil {
+		r.recordHistory(itemsToAdd)
+		return nil
+	}
+
 	revisedItemsToAdd := map[uint64]model.RuleStateHistory{}
 
 	lastSavedState, err := r.reader.GetLastSavedRuleStateHistory(ctx, r.ID())
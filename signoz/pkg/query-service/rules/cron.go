@@ -0,0 +1,226 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed five field cron expression, minute hour
+// day-of-month month day-of-week. Besides lists, ranges and steps it supports
+// the last day of the month (L in day-of-month) and the nth weekday of the
+// month (e.g. TUE#2 in day-of-week).
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are set when the field is *, the day matches both
+	// fields when one of them is *, otherwise it matches either of them
+	domStar, dowStar bool
+	lastDom          bool
+	// nthDow has the bitset of the nth weekdays of the month by weekday
+	nthDow [7]uint8
+}
+
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+var cronMonths = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronWeekdays = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: cronMonths}
+	// 7 is sunday as well
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: cronWeekdays}
+)
+
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	c := &cronSchedule{}
+	var err error
+	if c.minute, err = cronMinute.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if c.hour, err = cronHour.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if c.month, err = cronMonth.parse(fields[3]); err != nil {
+		return nil, err
+	}
+
+	c.domStar = fields[2] == "*" || fields[2] == "?"
+	var domParts []string
+	for _, part := range strings.Split(fields[2], ",") {
+		if strings.EqualFold(part, "L") {
+			c.lastDom = true
+			continue
+		}
+		domParts = append(domParts, part)
+	}
+	if len(domParts) > 0 {
+		if c.dom, err = cronDom.parse(strings.Join(domParts, ",")); err != nil {
+			return nil, err
+		}
+	}
+
+	c.dowStar = fields[4] == "*" || fields[4] == "?"
+	var dowParts []string
+	for _, part := range strings.Split(fields[4], ",") {
+		day, nth, found := strings.Cut(part, "#")
+		if !found {
+			dowParts = append(dowParts, part)
+			continue
+		}
+		weekday, err := cronDow.value(day)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(nth)
+		if err != nil || n < 1 || n > 5 {
+			return nil, fmt.Errorf("invalid nth weekday %q: expected 1 to 5", part)
+		}
+		c.nthDow[weekday%7] |= 1 << n
+	}
+	if len(dowParts) > 0 {
+		if c.dow, err = cronDow.parse(strings.Join(dowParts, ",")); err != nil {
+			return nil, err
+		}
+		// sunday is either 0 or 7
+		if c.dow&(1<<7) != 0 {
+			c.dow |= 1
+		}
+	}
+	return c, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q: expected %d to %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// parse returns the bitset of the values of a list of ranges with optional steps
+func (f cronField) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, part)
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case rng == "*" || rng == "?":
+			lo, hi = f.min, f.max
+		case strings.Contains(rng, "-"):
+			from, to, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(to); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, rng)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rng); err != nil {
+				return 0, err
+			}
+			hi = lo
+			// a/n is every n from a
+			if hasStep {
+				hi = f.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	day := t.Day()
+	lastDay := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	domMatch := c.dom&(1<<day) != 0 || (c.lastDom && day == lastDay)
+
+	weekday := int(t.Weekday())
+	nth := (day-1)/7 + 1
+	dowMatch := c.dow&(1<<weekday) != 0 || c.nthDow[weekday]&(1<<nth) != 0
+
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// next returns the first time matching the expression at or after t, in the
+// location of t. It gives up after five years.
+func (c *cronSchedule) next(t time.Time) (time.Time, bool) {
+	loc := t.Location()
+	if t.Second() != 0 || t.Nanosecond() != 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	}
+
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,48 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, 05, 14, 2, 30, 15, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		expr     string
+		expected time.Time
+	}{
+		{name: "every minute", expr: "* * * * *", expected: time.Date(2024, 05, 14, 2, 31, 0, 0, time.UTC)},
+		{name: "daily macro", expr: "@daily", expected: time.Date(2024, 05, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "step", expr: "*/20 * * * *", expected: time.Date(2024, 05, 14, 2, 40, 0, 0, time.UTC)},
+		{name: "weekdays", expr: "0 2 * * MON-FRI", expected: time.Date(2024, 05, 15, 2, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 0 * * 7", expected: time.Date(2024, 05, 19, 0, 0, 0, 0, time.UTC)},
+		{name: "nth weekday", expr: "0 2 * * tue#2", expected: time.Date(2024, 06, 11, 2, 0, 0, 0, time.UTC)},
+		{name: "last day of month", expr: "0 0 L * *", expected: time.Date(2024, 05, 31, 0, 0, 0, 0, time.UTC)},
+		{name: "month names", expr: "0 0 1 jan,jul *", expected: time.Date(2024, 07, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month or weekday", expr: "0 0 20 * SAT", expected: time.Date(2024, 05, 18, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", expr: "0 0 29 2 *", expected: time.Date(2028, 02, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cron, err := parseCron(c.expr)
+			require.NoError(t, err)
+			next, ok := cron.next(from)
+			require.True(t, ok)
+			assert.Equal(t, c.expected, next)
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "* * * * TUE#6", "* * * * FOO"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := parseCron(expr)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package rules
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"slices"
@@ -56,6 +57,55 @@ type RuleDB interface {
 	// GetAllPlannedMaintenance fetches the maintenance definitions from db
 	GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error)
 
+	// CreateSLO stores a given slo in db
+	CreateSLO(ctx context.Context, slo SLO) (int64, error)
+
+	// DeleteSLO deletes the given slo in the db
+	DeleteSLO(ctx context.Context, id string) error
+
+	// GetSLOByID fetches the slo definition from db by id
+	GetSLOByID(ctx context.Context, id string) (*SLO, error)
+
+	// EditSLO updates the given slo in the db
+	EditSLO(ctx context.Context, slo SLO, id string) error
+
+	// GetAllSLOs fetches the slo definitions from db
+	GetAllSLOs(ctx context.Context) ([]SLO, error)
+
+	// GetProvisionedObjects fetches the objects created from the provisioning files
+	GetProvisionedObjects(ctx context.Context) ([]ProvisionedObject, error)
+
+	// UpsertProvisionedObject stores the object created from a provisioning file
+	UpsertProvisionedObject(ctx context.Context, obj ProvisionedObject) error
+
+	// DeleteProvisionedObject deletes the provisioned object mapping in the db
+	DeleteProvisionedObject(ctx context.Context, kind ProvisionKind, provisionID string) error
+
+	// GetAlertStates fetches the persisted active alerts of the rule
+	GetAlertStates(ctx context.Context, ruleID string) ([]StoredAlertState, error)
+
+	// SaveAlertStates replaces the persisted active alerts of the rule
+	SaveAlertStates(ctx context.Context, ruleID string, states []StoredAlertState) error
+
+	// HeartbeatReplica records that the replica is alive
+	HeartbeatReplica(ctx context.Context, id string, startedAt time.Time, now time.Time) error
+
+	// DeleteReplica removes the replica and releases its leases
+	DeleteReplica(ctx context.Context, id string) error
+
+	// GetReplicas fetches the replicas evaluating the rules
+	GetReplicas(ctx context.Context) ([]RuleReplica, error)
+
+	// AcquireLease takes or renews the lease of the task for the owner, it
+	// returns false when the task is leased by another owner
+	AcquireLease(ctx context.Context, taskName string, owner string, now time.Time, duration time.Duration) (bool, error)
+
+	// ReleaseLease gives up the lease of the task held by the owner
+	ReleaseLease(ctx context.Context, taskName string, owner string) error
+
+	// GetLeases fetches the leases of the rule tasks
+	GetLeases(ctx context.Context) ([]RuleLease, error)
+
 	// used for internal telemetry
 	GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error)
 }
@@ -196,6 +246,10 @@ func (r *ruleDB) DeleteRuleTx(ctx context.Context, id string) (string, Tx, error
 		return groupName, nil, err
 	}
 
+	if _, err := r.Exec(`DELETE FROM rule_alert_states WHERE rule_id=$1;`, id); err != nil {
+		zap.L().Error("Error in deleting the alert states of the rule", zap.String("rule", id), zap.Error(err))
+	}
+
 	return groupName, nil, nil
 }
 
@@ -239,7 +293,7 @@ func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, err
 func (r *ruleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error) {
 	maintenances := []PlannedMaintenance{}
 
-	query := "SELECT id, name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by FROM planned_maintenance"
+	query := "SELECT id, name, description, schedule, alert_ids, matchers, created_at, created_by, updated_at, updated_by FROM planned_maintenance"
 
 	err := r.Select(&maintenances, query)
 
@@ -254,7 +308,7 @@ func (r *ruleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMainten
 func (r *ruleDB) GetPlannedMaintenanceByID(ctx context.Context, id string) (*PlannedMaintenance, error) {
 	maintenance := &PlannedMaintenance{}
 
-	query := "SELECT id, name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by FROM planned_maintenance WHERE id=$1"
+	query := "SELECT id, name, description, schedule, alert_ids, matchers, created_at, created_by, updated_at, updated_by FROM planned_maintenance WHERE id=$1"
 	err := r.Get(maintenance, query, id)
 
 	if err != nil {
@@ -273,9 +327,9 @@ func (r *ruleDB) CreatePlannedMaintenance(ctx context.Context, maintenance Plann
 	maintenance.UpdatedBy = email
 	maintenance.UpdatedAt = time.Now()
 
-	query := "INSERT INTO planned_maintenance (name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	query := "INSERT INTO planned_maintenance (name, description, schedule, alert_ids, matchers, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
 
-	result, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.CreatedAt, maintenance.CreatedBy, maintenance.UpdatedAt, maintenance.UpdatedBy)
+	result, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.Matchers, maintenance.CreatedAt, maintenance.CreatedBy, maintenance.UpdatedAt, maintenance.UpdatedBy)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -302,8 +356,8 @@ func (r *ruleDB) EditPlannedMaintenance(ctx context.Context, maintenance Planned
 	maintenance.UpdatedBy = email
 	maintenance.UpdatedAt = time.Now()
 
-	query := "UPDATE planned_maintenance SET name=$1, description=$2, schedule=$3, alert_ids=$4, updated_at=$5, updated_by=$6 WHERE id=$7"
-	_, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.UpdatedAt, maintenance.UpdatedBy, id)
+	query := "UPDATE planned_maintenance SET name=$1, description=$2, schedule=$3, alert_ids=$4, matchers=$5, updated_at=$6, updated_by=$7 WHERE id=$8"
+	_, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.Matchers, maintenance.UpdatedAt, maintenance.UpdatedBy, id)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -313,6 +367,140 @@ func (r *ruleDB) EditPlannedMaintenance(ctx context.Context, maintenance Planned
 	return "", nil
 }
 
+func (r *ruleDB) GetAllSLOs(ctx context.Context) ([]SLO, error) {
+	slos := []SLO{}
+
+	query := "SELECT id, name, description, objective, created_at, created_by, updated_at, updated_by FROM slos"
+
+	err := r.Select(&slos, query)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return slos, nil
+}
+
+func (r *ruleDB) GetSLOByID(ctx context.Context, id string) (*SLO, error) {
+	slo := &SLO{}
+
+	query := "SELECT id, name, description, objective, created_at, created_by, updated_at, updated_by FROM slos WHERE id=$1"
+	err := r.Get(slo, query, id)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return slo, nil
+}
+
+func (r *ruleDB) CreateSLO(ctx context.Context, slo SLO) (int64, error) {
+
+	email, _ := auth.GetEmailFromJwt(ctx)
+	slo.CreatedBy = email
+	slo.CreatedAt = time.Now()
+	slo.UpdatedBy = email
+	slo.UpdatedAt = time.Now()
+
+	query := "INSERT INTO slos (name, description, objective, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+
+	result, err := r.Exec(query, slo.Name, slo.Description, slo.Objective, slo.CreatedAt, slo.CreatedBy, slo.UpdatedAt, slo.UpdatedBy)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (r *ruleDB) DeleteSLO(ctx context.Context, id string) error {
+	query := "DELETE FROM slos WHERE id=$1"
+	result, err := r.Exec(query, id)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	// a missing slo is reported the same way as by GetSLOByID
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ruleDB) EditSLO(ctx context.Context, slo SLO, id string) error {
+	email, _ := auth.GetEmailFromJwt(ctx)
+	slo.UpdatedBy = email
+	slo.UpdatedAt = time.Now()
+
+	query := "UPDATE slos SET name=$1, description=$2, objective=$3, updated_at=$4, updated_by=$5 WHERE id=$6"
+	result, err := r.Exec(query, slo.Name, slo.Description, slo.Objective, slo.UpdatedAt, slo.UpdatedBy, id)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	// a missing slo is reported the same way as by GetSLOByID
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *ruleDB) GetProvisionedObjects(ctx context.Context) ([]ProvisionedObject, error) {
+	objects := []ProvisionedObject{}
+
+	query := "SELECT kind, provision_id, object_id, file, checksum, object_checksum, updated_at FROM provisioned_objects"
+
+	err := r.Select(&objects, query)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (r *ruleDB) UpsertProvisionedObject(ctx context.Context, obj ProvisionedObject) error {
+	obj.UpdatedAt = time.Now()
+
+	query := `INSERT INTO provisioned_objects (kind, provision_id, object_id, file, checksum, object_checksum, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (kind, provision_id) DO UPDATE SET object_id=excluded.object_id, file=excluded.file, checksum=excluded.checksum, object_checksum=excluded.object_checksum, updated_at=excluded.updated_at`
+
+	_, err := r.Exec(query, obj.Kind, obj.ProvisionID, obj.ObjectID, obj.File, obj.Checksum, obj.ObjectChecksum, obj.UpdatedAt)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *ruleDB) DeleteProvisionedObject(ctx context.Context, kind ProvisionKind, provisionID string) error {
+	query := "DELETE FROM provisioned_objects WHERE kind=$1 AND provision_id=$2"
+
+	_, err := r.Exec(query, kind, provisionID)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func getChannelType(receiver *am.Receiver) string {
 
 	if receiver.EmailConfigs != nil {
@@ -570,13 +758,15 @@ func (r *ruleDB) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 				}
 			}
 
-			for _, query := range rule.RuleCondition.CompositeQuery.BuilderQueries {
-				if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeBuilder {
-					if query.Filters != nil {
-						for _, item := range query.Filters.Items {
-							if slices.Contains([]string{"contains", "ncontains", "like", "nlike"}, string(item.Operator)) {
-								if item.Key.Key != "body" {
-									alertsInfo.AlertsWithLogsContainsOp += 1
+			if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
+				for _, query := range rule.RuleCondition.CompositeQuery.BuilderQueries {
+					if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeBuilder {
+						if query.Filters != nil {
+							for _, item := range query.Filters.Items {
+								if slices.Contains([]string{"contains", "ncontains", "like", "nlike"}, string(item.Operator)) {
+									if item.Key.Key != "body" {
+										alertsInfo.AlertsWithLogsContainsOp += 1
+									}
 								}
 							}
 						}
@@ -649,3 +839,126 @@ func (r *ruleDB) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 
 	return &alertsInfo, nil
 }
+
+func (r *ruleDB) GetAlertStates(ctx context.Context, ruleID string) ([]StoredAlertState, error) {
+	states := []StoredAlertState{}
+
+	query := "SELECT * FROM rule_alert_states WHERE rule_id=$1"
+	err := r.Select(&states, query, ruleID)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return states, nil
+}
+
+func (r *ruleDB) SaveAlertStates(ctx context.Context, ruleID string, states []StoredAlertState) error {
+	tx, err := r.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM rule_alert_states WHERE rule_id=$1;`, ruleID); err != nil {
+		zap.L().Error("Error in deleting the alert states of the rule", zap.String("rule", ruleID), zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	now := time.Now()
+	for _, s := range states {
+		_, err := tx.Exec(`INSERT INTO rule_alert_states (rule_id, fingerprint, state, labels, query_result_labels, annotations, generator_url, receivers, value, active_at, fired_at, resolved_at, last_sent_at, valid_until, missing, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16);`,
+			ruleID, s.Fingerprint, s.State.String(), s.Labels, s.QueryResultLabels, s.Annotations, s.GeneratorURL, s.Receivers, s.Value, s.ActiveAt, s.FiredAt, s.ResolvedAt, s.LastSentAt, s.ValidUntil, s.Missing, now)
+		if err != nil {
+			zap.L().Error("Error in inserting the alert state of the rule", zap.String("rule", ruleID), zap.Error(err))
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ruleDB) HeartbeatReplica(ctx context.Context, id string, startedAt time.Time, now time.Time) error {
+	query := `INSERT INTO rule_replicas (id, started_at, heartbeat_at) VALUES ($1, $2, $3)
+		ON CONFLICT(id) DO UPDATE SET heartbeat_at=excluded.heartbeat_at;`
+	if _, err := r.ExecContext(ctx, query, id, startedAt, now.UnixMilli()); err != nil {
+		zap.L().Error("Error in updating the heartbeat of the replica", zap.String("replica", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ruleDB) DeleteReplica(ctx context.Context, id string) error {
+	tx, err := r.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM rule_leases WHERE owner=$1;`, id); err != nil {
+		zap.L().Error("Error in releasing the leases of the replica", zap.String("replica", id), zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM rule_replicas WHERE id=$1;`, id); err != nil {
+		zap.L().Error("Error in deleting the replica", zap.String("replica", id), zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ruleDB) GetReplicas(ctx context.Context) ([]RuleReplica, error) {
+	replicas := []RuleReplica{}
+
+	query := "SELECT id, started_at, heartbeat_at FROM rule_replicas ORDER BY id"
+	err := r.Select(&replicas, query)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return replicas, nil
+}
+
+func (r *ruleDB) AcquireLease(ctx context.Context, taskName string, owner string, now time.Time, duration time.Duration) (bool, error) {
+	// the lease is taken over only when it is expired
+	query := `INSERT INTO rule_leases (task_name, owner, expires_at, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT(task_name) DO UPDATE SET owner=excluded.owner, expires_at=excluded.expires_at, updated_at=excluded.updated_at
+		WHERE rule_leases.owner=excluded.owner OR rule_leases.expires_at < $5;`
+
+	result, err := r.ExecContext(ctx, query, taskName, owner, now.Add(duration).UnixMilli(), now, now.UnixMilli())
+	if err != nil {
+		zap.L().Error("Error in acquiring the lease of the rule task", zap.String("task", taskName), zap.Error(err))
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *ruleDB) ReleaseLease(ctx context.Context, taskName string, owner string) error {
+	if _, err := r.ExecContext(ctx, `DELETE FROM rule_leases WHERE task_name=$1 AND owner=$2;`, taskName, owner); err != nil {
+		zap.L().Error("Error in releasing the lease of the rule task", zap.String("task", taskName), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ruleDB) GetLeases(ctx context.Context) ([]RuleLease, error) {
+	leases := []RuleLease{}
+
+	query := "SELECT task_name, owner, expires_at FROM rule_leases ORDER BY task_name"
+	err := r.Select(&leases, query)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return leases, nil
+}
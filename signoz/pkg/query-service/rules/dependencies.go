@@ -0,0 +1,230 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// RuleDependency is a parent of a rule, the notifications of the rule are
+// suppressed while an alert of the parent is firing. The parent is the rule
+// with the given id, or any rule with firing alerts matching the matchers.
+type RuleDependency struct {
+	RuleID string `yaml:"ruleId,omitempty" json:"ruleId,omitempty"`
+	// Matchers match the labels of the alerts of the parent, e.g. service="db"
+	Matchers []string `yaml:"matchers,omitempty" json:"matchers,omitempty"`
+	// Equal are the labels that must have the same value in the alert of the
+	// parent and the alert of the rule for the alert to be suppressed
+	Equal []string `yaml:"equal,omitempty" json:"equal,omitempty"`
+}
+
+func (d *RuleDependency) Validate() error {
+	if d.RuleID == "" && len(d.Matchers) == 0 {
+		return fmt.Errorf("dependency requires a rule id or matchers")
+	}
+	_, err := d.matchers()
+	return err
+}
+
+func (d *RuleDependency) matchers() (am.Matchers, error) {
+	matchers := make(am.Matchers, 0, len(d.Matchers))
+	for _, s := range d.Matchers {
+		m, err := am.ParseMatcher(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dependency matcher %q: %w", s, err)
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// inhibits returns true if the alert of the parent suppresses the alert
+func (d *RuleDependency) inhibits(matchers am.Matchers, parentID string, parent, alert map[string]string) bool {
+	if d.RuleID != "" && d.RuleID != parentID {
+		return false
+	}
+	if !matchers.Matches(parent) {
+		return false
+	}
+	for _, name := range d.Equal {
+		if parent[name] != alert[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleInhibitor suppresses the notifications of the rules whose dependencies
+// are firing. It keeps its own index of the rules because the notifications
+// are sent from the tasks, which the manager stops while holding its lock.
+type ruleInhibitor struct {
+	mtx   sync.RWMutex
+	rules map[string]Rule
+	// suppressed has the fingerprints of the suppressed alerts of each rule,
+	// with the time the alert resolved so its resolved notifications are
+	// dropped while the rule keeps the alert around
+	suppressed map[string]map[uint64]time.Time
+	// storedAlerts returns the persisted alerts of the rules evaluated by
+	// another replica, whose alerts are not kept in memory. It is nil when
+	// every rule is evaluated by this replica.
+	storedAlerts func(ctx context.Context, ruleID string) ([]*Alert, bool)
+}
+
+func newRuleInhibitor() *ruleInhibitor {
+	return &ruleInhibitor{
+		rules:      make(map[string]Rule),
+		suppressed: make(map[string]map[uint64]time.Time),
+	}
+}
+
+func (i *ruleInhibitor) setRule(r Rule) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.rules[r.ID()] = r
+}
+
+func (i *ruleInhibitor) removeRule(id string) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	delete(i.rules, id)
+	delete(i.suppressed, id)
+}
+
+// alertsOf returns the active alerts of the parent rule, the alerts are loaded
+// once per filter
+func (i *ruleInhibitor) alertsOf(ctx context.Context, id string, parent Rule, loaded map[string][]*Alert) []*Alert {
+	if alerts, ok := loaded[id]; ok {
+		return alerts
+	}
+	alerts := parent.ActiveAlerts()
+	if i.storedAlerts != nil {
+		if stored, ok := i.storedAlerts(ctx, id); ok {
+			alerts = stored
+		}
+	}
+	loaded[id] = alerts
+	return alerts
+}
+
+// inhibitedBy returns the id of a rule with a firing alert that suppresses the alert
+func (i *ruleInhibitor) inhibitedBy(ctx context.Context, rule Rule, alert *Alert, loaded map[string][]*Alert) (string, bool) {
+	lbls := alert.Labels.Map()
+	for _, dep := range rule.Dependencies() {
+		matchers, err := dep.matchers()
+		if err != nil {
+			continue
+		}
+		for id, parent := range i.rules {
+			if id == rule.ID() {
+				continue
+			}
+			for _, a := range i.alertsOf(ctx, id, parent, loaded) {
+				if a.State != model.StateFiring {
+					continue
+				}
+				if dep.inhibits(matchers, id, a.Labels.Map(), lbls) {
+					return id, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// filter drops the alerts suppressed by a firing dependency of their rule and
+// records the start and the end of the suppression in the state history
+func (i *ruleInhibitor) filter(ctx context.Context, ts time.Time, alerts []*Alert) []*Alert {
+	if len(alerts) == 0 {
+		return alerts
+	}
+
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	res := make([]*Alert, 0, len(alerts))
+	history := map[string][]model.RuleStateHistory{}
+	loaded := map[string][]*Alert{}
+	for _, alert := range alerts {
+		rule, ok := i.rules[alert.Labels.Get(labels.AlertRuleIdLabel)]
+		if !ok || len(rule.Dependencies()) == 0 {
+			res = append(res, alert)
+			continue
+		}
+
+		fp := alert.QueryResultLables.Hash()
+		resolvedAt, wasSuppressed := i.suppressed[rule.ID()][fp]
+
+		if !alert.ResolvedAt.IsZero() {
+			// the firing alert was never sent, so there is nothing to resolve
+			if wasSuppressed {
+				if resolvedAt.IsZero() {
+					i.suppressed[rule.ID()][fp] = alert.ResolvedAt
+				}
+				continue
+			}
+			res = append(res, alert)
+			continue
+		}
+		// the alert fires again after it resolved
+		wasSuppressed = wasSuppressed && resolvedAt.IsZero()
+
+		parentID, suppressed := i.inhibitedBy(ctx, rule, alert, loaded)
+		if suppressed != wasSuppressed {
+			state := model.StateFiring
+			if suppressed {
+				if i.suppressed[rule.ID()] == nil {
+					i.suppressed[rule.ID()] = make(map[uint64]time.Time)
+				}
+				i.suppressed[rule.ID()][fp] = time.Time{}
+				state = model.StateSuppressed
+				zap.L().Info("suppressing alert of dependent rule", zap.String("ruleid", rule.ID()), zap.String("parent", parentID))
+			} else {
+				delete(i.suppressed[rule.ID()], fp)
+			}
+
+			labelsJSON, err := json.Marshal(alert.QueryResultLables)
+			if err != nil {
+				zap.L().Error("error marshaling labels", zap.Error(err), zap.Any("labels", alert.Labels))
+			}
+			history[rule.ID()] = append(history[rule.ID()], model.RuleStateHistory{
+				RuleID:       rule.ID(),
+				RuleName:     rule.Name(),
+				OverallState: rule.State(),
+				State:        state,
+				StateChanged: true,
+				UnixMilli:    ts.UnixMilli(),
+				Labels:       model.LabelsString(labelsJSON),
+				Fingerprint:  fp,
+				Value:        alert.Value,
+			})
+		}
+		if !suppressed {
+			res = append(res, alert)
+		}
+	}
+
+	// forget the suppressed alerts the rules no longer keep
+	for _, fps := range i.suppressed {
+		for fp, resolvedAt := range fps {
+			if !resolvedAt.IsZero() && ts.Sub(resolvedAt) > ResolvedRetention {
+				delete(fps, fp)
+			}
+		}
+	}
+
+	for id, items := range history {
+		rule := i.rules[id]
+		if err := rule.RecordRuleStateHistory(ctx, rule.State(), rule.State(), items); err != nil {
+			zap.L().Error("error while recording suppressed alerts", zap.String("ruleid", id), zap.Error(err))
+		}
+	}
+	return res
+}
@@ -0,0 +1,154 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// historyRule records the state history in memory
+type historyRule struct {
+	*BaseRule
+	history []model.RuleStateHistory
+}
+
+func (r *historyRule) RecordRuleStateHistory(ctx context.Context, prevState, currentState model.AlertState, itemsToAdd []model.RuleStateHistory) error {
+	r.history = append(r.history, itemsToAdd...)
+	return nil
+}
+
+func (r *historyRule) Type() RuleType { return RuleTypeThreshold }
+func (r *historyRule) String() string { return r.Name() }
+func (r *historyRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+	return len(r.Active), nil
+}
+
+func newHistoryRule(t *testing.T, id string, deps ...RuleDependency) *historyRule {
+	target := 1.0
+	base, err := NewBaseRule(id, &PostableRule{
+		AlertName:    "rule " + id,
+		Dependencies: deps,
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder},
+			Target:         &target,
+			CompareOp:      ValueIsAbove,
+		},
+	}, nil)
+	require.NoError(t, err)
+	return &historyRule{BaseRule: base}
+}
+
+func testAlert(ruleID string, state model.AlertState, lbls map[string]string) *Alert {
+	series := labels.FromMap(lbls)
+	all := labels.NewBuilder(series).Set(labels.AlertRuleIdLabel, ruleID).Labels()
+	return &Alert{State: state, Labels: all, QueryResultLables: series}
+}
+
+func TestRuleInhibitor(t *testing.T) {
+	parent := newHistoryRule(t, "1")
+	child := newHistoryRule(t, "2", RuleDependency{Matchers: []string{`service="db"`}, Equal: []string{"env"}})
+	other := newHistoryRule(t, "3")
+
+	inhibitor := newRuleInhibitor()
+	for _, r := range []Rule{parent, child, other} {
+		inhibitor.setRule(r)
+	}
+
+	ctx := context.Background()
+	ts := time.Now()
+	childAlert := testAlert("2", model.StateFiring, map[string]string{"service": "api", "env": "prod"})
+	otherAlert := testAlert("3", model.StateFiring, map[string]string{"service": "api", "env": "prod"})
+
+	// the parent is not firing
+	res := inhibitor.filter(ctx, ts, []*Alert{childAlert, otherAlert})
+	assert.Len(t, res, 2)
+	assert.Empty(t, child.history)
+
+	// the parent fires in another env
+	parent.Active[1] = testAlert("1", model.StateFiring, map[string]string{"service": "db", "env": "staging"})
+	res = inhibitor.filter(ctx, ts, []*Alert{childAlert, otherAlert})
+	assert.Len(t, res, 2)
+
+	// the parent fires in the same env
+	parent.Active[2] = testAlert("1", model.StateFiring, map[string]string{"service": "db", "env": "prod"})
+	res = inhibitor.filter(ctx, ts, []*Alert{childAlert, otherAlert})
+	assert.Equal(t, []*Alert{otherAlert}, res)
+	require.Len(t, child.history, 1)
+	assert.Equal(t, model.StateSuppressed, child.history[0].State)
+	assert.Equal(t, childAlert.QueryResultLables.Hash(), child.history[0].Fingerprint)
+
+	// the suppression is recorded once
+	res = inhibitor.filter(ctx, ts, []*Alert{childAlert})
+	assert.Empty(t, res)
+	assert.Len(t, child.history, 1)
+
+	// the parent resolves
+	delete(parent.Active, 2)
+	res = inhibitor.filter(ctx, ts, []*Alert{childAlert})
+	assert.Equal(t, []*Alert{childAlert}, res)
+	require.Len(t, child.history, 2)
+	assert.Equal(t, model.StateFiring, child.history[1].State)
+	assert.Empty(t, other.history)
+}
+
+func TestRuleInhibitorResolvedWhileSuppressed(t *testing.T) {
+	parent := newHistoryRule(t, "1")
+	child := newHistoryRule(t, "2", RuleDependency{RuleID: "1"})
+
+	inhibitor := newRuleInhibitor()
+	inhibitor.setRule(parent)
+	inhibitor.setRule(child)
+
+	ctx := context.Background()
+	ts := time.Now()
+	parent.Active[1] = testAlert("1", model.StateFiring, map[string]string{"service": "db"})
+
+	alert := testAlert("2", model.StateFiring, map[string]string{"service": "api"})
+	assert.Empty(t, inhibitor.filter(ctx, ts, []*Alert{alert}))
+
+	// the resolved notifications of a suppressed alert are dropped
+	resolved := *alert
+	resolved.State = model.StateInactive
+	resolved.ResolvedAt = ts
+	assert.Empty(t, inhibitor.filter(ctx, ts, []*Alert{&resolved}))
+	assert.Empty(t, inhibitor.filter(ctx, ts.Add(time.Minute), []*Alert{&resolved}))
+
+	// the alert fires again once the parent resolved
+	delete(parent.Active, 1)
+	assert.Equal(t, []*Alert{alert}, inhibitor.filter(ctx, ts.Add(2*time.Minute), []*Alert{alert}))
+
+	// the removed rules are not suppressed
+	parent.Active[1] = testAlert("1", model.StateFiring, map[string]string{"service": "db"})
+	inhibitor.removeRule("1")
+	assert.Equal(t, []*Alert{alert}, inhibitor.filter(ctx, ts, []*Alert{alert}))
+}
+
+func TestRuleDependencyValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		dep     RuleDependency
+		wantErr bool
+	}{
+		{name: "rule id", dep: RuleDependency{RuleID: "1"}},
+		{name: "matchers", dep: RuleDependency{Matchers: []string{`service=~"db|cache"`}}},
+		{name: "empty", dep: RuleDependency{Equal: []string{"env"}}, wantErr: true},
+		{name: "invalid matcher", dep: RuleDependency{Matchers: []string{"service"}}, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.dep.Validate()
+			if c.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package rules
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"go.uber.org/zap"
+)
+
+// RuleReplica is a query service replica evaluating the rules
+type RuleReplica struct {
+	ID        string    `json:"id" db:"id"`
+	StartedAt time.Time `json:"startedAt" db:"started_at"`
+	// HeartbeatAt is the time of the last heartbeat in unix milliseconds
+	HeartbeatAt int64 `json:"heartbeatAt" db:"heartbeat_at"`
+	Live        bool  `json:"live" db:"-"`
+}
+
+// RuleLease is the claim of a replica on the evaluation of a rule task
+type RuleLease struct {
+	TaskName string `json:"taskName" db:"task_name"`
+	Owner    string `json:"owner" db:"owner"`
+	// ExpiresAt is the time in unix milliseconds after which the lease can
+	// be taken over by another replica
+	ExpiresAt int64 `json:"expiresAt" db:"expires_at"`
+}
+
+// RuleOwnership is the assignment of the rules to the replicas
+type RuleOwnership struct {
+	Enabled   bool          `json:"enabled"`
+	ReplicaID string        `json:"replicaId"`
+	Replicas  []RuleReplica `json:"replicas"`
+	Rules     []RuleOwner   `json:"rules"`
+}
+
+// RuleOwner is the replica evaluating a rule
+type RuleOwner struct {
+	RuleID   string `json:"ruleId"`
+	RuleName string `json:"ruleName"`
+	TaskName string `json:"taskName"`
+	// Owner is empty when no replica holds a valid lease of the rule
+	Owner     string `json:"owner"`
+	ExpiresAt int64  `json:"expiresAt,omitempty"`
+}
+
+// Coordinator distributes the rule tasks across the query service replicas
+// sharing the rules db. Each replica heartbeats in the db and the tasks are
+// assigned to the live replicas by rendezvous hashing. A task is evaluated
+// only by the replica holding its lease, the leases are renewed on every sync
+// and the leases of a failed replica are taken over once they expire.
+type Coordinator struct {
+	manager       *Manager
+	replicaID     string
+	leaseDuration time.Duration
+	startedAt     time.Time
+
+	// syncMtx serializes the syncs
+	syncMtx sync.Mutex
+	// leasesMtx guards the leases, it is not held while calling the manager
+	leasesMtx sync.Mutex
+	// leases holds the expiry of the leases held by the replica
+	leases map[string]time.Time
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewCoordinator(m *Manager, replicaID string, leaseDuration time.Duration) *Coordinator {
+	return &Coordinator{
+		manager:       m,
+		replicaID:     replicaID,
+		leaseDuration: leaseDuration,
+		startedAt:     time.Now(),
+		leases:        map[string]time.Time{},
+		done:          make(chan struct{}),
+	}
+}
+
+// Run syncs the leases well within their duration until the coordinator is stopped
+func (c *Coordinator) Run() {
+	ticker := time.NewTicker(c.leaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		c.Sync(context.Background(), time.Now())
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop releases the leases of the replica so that the other replicas take
+// over its rules without waiting for the leases to expire
+func (c *Coordinator) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+
+		c.syncMtx.Lock()
+		defer c.syncMtx.Unlock()
+
+		c.leasesMtx.Lock()
+		c.leases = map[string]time.Time{}
+		c.leasesMtx.Unlock()
+
+		if err := c.manager.ruleDB.DeleteReplica(context.Background(), c.replicaID); err != nil {
+			zap.L().Error("failed to release the rule leases of the replica", zap.String("replica", c.replicaID), zap.Error(err))
+		}
+	})
+}
+
+// Owns returns true if the replica holds a valid lease of the task
+func (c *Coordinator) Owns(taskName string) bool {
+	c.leasesMtx.Lock()
+	defer c.leasesMtx.Unlock()
+	return time.Now().Before(c.leases[taskName])
+}
+
+// storedAlerts returns the persisted alerts of the rule when it is evaluated by
+// another replica. The replicas only keep the alerts of the rules they own, so
+// the alerts of the other rules are read from the states saved by their owner.
+func (c *Coordinator) storedAlerts(ctx context.Context, ruleID string) ([]*Alert, bool) {
+	if c.Owns(prepareTaskName(ruleID)) {
+		return nil, false
+	}
+	states, err := c.manager.ruleDB.GetAlertStates(ctx, ruleID)
+	if err != nil {
+		zap.L().Error("failed to get the alert states of the rule", zap.String("ruleid", ruleID), zap.Error(err))
+		return nil, false
+	}
+	alerts := make([]*Alert, 0, len(states))
+	for _, state := range states {
+		_, alert, err := state.alert()
+		if err != nil {
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, true
+}
+
+// Sync reloads the rules and channels, heartbeats the replica, acquires the
+// leases of the tasks assigned to it and releases the others. The tasks
+// without a valid lease are paused.
+func (c *Coordinator) Sync(ctx context.Context, now time.Time) {
+	c.syncMtx.Lock()
+	defer c.syncMtx.Unlock()
+
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	// the rules and channels changed through the api of another replica are
+	// only loaded by that replica, they are picked up from the rules db
+	c.manager.reloadRules(ctx)
+	c.manager.reloadChannels()
+	tasks := c.manager.RuleTasks()
+
+	c.leasesMtx.Lock()
+	previous := c.leases
+	c.leasesMtx.Unlock()
+
+	leases := c.acquireLeases(ctx, tasks, previous, now)
+
+	c.leasesMtx.Lock()
+	c.leases = leases
+	c.leasesMtx.Unlock()
+
+	for _, task := range tasks {
+		name := task.Name()
+		owns := now.Before(leases[name])
+		_, owned := previous[name]
+		if owns && !owned {
+			zap.L().Info("acquired the lease of the rule task", zap.String("task", name), zap.String("replica", c.replicaID))
+			// continue from the alerts saved by the previous owner
+			restoreAlertStates(ctx, c.manager.ruleDB, task.Rules())
+		} else if !owns && owned {
+			zap.L().Info("lost the lease of the rule task", zap.String("task", name), zap.String("replica", c.replicaID))
+			dropAlerts(task.Rules())
+		}
+		task.Pause(!owns)
+	}
+}
+
+// acquireLeases returns the leases held by the replica after the sync
+func (c *Coordinator) acquireLeases(ctx context.Context, tasks []Task, previous map[string]time.Time, now time.Time) map[string]time.Time {
+	ruleDB := c.manager.ruleDB
+
+	// the valid leases are kept while the db is unavailable, the tasks are
+	// paused once they expire
+	held := map[string]time.Time{}
+	for name, expiry := range previous {
+		if now.Before(expiry) {
+			held[name] = expiry
+		}
+	}
+
+	if err := ruleDB.HeartbeatReplica(ctx, c.replicaID, c.startedAt, now); err != nil {
+		zap.L().Error("failed to heartbeat the replica", zap.String("replica", c.replicaID), zap.Error(err))
+		return held
+	}
+
+	replicas, err := ruleDB.GetReplicas(ctx)
+	if err != nil {
+		zap.L().Error("failed to get the rule replicas", zap.Error(err))
+		return held
+	}
+	live := []string{}
+	for _, r := range c.markLive(replicas, now) {
+		if r.Live {
+			live = append(live, r.ID)
+		}
+	}
+
+	leases := map[string]time.Time{}
+	names := map[string]struct{}{}
+	for _, task := range tasks {
+		name := task.Name()
+		names[name] = struct{}{}
+
+		if assignReplica(name, live) != c.replicaID {
+			if _, ok := previous[name]; ok {
+				c.releaseLease(ctx, name)
+			}
+			continue
+		}
+
+		acquired, err := ruleDB.AcquireLease(ctx, name, c.replicaID, now, c.leaseDuration)
+		if err != nil {
+			if expiry, ok := held[name]; ok {
+				leases[name] = expiry
+			}
+			continue
+		}
+		if acquired {
+			leases[name] = now.Add(c.leaseDuration)
+		}
+	}
+
+	// release the leases of the deleted tasks
+	for name := range previous {
+		if _, ok := names[name]; !ok {
+			c.releaseLease(ctx, name)
+		}
+	}
+
+	return leases
+}
+
+// provisioningLease is the lease of the sync of the provisioning files,
+// the files are synced by a single replica at a time so that the replicas
+// do not create the same objects
+const provisioningLease = "__provisioning__"
+
+// AcquireProvisioningLease takes or renews the provisioning lease, it
+// returns false if another replica holds it
+func (c *Coordinator) AcquireProvisioningLease(ctx context.Context, now time.Time) bool {
+	acquired, err := c.manager.ruleDB.AcquireLease(ctx, provisioningLease, c.replicaID, now, c.leaseDuration)
+	if err != nil {
+		zap.L().Error("failed to acquire the provisioning lease", zap.String("replica", c.replicaID), zap.Error(err))
+		return false
+	}
+	return acquired
+}
+
+func (c *Coordinator) releaseLease(ctx context.Context, taskName string) {
+	if err := c.manager.ruleDB.ReleaseLease(ctx, taskName, c.replicaID); err != nil {
+		zap.L().Error("failed to release the lease of the rule task", zap.String("task", taskName), zap.Error(err))
+	}
+}
+
+// markLive sets the replicas with a heartbeat within the lease duration as live
+func (c *Coordinator) markLive(replicas []RuleReplica, now time.Time) []RuleReplica {
+	since := now.Add(-c.leaseDuration).UnixMilli()
+	for i := range replicas {
+		replicas[i].Live = replicas[i].ID == c.replicaID || replicas[i].HeartbeatAt >= since
+	}
+	return replicas
+}
+
+// Ownership returns the replicas and the owners of the rules of the manager
+func (c *Coordinator) Ownership(ctx context.Context, now time.Time) (*RuleOwnership, error) {
+	replicas, err := c.manager.ruleDB.GetReplicas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	leases, err := c.manager.ruleDB.GetLeases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	leaseByTask := make(map[string]RuleLease, len(leases))
+	for _, lease := range leases {
+		if lease.ExpiresAt > now.UnixMilli() {
+			leaseByTask[lease.TaskName] = lease
+		}
+	}
+
+	ownership := &RuleOwnership{
+		Enabled:   true,
+		ReplicaID: c.replicaID,
+		Replicas:  c.markLive(replicas, now),
+		Rules:     []RuleOwner{},
+	}
+	for _, task := range c.manager.RuleTasks() {
+		lease := leaseByTask[task.Name()]
+		for _, rule := range task.Rules() {
+			ownership.Rules = append(ownership.Rules, RuleOwner{
+				RuleID:    rule.ID(),
+				RuleName:  rule.Name(),
+				TaskName:  task.Name(),
+				Owner:     lease.Owner,
+				ExpiresAt: lease.ExpiresAt,
+			})
+		}
+	}
+	sort.Slice(ownership.Rules, func(i, j int) bool {
+		return ownership.Rules[i].TaskName < ownership.Rules[j].TaskName
+	})
+	return ownership, nil
+}
+
+// assignReplica returns the replica with the highest rendezvous hash for the
+// task, the tasks of a failed replica are spread over the remaining replicas
+// while the other tasks keep their owner
+func assignReplica(taskName string, replicas []string) string {
+	var owner string
+	var best uint64
+	for _, id := range replicas {
+		score := xxhash.Sum64String(taskName + "\xff" + id)
+		if owner == "" || score > best {
+			owner, best = id, score
+		}
+	}
+	return owner
+}
+
+// dropAlerts clears the active alerts of the rules evaluated by another
+// replica, they are restored from the saved states if the rules come back
+func dropAlerts(rules []Rule) {
+	for _, rule := range rules {
+		if sr, ok := rule.(statefulRule); ok {
+			sr.dropAlerts()
+		}
+	}
+}
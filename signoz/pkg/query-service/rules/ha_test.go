@@ -0,0 +1,332 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// newCoordinatorTestManager returns a manager with a task per name, the
+// tasks of each manager are separate like in separate replicas
+func newCoordinatorTestManager(t *testing.T, ruleDB RuleDB, taskNames []string) *Manager {
+	m := &Manager{tasks: map[string]Task{}, rules: map[string]Rule{}, ruleDB: ruleDB}
+	opts := &ManagerOptions{Logger: zap.NewNop()}
+	for _, name := range taskNames {
+		rule := newAlertStateTestRule(t)
+		rule.id = RuleIdFromTaskName(name)
+		m.tasks[name] = NewRuleTask(name, "", time.Minute, []Rule{rule}, opts, nil, ruleDB)
+	}
+	return m
+}
+
+func ownedTasks(m *Manager) []string {
+	owned := []string{}
+	for name, task := range m.tasks {
+		if !task.(*RuleTask).isPaused() {
+			owned = append(owned, name)
+		}
+	}
+	return owned
+}
+
+func TestAcquireLease(t *testing.T) {
+	ctx := context.Background()
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), am.NewEmbeddedManager(nil))
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	ok, err := ruleDB.AcquireLease(ctx, "1-groupname", "a", now, 30*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// the lease is renewed by the owner only
+	ok, err = ruleDB.AcquireLease(ctx, "1-groupname", "b", now.Add(10*time.Second), 30*time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = ruleDB.AcquireLease(ctx, "1-groupname", "a", now.Add(10*time.Second), 30*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// and taken over once it expires
+	ok, err = ruleDB.AcquireLease(ctx, "1-groupname", "b", now.Add(41*time.Second), 30*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	leases, err := ruleDB.GetLeases(ctx)
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, "b", leases[0].Owner)
+
+	require.NoError(t, ruleDB.ReleaseLease(ctx, "1-groupname", "a"))
+	leases, err = ruleDB.GetLeases(ctx)
+	require.NoError(t, err)
+	assert.Len(t, leases, 1)
+	require.NoError(t, ruleDB.ReleaseLease(ctx, "1-groupname", "b"))
+	leases, err = ruleDB.GetLeases(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, leases)
+}
+
+func TestCoordinatorShardingAndFailover(t *testing.T) {
+	ctx := context.Background()
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), am.NewEmbeddedManager(nil))
+	leaseDuration := 30 * time.Second
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	taskNames := []string{}
+	for i := 1; i <= 8; i++ {
+		taskNames = append(taskNames, prepareTaskName(int64(i)))
+	}
+	ma := newCoordinatorTestManager(t, ruleDB, taskNames)
+	mb := newCoordinatorTestManager(t, ruleDB, taskNames)
+	a := NewCoordinator(ma, "a", leaseDuration)
+	b := NewCoordinator(mb, "b", leaseDuration)
+
+	// a alone evaluates all the rules
+	a.Sync(ctx, now)
+	assert.ElementsMatch(t, taskNames, ownedTasks(ma))
+
+	// once b joins, a hands over the rules assigned to b
+	b.Sync(ctx, now)
+	assert.Empty(t, ownedTasks(mb))
+	a.Sync(ctx, now.Add(10*time.Second))
+	b.Sync(ctx, now.Add(10*time.Second))
+
+	ownedA, ownedB := ownedTasks(ma), ownedTasks(mb)
+	assert.NotEmpty(t, ownedA)
+	assert.NotEmpty(t, ownedB)
+	assert.ElementsMatch(t, taskNames, append(append([]string{}, ownedA...), ownedB...))
+	for _, name := range ownedA {
+		assert.Equal(t, "a", assignReplica(name, []string{"a", "b"}))
+	}
+
+	ownership, err := a.Ownership(ctx, now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.True(t, ownership.Enabled)
+	assert.Equal(t, "a", ownership.ReplicaID)
+	require.Len(t, ownership.Replicas, 2)
+	assert.True(t, ownership.Replicas[0].Live)
+	assert.True(t, ownership.Replicas[1].Live)
+	require.Len(t, ownership.Rules, len(taskNames))
+	for _, owner := range ownership.Rules {
+		assert.Equal(t, RuleIdFromTaskName(owner.TaskName), owner.RuleID)
+		if assert.Contains(t, []string{"a", "b"}, owner.Owner) {
+			assert.Contains(t, map[string][]string{"a": ownedA, "b": ownedB}[owner.Owner], owner.TaskName)
+		}
+	}
+
+	// b fails, a takes over its rules once the leases of b expire
+	a.Sync(ctx, now.Add(30*time.Second))
+	assert.ElementsMatch(t, ownedA, ownedTasks(ma))
+	a.Sync(ctx, now.Add(45*time.Second))
+	assert.ElementsMatch(t, taskNames, ownedTasks(ma))
+
+	ownership, err = a.Ownership(ctx, now.Add(45*time.Second))
+	require.NoError(t, err)
+	for _, replica := range ownership.Replicas {
+		assert.Equal(t, replica.ID == "a", replica.Live)
+	}
+	for _, owner := range ownership.Rules {
+		assert.Equal(t, "a", owner.Owner)
+	}
+}
+
+func TestCoordinatorHandover(t *testing.T) {
+	ctx := context.Background()
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), am.NewEmbeddedManager(nil))
+	leaseDuration := 30 * time.Second
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// a task assigned to b when both replicas are live
+	var taskName string
+	for i := 1; taskName == ""; i++ {
+		if name := prepareTaskName(int64(i)); assignReplica(name, []string{"a", "b"}) == "b" {
+			taskName = name
+		}
+	}
+
+	ma := newCoordinatorTestManager(t, ruleDB, []string{taskName})
+	mb := newCoordinatorTestManager(t, ruleDB, []string{taskName})
+	a := NewCoordinator(ma, "a", leaseDuration)
+	b := NewCoordinator(mb, "b", leaseDuration)
+
+	a.Sync(ctx, now)
+	require.Equal(t, []string{taskName}, ownedTasks(ma))
+
+	// a evaluates the rule and saves its alerts
+	rule := ma.tasks[taskName].Rules()[0].(*ThresholdRule)
+	rule.Active[1] = &Alert{State: model.StateFiring, Labels: rule.labels, ActiveAt: now}
+	saveAlertStates(ctx, ruleDB, rule)
+
+	// the lease is released to b, which continues from the saved alerts
+	b.Sync(ctx, now)
+	a.Sync(ctx, now.Add(10*time.Second))
+	assert.Empty(t, ownedTasks(ma))
+	assert.Empty(t, rule.Active)
+	b.Sync(ctx, now.Add(10*time.Second))
+	require.Equal(t, []string{taskName}, ownedTasks(mb))
+	assert.Len(t, mb.tasks[taskName].Rules()[0].(*ThresholdRule).Active, 1)
+
+	// b stops and hands the rule back to a without waiting for the lease to expire
+	b.Stop()
+	replicas, err := ruleDB.GetReplicas(ctx)
+	require.NoError(t, err)
+	assert.Len(t, replicas, 1)
+	a.Sync(ctx, now.Add(15*time.Second))
+	assert.Equal(t, []string{taskName}, ownedTasks(ma))
+
+	// the lease of a deleted task is released
+	delete(ma.tasks, taskName)
+	a.Sync(ctx, now.Add(20*time.Second))
+	leases, err := ruleDB.GetLeases(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, leases)
+}
+
+// newReloadTestManager returns a running manager creating real rule tasks,
+// the test rules are evaluated daily so they are not evaluated in the tests
+func newReloadTestManager(ruleDB RuleDB) *Manager {
+	opts := defaultOptions(&ManagerOptions{Logger: zap.NewNop(), Context: context.Background()})
+	m := &Manager{
+		opts:            opts,
+		tasks:           map[string]Task{},
+		rules:           map[string]Rule{},
+		inhibitor:       newRuleInhibitor(),
+		ruleDB:          ruleDB,
+		block:           make(chan struct{}),
+		logger:          opts.Logger,
+		prepareTaskFunc: opts.PrepareTaskFunc,
+	}
+	close(m.block)
+	return m
+}
+
+const reloadTestRule = `{
+	"alert": "%s",
+	"alertType": "METRIC_BASED_ALERT",
+	"ruleType": "threshold_rule",
+	"evalWindow": "5m",
+	"frequency": "24h",
+	"condition": {
+		"compositeQuery": {
+			"queryType": "builder",
+			"panelType": "graph",
+			"builderQueries": {
+				"A": {"queryName": "A", "expression": "A", "dataSource": "metrics", "aggregateOperator": "avg", "aggregateAttribute": {"key": "cpu"}}
+			}
+		},
+		"op": "1",
+		"target": 90,
+		"matchType": "1"
+	}
+}`
+
+func TestCoordinatorReloadsRulesChangedOnOtherReplica(t *testing.T) {
+	ctx := context.Background()
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), am.NewEmbeddedManager(nil))
+	leaseDuration := 30 * time.Second
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	ma := newReloadTestManager(ruleDB)
+	mb := newReloadTestManager(ruleDB)
+	a := NewCoordinator(ma, "a", leaseDuration)
+	b := NewCoordinator(mb, "b", leaseDuration)
+	ma.coordinator, mb.coordinator = a, b
+	a.Sync(ctx, now)
+	b.Sync(ctx, now)
+
+	// a rule created through a after the startup and assigned to b
+	var ruleID, taskName string
+	for i := 0; taskName == ""; i++ {
+		require.Less(t, i, 50)
+		rule, err := ma.CreateRule(ctx, fmt.Sprintf(reloadTestRule, "cpu"))
+		require.NoError(t, err)
+		if name := prepareTaskName(rule.Id); assignReplica(name, []string{"a", "b"}) == "b" {
+			ruleID, taskName = rule.Id, name
+		}
+	}
+	require.NotContains(t, mb.tasks, taskName)
+
+	a.Sync(ctx, now.Add(10*time.Second))
+	b.Sync(ctx, now.Add(10*time.Second))
+	assert.NotContains(t, ownedTasks(ma), taskName)
+	require.Contains(t, ownedTasks(mb), taskName)
+
+	// the edits made through a are evaluated by b
+	require.NoError(t, ma.EditRule(ctx, fmt.Sprintf(reloadTestRule, "cpu edited"), ruleID))
+	b.Sync(ctx, now.Add(20*time.Second))
+	assert.Equal(t, "cpu edited", mb.tasks[taskName].Rules()[0].Name())
+	assert.Contains(t, ownedTasks(mb), taskName)
+
+	// and the rules deleted through a are no longer evaluated by b
+	require.NoError(t, ma.DeleteRule(ctx, ruleID))
+	b.Sync(ctx, now.Add(30*time.Second))
+	assert.NotContains(t, mb.tasks, taskName)
+}
+
+func TestRuleInhibitorParentOnOtherReplica(t *testing.T) {
+	ctx := context.Background()
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), am.NewEmbeddedManager(nil))
+
+	// the parent is evaluated by another replica, b only keeps its definition
+	parent := newHistoryRule(t, "1")
+	child := newHistoryRule(t, "2", RuleDependency{RuleID: "1", Equal: []string{"env"}})
+	b := NewCoordinator(&Manager{ruleDB: ruleDB}, "b", 30*time.Second)
+	require.False(t, b.Owns(prepareTaskName("1")))
+
+	inhibitor := newRuleInhibitor()
+	inhibitor.storedAlerts = b.storedAlerts
+	inhibitor.setRule(parent)
+	inhibitor.setRule(child)
+
+	childAlert := testAlert("2", model.StateFiring, map[string]string{"service": "api", "env": "prod"})
+	assert.Len(t, inhibitor.filter(ctx, time.Now(), []*Alert{childAlert}), 1)
+
+	// the owner of the parent saves its firing alert
+	parentAlert := testAlert("1", model.StateFiring, map[string]string{"service": "db", "env": "prod"})
+	require.NoError(t, ruleDB.SaveAlertStates(ctx, "1", []StoredAlertState{
+		newStoredAlertState("1", parentAlert.QueryResultLables.Hash(), parentAlert),
+	}))
+	assert.Empty(t, inhibitor.filter(ctx, time.Now(), []*Alert{childAlert}))
+	require.Len(t, child.history, 1)
+	assert.Equal(t, model.StateSuppressed, child.history[0].State)
+}
+
+func TestCoordinatorReloadsChannelsChangedOnOtherReplica(t *testing.T) {
+	ctx := context.Background()
+	db := utils.NewQueryServiceDBForTests(t)
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// the channels are changed through the api of a, which only updates the
+	// dispatcher of a
+	dispatcherA, dispatcherB := am.NewDispatcher(nil, 0), am.NewDispatcher(nil, 0)
+	ruleDBA := NewRuleDB(db, am.NewEmbeddedManager(dispatcherA))
+	mb := newReloadTestManager(NewRuleDB(db, am.NewEmbeddedManager(dispatcherB)))
+	mb.dispatcher = dispatcherB
+	b := NewCoordinator(mb, "b", 30*time.Second)
+
+	_, apiErr := ruleDBA.CreateChannel(&am.Receiver{
+		Name:           "ops",
+		WebhookConfigs: []interface{}{map[string]interface{}{"url": "http://localhost:9999/hook"}},
+	})
+	require.Nil(t, apiErr)
+	assert.Empty(t, dispatcherB.ReceiverNames())
+
+	b.Sync(ctx, now)
+	assert.Equal(t, []string{"ops"}, dispatcherB.ReceiverNames())
+
+	channels, apiErr := ruleDBA.GetChannels()
+	require.Nil(t, apiErr)
+	require.Len(t, *channels, 1)
+	require.Nil(t, ruleDBA.DeleteChannel(fmt.Sprint((*channels)[0].Id)))
+	b.Sync(ctx, now.Add(10*time.Second))
+	assert.Empty(t, dispatcherB.ReceiverNames())
+}
@@ -3,12 +3,16 @@ package rules
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 )
 
 var (
@@ -17,6 +21,7 @@ var (
 	ErrMissingTimezone   = errors.New("missing timezone")
 	ErrMissingRepeatType = errors.New("missing repeat type")
 	ErrMissingDuration   = errors.New("missing duration")
+	ErrMissingCron       = errors.New("missing cron expression")
 )
 
 type PlannedMaintenance struct {
@@ -25,12 +30,17 @@ type PlannedMaintenance struct {
 	Description string    `json:"description" db:"description"`
 	Schedule    *Schedule `json:"schedule" db:"schedule"`
 	AlertIds    *AlertIds `json:"alertIds" db:"alert_ids"`
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-	CreatedBy   string    `json:"createdBy" db:"created_by"`
-	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-	UpdatedBy   string    `json:"updatedBy" db:"updated_by"`
-	Status      string    `json:"status"`
-	Kind        string    `json:"kind"`
+	// Matchers scope the maintenance to the alerts with matching labels, the
+	// rules are evaluated and only the matching alerts are silenced
+	Matchers  *AlertMatchers `json:"matchers,omitempty" db:"matchers"`
+	CreatedAt time.Time      `json:"createdAt" db:"created_at"`
+	CreatedBy string         `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time      `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string         `json:"updatedBy" db:"updated_by"`
+	Status    string         `json:"status"`
+	Kind      string         `json:"kind"`
+	// Managed is true when the schedule is provisioned from a file and can not be changed in the API
+	Managed bool `json:"managed,omitempty" db:"-"`
 }
 
 type AlertIds []string
@@ -46,11 +56,43 @@ func (a *AlertIds) Value() (driver.Value, error) {
 	return json.Marshal(a)
 }
 
+// AlertMatchers are label matchers, e.g. service="db"
+type AlertMatchers []string
+
+func (a *AlertMatchers) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, a)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), a)
+	}
+	return nil
+}
+
+func (a *AlertMatchers) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *AlertMatchers) matchers() (am.Matchers, error) {
+	matchers := make(am.Matchers, 0, len(*a))
+	for _, s := range *a {
+		m, err := am.ParseMatcher(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", s, err)
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
 type Schedule struct {
 	Timezone   string      `json:"timezone"`
 	StartTime  time.Time   `json:"startTime,omitempty"`
 	EndTime    time.Time   `json:"endTime,omitempty"`
 	Recurrence *Recurrence `json:"recurrence"`
+	// Exclusions are the dates, in the timezone of the schedule, on which
+	// the recurring windows do not start, e.g. 2024-12-25
+	Exclusions []string `json:"exclusions,omitempty"`
 }
 
 func (s *Schedule) Scan(src interface{}) error {
@@ -70,6 +112,8 @@ const (
 	RepeatTypeDaily   RepeatType = "daily"
 	RepeatTypeWeekly  RepeatType = "weekly"
 	RepeatTypeMonthly RepeatType = "monthly"
+	// RepeatTypeCron starts the windows at the times of the cron expression
+	RepeatTypeCron RepeatType = "cron"
 )
 
 type RepeatOn string
@@ -90,6 +134,10 @@ type Recurrence struct {
 	Duration   Duration   `json:"duration"`
 	RepeatType RepeatType `json:"repeatType"`
 	RepeatOn   []RepeatOn `json:"repeatOn"`
+	// Cron is the five field cron expression of the cron repeat type, it
+	// supports L for the last day of the month and TUE#2 for the second
+	// tuesday of the month
+	Cron string `json:"cron,omitempty"`
 }
 
 func (r *Recurrence) Scan(src interface{}) error {
@@ -131,6 +179,7 @@ func (s Schedule) MarshalJSON() ([]byte, error) {
 			Duration:   s.Recurrence.Duration,
 			RepeatType: s.Recurrence.RepeatType,
 			RepeatOn:   s.Recurrence.RepeatOn,
+			Cron:       s.Recurrence.Cron,
 		}
 	}
 
@@ -139,11 +188,13 @@ func (s Schedule) MarshalJSON() ([]byte, error) {
 		StartTime  string      `json:"startTime"`
 		EndTime    string      `json:"endTime"`
 		Recurrence *Recurrence `json:"recurrence,omitempty"`
+		Exclusions []string    `json:"exclusions,omitempty"`
 	}{
 		Timezone:   s.Timezone,
 		StartTime:  startTime.Format(time.RFC3339),
 		EndTime:    endTime.Format(time.RFC3339),
 		Recurrence: recurrence,
+		Exclusions: s.Exclusions,
 	})
 }
 
@@ -153,6 +204,7 @@ func (s *Schedule) UnmarshalJSON(data []byte) error {
 		StartTime  string      `json:"startTime"`
 		EndTime    string      `json:"endTime"`
 		Recurrence *Recurrence `json:"recurrence,omitempty"`
+		Exclusions []string    `json:"exclusions,omitempty"`
 	}{}
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
@@ -182,6 +234,7 @@ func (s *Schedule) UnmarshalJSON(data []byte) error {
 	}
 
 	s.Timezone = aux.Timezone
+	s.Exclusions = aux.Exclusions
 
 	if aux.Recurrence != nil {
 		recStartTime, err := time.Parse(time.RFC3339, aux.Recurrence.StartTime.Format(time.RFC3339))
@@ -205,117 +258,272 @@ func (s *Schedule) UnmarshalJSON(data []byte) error {
 			Duration:   aux.Recurrence.Duration,
 			RepeatType: aux.Recurrence.RepeatType,
 			RepeatOn:   aux.Recurrence.RepeatOn,
+			Cron:       aux.Recurrence.Cron,
 		}
 	}
 	return nil
 }
 
+// appliesTo returns true if the maintenance covers the rule
+func (m *PlannedMaintenance) appliesTo(ruleID string) bool {
+	// If no alert ids, then the maintenance covers all alerts
+	if m.AlertIds == nil || len(*m.AlertIds) == 0 {
+		return true
+	}
+	return slices.Contains(*m.AlertIds, ruleID)
+}
+
+// silences returns true if the maintenance is scoped by labels and the alert
+// of the rule with the labels is in a window of the maintenance
+func (m *PlannedMaintenance) silences(ruleID string, lbls map[string]string, now time.Time) bool {
+	if m.Matchers == nil || len(*m.Matchers) == 0 || !m.appliesTo(ruleID) {
+		return false
+	}
+	matchers, err := m.Matchers.matchers()
+	if err != nil {
+		zap.L().Error("invalid maintenance matchers", zap.String("maintenance", m.Name), zap.Error(err))
+		return false
+	}
+	return matchers.Matches(lbls) && m.inWindow(now)
+}
+
 func (m *PlannedMaintenance) shouldSkip(ruleID string, now time.Time) bool {
+	// the maintenances scoped by labels silence the alerts, the rule is evaluated
+	if m.Matchers != nil && len(*m.Matchers) > 0 {
+		return false
+	}
 
-	found := false
-	if m.AlertIds != nil {
-		for _, alertID := range *m.AlertIds {
-			if alertID == ruleID {
-				found = true
-				break
-			}
-		}
+	// If alert is not found, we return false
+	if !m.appliesTo(ruleID) {
+		return false
 	}
 
-	// If no alert ids, then skip all alerts
-	if m.AlertIds == nil || len(*m.AlertIds) == 0 {
-		found = true
+	zap.L().Info("alert found in maintenance", zap.String("alert", ruleID), zap.Any("maintenance", m.Name))
+	return m.inWindow(now)
+}
+
+// inWindow returns true if now is in a window of the schedule
+func (m *PlannedMaintenance) inWindow(now time.Time) bool {
+	// fixed schedule
+	if !m.Schedule.StartTime.IsZero() && !m.Schedule.EndTime.IsZero() {
+		// if the current time in the timezone is between the start and end time
+		loc, err := time.LoadLocation(m.Schedule.Timezone)
+		if err != nil {
+			zap.L().Error("Error loading location", zap.String("timezone", m.Schedule.Timezone), zap.Error(err))
+			return false
+		}
+
+		currentTime := now.In(loc)
+		zap.L().Info("checking fixed schedule", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", m.Schedule.StartTime), zap.Time("endTime", m.Schedule.EndTime))
+		if currentTime.After(m.Schedule.StartTime) && currentTime.Before(m.Schedule.EndTime) {
+			return true
+		}
 	}
 
-	if found {
+	// recurring schedule
+	if m.Schedule.Recurrence != nil {
+		zap.L().Info("evaluating recurrence schedule")
+		start := m.Schedule.Recurrence.StartTime
+		end := m.Schedule.Recurrence.StartTime.Add(time.Duration(m.Schedule.Recurrence.Duration))
+		// if the current time in the timezone is between the start and end time
+		loc, err := time.LoadLocation(m.Schedule.Timezone)
+		if err != nil {
+			zap.L().Error("Error loading location", zap.String("timezone", m.Schedule.Timezone), zap.Error(err))
+			return false
+		}
+		currentTime := now.In(loc)
+
+		zap.L().Info("checking recurring schedule", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", start), zap.Time("endTime", end))
 
-		zap.L().Info("alert found in maintenance", zap.String("alert", ruleID), zap.Any("maintenance", m.Name))
+		// make sure the start time is not after the current time
+		if currentTime.Before(start.In(loc)) {
+			zap.L().Info("current time is before start time", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", start.In(loc)))
+			return false
+		}
 
-		// If alert is found, we check if it should be skipped based on the schedule
-		// If it should be skipped, we return true
-		// If it should not be skipped, we return false
+		var endTime time.Time
+		if m.Schedule.Recurrence.EndTime != nil {
+			endTime = *m.Schedule.Recurrence.EndTime
+		}
+		if !endTime.IsZero() && currentTime.After(endTime.In(loc)) {
+			zap.L().Info("current time is after end time", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("endTime", end.In(loc)))
+			return false
+		}
 
-		// fixed schedule
-		if !m.Schedule.StartTime.IsZero() && !m.Schedule.EndTime.IsZero() {
-			// if the current time in the timezone is between the start and end time
-			loc, err := time.LoadLocation(m.Schedule.Timezone)
+		// the windows of the fixed repeat types start on the day they are in
+		if m.Schedule.Recurrence.RepeatType != RepeatTypeCron && m.Schedule.excluded(currentTime) {
+			zap.L().Info("current date is excluded", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime))
+			return false
+		}
+
+		switch m.Schedule.Recurrence.RepeatType {
+		case RepeatTypeDaily:
+			// take the hours and minutes from the start time and add them to the current time
+			startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+			zap.L().Info("checking daily schedule", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
+
+			if currentTime.After(startTime) && currentTime.Before(endTime) {
+				return true
+			}
+		case RepeatTypeWeekly:
+			// if the current time in the timezone is between the start and end time on the RepeatOn day
+			startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+			zap.L().Info("checking weekly schedule", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
+			if currentTime.After(startTime) && currentTime.Before(endTime) {
+				if len(m.Schedule.Recurrence.RepeatOn) == 0 {
+					return true
+				} else if slices.Contains(m.Schedule.Recurrence.RepeatOn, RepeatOn(strings.ToLower(currentTime.Weekday().String()))) {
+					return true
+				}
+			}
+		case RepeatTypeMonthly:
+			// if the current time in the timezone is between the start and end time on the day of the current month
+			startTime := time.Date(currentTime.Year(), currentTime.Month(), start.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			endTime := time.Date(currentTime.Year(), currentTime.Month(), end.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+			zap.L().Info("checking monthly schedule", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
+			if currentTime.After(startTime) && currentTime.Before(endTime) && currentTime.Day() == start.Day() {
+				return true
+			}
+		case RepeatTypeCron:
+			// the first window ending after the current time
+			windows, err := m.Schedule.recurringWindows(currentTime, 1, loc)
 			if err != nil {
-				zap.L().Error("Error loading location", zap.String("timezone", m.Schedule.Timezone), zap.Error(err))
+				zap.L().Error("Error computing cron schedule", zap.String("maintenance", m.Name), zap.Error(err))
 				return false
 			}
-
-			currentTime := now.In(loc)
-			zap.L().Info("checking fixed schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", m.Schedule.StartTime), zap.Time("endTime", m.Schedule.EndTime))
-			if currentTime.After(m.Schedule.StartTime) && currentTime.Before(m.Schedule.EndTime) {
+			zap.L().Info("checking cron schedule", zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Any("windows", windows))
+			if len(windows) > 0 && currentTime.After(windows[0].Start) {
 				return true
 			}
 		}
+	}
+	return false
+}
 
-		// recurring schedule
-		if m.Schedule.Recurrence != nil {
-			zap.L().Info("evaluating recurrence schedule")
-			start := m.Schedule.Recurrence.StartTime
-			end := m.Schedule.Recurrence.StartTime.Add(time.Duration(m.Schedule.Recurrence.Duration))
-			// if the current time in the timezone is between the start and end time
-			loc, err := time.LoadLocation(m.Schedule.Timezone)
-			if err != nil {
-				zap.L().Error("Error loading location", zap.String("timezone", m.Schedule.Timezone), zap.Error(err))
-				return false
-			}
-			currentTime := now.In(loc)
+// MaintenanceWindow is a window of a maintenance schedule
+type MaintenanceWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
 
-			zap.L().Info("checking recurring schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", start), zap.Time("endTime", end))
+// MaxMaintenanceWindows limits the windows computed for a preview
+const MaxMaintenanceWindows = 100
 
-			// make sure the start time is not after the current time
-			if currentTime.Before(start.In(loc)) {
-				zap.L().Info("current time is before start time", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", start.In(loc)))
-				return false
-			}
+// NextWindows returns the next n windows of the schedule ending after from,
+// the first window is the current one when from is in a window
+func (s *Schedule) NextWindows(from time.Time, n int) ([]MaintenanceWindow, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	from = from.In(loc)
 
-			var endTime time.Time
-			if m.Schedule.Recurrence.EndTime != nil {
-				endTime = *m.Schedule.Recurrence.EndTime
-			}
-			if !endTime.IsZero() && currentTime.After(endTime.In(loc)) {
-				zap.L().Info("current time is after end time", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("endTime", end.In(loc)))
-				return false
-			}
+	windows := []MaintenanceWindow{}
+	if !s.StartTime.IsZero() && !s.EndTime.IsZero() && s.EndTime.After(from) {
+		windows = append(windows, MaintenanceWindow{Start: s.StartTime.In(loc), End: s.EndTime.In(loc)})
+	}
+	if s.Recurrence != nil {
+		recurring, err := s.recurringWindows(from, n, loc)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, recurring...)
+		sort.Slice(windows, func(i, j int) bool { return windows[i].Start.Before(windows[j].Start) })
+	}
+	if len(windows) > n {
+		windows = windows[:n]
+	}
+	return windows, nil
+}
 
-			switch m.Schedule.Recurrence.RepeatType {
-			case RepeatTypeDaily:
-				// take the hours and minutes from the start time and add them to the current time
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking daily schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
+// recurringWindows returns the next n windows of the recurrence ending after
+// from, the windows starting on an excluded date are skipped
+func (s *Schedule) recurringWindows(from time.Time, n int, loc *time.Location) ([]MaintenanceWindow, error) {
+	r := s.Recurrence
+	next, err := r.nextStart(loc)
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Duration(r.Duration)
 
-				if currentTime.After(startTime) && currentTime.Before(endTime) {
-					return true
+	windows := []MaintenanceWindow{}
+	t := from.Add(-duration)
+	if start := r.StartTime.In(loc); t.Before(start) {
+		t = start
+	}
+	// bound the search when most of the windows are excluded
+	for i := 0; len(windows) < n && i < 10*MaxMaintenanceWindows; i++ {
+		start, ok := next(t)
+		if !ok || (r.EndTime != nil && start.After(*r.EndTime)) {
+			break
+		}
+		t = start.Add(time.Nanosecond)
+		end := start.Add(duration)
+		if !end.After(from) || s.excluded(start) {
+			continue
+		}
+		windows = append(windows, MaintenanceWindow{Start: start, End: end})
+	}
+	return windows, nil
+}
+
+// excluded returns true if the date of t in the timezone of the schedule is excluded
+func (s *Schedule) excluded(t time.Time) bool {
+	if len(s.Exclusions) == 0 {
+		return false
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(s.Exclusions, t.In(loc).Format(time.DateOnly))
+}
+
+// nextStart returns a function returning the first start of a window of the
+// recurrence at or after a time
+func (r *Recurrence) nextStart(loc *time.Location) (func(time.Time) (time.Time, bool), error) {
+	start := r.StartTime.In(loc)
+	switch r.RepeatType {
+	case RepeatTypeDaily, RepeatTypeWeekly:
+		return func(t time.Time) (time.Time, bool) {
+			t = t.In(loc)
+			for i := 0; i <= 7; i++ {
+				day := time.Date(t.Year(), t.Month(), t.Day()+i, start.Hour(), start.Minute(), 0, 0, loc)
+				if day.Before(t) {
+					continue
 				}
-			case RepeatTypeWeekly:
-				// if the current time in the timezone is between the start and end time on the RepeatOn day
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking weekly schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
-				if currentTime.After(startTime) && currentTime.Before(endTime) {
-					if len(m.Schedule.Recurrence.RepeatOn) == 0 {
-						return true
-					} else if slices.Contains(m.Schedule.Recurrence.RepeatOn, RepeatOn(strings.ToLower(currentTime.Weekday().String()))) {
-						return true
-					}
+				if r.RepeatType == RepeatTypeWeekly && len(r.RepeatOn) > 0 && !slices.Contains(r.RepeatOn, RepeatOn(strings.ToLower(day.Weekday().String()))) {
+					continue
 				}
-			case RepeatTypeMonthly:
-				// if the current time in the timezone is between the start and end time on the day of the current month
-				startTime := time.Date(currentTime.Year(), currentTime.Month(), start.Day(), start.Hour(), start.Minute(), 0, 0, loc)
-				endTime := time.Date(currentTime.Year(), currentTime.Month(), end.Day(), end.Hour(), end.Minute(), 0, 0, loc)
-				zap.L().Info("checking monthly schedule", zap.Any("rule", ruleID), zap.String("maintenance", m.Name), zap.Time("currentTime", currentTime), zap.Time("startTime", startTime), zap.Time("endTime", endTime))
-				if currentTime.After(startTime) && currentTime.Before(endTime) && currentTime.Day() == start.Day() {
-					return true
+				return day, true
+			}
+			return time.Time{}, false
+		}, nil
+	case RepeatTypeMonthly:
+		return func(t time.Time) (time.Time, bool) {
+			t = t.In(loc)
+			for i := 0; i <= 12; i++ {
+				// the months without the day of the start are skipped
+				day := time.Date(t.Year(), t.Month()+time.Month(i), start.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+				if day.Day() != start.Day() || day.Before(t) {
+					continue
 				}
+				return day, true
 			}
+			return time.Time{}, false
+		}, nil
+	case RepeatTypeCron:
+		c, err := parseCron(r.Cron)
+		if err != nil {
+			return nil, err
 		}
+		return func(t time.Time) (time.Time, bool) {
+			return c.next(t.In(loc))
+		}, nil
 	}
-	// If alert is not found, we return false
-	return false
+	return nil, fmt.Errorf("unknown repeat type %s", r.RepeatType)
 }
 
 func (m *PlannedMaintenance) IsActive(now time.Time) bool {
@@ -373,6 +581,28 @@ func (m *PlannedMaintenance) Validate() error {
 		if m.Schedule.Recurrence.EndTime != nil && m.Schedule.Recurrence.EndTime.Before(m.Schedule.Recurrence.StartTime) {
 			return errors.New("end time cannot be before start time")
 		}
+		if m.Schedule.Recurrence.RepeatType == RepeatTypeCron {
+			if m.Schedule.Recurrence.Cron == "" {
+				return ErrMissingCron
+			}
+			if _, err := parseCron(m.Schedule.Recurrence.Cron); err != nil {
+				return err
+			}
+		} else if m.Schedule.Recurrence.Cron != "" {
+			return errors.New("cron expression requires the cron repeat type")
+		}
+	}
+
+	for _, date := range m.Schedule.Exclusions {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("invalid exclusion date %q, expected YYYY-MM-DD", date)
+		}
+	}
+
+	if m.Matchers != nil {
+		if _, err := m.Matchers.matchers(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -396,28 +626,32 @@ func (m PlannedMaintenance) MarshalJSON() ([]byte, error) {
 	}
 
 	return json.Marshal(struct {
-		Id          int64     `json:"id" db:"id"`
-		Name        string    `json:"name" db:"name"`
-		Description string    `json:"description" db:"description"`
-		Schedule    *Schedule `json:"schedule" db:"schedule"`
-		AlertIds    *AlertIds `json:"alertIds" db:"alert_ids"`
-		CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-		CreatedBy   string    `json:"createdBy" db:"created_by"`
-		UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-		UpdatedBy   string    `json:"updatedBy" db:"updated_by"`
-		Status      string    `json:"status"`
-		Kind        string    `json:"kind"`
+		Id          int64          `json:"id" db:"id"`
+		Name        string         `json:"name" db:"name"`
+		Description string         `json:"description" db:"description"`
+		Schedule    *Schedule      `json:"schedule" db:"schedule"`
+		AlertIds    *AlertIds      `json:"alertIds" db:"alert_ids"`
+		Matchers    *AlertMatchers `json:"matchers,omitempty"`
+		CreatedAt   time.Time      `json:"createdAt" db:"created_at"`
+		CreatedBy   string         `json:"createdBy" db:"created_by"`
+		UpdatedAt   time.Time      `json:"updatedAt" db:"updated_at"`
+		UpdatedBy   string         `json:"updatedBy" db:"updated_by"`
+		Status      string         `json:"status"`
+		Kind        string         `json:"kind"`
+		Managed     bool           `json:"managed,omitempty"`
 	}{
 		Id:          m.Id,
 		Name:        m.Name,
 		Description: m.Description,
 		Schedule:    m.Schedule,
 		AlertIds:    m.AlertIds,
+		Matchers:    m.Matchers,
 		CreatedAt:   m.CreatedAt,
 		CreatedBy:   m.CreatedBy,
 		UpdatedAt:   m.UpdatedAt,
 		UpdatedBy:   m.UpdatedBy,
 		Status:      status,
 		Kind:        kind,
+		Managed:     m.Managed,
 	})
 }
@@ -219,6 +219,84 @@ func TestShouldSkipMaintenance(t *testing.T) {
 			ts:       time.Date(2024, 05, 04, 12, 10, 0, 0, time.UTC),
 			expected: true,
 		},
+		{
+			name: "recurring maintenance, cron on 2nd tuesday from 02:00 to 03:00",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "UTC",
+					Recurrence: &Recurrence{
+						StartTime:  time.Date(2024, 04, 01, 0, 0, 0, 0, time.UTC),
+						Duration:   Duration(time.Hour),
+						RepeatType: RepeatTypeCron,
+						Cron:       "0 2 * * TUE#2",
+					},
+				},
+			},
+			ts:       time.Date(2024, 05, 14, 2, 30, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name: "recurring maintenance, cron on 2nd tuesday, 1st tuesday",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "UTC",
+					Recurrence: &Recurrence{
+						StartTime:  time.Date(2024, 04, 01, 0, 0, 0, 0, time.UTC),
+						Duration:   Duration(time.Hour),
+						RepeatType: RepeatTypeCron,
+						Cron:       "0 2 * * TUE#2",
+					},
+				},
+			},
+			ts:       time.Date(2024, 05, 07, 2, 30, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name: "recurring maintenance, cron on weekdays, excluded date",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "UTC",
+					Recurrence: &Recurrence{
+						StartTime:  time.Date(2024, 01, 01, 0, 0, 0, 0, time.UTC),
+						Duration:   Duration(time.Hour),
+						RepeatType: RepeatTypeCron,
+						Cron:       "0 2 * * MON-FRI",
+					},
+					Exclusions: []string{"2024-12-25"},
+				},
+			},
+			ts:       time.Date(2024, 12, 25, 2, 30, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name: "recurring maintenance, repeat daily, excluded date",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "UTC",
+					Recurrence: &Recurrence{
+						StartTime:  time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+						Duration:   Duration(time.Hour * 2),
+						RepeatType: RepeatTypeDaily,
+					},
+					Exclusions: []string{"2024-04-04"},
+				},
+			},
+			ts:       time.Date(2024, 04, 04, 12, 10, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name: "fixed planned maintenance scoped by labels",
+			maintenance: &PlannedMaintenance{
+				Matchers: &AlertMatchers{`service="db"`},
+				Schedule: &Schedule{
+					Timezone:  "UTC",
+					StartTime: time.Now().UTC().Add(-time.Hour),
+					EndTime:   time.Now().UTC().Add(time.Hour * 2),
+				},
+			},
+			ts:       time.Now().UTC(),
+			expected: false,
+		},
 	}
 
 	for _, c := range cases {
@@ -228,3 +306,167 @@ func TestShouldSkipMaintenance(t *testing.T) {
 		}
 	}
 }
+
+func TestMaintenanceSilences(t *testing.T) {
+	maintenance := &PlannedMaintenance{
+		AlertIds: &AlertIds{"1"},
+		Matchers: &AlertMatchers{`service="db"`, `env=~"prod|staging"`},
+		Schedule: &Schedule{
+			Timezone:  "UTC",
+			StartTime: time.Date(2024, 04, 04, 12, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2024, 04, 04, 14, 0, 0, 0, time.UTC),
+		},
+	}
+	inWindow := time.Date(2024, 04, 04, 13, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		ruleID   string
+		labels   map[string]string
+		ts       time.Time
+		expected bool
+	}{
+		{name: "matching labels", ruleID: "1", labels: map[string]string{"service": "db", "env": "prod"}, ts: inWindow, expected: true},
+		{name: "other labels", ruleID: "1", labels: map[string]string{"service": "api", "env": "prod"}, ts: inWindow, expected: false},
+		{name: "other rule", ruleID: "2", labels: map[string]string{"service": "db", "env": "prod"}, ts: inWindow, expected: false},
+		{name: "outside of the window", ruleID: "1", labels: map[string]string{"service": "db", "env": "prod"}, ts: inWindow.Add(2 * time.Hour), expected: false},
+	}
+	for _, c := range cases {
+		if result := maintenance.silences(c.ruleID, c.labels, c.ts); result != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, result)
+		}
+	}
+}
+
+func TestMaintenanceNextWindows(t *testing.T) {
+	from := time.Date(2024, 05, 14, 2, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		schedule *Schedule
+		expected []time.Time
+	}{
+		{
+			name: "cron on 2nd tuesday, current window first",
+			schedule: &Schedule{
+				Timezone: "UTC",
+				Recurrence: &Recurrence{
+					Duration:   Duration(time.Hour),
+					RepeatType: RepeatTypeCron,
+					Cron:       "0 2 * * TUE#2",
+				},
+			},
+			expected: []time.Time{
+				time.Date(2024, 05, 14, 2, 0, 0, 0, time.UTC),
+				time.Date(2024, 06, 11, 2, 0, 0, 0, time.UTC),
+				time.Date(2024, 07, 9, 2, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "cron on the last day of the month with an exclusion",
+			schedule: &Schedule{
+				Timezone:   "UTC",
+				Exclusions: []string{"2024-06-30"},
+				Recurrence: &Recurrence{
+					Duration:   Duration(time.Hour),
+					RepeatType: RepeatTypeCron,
+					Cron:       "0 22 L * *",
+				},
+			},
+			expected: []time.Time{
+				time.Date(2024, 05, 31, 22, 0, 0, 0, time.UTC),
+				time.Date(2024, 07, 31, 22, 0, 0, 0, time.UTC),
+				time.Date(2024, 8, 31, 22, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "weekly on monday and friday until the end of the recurrence",
+			schedule: &Schedule{
+				Timezone: "UTC",
+				Recurrence: &Recurrence{
+					StartTime:  time.Date(2024, 01, 01, 23, 0, 0, 0, time.UTC),
+					EndTime:    timePtr(time.Date(2024, 05, 21, 0, 0, 0, 0, time.UTC)),
+					Duration:   Duration(2 * time.Hour),
+					RepeatType: RepeatTypeWeekly,
+					RepeatOn:   []RepeatOn{RepeatOnMonday, RepeatOnFriday},
+				},
+			},
+			expected: []time.Time{
+				time.Date(2024, 05, 17, 23, 0, 0, 0, time.UTC),
+				time.Date(2024, 05, 20, 23, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "fixed and monthly",
+			schedule: &Schedule{
+				Timezone:  "UTC",
+				StartTime: time.Date(2024, 05, 20, 0, 0, 0, 0, time.UTC),
+				EndTime:   time.Date(2024, 05, 21, 0, 0, 0, 0, time.UTC),
+				Recurrence: &Recurrence{
+					StartTime:  time.Date(2024, 01, 31, 12, 0, 0, 0, time.UTC),
+					Duration:   Duration(time.Hour),
+					RepeatType: RepeatTypeMonthly,
+				},
+			},
+			expected: []time.Time{
+				time.Date(2024, 05, 20, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 05, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 07, 31, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		windows, err := c.schedule.NextWindows(from, 3)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", c.name, err)
+		}
+		if len(windows) != len(c.expected) {
+			t.Fatalf("%s: expected %d windows, got %v", c.name, len(c.expected), windows)
+		}
+		for idx, window := range windows {
+			if !window.Start.Equal(c.expected[idx]) {
+				t.Errorf("%s: expected window %d to start at %v, got %v", c.name, idx, c.expected[idx], window.Start)
+			}
+			if window.End.Sub(window.Start) != time.Duration(c.schedule.Recurrence.Duration) && c.schedule.StartTime.IsZero() {
+				t.Errorf("%s: unexpected window duration %v", c.name, window.End.Sub(window.Start))
+			}
+		}
+	}
+}
+
+func TestMaintenanceValidateCron(t *testing.T) {
+	cases := []struct {
+		name    string
+		mutate  func(m *PlannedMaintenance)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(m *PlannedMaintenance) {}},
+		{name: "missing cron", mutate: func(m *PlannedMaintenance) { m.Schedule.Recurrence.Cron = "" }, wantErr: true},
+		{name: "invalid cron", mutate: func(m *PlannedMaintenance) { m.Schedule.Recurrence.Cron = "0 25 * * *" }, wantErr: true},
+		{name: "cron with daily repeat", mutate: func(m *PlannedMaintenance) { m.Schedule.Recurrence.RepeatType = RepeatTypeDaily }, wantErr: true},
+		{name: "invalid exclusion", mutate: func(m *PlannedMaintenance) { m.Schedule.Exclusions = []string{"25/12/2024"} }, wantErr: true},
+		{name: "invalid matcher", mutate: func(m *PlannedMaintenance) { m.Matchers = &AlertMatchers{"service"} }, wantErr: true},
+	}
+	for _, c := range cases {
+		m := &PlannedMaintenance{
+			Name: "weekdays",
+			Schedule: &Schedule{
+				Timezone: "UTC",
+				Recurrence: &Recurrence{
+					Duration:   Duration(time.Hour),
+					RepeatType: RepeatTypeCron,
+					Cron:       "0 2 * * MON-FRI",
+				},
+			},
+		}
+		c.mutate(m)
+		if err := m.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("%s: expected error %v, got %v", c.name, c.wantErr, err)
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
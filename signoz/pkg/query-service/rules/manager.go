@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,11 +18,13 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	"go.signoz.io/signoz/pkg/query-service/cache"
+	"go.signoz.io/signoz/pkg/query-service/constants"
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	pqle "go.signoz.io/signoz/pkg/query-service/pqlEngine"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 )
 
 type PrepareTaskOptions struct {
@@ -96,6 +99,8 @@ type ManagerOptions struct {
 	UseLogsNewSchema    bool
 	UseTraceNewSchema   bool
 	PrepareTestRuleFunc func(opts PrepareTestRuleOptions) (int, *model.ApiError)
+	// PrepareBackfillRuleFunc creates the rules evaluated over a past range
+	PrepareBackfillRuleFunc func(opts PrepareTestRuleOptions, ruleOpts ...RuleOption) (Rule, error)
 }
 
 // The Manager manages recording and alerting rules.
@@ -106,7 +111,22 @@ type Manager struct {
 	mtx   sync.RWMutex
 	block chan struct{}
 	// Notifier sends messages through alert manager
-	notifier *am.Notifier
+	notifier am.AlertNotifier
+	// dispatcher is the embedded alert manager, it is nil when the
+	// notifications are sent by an external alert manager
+	dispatcher *am.Dispatcher
+	// inhibitor suppresses the notifications of the rules while their dependencies fire
+	inhibitor *ruleInhibitor
+	// provisioner syncs the rules, channels and downtime schedules from files,
+	// it is nil when provisioning is disabled
+	provisioner *Provisioner
+	// coordinator distributes the rules across the replicas sharing the
+	// rules db, it is nil when every replica evaluates all the rules
+	coordinator *Coordinator
+	// loadedRules holds the stored definition of the rules by task name
+	// as last synced with the tasks, it is guarded by loadedMtx
+	loadedRules map[string]string
+	loadedMtx   sync.Mutex
 
 	// datastore to store alert definitions
 	ruleDB RuleDB
@@ -118,6 +138,8 @@ type Manager struct {
 	cache               cache.Cache
 	prepareTaskFunc     func(opts PrepareTaskOptions) (Task, error)
 	prepareTestRuleFunc func(opts PrepareTestRuleOptions) (int, *model.ApiError)
+	// prepareBackfillRuleFunc creates the rules evaluated in a backfill
+	prepareBackfillRuleFunc func(opts PrepareTestRuleOptions, ruleOpts ...RuleOption) (Rule, error)
 
 	UseLogsNewSchema  bool
 	UseTraceNewSchema bool
@@ -142,6 +164,9 @@ func defaultOptions(o *ManagerOptions) *ManagerOptions {
 	if o.PrepareTestRuleFunc == nil {
 		o.PrepareTestRuleFunc = defaultTestNotification
 	}
+	if o.PrepareBackfillRuleFunc == nil {
+		o.PrepareBackfillRuleFunc = defaultPrepareBackfillRule
+	}
 	return o
 }
 
@@ -192,8 +217,51 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 		// create promql rule task for evalution
 		task = newTask(TaskTypeProm, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
 
+	} else if opts.Rule.RuleType == RuleTypeSLO {
+		// create slo rule
+		sr, err := NewSLORule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.RuleDB,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			WithEvalDelay(opts.ManagerOpts.EvalDelay),
+		)
+
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, sr)
+
+		// create ch rule task for evalution
+		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+
+	} else if opts.Rule.RuleType == RuleTypeRecording {
+		// create recording rule
+		rr, err := NewRecordingRule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			WithEvalDelay(opts.ManagerOpts.EvalDelay),
+		)
+
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, rr)
+
+		// create ch rule task for evalution
+		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+
 	} else {
-		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s", opts.Rule.RuleType, RuleTypeProm, RuleTypeThreshold)
+		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s, %s, %s", opts.Rule.RuleType, RuleTypeProm, RuleTypeThreshold, RuleTypeSLO, RuleTypeRecording)
 	}
 
 	return task, nil
@@ -204,28 +272,49 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 func NewManager(o *ManagerOptions) (*Manager, error) {
 
 	o = defaultOptions(o)
-	// here we just initiate notifier, it will be started
-	// in run()
-	notifier, err := am.NewNotifier(&o.NotifierOpts, nil)
-	if err != nil {
-		// todo(amol): rethink on this, the query service
-		// should not be down because alert manager is not available
-		return nil, err
-	}
 
-	amManager, err := am.New()
-	if err != nil {
-		return nil, err
+	var notifier am.AlertNotifier
+	var dispatcher *am.Dispatcher
+	var amManager am.Manager
+	if constants.IsEmbeddedAlertManagerEnabled() {
+		config, err := am.LoadConfig(constants.AmConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		dispatcher = am.NewDispatcher(config, o.NotifierOpts.Timeout)
+		notifier = dispatcher
+		amManager = am.NewEmbeddedManager(dispatcher)
+	} else {
+		// here we just initiate notifier, it will be started
+		// in run()
+		amNotifier, err := am.NewNotifier(&o.NotifierOpts, nil)
+		if err != nil {
+			// todo(amol): rethink on this, the query service
+			// should not be down because alert manager is not available
+			return nil, err
+		}
+		notifier = amNotifier
+
+		amManager, err = am.New()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	db := NewRuleDB(o.DBConn, amManager)
 
+	if dispatcher != nil {
+		syncChannels(db, dispatcher)
+	}
+
 	telemetry.GetInstance().SetAlertsInfoCallback(db.GetAlertsInfo)
 
 	m := &Manager{
 		tasks:               map[string]Task{},
 		rules:               map[string]Rule{},
 		notifier:            notifier,
+		dispatcher:          dispatcher,
+		inhibitor:           newRuleInhibitor(),
 		ruleDB:              db,
 		opts:                o,
 		block:               make(chan struct{}),
@@ -235,10 +324,56 @@ func NewManager(o *ManagerOptions) (*Manager, error) {
 		cache:               o.Cache,
 		prepareTaskFunc:     o.PrepareTaskFunc,
 		prepareTestRuleFunc: o.PrepareTestRuleFunc,
+
+		prepareBackfillRuleFunc: o.PrepareBackfillRuleFunc,
+	}
+
+	if path := constants.GetAlertsProvisioningPath(); path != "" {
+		m.provisioner = NewProvisioner(m, path, constants.GetAlertsProvisioningInterval())
+	}
+	if constants.IsRulesHAEnabled() {
+		m.coordinator = NewCoordinator(m, constants.GetRulesReplicaID(), constants.GetRulesLeaseDuration())
+		m.inhibitor.storedAlerts = m.coordinator.storedAlerts
 	}
 	return m, nil
 }
 
+// syncChannels sets the receivers of the embedded alert manager to the
+// notification channels stored in the db
+func syncChannels(db RuleDB, dispatcher *am.Dispatcher) {
+	channels, apiErr := db.GetChannels()
+	if apiErr != nil {
+		zap.L().Error("failed to load notification channels", zap.Error(apiErr.Err))
+		return
+	}
+	names := make(map[string]struct{}, len(*channels))
+	for _, channel := range *channels {
+		names[channel.Name] = struct{}{}
+		receiver := &am.Receiver{}
+		if err := json.Unmarshal([]byte(channel.Data), receiver); err != nil {
+			zap.L().Error("invalid notification channel data", zap.String("name", channel.Name), zap.Error(err))
+			continue
+		}
+		if err := dispatcher.SetReceiver(receiver); err != nil {
+			zap.L().Error("failed to load notification channel", zap.String("name", channel.Name), zap.Error(err))
+		}
+	}
+	for _, name := range dispatcher.ReceiverNames() {
+		if _, ok := names[name]; !ok {
+			dispatcher.RemoveReceiver(name)
+		}
+	}
+}
+
+// reloadChannels syncs the embedded alert manager with the channels created,
+// edited or deleted in the db by the other replicas or by the provisioning
+func (m *Manager) reloadChannels() {
+	if m.dispatcher == nil {
+		return
+	}
+	syncChannels(m.ruleDB, m.dispatcher)
+}
+
 func (m *Manager) Start() {
 	if err := m.initiate(); err != nil {
 		zap.L().Error("failed to initialize alerting rules manager", zap.Error(err))
@@ -250,6 +385,37 @@ func (m *Manager) RuleDB() RuleDB {
 	return m.ruleDB
 }
 
+// Dispatcher returns the embedded alert manager, it is nil when the
+// notifications are sent by an external alert manager
+func (m *Manager) Dispatcher() *am.Dispatcher {
+	return m.dispatcher
+}
+
+// ProvisioningStatus returns the status of the last sync of the provisioning files
+func (m *Manager) ProvisioningStatus() *ProvisioningStatus {
+	if m.provisioner == nil {
+		return &ProvisioningStatus{Objects: []ProvisionedObjectStatus{}}
+	}
+	return m.provisioner.Status()
+}
+
+// IsProvisioned returns true if the object is managed by the provisioning
+// files, such objects are read-only in the API
+func (m *Manager) IsProvisioned(ctx context.Context, kind ProvisionKind, id string) bool {
+	if m.provisioner == nil {
+		return false
+	}
+	return m.provisioner.IsManaged(ctx, kind, id)
+}
+
+// ProvisionedObjects returns the ids of the objects of the kind managed by the provisioning files
+func (m *Manager) ProvisionedObjects(ctx context.Context, kind ProvisionKind) map[string]struct{} {
+	if m.provisioner == nil {
+		return map[string]struct{}{}
+	}
+	return m.provisioner.ManagedObjects(ctx, kind)
+}
+
 func (m *Manager) Pause(b bool) {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
@@ -258,6 +424,14 @@ func (m *Manager) Pause(b bool) {
 	}
 }
 
+// RuleOwnership returns the replicas evaluating the rules and the owner of each rule
+func (m *Manager) RuleOwnership(ctx context.Context) (*RuleOwnership, error) {
+	if m.coordinator == nil {
+		return &RuleOwnership{Enabled: false, Replicas: []RuleReplica{}, Rules: []RuleOwner{}}, nil
+	}
+	return m.coordinator.Ownership(ctx, time.Now())
+}
+
 func (m *Manager) initiate() error {
 	storedRules, err := m.ruleDB.GetStoredRules(context.Background())
 	if err != nil {
@@ -270,28 +444,13 @@ func (m *Manager) initiate() error {
 
 	for _, rec := range storedRules {
 		taskName := fmt.Sprintf("%d-groupname", rec.Id)
-		parsedRule, err := ParsePostableRule([]byte(rec.Data))
-
+		parsedRule, err := parseStoredRule(rec, taskName)
 		if err != nil {
-			if errors.Is(err, ErrFailedToParseJSON) {
-				zap.L().Info("failed to load rule in json format, trying yaml now:", zap.String("name", taskName))
-
-				// see if rule is stored in yaml format
-				parsedRule, err = parsePostableRule([]byte(rec.Data), RuleDataKindYaml)
-
-				if err != nil {
-					zap.L().Error("failed to parse and initialize yaml rule", zap.String("name", taskName), zap.Error(err))
-					// just one rule is being parsed so expect just one error
-					loadErrors = append(loadErrors, err)
-					continue
-				}
-			} else {
-				zap.L().Error("failed to parse and initialize rule", zap.String("name", taskName), zap.Error(err))
-				// just one rule is being parsed so expect just one error
-				loadErrors = append(loadErrors, err)
-				continue
-			}
+			// just one rule is being parsed so expect just one error
+			loadErrors = append(loadErrors, err)
+			continue
 		}
+		m.setLoadedRule(taskName, rec.Data)
 		if !parsedRule.Disabled {
 			err := m.addTask(parsedRule, taskName)
 			if err != nil {
@@ -307,6 +466,87 @@ func (m *Manager) initiate() error {
 	return nil
 }
 
+// parseStoredRule parses a rule stored in json or in yaml format
+func parseStoredRule(rec StoredRule, taskName string) (*PostableRule, error) {
+	parsedRule, err := ParsePostableRule([]byte(rec.Data))
+	if err == nil {
+		return parsedRule, nil
+	}
+	if !errors.Is(err, ErrFailedToParseJSON) {
+		zap.L().Error("failed to parse and initialize rule", zap.String("name", taskName), zap.Error(err))
+		return nil, err
+	}
+
+	zap.L().Info("failed to load rule in json format, trying yaml now:", zap.String("name", taskName))
+
+	// see if rule is stored in yaml format
+	parsedRule, err = parsePostableRule([]byte(rec.Data), RuleDataKindYaml)
+	if err != nil {
+		zap.L().Error("failed to parse and initialize yaml rule", zap.String("name", taskName), zap.Error(err))
+		return nil, err
+	}
+	return parsedRule, nil
+}
+
+// setLoadedRule records the stored definition the task of a rule was last
+// synced with, an empty definition forgets the rule
+func (m *Manager) setLoadedRule(taskName string, data string) {
+	m.loadedMtx.Lock()
+	defer m.loadedMtx.Unlock()
+	if m.loadedRules == nil {
+		m.loadedRules = map[string]string{}
+	}
+	if data == "" {
+		delete(m.loadedRules, taskName)
+		return
+	}
+	m.loadedRules[taskName] = data
+}
+
+// reloadRules syncs the tasks with the rules created, edited or deleted in
+// the rules db by the other replicas since the rules were last loaded
+func (m *Manager) reloadRules(ctx context.Context) {
+	if m.opts == nil || m.opts.DisableRules {
+		return
+	}
+
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		zap.L().Error("failed to reload the rules", zap.Error(err))
+		return
+	}
+
+	m.loadedMtx.Lock()
+	loaded := maps.Clone(m.loadedRules)
+	m.loadedMtx.Unlock()
+
+	stored := map[string]struct{}{}
+	for _, rec := range storedRules {
+		taskName := prepareTaskName(int64(rec.Id))
+		stored[taskName] = struct{}{}
+		if data, ok := loaded[taskName]; ok && data == rec.Data {
+			continue
+		}
+
+		parsedRule, err := parseStoredRule(rec, taskName)
+		if err != nil {
+			continue
+		}
+		if err := m.syncRuleStateWithTask(taskName, parsedRule); err != nil {
+			zap.L().Error("failed to reload the rule", zap.String("name", taskName), zap.Error(err))
+			continue
+		}
+		m.setLoadedRule(taskName, rec.Data)
+	}
+
+	for taskName := range loaded {
+		if _, ok := stored[taskName]; !ok {
+			m.deleteTask(taskName)
+			m.setLoadedRule(taskName, "")
+		}
+	}
+}
+
 // Run starts processing of the rule manager.
 func (m *Manager) run() {
 	// initiate notifier
@@ -314,10 +554,28 @@ func (m *Manager) run() {
 
 	// initiate blocked tasks
 	close(m.block)
+
+	if m.provisioner != nil {
+		go m.provisioner.Run()
+	}
+
+	if m.coordinator != nil {
+		go m.coordinator.Run()
+	}
 }
 
 // Stop the rule manager's rule evaluation cycles.
 func (m *Manager) Stop() {
+	// stop syncing the files before the tasks are stopped
+	if m.provisioner != nil {
+		m.provisioner.Stop()
+	}
+
+	// hand over the rules to the other replicas
+	if m.coordinator != nil {
+		m.coordinator.Stop()
+	}
+
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 
@@ -350,6 +608,7 @@ func (m *Manager) EditRule(ctx context.Context, ruleStr string, id string) error
 		if err != nil {
 			return err
 		}
+		m.setLoadedRule(taskName, ruleStr)
 	}
 
 	return nil
@@ -383,6 +642,7 @@ func (m *Manager) editTask(rule *PostableRule, taskName string) error {
 
 	for _, r := range newTask.Rules() {
 		m.rules[r.ID()] = r
+		m.inhibitor.setRule(r)
 	}
 
 	// If there is an old task with the same identifier, stop it and wait for
@@ -398,6 +658,11 @@ func (m *Manager) editTask(rule *PostableRule, taskName string) error {
 		oldTask.Stop()
 		newTask.CopyState(oldTask)
 	}
+	// the task is evaluated by the replica holding its lease
+	if m.coordinator != nil {
+		newTask.Pause(!m.coordinator.Owns(taskName))
+	}
+
 	go func() {
 		// Wait with starting evaluation until the rule manager
 		// is told to run. This is necessary to avoid running
@@ -427,6 +692,7 @@ func (m *Manager) DeleteRule(ctx context.Context, id string) error {
 		zap.L().Error("failed to delete the rule from rule db", zap.String("id", id), zap.Error(err))
 		return err
 	}
+	m.setLoadedRule(taskName, "")
 
 	return nil
 }
@@ -441,6 +707,7 @@ func (m *Manager) deleteTask(taskName string) {
 		oldg.Stop()
 		delete(m.tasks, taskName)
 		delete(m.rules, RuleIdFromTaskName(taskName))
+		m.inhibitor.removeRule(RuleIdFromTaskName(taskName))
 		zap.L().Debug("rule task deleted", zap.String("name", taskName))
 	} else {
 		zap.L().Info("rule not found for deletion", zap.String("name", taskName))
@@ -471,6 +738,9 @@ func (m *Manager) CreateRule(ctx context.Context, ruleStr string) (*GettableRule
 	if err != nil {
 		return nil, err
 	}
+	if !m.opts.DisableRules {
+		m.setLoadedRule(taskName, ruleStr)
+	}
 
 	gettableRule := &GettableRule{
 		Id:           fmt.Sprintf("%d", lastInsertId),
@@ -506,6 +776,7 @@ func (m *Manager) addTask(rule *PostableRule, taskName string) error {
 
 	for _, r := range newTask.Rules() {
 		m.rules[r.ID()] = r
+		m.inhibitor.setRule(r)
 	}
 
 	// If there is an another task with the same identifier, raise an error
@@ -514,6 +785,11 @@ func (m *Manager) addTask(rule *PostableRule, taskName string) error {
 		return fmt.Errorf("a rule with the same name already exists")
 	}
 
+	// the task is evaluated by the replica holding its lease
+	if m.coordinator != nil {
+		newTask.Pause(!m.coordinator.Owns(taskName))
+	}
+
 	go func() {
 		// Wait with starting evaluation until the rule manager
 		// is told to run. This is necessary to avoid running
@@ -601,6 +877,9 @@ func (m *Manager) prepareNotifyFunc() NotifyFunc {
 	return func(ctx context.Context, expr string, alerts ...*Alert) {
 		var res []*am.Alert
 
+		alerts = m.inhibitor.filter(ctx, time.Now(), alerts)
+		alerts = m.filterSilenced(ctx, time.Now(), alerts)
+
 		for _, alert := range alerts {
 			generatorURL := alert.GeneratorURL
 			if generatorURL == "" {
@@ -628,6 +907,36 @@ func (m *Manager) prepareNotifyFunc() NotifyFunc {
 	}
 }
 
+// filterSilenced drops the alerts silenced by the maintenances scoped by labels
+func (m *Manager) filterSilenced(ctx context.Context, ts time.Time, alerts []*Alert) []*Alert {
+	if len(alerts) == 0 {
+		return alerts
+	}
+	maintenance, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	if err != nil {
+		zap.L().Error("failed to get planned maintenance", zap.Error(err))
+		return alerts
+	}
+
+	res := make([]*Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		ruleID := alert.Labels.Get(labels.AlertRuleIdLabel)
+		lbls := alert.Labels.Map()
+		silenced := false
+		for _, mw := range maintenance {
+			if mw.silences(ruleID, lbls, ts) {
+				zap.L().Info("alert silenced by maintenance", zap.String("ruleid", ruleID), zap.String("maintenance", mw.Name))
+				silenced = true
+				break
+			}
+		}
+		if !silenced {
+			res = append(res, alert)
+		}
+	}
+	return res
+}
+
 func (m *Manager) ListActiveRules() ([]Rule, error) {
 	ruleList := []Rule{}
 
@@ -648,6 +957,7 @@ func (m *Manager) ListRuleStates(ctx context.Context) (*GettableRules, error) {
 
 	// initiate response object
 	resp := make([]*GettableRule, 0)
+	provisioned := m.ProvisionedObjects(ctx, ProvisionKindRule)
 
 	for _, s := range storedRules {
 
@@ -670,6 +980,7 @@ func (m *Manager) ListRuleStates(ctx context.Context) (*GettableRules, error) {
 		ruleResponse.CreatedBy = s.CreatedBy
 		ruleResponse.UpdatedAt = s.UpdatedAt
 		ruleResponse.UpdatedBy = s.UpdatedBy
+		_, ruleResponse.Managed = provisioned[ruleResponse.Id]
 		resp = append(resp, ruleResponse)
 	}
 
@@ -697,6 +1008,7 @@ func (m *Manager) GetRule(ctx context.Context, id string) (*GettableRule, error)
 	r.CreatedBy = s.CreatedBy
 	r.UpdatedAt = s.UpdatedAt
 	r.UpdatedBy = s.UpdatedBy
+	r.Managed = m.IsProvisioned(ctx, ProvisionKindRule, r.Id)
 
 	return r, nil
 }
@@ -786,6 +1098,7 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 
 		return nil, err
 	}
+	m.setLoadedRule(taskName, string(patchedRuleBytes))
 
 	// prepare http response
 	response := GettableRule{
@@ -829,3 +1142,47 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 
 	return alertCount, apiErr
 }
+
+// Backfill evaluates the rule at its frequency over a past range without
+// sending notifications or storing the state history, and returns the state
+// transitions the rule would have gone through
+func (m *Manager) Backfill(ctx context.Context, req *BackfillRequest) (*BackfillResult, *model.ApiError) {
+	if err := req.Validate(); err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	parsedRule, err := ParsePostableRule(req.Rule)
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	start, end := time.UnixMilli(req.Start), time.UnixMilli(req.End)
+	step := time.Duration(parsedRule.Frequency)
+	if evaluations := int64(end.Sub(start)/step) + 1; evaluations > maxBackfillEvaluations {
+		return nil, model.BadRequest(fmt.Errorf("backfill needs %d evaluations, the maximum is %d; shorten the range or increase the frequency", evaluations, maxBackfillEvaluations))
+	}
+
+	transitions := []model.RuleStateHistory{}
+	rule, err := m.prepareBackfillRuleFunc(PrepareTestRuleOptions{
+		Rule:              parsedRule,
+		RuleDB:            m.ruleDB,
+		Logger:            m.logger,
+		Reader:            m.reader,
+		Cache:             m.cache,
+		FF:                m.featureFlags,
+		ManagerOpts:       m.opts,
+		UseLogsNewSchema:  m.opts.UseLogsNewSchema,
+		UseTraceNewSchema: m.opts.UseTraceNewSchema,
+	},
+		WithHoldDuration(time.Duration(req.HoldDuration)),
+		WithDryRun(func(items []model.RuleStateHistory) {
+			transitions = append(transitions, items...)
+		}),
+	)
+	if err != nil {
+		zap.L().Error("failed to prepare rule for backfill", zap.Error(err))
+		return nil, model.BadRequest(err)
+	}
+
+	return runBackfill(ctx, rule, start, end, step, &transitions)
+}
@@ -132,7 +132,8 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 			continue
 		}
 
-		alertSmpl, shouldAlert := r.ShouldAlert(toCommonSeries(series))
+		commonSeries := toCommonSeries(series)
+		alertSmpl, shouldAlert := r.ShouldAlert(commonSeries)
 		if !shouldAlert {
 			continue
 		}
@@ -140,7 +141,10 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 
 		threshold := valueFormatter.Format(r.targetVal(), r.Unit())
 
-		tmplData := AlertTemplateData(l, valueFormatter.Format(alertSmpl.V, r.Unit()), threshold)
+		tmplData := AlertTemplateDataWithContext(l, valueFormatter.Format(alertSmpl.V, r.Unit()), threshold, AlertTemplateContext{
+			Unit:   r.Unit(),
+			Points: removeGroupinSetPoints(commonSeries),
+		})
 		// Inject some convenience variables that are easier to remember for users
 		// who are not used to Go's templating system.
 		defs := "{{$labels := .Labels}}{{$value := .Value}}{{$threshold := .Threshold}}"
@@ -36,6 +36,8 @@ type PromRuleTask struct {
 	notify NotifyFunc
 
 	ruleDB RuleDB
+	// restored is set once the persisted alert states of the rules are restored
+	restored bool
 }
 
 // newPromRuleTask holds rules that have promql condition
@@ -85,6 +87,12 @@ func (g *PromRuleTask) Pause(b bool) {
 	g.pause = b
 }
 
+func (g *PromRuleTask) isPaused() bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.pause
+}
+
 func (g *PromRuleTask) Run(ctx context.Context) {
 	defer close(g.terminated)
 
@@ -103,7 +111,9 @@ func (g *PromRuleTask) Run(ctx context.Context) {
 	})
 
 	iter := func() {
-
+		if g.isPaused() {
+			return
+		}
 		start := time.Now()
 		g.Eval(ctx, evalTimestamp)
 		timeSinceStart := time.Since(start)
@@ -317,6 +327,11 @@ func (g *PromRuleTask) CopyState(fromTask Task) error {
 func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 	zap.L().Info("promql rule task", zap.String("name", g.name), zap.Time("eval started at", ts))
 
+	if !g.restored {
+		restoreAlertStates(ctx, g.ruleDB, g.rules)
+		g.restored = true
+	}
+
 	maintenance, err := g.ruleDB.GetAllPlannedMaintenance(ctx)
 
 	if err != nil {
@@ -382,6 +397,7 @@ func (g *PromRuleTask) Eval(ctx context.Context, ts time.Time) {
 				return
 			}
 			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, g.notify)
+			saveAlertStates(ctx, g.ruleDB, rule)
 
 		}(i, rule)
 	}
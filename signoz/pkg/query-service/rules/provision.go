@@ -0,0 +1,610 @@
+package rules
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+)
+
+// ProvisionKind is the kind of an object provisioned from files
+type ProvisionKind string
+
+const (
+	ProvisionKindRule     ProvisionKind = "rule"
+	ProvisionKindChannel  ProvisionKind = "channel"
+	ProvisionKindDowntime ProvisionKind = "downtime"
+)
+
+// ProvisionStatus is the outcome of the reconciliation of a provisioned object
+type ProvisionStatus string
+
+const (
+	ProvisionStatusCreated ProvisionStatus = "created"
+	ProvisionStatusUpdated ProvisionStatus = "updated"
+	// ProvisionStatusDrifted means the object was changed or deleted outside
+	// of the files, it is overwritten with the definition in the files
+	ProvisionStatusDrifted ProvisionStatus = "drifted"
+	ProvisionStatusInSync  ProvisionStatus = "in_sync"
+	ProvisionStatusDeleted ProvisionStatus = "deleted"
+	ProvisionStatusError   ProvisionStatus = "error"
+)
+
+// ProvisionedObject maps an object of a provisioning file, by its stable id,
+// to the object created from it
+type ProvisionedObject struct {
+	Kind        ProvisionKind `db:"kind"`
+	ProvisionID string        `db:"provision_id"`
+	ObjectID    string        `db:"object_id"`
+	File        string        `db:"file"`
+	// Checksum is the checksum of the definition in the file
+	Checksum string `db:"checksum"`
+	// ObjectChecksum is the checksum of the stored object after it was
+	// provisioned, it differs from the stored object when the object drifted
+	ObjectChecksum string    `db:"object_checksum"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// ProvisionedObjectStatus is the status of an object after the last sync
+type ProvisionedObjectStatus struct {
+	Kind        ProvisionKind   `json:"kind"`
+	ProvisionID string          `json:"provisionId"`
+	ObjectID    string          `json:"objectId,omitempty"`
+	File        string          `json:"file"`
+	Status      ProvisionStatus `json:"status"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// ProvisioningStatus is the result of the last sync of the provisioning files
+type ProvisioningStatus struct {
+	Enabled  bool                      `json:"enabled"`
+	Path     string                    `json:"path,omitempty"`
+	LastSync *time.Time                `json:"lastSync,omitempty"`
+	Objects  []ProvisionedObjectStatus `json:"objects"`
+	// Errors are the errors of the files that could not be loaded
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Drifted returns the objects that were changed outside of the files
+func (s *ProvisioningStatus) Drifted() []ProvisionedObjectStatus {
+	drifted := []ProvisionedObjectStatus{}
+	for _, obj := range s.Objects {
+		if obj.Status == ProvisionStatusDrifted {
+			drifted = append(drifted, obj)
+		}
+	}
+	return drifted
+}
+
+// provisionFile is the format of the provisioning files, the rules and the
+// downtime schedules have an id field with their stable id, the channels are
+// identified by their name
+type provisionFile struct {
+	Rules             []map[string]interface{} `yaml:"rules"`
+	Channels          []map[string]interface{} `yaml:"channels"`
+	DowntimeSchedules []map[string]interface{} `yaml:"downtimeSchedules"`
+}
+
+// provisionItem is an object defined in a provisioning file
+type provisionItem struct {
+	kind ProvisionKind
+	id   string
+	file string
+	data map[string]interface{}
+}
+
+// definition returns the json definition of the item and its checksum
+func (i *provisionItem) definition() ([]byte, string, error) {
+	data, err := json.Marshal(i.data)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, checksum(data), nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// provisionKinds is the order in which the objects are created, the rules
+// refer to the channels and the downtime schedules refer to the rules. The
+// objects are deleted in the reverse order.
+var provisionKinds = []ProvisionKind{ProvisionKindChannel, ProvisionKindRule, ProvisionKindDowntime}
+
+// Provisioner keeps the rules, notification channels and downtime schedules
+// in sync with the definitions in the files of a directory
+type Provisioner struct {
+	manager  *Manager
+	dir      string
+	interval time.Duration
+
+	// mtx serializes the syncs and guards the status
+	mtx    sync.Mutex
+	status *ProvisioningStatus
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewProvisioner(m *Manager, dir string, interval time.Duration) *Provisioner {
+	return &Provisioner{
+		manager:  m,
+		dir:      dir,
+		interval: interval,
+		status:   &ProvisioningStatus{Enabled: true, Path: dir, Objects: []ProvisionedObjectStatus{}},
+		done:     make(chan struct{}),
+	}
+}
+
+// Run syncs the files at the interval until the provisioner is stopped
+func (p *Provisioner) Run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.syncIfLeaseHolder(context.Background())
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncIfLeaseHolder syncs the files, when the rules are distributed across
+// replicas only the replica holding the provisioning lease syncs them
+func (p *Provisioner) syncIfLeaseHolder(ctx context.Context) {
+	if c := p.manager.coordinator; c != nil && !c.AcquireProvisioningLease(ctx, time.Now()) {
+		return
+	}
+	p.Sync(ctx)
+}
+
+func (p *Provisioner) Stop() {
+	p.stopOnce.Do(func() { close(p.done) })
+}
+
+// Status returns the status of the last sync
+func (p *Provisioner) Status() *ProvisioningStatus {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.status
+}
+
+// IsManaged returns true if the object was created from a provisioning file
+func (p *Provisioner) IsManaged(ctx context.Context, kind ProvisionKind, objectID string) bool {
+	_, ok := p.ManagedObjects(ctx, kind)[objectID]
+	return ok
+}
+
+// ManagedObjects returns the ids of the objects of the kind created from the
+// provisioning files
+func (p *Provisioner) ManagedObjects(ctx context.Context, kind ProvisionKind) map[string]struct{} {
+	managed := map[string]struct{}{}
+	objects, err := p.manager.ruleDB.GetProvisionedObjects(ctx)
+	if err != nil {
+		zap.L().Error("failed to get provisioned objects", zap.Error(err))
+		return managed
+	}
+	for _, obj := range objects {
+		if obj.Kind == kind {
+			managed[obj.ObjectID] = struct{}{}
+		}
+	}
+	return managed
+}
+
+// Sync reconciles the objects with the files. The objects are matched to their
+// definitions by stable id, the objects whose definition was removed from the
+// files are deleted.
+func (p *Provisioner) Sync(ctx context.Context) *ProvisioningStatus {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := time.Now()
+	status := &ProvisioningStatus{Enabled: true, Path: p.dir, LastSync: &now, Objects: []ProvisionedObjectStatus{}}
+
+	items, failedFiles, errs := p.load()
+	for _, err := range errs {
+		status.Errors = append(status.Errors, err.Error())
+	}
+
+	stored, err := p.manager.ruleDB.GetProvisionedObjects(ctx)
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("failed to get provisioned objects: %v", err))
+		p.status = status
+		return status
+	}
+	existing := map[ProvisionKind]map[string]ProvisionedObject{}
+	for _, obj := range stored {
+		if existing[obj.Kind] == nil {
+			existing[obj.Kind] = map[string]ProvisionedObject{}
+		}
+		existing[obj.Kind][obj.ProvisionID] = obj
+	}
+
+	for _, kind := range provisionKinds {
+		for _, item := range items[kind] {
+			if kind == ProvisionKindDowntime {
+				p.resolveAlertIds(item, existing[ProvisionKindRule])
+			}
+			var prev *ProvisionedObject
+			if obj, ok := existing[kind][item.id]; ok {
+				prev = &obj
+			}
+			objStatus, obj := p.reconcile(ctx, item, prev)
+			if obj != nil {
+				if existing[kind] == nil {
+					existing[kind] = map[string]ProvisionedObject{}
+				}
+				existing[kind][item.id] = *obj
+			}
+			status.Objects = append(status.Objects, objStatus)
+		}
+	}
+
+	for idx := len(provisionKinds) - 1; idx >= 0; idx-- {
+		kind := provisionKinds[idx]
+		for id, obj := range existing[kind] {
+			if _, ok := items[kind][id]; ok {
+				continue
+			}
+			// the definitions of a file that failed to load are kept
+			if _, ok := failedFiles[obj.File]; ok {
+				continue
+			}
+			status.Objects = append(status.Objects, p.remove(ctx, obj))
+		}
+	}
+
+	p.status = status
+	return status
+}
+
+// load reads the definitions from the files of the directory, it returns the
+// files that could not be loaded
+func (p *Provisioner) load() (map[ProvisionKind]map[string]*provisionItem, map[string]struct{}, []error) {
+	items := map[ProvisionKind]map[string]*provisionItem{}
+	for _, kind := range provisionKinds {
+		items[kind] = map[string]*provisionItem{}
+	}
+	failedFiles := map[string]struct{}{}
+	var errs []error
+
+	entries, err := os.ReadDir(p.dir)
+	if err != nil {
+		// nothing is deleted when the directory can not be read
+		zap.L().Error("failed to read the alerts provisioning directory", zap.String("path", p.dir), zap.Error(err))
+		return items, nil, []error{fmt.Errorf("failed to read %s: %w", p.dir, err)}
+	}
+
+	// the items of the files that failed to load are not added so that
+	// a partially valid file does not delete some of its objects
+	var fileItems []*provisionItem
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		loaded, err := loadProvisionFile(filepath.Join(p.dir, entry.Name()), entry.Name())
+		if err != nil {
+			zap.L().Error("failed to load alerts provisioning file", zap.String("filename", entry.Name()), zap.Error(err))
+			failedFiles[entry.Name()] = struct{}{}
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name(), err))
+			continue
+		}
+		fileItems = append(fileItems, loaded...)
+	}
+
+	for _, item := range fileItems {
+		if prev, ok := items[item.kind][item.id]; ok {
+			errs = append(errs, fmt.Errorf("%s: duplicate %s id %q, already defined in %s", item.file, item.kind, item.id, prev.file))
+			continue
+		}
+		items[item.kind][item.id] = item
+	}
+	return items, failedFiles, errs
+}
+
+func loadProvisionFile(path, name string) ([]*provisionItem, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// yaml is a superset of json so both are decoded as yaml
+	file := provisionFile{}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+
+	var items []*provisionItem
+	add := func(kind ProvisionKind, idField string, defs []map[string]interface{}) error {
+		for idx, def := range defs {
+			id := ""
+			if v, ok := def[idField]; ok && v != nil {
+				id = fmt.Sprint(v)
+			}
+			if id == "" {
+				return fmt.Errorf("%s %d is missing the %s field", kind, idx, idField)
+			}
+			// the id of the rules and schedules is not part of their definition
+			if idField == "id" {
+				delete(def, idField)
+			}
+			items = append(items, &provisionItem{kind: kind, id: id, file: name, data: def})
+		}
+		return nil
+	}
+	if err := add(ProvisionKindRule, "id", file.Rules); err != nil {
+		return nil, err
+	}
+	if err := add(ProvisionKindChannel, "name", file.Channels); err != nil {
+		return nil, err
+	}
+	if err := add(ProvisionKindDowntime, "id", file.DowntimeSchedules); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// resolveAlertIds replaces the stable ids of the provisioned rules in the
+// alert ids of a downtime schedule with the ids of the rules
+func (p *Provisioner) resolveAlertIds(item *provisionItem, rules map[string]ProvisionedObject) {
+	alertIds, ok := item.data["alertIds"].([]interface{})
+	if !ok {
+		return
+	}
+	resolved := make([]interface{}, 0, len(alertIds))
+	for _, v := range alertIds {
+		if rule, ok := rules[fmt.Sprint(v)]; ok {
+			resolved = append(resolved, rule.ObjectID)
+			continue
+		}
+		resolved = append(resolved, fmt.Sprint(v))
+	}
+	item.data["alertIds"] = resolved
+}
+
+// reconcile creates or updates the object of the item, it returns the mapping
+// to store when the object was changed
+func (p *Provisioner) reconcile(ctx context.Context, item *provisionItem, prev *ProvisionedObject) (ProvisionedObjectStatus, *ProvisionedObject) {
+	status := ProvisionedObjectStatus{Kind: item.kind, ProvisionID: item.id, File: item.file}
+	fail := func(err error) (ProvisionedObjectStatus, *ProvisionedObject) {
+		zap.L().Error("failed to provision object", zap.String("kind", string(item.kind)), zap.String("id", item.id), zap.String("filename", item.file), zap.Error(err))
+		status.Status = ProvisionStatusError
+		status.Error = err.Error()
+		return status, nil
+	}
+
+	data, sum, err := item.definition()
+	if err != nil {
+		return fail(err)
+	}
+
+	objectID := ""
+	switch {
+	case prev == nil:
+		objectID, err = p.create(ctx, item.kind, data)
+		status.Status = ProvisionStatusCreated
+	default:
+		objectID = prev.ObjectID
+		current, found, checksumErr := p.objectChecksum(ctx, item.kind, objectID)
+		if checksumErr != nil {
+			return fail(checksumErr)
+		}
+		switch {
+		case !found:
+			zap.L().Warn("provisioned object was deleted, recreating it", zap.String("kind", string(item.kind)), zap.String("id", item.id))
+			objectID, err = p.create(ctx, item.kind, data)
+			status.Status = ProvisionStatusDrifted
+		case current != prev.ObjectChecksum:
+			zap.L().Warn("provisioned object was changed, overwriting it", zap.String("kind", string(item.kind)), zap.String("id", item.id))
+			err = p.update(ctx, item.kind, objectID, data)
+			status.Status = ProvisionStatusDrifted
+		case sum != prev.Checksum:
+			err = p.update(ctx, item.kind, objectID, data)
+			status.Status = ProvisionStatusUpdated
+		default:
+			status.Status = ProvisionStatusInSync
+			status.ObjectID = objectID
+			return status, nil
+		}
+	}
+	if err != nil {
+		return fail(err)
+	}
+	status.ObjectID = objectID
+
+	objectSum, _, err := p.objectChecksum(ctx, item.kind, objectID)
+	if err != nil {
+		return fail(err)
+	}
+	obj := &ProvisionedObject{
+		Kind:           item.kind,
+		ProvisionID:    item.id,
+		ObjectID:       objectID,
+		File:           item.file,
+		Checksum:       sum,
+		ObjectChecksum: objectSum,
+	}
+	if err := p.manager.ruleDB.UpsertProvisionedObject(ctx, *obj); err != nil {
+		return fail(err)
+	}
+	return status, obj
+}
+
+// remove deletes the object whose definition was removed from the files
+func (p *Provisioner) remove(ctx context.Context, obj ProvisionedObject) ProvisionedObjectStatus {
+	status := ProvisionedObjectStatus{Kind: obj.Kind, ProvisionID: obj.ProvisionID, ObjectID: obj.ObjectID, File: obj.File, Status: ProvisionStatusDeleted}
+
+	_, found, err := p.objectChecksum(ctx, obj.Kind, obj.ObjectID)
+	if err == nil && found {
+		err = p.delete(ctx, obj.Kind, obj.ObjectID)
+	}
+	if err == nil {
+		err = p.manager.ruleDB.DeleteProvisionedObject(ctx, obj.Kind, obj.ProvisionID)
+	}
+	if err != nil {
+		zap.L().Error("failed to delete provisioned object", zap.String("kind", string(obj.Kind)), zap.String("id", obj.ProvisionID), zap.Error(err))
+		status.Status = ProvisionStatusError
+		status.Error = err.Error()
+	}
+	return status
+}
+
+func (p *Provisioner) create(ctx context.Context, kind ProvisionKind, data []byte) (string, error) {
+	switch kind {
+	case ProvisionKindRule:
+		rule, err := p.manager.CreateRule(ctx, string(data))
+		if err != nil {
+			return "", err
+		}
+		return rule.Id, nil
+	case ProvisionKindChannel:
+		receiver := &am.Receiver{}
+		if err := json.Unmarshal(data, receiver); err != nil {
+			return "", err
+		}
+		if _, apiErr := p.manager.ruleDB.CreateChannel(receiver); apiErr != nil {
+			return "", apiErr.Err
+		}
+		// the id of the created channel is not returned
+		channels, apiErr := p.manager.ruleDB.GetChannels()
+		if apiErr != nil {
+			return "", apiErr.Err
+		}
+		for _, channel := range *channels {
+			if channel.Name == receiver.Name {
+				return strconv.Itoa(channel.Id), nil
+			}
+		}
+		return "", fmt.Errorf("channel %s not found after creating it", receiver.Name)
+	case ProvisionKindDowntime:
+		schedule, err := parseProvisionedDowntime(data)
+		if err != nil {
+			return "", err
+		}
+		id, err := p.manager.ruleDB.CreatePlannedMaintenance(ctx, *schedule)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(id, 10), nil
+	}
+	return "", fmt.Errorf("unknown provisioned object kind %s", kind)
+}
+
+func (p *Provisioner) update(ctx context.Context, kind ProvisionKind, id string, data []byte) error {
+	switch kind {
+	case ProvisionKindRule:
+		return p.manager.EditRule(ctx, string(data), id)
+	case ProvisionKindChannel:
+		receiver := &am.Receiver{}
+		if err := json.Unmarshal(data, receiver); err != nil {
+			return err
+		}
+		if _, apiErr := p.manager.ruleDB.EditChannel(receiver, id); apiErr != nil {
+			return apiErr.Err
+		}
+		return nil
+	case ProvisionKindDowntime:
+		schedule, err := parseProvisionedDowntime(data)
+		if err != nil {
+			return err
+		}
+		_, err = p.manager.ruleDB.EditPlannedMaintenance(ctx, *schedule, id)
+		return err
+	}
+	return fmt.Errorf("unknown provisioned object kind %s", kind)
+}
+
+func (p *Provisioner) delete(ctx context.Context, kind ProvisionKind, id string) error {
+	switch kind {
+	case ProvisionKindRule:
+		return p.manager.DeleteRule(ctx, id)
+	case ProvisionKindChannel:
+		if apiErr := p.manager.ruleDB.DeleteChannel(id); apiErr != nil {
+			return apiErr.Err
+		}
+		return nil
+	case ProvisionKindDowntime:
+		_, err := p.manager.ruleDB.DeletePlannedMaintenance(ctx, id)
+		return err
+	}
+	return fmt.Errorf("unknown provisioned object kind %s", kind)
+}
+
+// objectChecksum returns the checksum of the stored object, found is false
+// when the object does not exist
+func (p *Provisioner) objectChecksum(ctx context.Context, kind ProvisionKind, id string) (string, bool, error) {
+	switch kind {
+	case ProvisionKindRule:
+		rule, err := p.manager.ruleDB.GetStoredRule(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return checksum([]byte(rule.Data)), true, nil
+	case ProvisionKindChannel:
+		channels, apiErr := p.manager.ruleDB.GetChannels()
+		if apiErr != nil {
+			return "", false, apiErr.Err
+		}
+		for _, channel := range *channels {
+			if strconv.Itoa(channel.Id) == id {
+				return checksum([]byte(channel.Name + channel.Data)), true, nil
+			}
+		}
+		return "", false, nil
+	case ProvisionKindDowntime:
+		schedules, err := p.manager.ruleDB.GetAllPlannedMaintenance(ctx)
+		if err != nil {
+			return "", false, err
+		}
+		for _, schedule := range schedules {
+			if strconv.FormatInt(schedule.Id, 10) != id {
+				continue
+			}
+			data, err := json.Marshal(struct {
+				Name        string         `json:"name"`
+				Description string         `json:"description"`
+				Schedule    *Schedule      `json:"schedule"`
+				AlertIds    *AlertIds      `json:"alertIds"`
+				Matchers    *AlertMatchers `json:"matchers"`
+			}{schedule.Name, schedule.Description, schedule.Schedule, schedule.AlertIds, schedule.Matchers})
+			if err != nil {
+				return "", false, err
+			}
+			return checksum(data), true, nil
+		}
+		return "", false, nil
+	}
+	return "", false, fmt.Errorf("unknown provisioned object kind %s", kind)
+}
+
+func parseProvisionedDowntime(data []byte) (*PlannedMaintenance, error) {
+	schedule := &PlannedMaintenance{}
+	if err := json.Unmarshal(data, schedule); err != nil {
+		return nil, err
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
@@ -0,0 +1,231 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+const provisionTestFile = `
+channels:
+  - name: ops-webhook
+    webhook_configs:
+      - url: http://localhost:9999/alerts
+rules:
+  - id: cpu-high
+    alert: High CPU
+    description: cpu is high
+    alertType: METRIC_BASED_ALERT
+    ruleType: promql_rule
+    evalWindow: 5m
+    frequency: 1m
+    condition:
+      compositeQuery:
+        queryType: promql
+        panelType: graph
+        promQueries:
+          A:
+            query: avg(cpu_usage)
+      op: "1"
+      target: 90
+      matchType: "1"
+downtimeSchedules:
+  - id: weekly
+    name: weekly maintenance
+    alertIds: [cpu-high]
+    schedule:
+      timezone: UTC
+      startTime: "2024-01-01T00:00:00Z"
+      endTime: "2024-01-01T02:00:00Z"
+`
+
+func newProvisionTestManager(t *testing.T, dir string) (*Manager, *Provisioner) {
+	db := utils.NewQueryServiceDBForTests(t)
+	m := &Manager{
+		opts:      &ManagerOptions{DisableRules: true},
+		tasks:     map[string]Task{},
+		rules:     map[string]Rule{},
+		inhibitor: newRuleInhibitor(),
+		ruleDB:    NewRuleDB(db, am.NewEmbeddedManager(nil)),
+		logger:    zap.L(),
+	}
+	m.provisioner = NewProvisioner(m, dir, time.Minute)
+	return m, m.provisioner
+}
+
+func provisionStatuses(status *ProvisioningStatus) map[ProvisionKind]ProvisionStatus {
+	res := map[ProvisionKind]ProvisionStatus{}
+	for _, obj := range status.Objects {
+		res[obj.Kind] = obj.Status
+	}
+	return res
+}
+
+func provisionedID(t *testing.T, status *ProvisioningStatus, kind ProvisionKind) string {
+	for _, obj := range status.Objects {
+		if obj.Kind == kind {
+			return obj.ObjectID
+		}
+	}
+	t.Fatalf("no provisioned %s", kind)
+	return ""
+}
+
+func TestProvisionerSync(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "alerts.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(provisionTestFile), 0o644))
+
+	m, p := newProvisionTestManager(t, dir)
+	ctx := context.Background()
+
+	status := p.Sync(ctx)
+	require.Empty(t, status.Errors)
+	assert.Equal(t, map[ProvisionKind]ProvisionStatus{
+		ProvisionKindChannel:  ProvisionStatusCreated,
+		ProvisionKindRule:     ProvisionStatusCreated,
+		ProvisionKindDowntime: ProvisionStatusCreated,
+	}, provisionStatuses(status))
+
+	ruleID := provisionedID(t, status, ProvisionKindRule)
+	rule, err := m.GetRule(ctx, ruleID)
+	require.NoError(t, err)
+	assert.Equal(t, "High CPU", rule.AlertName)
+	assert.True(t, rule.Managed)
+	assert.True(t, m.IsProvisioned(ctx, ProvisionKindChannel, provisionedID(t, status, ProvisionKindChannel)))
+
+	// the stable id of the rule is replaced with the id of the created rule
+	schedule, err := m.ruleDB.GetPlannedMaintenanceByID(ctx, provisionedID(t, status, ProvisionKindDowntime))
+	require.NoError(t, err)
+	assert.Equal(t, AlertIds{ruleID}, *schedule.AlertIds)
+
+	status = p.Sync(ctx)
+	assert.Equal(t, map[ProvisionKind]ProvisionStatus{
+		ProvisionKindChannel:  ProvisionStatusInSync,
+		ProvisionKindRule:     ProvisionStatusInSync,
+		ProvisionKindDowntime: ProvisionStatusInSync,
+	}, provisionStatuses(status))
+
+	// the changes made outside of the files are reported and overwritten
+	edited := rule.PostableRule
+	edited.Description = "memory is high"
+	data, err := json.Marshal(edited)
+	require.NoError(t, err)
+	_, _, err = m.ruleDB.EditRuleTx(ctx, string(data), ruleID)
+	require.NoError(t, err)
+	status = p.Sync(ctx)
+	assert.Equal(t, ProvisionStatusDrifted, provisionStatuses(status)[ProvisionKindRule])
+	require.Len(t, status.Drifted(), 1)
+	rule, err = m.GetRule(ctx, ruleID)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu is high", rule.Description)
+
+	// the changes of the files are applied
+	require.NoError(t, os.WriteFile(file, []byte(strings.Replace(provisionTestFile, "cpu is high", "cpu is very high", 1)), 0o644))
+	status = p.Sync(ctx)
+	assert.Equal(t, ProvisionStatusUpdated, provisionStatuses(status)[ProvisionKindRule])
+	assert.Equal(t, ProvisionStatusInSync, provisionStatuses(status)[ProvisionKindChannel])
+	rule, err = m.GetRule(ctx, ruleID)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu is very high", rule.Description)
+
+	// the objects of a file that fails to load are kept
+	require.NoError(t, os.WriteFile(file, []byte("rules: [\n"), 0o644))
+	status = p.Sync(ctx)
+	assert.Len(t, status.Errors, 1)
+	assert.Empty(t, status.Objects)
+	assert.True(t, m.IsProvisioned(ctx, ProvisionKindRule, ruleID))
+
+	// the objects removed from the files are deleted
+	require.NoError(t, os.Remove(file))
+	status = p.Sync(ctx)
+	assert.Equal(t, map[ProvisionKind]ProvisionStatus{
+		ProvisionKindChannel:  ProvisionStatusDeleted,
+		ProvisionKindRule:     ProvisionStatusDeleted,
+		ProvisionKindDowntime: ProvisionStatusDeleted,
+	}, provisionStatuses(status))
+	_, err = m.GetRule(ctx, ruleID)
+	assert.Error(t, err)
+	assert.False(t, m.IsProvisioned(ctx, ProvisionKindRule, ruleID))
+}
+
+func TestLoadProvisionFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		wantErr bool
+		items   int
+	}{
+		{name: "yaml", content: provisionTestFile, items: 3},
+		{name: "json", content: `{"channels": [{"name": "ops", "webhook_configs": [{"url": "http://localhost"}]}]}`, items: 1},
+		{name: "rule without id", content: "rules:\n  - alert: test\n", wantErr: true},
+		{name: "channel without name", content: "channels:\n  - webhook_configs: []\n", wantErr: true},
+		{name: "invalid", content: "rules: [", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file")
+			require.NoError(t, os.WriteFile(path, []byte(c.content), 0o644))
+			items, err := loadProvisionFile(path, "file")
+			if c.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, items, c.items)
+			for _, item := range items {
+				assert.NotContains(t, item.data, "id")
+			}
+		})
+	}
+}
+
+func TestProvisionerSyncsOnLeaseHolderOnly(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "alerts.yaml"), []byte(provisionTestFile), 0o644))
+	ctx := context.Background()
+
+	ma, pa := newProvisionTestManager(t, dir)
+	ma.coordinator = NewCoordinator(ma, "a", time.Minute)
+	// b shares the rules db of a
+	mb := &Manager{
+		opts:      ma.opts,
+		tasks:     map[string]Task{},
+		rules:     map[string]Rule{},
+		inhibitor: newRuleInhibitor(),
+		ruleDB:    ma.ruleDB,
+		logger:    zap.L(),
+	}
+	mb.provisioner = NewProvisioner(mb, dir, time.Minute)
+	mb.coordinator = NewCoordinator(mb, "b", time.Minute)
+
+	pa.syncIfLeaseHolder(ctx)
+	mb.provisioner.syncIfLeaseHolder(ctx)
+	require.NotNil(t, pa.Status().LastSync)
+	assert.Nil(t, mb.provisioner.Status().LastSync)
+
+	rules, err := ma.ruleDB.GetStoredRules(ctx)
+	require.NoError(t, err)
+	assert.Len(t, rules, 1)
+
+	// b takes over once a stops
+	ma.coordinator.Stop()
+	mb.provisioner.syncIfLeaseHolder(ctx)
+	require.NotNil(t, mb.provisioner.Status().LastSync)
+	assert.Equal(t, map[ProvisionKind]ProvisionStatus{
+		ProvisionKindChannel:  ProvisionStatusInSync,
+		ProvisionKindRule:     ProvisionStatusInSync,
+		ProvisionKindDowntime: ProvisionStatusInSync,
+	}, provisionStatuses(mb.provisioner.Status()))
+}
@@ -0,0 +1,197 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+var metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// RecordingCondition is the metric a recording rule writes the result of
+// the selected query to
+type RecordingCondition struct {
+	MetricName string `yaml:"metricName" json:"metricName"`
+}
+
+func (c *RecordingCondition) Validate() error {
+	if c.MetricName == "" {
+		return fmt.Errorf("recording rule requires the metric name")
+	}
+	if !metricNameRegex.MatchString(c.MetricName) {
+		return fmt.Errorf("invalid metric name %q: must match %s", c.MetricName, metricNameRegex.String())
+	}
+	return nil
+}
+
+// RecordingRule evaluates the composite query of the rule condition on the
+// schedule of the rule and writes the series of the selected query to the
+// metrics tables under a new metric name. The labels of the rule are added
+// to each recorded series.
+type RecordingRule struct {
+	*ThresholdRule
+
+	metricName  string
+	description string
+	// lastRecorded is the timestamp of the latest recorded point, the points
+	// at or before it are not written again
+	lastRecorded int64
+}
+
+func NewRecordingRule(
+	id string,
+	p *PostableRule,
+	featureFlags interfaces.FeatureLookup,
+	reader interfaces.Reader,
+	useLogsNewSchema bool,
+	useTraceNewSchema bool,
+	opts ...RuleOption,
+) (*RecordingRule, error) {
+
+	zap.L().Info("creating new RecordingRule", zap.String("id", id), zap.Any("opts", opts))
+
+	if p.RuleCondition == nil || p.RuleCondition.Recording == nil {
+		return nil, fmt.Errorf("recording rule requires the recording condition")
+	}
+	if err := p.RuleCondition.Recording.Validate(); err != nil {
+		return nil, err
+	}
+
+	thresholdRule, err := NewThresholdRule(id, p, featureFlags, reader, useLogsNewSchema, useTraceNewSchema, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	description := p.Description
+	if description == "" {
+		description = p.Annotations[labels.AlertDescriptionLabel]
+	}
+
+	return &RecordingRule{
+		ThresholdRule: thresholdRule,
+		metricName:    p.RuleCondition.Recording.MetricName,
+		description:   description,
+	}, nil
+}
+
+func (r *RecordingRule) Type() RuleType {
+	return RuleTypeRecording
+}
+
+// stepMillis returns the width of the points of the selected query, a point
+// is recorded once its whole step is before the end of the evaluation
+func (r *RecordingRule) stepMillis(params *v3.QueryRangeParamsV3) int64 {
+	switch params.CompositeQuery.QueryType {
+	case v3.QueryTypeBuilder:
+		var step int64
+		for _, q := range params.CompositeQuery.BuilderQueries {
+			step = max(step, q.StepInterval)
+		}
+		return step * 1000
+	case v3.QueryTypeClickHouseSQL:
+		return params.Step * 1000
+	}
+	// promql points are instant evaluations
+	return 0
+}
+
+// recordedSamples returns the points of the series that are complete and not
+// recorded yet
+func (r *RecordingRule) recordedSamples(result *v3.Result, end int64, step int64) ([]model.RecordedSample, error) {
+	if result == nil {
+		return nil, nil
+	}
+
+	var samples []model.RecordedSample
+	for _, series := range result.Series {
+		lb := labels.NewBuilder(labels.FromMap(series.Labels))
+		for name, value := range r.labels.Map() {
+			lb.Set(name, value)
+		}
+		lb.Set(labels.MetricNameLabel, r.metricName)
+		lbls := lb.Labels()
+
+		data, err := json.Marshal(lbls)
+		if err != nil {
+			return nil, err
+		}
+		fingerprint := lbls.Hash()
+
+		for _, p := range series.Points {
+			if p.Timestamp <= r.lastRecorded || p.Timestamp+step > end {
+				continue
+			}
+			if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+				continue
+			}
+			samples = append(samples, model.RecordedSample{
+				Fingerprint: fingerprint,
+				Labels:      string(data),
+				UnixMilli:   p.Timestamp,
+				Value:       p.Value,
+			})
+		}
+	}
+	return samples, nil
+}
+
+func (r *RecordingRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+	params, result, err := r.runSelectedQuery(ctx, ts)
+	if err != nil {
+		r.SetHealth(HealthBad)
+		r.SetLastError(err)
+		return nil, err
+	}
+
+	// the points recorded before a restart are not written again
+	if r.lastRecorded == 0 {
+		r.lastRecorded, err = r.reader.GetLastRecordedSampleTime(ctx, r.metricName, params.Start)
+		if err != nil {
+			zap.L().Error("failed to get the last recorded sample", zap.String("rule", r.Name()), zap.String("metric", r.metricName), zap.Error(err))
+			r.SetHealth(HealthBad)
+			r.SetLastError(err)
+			return nil, fmt.Errorf("internal error while getting the last recorded sample")
+		}
+	}
+
+	samples, err := r.recordedSamples(result, params.End, r.stepMillis(params))
+	if err != nil {
+		r.SetHealth(HealthBad)
+		r.SetLastError(err)
+		return nil, err
+	}
+
+	if len(samples) > 0 {
+		err = r.reader.WriteRecordedMetric(ctx, &model.RecordedMetric{
+			Name:        r.metricName,
+			Description: r.description,
+			Unit:        r.Unit(),
+			Samples:     samples,
+		})
+		if err != nil {
+			zap.L().Error("failed to write the recorded samples", zap.String("rule", r.Name()), zap.String("metric", r.metricName), zap.Error(err))
+			r.SetHealth(HealthBad)
+			r.SetLastError(err)
+			return nil, fmt.Errorf("internal error while writing the recorded samples")
+		}
+		for _, s := range samples {
+			r.lastRecorded = max(r.lastRecorded, s.UnixMilli)
+		}
+	}
+
+	zap.L().Debug("recorded samples", zap.String("rule", r.Name()), zap.String("metric", r.metricName), zap.Int("samples", len(samples)))
+
+	r.SetHealth(HealthGood)
+	r.SetLastError(nil)
+	return len(samples), nil
+}
@@ -0,0 +1,148 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+type recordingFakeQuerier struct {
+	series []*v3.Series
+}
+
+func (q *recordingFakeQuerier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3) ([]*v3.Result, map[string]error, error) {
+	return []*v3.Result{{QueryName: "A", Series: q.series}}, nil, nil
+}
+
+func (q *recordingFakeQuerier) QueriesExecuted() []string {
+	return nil
+}
+
+func (q *recordingFakeQuerier) TimeRanges() [][]int {
+	return nil
+}
+
+// recordingFakeReader keeps the written metrics, the other methods of the
+// reader are not used by the recording rule
+type recordingFakeReader struct {
+	interfaces.Reader
+	last    int64
+	written []*model.RecordedMetric
+}
+
+func (r *recordingFakeReader) WriteRecordedMetric(ctx context.Context, metric *model.RecordedMetric) error {
+	r.written = append(r.written, metric)
+	return nil
+}
+
+func (r *recordingFakeReader) GetLastRecordedSampleTime(ctx context.Context, metricName string, since int64) (int64, error) {
+	return r.last, nil
+}
+
+const recordingTestRule = `{
+	"alert": "request rate by service",
+	"description": "requests per second",
+	"version": "v4",
+	"labels": {"team": "checkout"},
+	"condition": {
+		"compositeQuery": {
+			"queryType": "promql",
+			"panelType": "graph",
+			"promQueries": {"A": {"query": "sum by (service) (rate(requests_total[5m]))"}}
+		},
+		"recording": {"metricName": "service:requests:rate5m"}
+	}
+}`
+
+func TestParseRecordingRule(t *testing.T) {
+	rule, err := ParsePostableRule([]byte(recordingTestRule))
+	require.NoError(t, err)
+	assert.Equal(t, RuleType(RuleTypeRecording), rule.RuleType)
+	assert.Equal(t, "A", rule.RuleCondition.GetSelectedQueryName())
+
+	invalid := &RecordingCondition{MetricName: "requests-rate"}
+	assert.Error(t, invalid.Validate())
+	_, err = ParsePostableRule([]byte(`{"alert": "x", "condition": {"compositeQuery": {"queryType": "promql", "promQueries": {"A": {"query": "up"}}}, "recording": {"metricName": "1up"}}}`))
+	assert.Error(t, err)
+}
+
+func TestRecordingRuleEval(t *testing.T) {
+	postableRule, err := ParsePostableRule([]byte(recordingTestRule))
+	require.NoError(t, err)
+
+	reader := &recordingFakeReader{}
+	rule, err := NewRecordingRule("1", postableRule, nil, reader, true, true)
+	require.NoError(t, err)
+
+	ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t1 := ts.Add(-2 * time.Minute).UnixMilli()
+	t2 := ts.Add(-1 * time.Minute).UnixMilli()
+	querier := &recordingFakeQuerier{series: []*v3.Series{
+		{Labels: map[string]string{"service": "cart"}, Points: []v3.Point{{Timestamp: t1, Value: 1}, {Timestamp: t2, Value: 2}}},
+		{Labels: map[string]string{"service": "frontend"}, Points: []v3.Point{{Timestamp: t1, Value: math.NaN()}, {Timestamp: t2, Value: 5}}},
+	}}
+	rule.querierV2 = querier
+
+	// the points written before a restart are skipped
+	reader.last = t1
+	count, err := rule.Eval(context.Background(), ts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	require.Len(t, reader.written, 1)
+
+	metric := reader.written[0]
+	assert.Equal(t, "service:requests:rate5m", metric.Name)
+	assert.Equal(t, "requests per second", metric.Description)
+	require.Len(t, metric.Samples, 2)
+	for _, sample := range metric.Samples {
+		assert.Equal(t, t2, sample.UnixMilli)
+		var lbls map[string]string
+		require.NoError(t, json.Unmarshal([]byte(sample.Labels), &lbls))
+		assert.Equal(t, "service:requests:rate5m", lbls["__name__"])
+		assert.Equal(t, "checkout", lbls["team"])
+	}
+	assert.NotEqual(t, metric.Samples[0].Fingerprint, metric.Samples[1].Fingerprint)
+
+	// the same points are not written again
+	count, err = rule.Eval(context.Background(), ts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Len(t, reader.written, 1)
+
+	t3 := ts.UnixMilli()
+	querier.series[0].Points = append(querier.series[0].Points, v3.Point{Timestamp: t3, Value: 3})
+	count, err = rule.Eval(context.Background(), ts.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	require.Len(t, reader.written, 2)
+	assert.Equal(t, t3, reader.written[1].Samples[0].UnixMilli)
+	assert.Equal(t, HealthGood, rule.Health())
+}
+
+func TestRecordingRuleIncompleteSteps(t *testing.T) {
+	postableRule, err := ParsePostableRule([]byte(recordingTestRule))
+	require.NoError(t, err)
+	rule, err := NewRecordingRule("1", postableRule, nil, &recordingFakeReader{}, true, true)
+	require.NoError(t, err)
+
+	end := time.Date(2024, 1, 1, 10, 0, 30, 0, time.UTC).UnixMilli()
+	step := time.Minute.Milliseconds()
+	complete := end - 90*time.Second.Milliseconds()
+	partial := end - 30*time.Second.Milliseconds()
+
+	samples, err := rule.recordedSamples(&v3.Result{Series: []*v3.Series{
+		{Points: []v3.Point{{Timestamp: complete, Value: 1}, {Timestamp: partial, Value: 2}}},
+	}}, end, step)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, complete, samples[0].UnixMilli)
+}
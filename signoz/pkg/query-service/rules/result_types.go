@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 )
 
@@ -18,6 +19,9 @@ type Sample struct {
 	Metric labels.Labels
 
 	IsMissing bool
+
+	// Points are the points of the evaluated series, used in the templates
+	Points []v3.Point
 }
 
 func (s Sample) String() string {
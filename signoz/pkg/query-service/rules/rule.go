@@ -25,6 +25,8 @@ type Rule interface {
 	ActiveAlerts() []*Alert
 
 	PreferredChannels() []string
+	// Dependencies are the rules whose firing alerts suppress the notifications of the rule
+	Dependencies() []RuleDependency
 
 	Eval(context.Context, time.Time) (interface{}, error)
 	String() string
@@ -33,6 +33,8 @@ type RuleTask struct {
 	notify NotifyFunc
 
 	ruleDB RuleDB
+	// restored is set once the persisted alert states of the rules are restored
+	restored bool
 }
 
 const DefaultFrequency = 1 * time.Minute
@@ -82,6 +84,12 @@ func (g *RuleTask) Pause(b bool) {
 	g.pause = b
 }
 
+func (g *RuleTask) isPaused() bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	return g.pause
+}
+
 type QueryOrigin struct{}
 
 func NewQueryOriginContext(ctx context.Context, data map[string]interface{}) context.Context {
@@ -107,9 +115,7 @@ func (g *RuleTask) Run(ctx context.Context) {
 	})
 
 	iter := func() {
-		if g.pause {
-			// todo(amol): remove in memory active alerts
-			// and last series state
+		if g.isPaused() {
 			return
 		}
 		start := time.Now()
@@ -299,6 +305,11 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 
 	zap.L().Debug("rule task eval started", zap.String("name", g.name), zap.Time("start time", ts))
 
+	if !g.restored {
+		restoreAlertStates(ctx, g.ruleDB, g.rules)
+		g.restored = true
+	}
+
 	maintenance, err := g.ruleDB.GetAllPlannedMaintenance(ctx)
 
 	if err != nil {
@@ -365,6 +376,7 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 			}
 
 			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, g.notify)
+			saveAlertStates(ctx, g.ruleDB, rule)
 
 		}(i, rule)
 	}
@@ -0,0 +1,526 @@
+package rules
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	logsv3 "go.signoz.io/signoz/pkg/query-service/app/logs/v3"
+	tracesV3 "go.signoz.io/signoz/pkg/query-service/app/traces/v3"
+	tracesV4 "go.signoz.io/signoz/pkg/query-service/app/traces/v4"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/postprocess"
+	qslabels "go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+var (
+	ErrMissingSLOObjective  = errors.New("missing objective")
+	ErrInvalidSLOTarget     = errors.New("target must be greater than 0 and less than 100")
+	ErrInvalidSLOWindow     = errors.New("window must be between 1h and 90d")
+	ErrMissingSLOGoodQuery  = errors.New("missing good events query")
+	ErrMissingSLOTotalQuery = errors.New("missing total events query")
+)
+
+const (
+	sloGoodQueryName  = "G"
+	sloTotalQueryName = "T"
+
+	// names of the results of the budget history
+	SLOResultSLI             = "sli"
+	SLOResultBudgetRemaining = "error_budget_remaining"
+	SLOResultBurnRate        = "burn_rate"
+
+	sloLabel         = "slo"
+	longWindowLabel  = "long_window"
+	shortWindowLabel = "short_window"
+	severityLabel    = "severity"
+)
+
+// SLO is a service level objective, the ratio of the good events to the total
+// events of a service over a rolling window has to stay above the target
+type SLO struct {
+	Id          int64         `json:"id" db:"id"`
+	Name        string        `json:"name" db:"name"`
+	Description string        `json:"description" db:"description"`
+	Objective   *SLOObjective `json:"objective" db:"objective"`
+	CreatedAt   time.Time     `json:"createdAt" db:"created_at"`
+	CreatedBy   string        `json:"createdBy" db:"created_by"`
+	UpdatedAt   time.Time     `json:"updatedAt" db:"updated_at"`
+	UpdatedBy   string        `json:"updatedBy" db:"updated_by"`
+}
+
+// SLOObjective has the target of the SLO and the queries of its events. The
+// queries are builder queries over traces, logs or metrics, and their group by
+// attributes split the SLO in a SLI per group.
+type SLOObjective struct {
+	// Target is the percentage of good events, e.g. 99.9
+	Target float64 `json:"target"`
+	// Window is the rolling window of the SLO, e.g. 30 days
+	Window Duration `json:"window"`
+
+	Good  *v3.BuilderQuery `json:"good"`
+	Total *v3.BuilderQuery `json:"total"`
+}
+
+func (o *SLOObjective) Scan(src interface{}) error {
+	if data, ok := src.([]byte); ok {
+		return json.Unmarshal(data, o)
+	}
+	if data, ok := src.(string); ok {
+		return json.Unmarshal([]byte(data), o)
+	}
+	return nil
+}
+
+func (o *SLOObjective) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// ErrorBudget is the allowed ratio of bad events
+func (o *SLOObjective) ErrorBudget() float64 {
+	return 1 - o.Target/100
+}
+
+func (s *SLO) Validate() error {
+	if s.Name == "" {
+		return ErrMissingName
+	}
+	o := s.Objective
+	if o == nil {
+		return ErrMissingSLOObjective
+	}
+
+	var errs []error
+	if o.Target <= 0 || o.Target >= 100 {
+		errs = append(errs, ErrInvalidSLOTarget)
+	}
+	if time.Duration(o.Window) < time.Hour || time.Duration(o.Window) > 90*24*time.Hour {
+		errs = append(errs, ErrInvalidSLOWindow)
+	}
+	if o.Good == nil {
+		errs = append(errs, ErrMissingSLOGoodQuery)
+	}
+	if o.Total == nil {
+		errs = append(errs, ErrMissingSLOTotalQuery)
+	}
+	if len(errs) > 0 {
+		return multierr.Combine(errs...)
+	}
+
+	for name, q := range map[string]*v3.BuilderQuery{sloGoodQueryName: o.Good, sloTotalQueryName: o.Total} {
+		q := q.Clone()
+		q.QueryName = name
+		q.Expression = name
+		if err := q.Validate(v3.PanelTypeGraph); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s query: %w", sloQueryKind(name), err))
+		}
+	}
+	if !sameGroupBy(o.Good.GroupBy, o.Total.GroupBy) {
+		errs = append(errs, errors.New("good and total queries must have the same group by"))
+	}
+	return multierr.Combine(errs...)
+}
+
+func sloQueryKind(name string) string {
+	if name == sloGoodQueryName {
+		return "good events"
+	}
+	return "total events"
+}
+
+func sameGroupBy(a, b []v3.AttributeKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	keys := make(map[string]struct{}, len(a))
+	for _, k := range a {
+		keys[k.Key] = struct{}{}
+	}
+	for _, k := range b {
+		if _, ok := keys[k.Key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SLOCondition is the condition of a SLO rule
+type SLOCondition struct {
+	SLOId string `json:"sloId" yaml:"sloId"`
+	// Windows are the burn rate windows, the default windows of the multi
+	// window multi burn rate alerts are used when there are none
+	Windows []BurnRateWindow `json:"windows,omitempty" yaml:"windows,omitempty"`
+}
+
+// BurnRateWindow fires when the error budget burns at least at the burn rate
+// over both the long and the short window. The long window makes the alert
+// significant and the short window resets it quickly once the errors stop.
+type BurnRateWindow struct {
+	LongWindow  Duration `json:"longWindow" yaml:"longWindow"`
+	ShortWindow Duration `json:"shortWindow" yaml:"shortWindow"`
+	BurnRate    float64  `json:"burnRate" yaml:"burnRate"`
+	Severity    string   `json:"severity,omitempty" yaml:"severity,omitempty"`
+}
+
+// DefaultBurnRateWindows are the windows recommended for a 30 days SLO, they page
+// when 2% of the budget is spent in an hour or 5% in six hours and open a ticket
+// when 10% is spent in a day or three days
+var DefaultBurnRateWindows = []BurnRateWindow{
+	{LongWindow: Duration(time.Hour), ShortWindow: Duration(5 * time.Minute), BurnRate: 14.4, Severity: "critical"},
+	{LongWindow: Duration(6 * time.Hour), ShortWindow: Duration(30 * time.Minute), BurnRate: 6, Severity: "critical"},
+	{LongWindow: Duration(24 * time.Hour), ShortWindow: Duration(2 * time.Hour), BurnRate: 3, Severity: "warning"},
+	{LongWindow: Duration(72 * time.Hour), ShortWindow: Duration(6 * time.Hour), BurnRate: 1, Severity: "warning"},
+}
+
+func (c *SLOCondition) burnRateWindows() []BurnRateWindow {
+	if len(c.Windows) == 0 {
+		return DefaultBurnRateWindows
+	}
+	return c.Windows
+}
+
+func (c *SLOCondition) Validate() error {
+	if c.SLOId == "" {
+		return errors.New("slo condition missing the slo id")
+	}
+	var errs []error
+	for idx, w := range c.Windows {
+		if w.ShortWindow <= 0 || w.LongWindow <= 0 {
+			errs = append(errs, fmt.Errorf("burn rate window %d: long and short windows are required", idx))
+		} else if w.ShortWindow >= w.LongWindow {
+			errs = append(errs, fmt.Errorf("burn rate window %d: short window must be shorter than the long window", idx))
+		}
+		if w.BurnRate <= 0 {
+			errs = append(errs, fmt.Errorf("burn rate window %d: burn rate must be greater than 0", idx))
+		}
+	}
+	return multierr.Combine(errs...)
+}
+
+// sloCounts are the good and total events of a group of the SLO over a window
+type sloCounts struct {
+	labels map[string]string
+	good   float64
+	total  float64
+}
+
+// errorRatio returns the ratio of bad events, a window without events has no errors
+func (c *sloCounts) errorRatio() float64 {
+	if c == nil || c.total <= 0 {
+		return 0
+	}
+	return math.Max(0, 1-c.good/c.total)
+}
+
+// burnRate returns how fast the error budget is spent, a burn rate of 1 spends
+// the whole budget by the end of the SLO window
+func burnRate(c *sloCounts, errorBudget float64) float64 {
+	return c.errorRatio() / errorBudget
+}
+
+// budgetRemaining returns the percentage of the error budget left over the SLO window
+func budgetRemaining(c *sloCounts, errorBudget float64) float64 {
+	return (1 - c.errorRatio()/errorBudget) * 100
+}
+
+// SLOEvaluator queries the events of the SLOs
+type SLOEvaluator struct {
+	querier           interfaces.Querier
+	reader            interfaces.Reader
+	useTraceNewSchema bool
+
+	temporality map[string]map[v3.Temporality]bool
+}
+
+// NewSLOEvaluator returns an evaluator running the queries of the SLOs with the
+// given querier, the querier has to support the v4 metrics queries
+func NewSLOEvaluator(querier interfaces.Querier, reader interfaces.Reader, useTraceNewSchema bool) *SLOEvaluator {
+	return &SLOEvaluator{
+		querier:           querier,
+		reader:            reader,
+		useTraceNewSchema: useTraceNewSchema,
+		temporality:       make(map[string]map[v3.Temporality]bool),
+	}
+}
+
+// prepareQueryRange builds the query of the good and the total events
+func (e *SLOEvaluator) prepareQueryRange(slo *SLO, start, end time.Time, step int64) *v3.QueryRangeParamsV3 {
+	if minStep := common.MinAllowedStepInterval(start.UnixMilli(), end.UnixMilli()); step < minStep {
+		step = minStep
+	}
+	if step < 60 {
+		step = 60
+	}
+	queries := make(map[string]*v3.BuilderQuery, 2)
+	for name, q := range map[string]*v3.BuilderQuery{sloGoodQueryName: slo.Objective.Good, sloTotalQueryName: slo.Objective.Total} {
+		q = q.Clone()
+		q.QueryName = name
+		q.Expression = name
+		q.Disabled = false
+		q.StepInterval = step
+		q.Limit = 0
+		q.Offset = 0
+		q.OrderBy = nil
+		q.Functions = nil
+		q.ShiftBy = 0
+		queries[name] = q
+	}
+	return &v3.QueryRangeParamsV3{
+		Start: start.UnixMilli(),
+		End:   end.UnixMilli(),
+		Step:  step,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType:      v3.QueryTypeBuilder,
+			PanelType:      v3.PanelTypeGraph,
+			BuilderQueries: queries,
+		},
+		Variables: make(map[string]interface{}),
+		NoCache:   true,
+	}
+}
+
+// queryRange runs the queries of the SLO and returns the good and the total series
+func (e *SLOEvaluator) queryRange(ctx context.Context, params *v3.QueryRangeParamsV3) (good, total []*v3.Series, err error) {
+	if err := e.enrich(ctx, params); err != nil {
+		return nil, nil, err
+	}
+
+	results, queryErrors, err := e.querier.QueryRange(ctx, params)
+	if err != nil {
+		zap.L().Error("failed to run slo queries", zap.Error(err), zap.Any("errors", queryErrors))
+		return nil, nil, fmt.Errorf("internal error while querying")
+	}
+	results, err = postprocess.PostProcessResult(results, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("internal error while post processing")
+	}
+	for _, res := range results {
+		switch res.QueryName {
+		case sloGoodQueryName:
+			good = res.Series
+		case sloTotalQueryName:
+			total = res.Series
+		}
+	}
+	return good, total, nil
+}
+
+// enrich adds the attribute metadata of the logs and traces queries and the
+// temporality of the metrics queries
+func (e *SLOEvaluator) enrich(ctx context.Context, params *v3.QueryRangeParamsV3) error {
+	if logsv3.EnrichmentRequired(params) {
+		logsFields, apiErr := e.reader.GetLogFields(ctx)
+		if apiErr != nil {
+			return apiErr.Err
+		}
+		logsv3.Enrich(params, model.GetLogFieldsV3(ctx, params, logsFields))
+	}
+
+	var missingTemporality []string
+	hasTracesQuery := false
+	for _, q := range params.CompositeQuery.BuilderQueries {
+		if q.DataSource == v3.DataSourceTraces {
+			hasTracesQuery = true
+		}
+		if q.DataSource == v3.DataSourceMetrics && q.Temporality == "" {
+			if _, ok := e.temporality[q.AggregateAttribute.Key]; !ok {
+				missingTemporality = append(missingTemporality, q.AggregateAttribute.Key)
+			}
+		}
+	}
+
+	if hasTracesQuery {
+		spanKeys, err := e.reader.GetSpanAttributeKeys(ctx)
+		if err != nil {
+			return err
+		}
+		if e.useTraceNewSchema {
+			tracesV4.Enrich(params, spanKeys)
+		} else {
+			tracesV3.Enrich(params, spanKeys)
+		}
+	}
+
+	if len(missingTemporality) > 0 {
+		nameToTemporality, err := e.reader.FetchTemporality(ctx, missingTemporality)
+		if err != nil {
+			return err
+		}
+		for _, name := range missingTemporality {
+			e.temporality[name] = nameToTemporality[name]
+		}
+	}
+	for _, q := range params.CompositeQuery.BuilderQueries {
+		if q.DataSource != v3.DataSourceMetrics || q.Temporality != "" {
+			continue
+		}
+		// We prefer delta if it is available
+		if e.temporality[q.AggregateAttribute.Key][v3.Delta] {
+			q.Temporality = v3.Delta
+		} else if e.temporality[q.AggregateAttribute.Key][v3.Cumulative] {
+			q.Temporality = v3.Cumulative
+		} else {
+			q.Temporality = v3.Unspecified
+		}
+	}
+	return nil
+}
+
+// Counts returns the good and the total events of each group of the SLO between start and end
+func (e *SLOEvaluator) Counts(ctx context.Context, slo *SLO, start, end time.Time) (map[string]*sloCounts, error) {
+	params := e.prepareQueryRange(slo, start, end, 60)
+	good, total, err := e.queryRange(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return sumSLOSeries(good, total), nil
+}
+
+// sumSLOSeries sums the points of the good and the total series of each group
+func sumSLOSeries(good, total []*v3.Series) map[string]*sloCounts {
+	counts := make(map[string]*sloCounts)
+	get := func(s *v3.Series) *sloCounts {
+		key := qslabels.FromMap(s.Labels).String()
+		c, ok := counts[key]
+		if !ok {
+			c = &sloCounts{labels: s.Labels}
+			counts[key] = c
+		}
+		return c
+	}
+	for _, s := range total {
+		c := get(s)
+		for _, p := range s.Points {
+			if !math.IsNaN(p.Value) && !math.IsInf(p.Value, 0) {
+				c.total += p.Value
+			}
+		}
+	}
+	for _, s := range good {
+		c := get(s)
+		for _, p := range s.Points {
+			if !math.IsNaN(p.Value) && !math.IsInf(p.Value, 0) {
+				c.good += p.Value
+			}
+		}
+	}
+	return counts
+}
+
+// BudgetHistory returns the SLI, the remaining error budget over the rolling
+// window of the SLO and the burn rate of each step between start and end, the
+// results have the shape of the query range results so they can be plotted
+// in the dashboards
+func (e *SLOEvaluator) BudgetHistory(ctx context.Context, slo *SLO, start, end time.Time, step int64) ([]*v3.Result, error) {
+	window := time.Duration(slo.Objective.Window)
+	params := e.prepareQueryRange(slo, start.Add(-window), end, step)
+	good, total, err := e.queryRange(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return budgetHistory(good, total, slo.Objective, start.UnixMilli(), end.UnixMilli(), params.Step*1000), nil
+}
+
+// budgetHistory computes the results of the budget history from the good and
+// the total series, the series have a point per step
+func budgetHistory(good, total []*v3.Series, objective *SLOObjective, start, end, stepMs int64) []*v3.Result {
+	type groupPoints struct {
+		labels      map[string]string
+		good, total map[int64]float64
+	}
+	groups := make(map[string]*groupPoints)
+	var keys []string
+	add := func(series []*v3.Series, isGood bool) {
+		for _, s := range series {
+			key := qslabels.FromMap(s.Labels).String()
+			g, ok := groups[key]
+			if !ok {
+				g = &groupPoints{labels: s.Labels, good: map[int64]float64{}, total: map[int64]float64{}}
+				groups[key] = g
+				keys = append(keys, key)
+			}
+			for _, p := range s.Points {
+				if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+					continue
+				}
+				if isGood {
+					g.good[p.Timestamp] += p.Value
+				} else {
+					g.total[p.Timestamp] += p.Value
+				}
+			}
+		}
+	}
+	add(total, false)
+	add(good, true)
+	sort.Strings(keys)
+
+	windowMs := time.Duration(objective.Window).Milliseconds()
+	errorBudget := objective.ErrorBudget()
+	start = start - start%stepMs
+
+	sli := &v3.Result{QueryName: SLOResultSLI}
+	remaining := &v3.Result{QueryName: SLOResultBudgetRemaining}
+	burn := &v3.Result{QueryName: SLOResultBurnRate}
+	for _, key := range keys {
+		g := groups[key]
+		timestamps := make([]int64, 0, len(g.total))
+		for ts := range g.total {
+			timestamps = append(timestamps, ts)
+		}
+		sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+		sliSeries := newSLOSeries(g.labels)
+		remainingSeries := newSLOSeries(g.labels)
+		burnSeries := newSLOSeries(g.labels)
+
+		// the rolling window is the steps in (ts - window, ts]
+		var windowCounts sloCounts
+		first := 0
+		next := 0
+		for ts := start; ts <= end; ts += stepMs {
+			for next < len(timestamps) && timestamps[next] <= ts {
+				windowCounts.good += g.good[timestamps[next]]
+				windowCounts.total += g.total[timestamps[next]]
+				next++
+			}
+			for first < next && timestamps[first] <= ts-windowMs {
+				windowCounts.good -= g.good[timestamps[first]]
+				windowCounts.total -= g.total[timestamps[first]]
+				first++
+			}
+			if windowCounts.total > 0 {
+				sliSeries.Points = append(sliSeries.Points, v3.Point{Timestamp: ts, Value: (1 - windowCounts.errorRatio()) * 100})
+				remainingSeries.Points = append(remainingSeries.Points, v3.Point{Timestamp: ts, Value: budgetRemaining(&windowCounts, errorBudget)})
+			}
+			if stepTotal := g.total[ts]; stepTotal > 0 {
+				stepCounts := &sloCounts{good: g.good[ts], total: stepTotal}
+				burnSeries.Points = append(burnSeries.Points, v3.Point{Timestamp: ts, Value: burnRate(stepCounts, errorBudget)})
+			}
+		}
+		sli.Series = append(sli.Series, sliSeries)
+		remaining.Series = append(remaining.Series, remainingSeries)
+		burn.Series = append(burn.Series, burnSeries)
+	}
+	return []*v3.Result{sli, remaining, burn}
+}
+
+func newSLOSeries(lbls map[string]string) *v3.Series {
+	s := &v3.Series{Labels: make(map[string]string, len(lbls)), LabelsArray: make([]map[string]string, 0, len(lbls)), Points: []v3.Point{}}
+	for k, v := range lbls {
+		s.Labels[k] = v
+		s.LabelsArray = append(s.LabelsArray, map[string]string{k: v})
+	}
+	return s
+}
@@ -0,0 +1,329 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	querierV2 "go.signoz.io/signoz/pkg/query-service/app/querier/v2"
+	"go.signoz.io/signoz/pkg/query-service/app/queryBuilder"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.signoz.io/signoz/pkg/query-service/utils/times"
+	"go.signoz.io/signoz/pkg/query-service/utils/timestamp"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// SLORule alerts on the burn rate of the error budget of a SLO. Each burn rate
+// window fires when the budget burns at least at its burn rate over both the
+// long and the short window.
+type SLORule struct {
+	*BaseRule
+
+	ruleDB    RuleDB
+	evaluator *SLOEvaluator
+}
+
+func NewSLORule(
+	id string,
+	p *PostableRule,
+	featureFlags interfaces.FeatureLookup,
+	reader interfaces.Reader,
+	ruleDB RuleDB,
+	useLogsNewSchema bool,
+	useTraceNewSchema bool,
+	opts ...RuleOption,
+) (*SLORule, error) {
+
+	zap.L().Info("creating new SLORule", zap.String("id", id), zap.Any("opts", opts))
+
+	if p.RuleCondition == nil || p.RuleCondition.SLO == nil {
+		return nil, fmt.Errorf("slo rule requires the slo condition")
+	}
+
+	baseRule, err := NewBaseRule(id, p, reader, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	querierOptsV2 := querierV2.QuerierOptions{
+		Reader:            reader,
+		Cache:             nil,
+		KeyGenerator:      queryBuilder.NewKeyGenerator(),
+		FeatureLookup:     featureFlags,
+		UseLogsNewSchema:  useLogsNewSchema,
+		UseTraceNewSchema: useTraceNewSchema,
+	}
+
+	return &SLORule{
+		BaseRule:  baseRule,
+		ruleDB:    ruleDB,
+		evaluator: NewSLOEvaluator(querierV2.NewQuerier(querierOptsV2), reader, useTraceNewSchema),
+	}, nil
+}
+
+func (r *SLORule) Type() RuleType {
+	return RuleTypeSLO
+}
+
+// sloBurnRateSample is a burn rate window of a group of the SLO that burns
+// the budget faster than the threshold
+type sloBurnRateSample struct {
+	window    BurnRateWindow
+	labels    map[string]string
+	longRate  float64
+	shortRate float64
+}
+
+// evalBurnRates returns the windows of each group that exceed their burn rate
+func (r *SLORule) evalBurnRates(ctx context.Context, slo *SLO, ts time.Time) ([]sloBurnRateSample, error) {
+	end := ts.Add(-r.evalDelay)
+	end = end.Truncate(time.Minute)
+
+	windows := r.ruleCondition.SLO.burnRateWindows()
+	counts := make(map[Duration]map[string]*sloCounts)
+	for _, w := range windows {
+		for _, d := range []Duration{w.LongWindow, w.ShortWindow} {
+			if _, ok := counts[d]; ok {
+				continue
+			}
+			c, err := r.evaluator.Counts(ctx, slo, end.Add(-time.Duration(d)), end)
+			if err != nil {
+				return nil, err
+			}
+			counts[d] = c
+		}
+	}
+
+	errorBudget := slo.Objective.ErrorBudget()
+	var samples []sloBurnRateSample
+	for _, w := range windows {
+		keys := make([]string, 0, len(counts[w.LongWindow]))
+		for key := range counts[w.LongWindow] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			long := counts[w.LongWindow][key]
+			longRate := burnRate(long, errorBudget)
+			shortRate := burnRate(counts[w.ShortWindow][key], errorBudget)
+			if longRate >= w.BurnRate && shortRate >= w.BurnRate {
+				samples = append(samples, sloBurnRateSample{window: w, labels: long.labels, longRate: longRate, shortRate: shortRate})
+			}
+		}
+	}
+	return samples, nil
+}
+
+func (r *SLORule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+
+	prevState := r.State()
+
+	slo, err := r.ruleDB.GetSLOByID(ctx, r.ruleCondition.SLO.SLOId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get slo %s: %w", r.ruleCondition.SLO.SLOId, err)
+	}
+
+	samples, err := r.evalBurnRates(ctx, slo, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	// the remaining budget is only needed for the annotations of the alerts
+	var remaining map[string]*sloCounts
+	if len(samples) > 0 {
+		end := ts.Add(-r.evalDelay).Truncate(time.Minute)
+		remaining, err = r.evaluator.Counts(ctx, slo, end.Add(-time.Duration(slo.Objective.Window)), end)
+		if err != nil {
+			zap.L().Error("failed to get the remaining error budget", zap.String("ruleid", r.ID()), zap.Error(err))
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	resultFPs := map[uint64]struct{}{}
+	var alerts = make(map[uint64]*Alert, len(samples))
+
+	for _, smpl := range samples {
+		value := strconv.FormatFloat(smpl.longRate, 'f', 2, 64)
+		threshold := strconv.FormatFloat(smpl.window.BurnRate, 'f', 2, 64)
+
+		tmplData := AlertTemplateData(smpl.labels, value, threshold)
+		// Inject some convenience variables that are easier to remember for users
+		// who are not used to Go's templating system.
+		defs := "{{$labels := .Labels}}{{$value := .Value}}{{$threshold := .Threshold}}"
+
+		// utility function to apply go template on labels and annotations
+		expand := func(text string) string {
+
+			tmpl := NewTemplateExpander(
+				ctx,
+				defs+text,
+				"__alert_"+r.Name(),
+				tmplData,
+				times.Time(timestamp.FromTime(ts)),
+				nil,
+			)
+			result, err := tmpl.Expand()
+			if err != nil {
+				result = fmt.Sprintf("<error expanding template: %s>", err)
+				zap.L().Error("Expanding alert template failed", zap.Error(err), zap.Any("data", tmplData))
+			}
+			return result
+		}
+
+		resultLabels := labels.FromMap(smpl.labels)
+		lb := labels.NewBuilder(resultLabels)
+
+		for name, value := range r.labels.Map() {
+			lb.Set(name, expand(value))
+		}
+
+		lb.Set(sloLabel, slo.Name)
+		lb.Set(longWindowLabel, time.Duration(smpl.window.LongWindow).String())
+		lb.Set(shortWindowLabel, time.Duration(smpl.window.ShortWindow).String())
+		if smpl.window.Severity != "" {
+			lb.Set(severityLabel, smpl.window.Severity)
+		}
+		lb.Set(labels.AlertNameLabel, r.Name())
+		lb.Set(labels.AlertRuleIdLabel, r.ID())
+		lb.Set(labels.RuleSourceLabel, r.GeneratorURL())
+
+		annotations := make(labels.Labels, 0, len(r.annotations.Map())+3)
+		for name, value := range r.annotations.Map() {
+			annotations = append(annotations, labels.Label{Name: name, Value: expand(value)})
+		}
+		annotations = append(annotations,
+			labels.Label{Name: "long_window_burn_rate", Value: value},
+			labels.Label{Name: "short_window_burn_rate", Value: strconv.FormatFloat(smpl.shortRate, 'f', 2, 64)},
+		)
+		if c, ok := remaining[resultLabels.String()]; ok {
+			annotations = append(annotations, labels.Label{
+				Name:  "error_budget_remaining",
+				Value: strconv.FormatFloat(budgetRemaining(c, slo.Objective.ErrorBudget()), 'f', 2, 64) + "%",
+			})
+		}
+
+		lbs := lb.Labels()
+		h := lbs.Hash()
+		resultFPs[h] = struct{}{}
+
+		alerts[h] = &Alert{
+			Labels:            lbs,
+			QueryResultLables: resultLabels,
+			Annotations:       annotations,
+			ActiveAt:          ts,
+			State:             model.StatePending,
+			Value:             smpl.longRate,
+			GeneratorURL:      r.GeneratorURL(),
+			Receivers:         r.preferredChannels,
+		}
+	}
+
+	zap.L().Info("number of alerts found", zap.String("name", r.Name()), zap.Int("count", len(alerts)))
+
+	// alerts[h] is ready, add or update active list now
+	for h, a := range alerts {
+		// Check whether we already have alerting state for the identifying label set.
+		// Update the last value and annotations if so, create a new alert entry otherwise.
+		if alert, ok := r.Active[h]; ok && alert.State != model.StateInactive {
+
+			alert.Value = a.Value
+			alert.Annotations = a.Annotations
+			alert.Receivers = r.preferredChannels
+			continue
+		}
+
+		r.Active[h] = a
+	}
+
+	itemsToAdd := []model.RuleStateHistory{}
+
+	// Check if any pending alerts should be removed or fire now. Write out alert timeseries.
+	for fp, a := range r.Active {
+		labelsJSON, err := json.Marshal(a.QueryResultLables)
+		if err != nil {
+			zap.L().Error("error marshaling labels", zap.Error(err), zap.Any("labels", a.Labels))
+		}
+		if _, ok := resultFPs[fp]; !ok {
+			// If the alert was previously firing, keep it around for a given
+			// retention time so it is reported as resolved to the AlertManager.
+			if a.State == model.StatePending || (!a.ResolvedAt.IsZero() && ts.Sub(a.ResolvedAt) > ResolvedRetention) {
+				delete(r.Active, fp)
+			}
+			if a.State != model.StateInactive {
+				a.State = model.StateInactive
+				a.ResolvedAt = ts
+				itemsToAdd = append(itemsToAdd, model.RuleStateHistory{
+					RuleID:       r.ID(),
+					RuleName:     r.Name(),
+					State:        model.StateInactive,
+					StateChanged: true,
+					UnixMilli:    ts.UnixMilli(),
+					Labels:       model.LabelsString(labelsJSON),
+					Fingerprint:  a.QueryResultLables.Hash(),
+					Value:        a.Value,
+				})
+			}
+			continue
+		}
+
+		if a.State == model.StatePending && ts.Sub(a.ActiveAt) >= r.holdDuration {
+			a.State = model.StateFiring
+			a.FiredAt = ts
+			itemsToAdd = append(itemsToAdd, model.RuleStateHistory{
+				RuleID:       r.ID(),
+				RuleName:     r.Name(),
+				State:        model.StateFiring,
+				StateChanged: true,
+				UnixMilli:    ts.UnixMilli(),
+				Labels:       model.LabelsString(labelsJSON),
+				Fingerprint:  a.QueryResultLables.Hash(),
+				Value:        a.Value,
+			})
+		}
+	}
+
+	currentState := r.State()
+
+	overallStateChanged := currentState != prevState
+	for idx, item := range itemsToAdd {
+		item.OverallStateChanged = overallStateChanged
+		item.OverallState = currentState
+		itemsToAdd[idx] = item
+	}
+
+	r.RecordRuleStateHistory(ctx, prevState, currentState, itemsToAdd)
+
+	r.health = HealthGood
+	r.lastError = nil
+
+	return len(r.Active), nil
+}
+
+func (r *SLORule) String() string {
+
+	ar := PostableRule{
+		AlertName:         r.name,
+		RuleCondition:     r.ruleCondition,
+		EvalWindow:        Duration(r.evalWindow),
+		Labels:            r.labels.Map(),
+		Annotations:       r.annotations.Map(),
+		PreferredChannels: r.preferredChannels,
+	}
+
+	byt, err := yaml.Marshal(ar)
+	if err != nil {
+		return fmt.Sprintf("error marshaling alerting rule: %s", err.Error())
+	}
+
+	return string(byt)
+}
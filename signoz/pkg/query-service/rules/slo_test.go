@@ -0,0 +1,228 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+// sloFakeQuerier returns a point of the good and total events per query, the
+// good events depend on the length of the queried window
+type sloFakeQuerier struct {
+	total float64
+	good  map[time.Duration]float64
+}
+
+func (q *sloFakeQuerier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3) ([]*v3.Result, map[string]error, error) {
+	window := time.Duration(params.End-params.Start) * time.Millisecond
+	series := func(v float64) []*v3.Series {
+		return []*v3.Series{{
+			Labels:      map[string]string{"service_name": "frontend"},
+			LabelsArray: []map[string]string{{"service_name": "frontend"}},
+			Points:      []v3.Point{{Timestamp: params.End, Value: v}},
+		}}
+	}
+	return []*v3.Result{
+		{QueryName: sloGoodQueryName, Series: series(q.good[window])},
+		{QueryName: sloTotalQueryName, Series: series(q.total)},
+	}, nil, nil
+}
+
+func (q *sloFakeQuerier) QueriesExecuted() []string { return nil }
+func (q *sloFakeQuerier) TimeRanges() [][]int       { return nil }
+
+func sloQuery(filter string) *v3.BuilderQuery {
+	return &v3.BuilderQuery{
+		DataSource:         v3.DataSourceMetrics,
+		AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"},
+		Temporality:        v3.Delta,
+		TimeAggregation:    v3.TimeAggregationIncrease,
+		SpaceAggregation:   v3.SpaceAggregationSum,
+		AggregateOperator:  v3.AggregateOperatorSumRate,
+		Filters: &v3.FilterSet{Operator: "AND", Items: []v3.FilterItem{
+			{Key: v3.AttributeKey{Key: "status_code"}, Operator: v3.FilterOperatorEqual, Value: filter},
+		}},
+		GroupBy: []v3.AttributeKey{{Key: "service_name"}},
+	}
+}
+
+func testSLO() *SLO {
+	return &SLO{
+		Name: "frontend availability",
+		Objective: &SLOObjective{
+			Target: 99,
+			Window: Duration(30 * 24 * time.Hour),
+			Good:   sloQuery("STATUS_CODE_UNSET"),
+			Total:  sloQuery(""),
+		},
+	}
+}
+
+func TestSLOBurnRate(t *testing.T) {
+	cases := []struct {
+		name      string
+		counts    *sloCounts
+		burnRate  float64
+		remaining float64
+	}{
+		{name: "no events", counts: &sloCounts{}, burnRate: 0, remaining: 100},
+		{name: "no errors", counts: &sloCounts{good: 100, total: 100}, burnRate: 0, remaining: 100},
+		{name: "at budget", counts: &sloCounts{good: 99, total: 100}, burnRate: 1, remaining: 0},
+		{name: "half budget", counts: &sloCounts{good: 995, total: 1000}, burnRate: 0.5, remaining: 50},
+		{name: "over budget", counts: &sloCounts{good: 80, total: 100}, burnRate: 20, remaining: -1900},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.InDelta(t, c.burnRate, burnRate(c.counts, 0.01), 1e-9)
+			assert.InDelta(t, c.remaining, budgetRemaining(c.counts, 0.01), 1e-9)
+		})
+	}
+}
+
+func TestSLOValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		mutate  func(s *SLO)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(s *SLO) {}},
+		{name: "missing name", mutate: func(s *SLO) { s.Name = "" }, wantErr: true},
+		{name: "missing objective", mutate: func(s *SLO) { s.Objective = nil }, wantErr: true},
+		{name: "target too high", mutate: func(s *SLO) { s.Objective.Target = 100 }, wantErr: true},
+		{name: "window too short", mutate: func(s *SLO) { s.Objective.Window = Duration(time.Minute) }, wantErr: true},
+		{name: "missing good query", mutate: func(s *SLO) { s.Objective.Good = nil }, wantErr: true},
+		{name: "different group by", mutate: func(s *SLO) { s.Objective.Good.GroupBy = nil }, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			slo := testSLO()
+			c.mutate(slo)
+			err := slo.Validate()
+			if c.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseSLORule(t *testing.T) {
+	rule, err := ParsePostableRule([]byte(`{
+		"alert": "frontend slo burn",
+		"ruleType": "threshold_rule",
+		"condition": {"slo": {"sloId": "1"}}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, RuleType(RuleTypeSLO), rule.RuleType)
+	assert.True(t, rule.RuleCondition.IsValid())
+
+	_, err = ParsePostableRule([]byte(`{
+		"alert": "frontend slo burn",
+		"condition": {"slo": {"sloId": "1", "windows": [{"longWindow": "5m", "shortWindow": "1h", "burnRate": 14.4}]}}
+	}`))
+	assert.Error(t, err)
+
+	_, err = ParsePostableRule([]byte(`{
+		"alert": "frontend slo burn",
+		"condition": {"slo": {"sloId": ""}}
+	}`))
+	assert.Error(t, err)
+}
+
+func TestSLORuleBurnRates(t *testing.T) {
+	// target 99% so a burn rate of 1 is 1% of errors
+	querier := &sloFakeQuerier{
+		total: 1000,
+		good: map[time.Duration]float64{
+			time.Hour:        850, // 15
+			5 * time.Minute:  800, // 20
+			6 * time.Hour:    950, // 5
+			30 * time.Minute: 900, // 10
+			24 * time.Hour:   960, // 4
+			2 * time.Hour:    990, // 1
+			72 * time.Hour:   985, // 1.5
+		},
+	}
+	rule := &SLORule{
+		BaseRule:  &BaseRule{ruleCondition: &RuleCondition{SLO: &SLOCondition{SLOId: "1"}}},
+		evaluator: NewSLOEvaluator(querier, nil, false),
+	}
+
+	samples, err := rule.evalBurnRates(context.Background(), testSLO(), time.Now())
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+
+	// 1h/5m fires, 6h/30m and 24h/2h are under the burn rate on one of the windows
+	assert.Equal(t, DefaultBurnRateWindows[0], samples[0].window)
+	assert.InDelta(t, 15, samples[0].longRate, 1e-9)
+	assert.InDelta(t, 20, samples[0].shortRate, 1e-9)
+	assert.Equal(t, map[string]string{"service_name": "frontend"}, samples[0].labels)
+
+	assert.Equal(t, DefaultBurnRateWindows[3], samples[1].window)
+	assert.InDelta(t, 1.5, samples[1].longRate, 1e-9)
+	assert.InDelta(t, 5, samples[1].shortRate, 1e-9)
+}
+
+func TestSLOBudgetHistory(t *testing.T) {
+	lbls := map[string]string{"service_name": "frontend"}
+	points := func(values ...float64) []v3.Point {
+		res := make([]v3.Point, 0, len(values))
+		for idx, v := range values {
+			res = append(res, v3.Point{Timestamp: int64(idx) * 60000, Value: v})
+		}
+		return res
+	}
+	good := []*v3.Series{{Labels: lbls, Points: points(100, 90, 80, 100, 100)}}
+	total := []*v3.Series{{Labels: lbls, Points: points(100, 100, 100, 100, 100)}}
+
+	objective := &SLOObjective{Target: 90, Window: Duration(3 * time.Minute)}
+	results := budgetHistory(good, total, objective, 120000, 240000, 60000)
+	require.Len(t, results, 3)
+
+	values := func(res *v3.Result) []float64 {
+		require.Len(t, res.Series, 1)
+		assert.Equal(t, lbls, res.Series[0].Labels)
+		var vals []float64
+		for _, p := range res.Series[0].Points {
+			vals = append(vals, p.Value)
+		}
+		return vals
+	}
+
+	assert.Equal(t, SLOResultSLI, results[0].QueryName)
+	assert.InDeltaSlice(t, []float64{90, 90, 93.333333}, values(results[0]), 1e-5)
+
+	assert.Equal(t, SLOResultBudgetRemaining, results[1].QueryName)
+	assert.InDeltaSlice(t, []float64{0, 0, 33.333333}, values(results[1]), 1e-5)
+
+	assert.Equal(t, SLOResultBurnRate, results[2].QueryName)
+	assert.InDeltaSlice(t, []float64{2, 0, 0}, values(results[2]), 1e-5)
+}
+
+func TestSLODBMissingID(t *testing.T) {
+	ctx := context.Background()
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), am.NewEmbeddedManager(nil))
+
+	id, err := ruleDB.CreateSLO(ctx, *testSLO())
+	require.NoError(t, err)
+	require.NoError(t, ruleDB.EditSLO(ctx, *testSLO(), strconv.FormatInt(id, 10)))
+
+	// the missing slos are reported as no rows for the handlers to respond with not found
+	missing := strconv.FormatInt(id+1, 10)
+	_, err = ruleDB.GetSLOByID(ctx, missing)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, ruleDB.EditSLO(ctx, *testSLO(), missing), sql.ErrNoRows)
+	assert.ErrorIs(t, ruleDB.DeleteSLO(ctx, missing), sql.ErrNoRows)
+
+	require.NoError(t, ruleDB.DeleteSLO(ctx, strconv.FormatInt(id, 10)))
+}
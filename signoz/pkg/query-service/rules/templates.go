@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	html_template "html/template"
 	text_template "text/template"
@@ -17,6 +18,8 @@ import (
 	"golang.org/x/text/cases"
 
 	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/formatter"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/utils/times"
 )
 
@@ -47,6 +50,168 @@ func (q tmplQueryResultsByLabelSorter) Swap(i, j int) {
 	q.results[i], q.results[j] = q.results[j], q.results[i]
 }
 
+const (
+	// maxTemplateTop is the number of alerting series available in the templates
+	maxTemplateTop = 10
+	// maxTemplateSamples is the number of log lines or trace ids available in the templates
+	maxTemplateSamples = 5
+	// maxSparklineWidth is the number of characters of a sparkline, longer
+	// series are averaged into buckets
+	maxSparklineWidth = 40
+)
+
+var sparklineTicks = []rune("▁▂▃▄▅▆▇█")
+
+// AlertTemplateContext is the query result context of an alert available in
+// the templates in addition to the labels, value and threshold.
+type AlertTemplateContext struct {
+	// Unit is the unit of the values of the rule
+	Unit string
+	// Points are the points of the evaluated series
+	Points []v3.Point
+	// Top are the alerting series of the evaluation, most offending first
+	Top []TemplateSeries
+	// Logs are the latest log lines matching the alert, for logs based alerts.
+	// Samples are only fetched for the series in Top
+	Logs []TemplateLogLine
+	// TraceIDs are the ids of the latest spans matching the alert, for traces based alerts
+	TraceIDs []string
+	// RelatedLogs and RelatedTraces are the links to the explorer pages
+	RelatedLogs   string
+	RelatedTraces string
+}
+
+// TemplateSeries is the label set and the value of an alerting series
+type TemplateSeries struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// TemplateLogLine is a log line matching an alert
+type TemplateLogLine struct {
+	Timestamp    time.Time
+	SeverityText string
+	Body         string
+}
+
+func (l TemplateLogLine) String() string {
+	if l.SeverityText == "" {
+		return fmt.Sprintf("%s %s", l.Timestamp.UTC().Format(time.RFC3339), l.Body)
+	}
+	return fmt.Sprintf("%s %s %s", l.Timestamp.UTC().Format(time.RFC3339), l.SeverityText, l.Body)
+}
+
+// TemplateSummary summarizes the points of a series
+type TemplateSummary struct {
+	Min   float64
+	Max   float64
+	Avg   float64
+	Last  float64
+	Count int
+}
+
+func (s TemplateSummary) String() string {
+	if s.Count == 0 {
+		return "no data"
+	}
+	return fmt.Sprintf("min=%.4g max=%.4g avg=%.4g last=%.4g", s.Min, s.Max, s.Avg, s.Last)
+}
+
+func validPoints(points []v3.Point) []float64 {
+	values := make([]float64, 0, len(points))
+	for _, p := range points {
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		values = append(values, p.Value)
+	}
+	return values
+}
+
+func summarize(points []v3.Point) TemplateSummary {
+	values := validPoints(points)
+	if len(values) == 0 {
+		return TemplateSummary{}
+	}
+	s := TemplateSummary{Min: values[0], Max: values[0], Last: values[len(values)-1], Count: len(values)}
+	sum := 0.0
+	for _, v := range values {
+		s.Min = math.Min(s.Min, v)
+		s.Max = math.Max(s.Max, v)
+		sum += v
+	}
+	s.Avg = sum / float64(len(values))
+	return s
+}
+
+// sparkline renders the points with unicode block characters
+func sparkline(points []v3.Point) string {
+	values := validPoints(points)
+	if len(values) > maxSparklineWidth {
+		buckets := make([]float64, maxSparklineWidth)
+		for i := range buckets {
+			from := i * len(values) / maxSparklineWidth
+			to := (i + 1) * len(values) / maxSparklineWidth
+			sum := 0.0
+			for _, v := range values[from:to] {
+				sum += v
+			}
+			buckets[i] = sum / float64(to-from)
+		}
+		values = buckets
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		tick := 0
+		if hi > lo {
+			tick = int(math.Round((v - lo) / (hi - lo) * float64(len(sparklineTicks)-1)))
+		}
+		sb.WriteRune(sparklineTicks[tick])
+	}
+	return sb.String()
+}
+
+// formatLabels renders the labels as comma separated key=value pairs sorted by key
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, labels[k]))
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// topSeries returns the alerting samples as template series, most offending first
+func topSeries(samples Vector, op CompareOp, n int) []TemplateSeries {
+	series := make([]TemplateSeries, 0, len(samples))
+	for _, smpl := range samples {
+		if smpl.IsMissing {
+			continue
+		}
+		series = append(series, TemplateSeries{Labels: smpl.Metric.Map(), Value: smpl.V})
+	}
+	sort.SliceStable(series, func(i, j int) bool {
+		if op == ValueIsBelow {
+			return series[i].Value < series[j].Value
+		}
+		return series[i].Value > series[j].Value
+	})
+	if len(series) > n {
+		series = series[:n]
+	}
+	return series
+}
+
 // Expander executes templates in text or HTML mode with a common set of Prometheus template functions.
 type TemplateExpander struct {
 	text    string
@@ -193,6 +358,18 @@ func NewTemplateExpander(
 				t := times.TimeFromUnixNano(int64(v * 1e9)).Time().UTC()
 				return fmt.Sprint(t)
 			},
+			"humanizeUnit": func(unit string, v float64) string {
+				return formatter.FromUnit(unit).Format(v, unit)
+			},
+			"sparkline": sparkline,
+			"summary":   summarize,
+			"topN": func(n int, v []TemplateSeries) []TemplateSeries {
+				if n < len(v) {
+					return v[:n]
+				}
+				return v
+			},
+			"formatLabels": formatLabels,
 			"pathPrefix": func() string {
 				return externalURL.Path
 			},
@@ -203,8 +380,22 @@ func NewTemplateExpander(
 	}
 }
 
+// tmplAlertData is the data used in expanding the alert templates
+type tmplAlertData struct {
+	Labels    map[string]string
+	Value     string
+	Threshold string
+	AlertTemplateContext
+}
+
 // AlertTemplateData returns the interface to be used in expanding the template.
 func AlertTemplateData(labels map[string]string, value string, threshold string) interface{} {
+	return AlertTemplateDataWithContext(labels, value, threshold, AlertTemplateContext{})
+}
+
+// AlertTemplateDataWithContext returns the interface to be used in expanding
+// the template with the query result context of the alert.
+func AlertTemplateDataWithContext(labels map[string]string, value string, threshold string, alertCtx AlertTemplateContext) interface{} {
 	// This exists here for backwards compatibility.
 	// The labels map passed in no longer contains the normalized labels.
 	// To continue supporting the old way of referencing labels, we need to
@@ -216,14 +407,11 @@ func AlertTemplateData(labels map[string]string, value string, threshold string)
 		newLabels[common.NormalizeLabelName(k)] = v
 	}
 
-	return struct {
-		Labels    map[string]string
-		Value     string
-		Threshold string
-	}{
-		Labels:    newLabels,
-		Value:     value,
-		Threshold: threshold,
+	return tmplAlertData{
+		Labels:               newLabels,
+		Value:                value,
+		Threshold:            threshold,
+		AlertTemplateContext: alertCtx,
 	}
 }
 
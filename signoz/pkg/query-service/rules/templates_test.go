@@ -2,10 +2,14 @@ package rules
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/require"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.signoz.io/signoz/pkg/query-service/utils/times"
 )
 
@@ -85,3 +89,74 @@ func TestTemplateExpander_WithVariableSyntax(t *testing.T) {
 	}
 	require.Equal(t, "test my-service exceeds 100 and observed at 200", result)
 }
+
+func TestTemplateExpander_WithContext(t *testing.T) {
+	points := []v3.Point{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 5}, {Timestamp: 3, Value: 3}, {Timestamp: 4, Value: 8}}
+	alertCtx := AlertTemplateContext{
+		Unit:   "ms",
+		Points: points,
+		Top: []TemplateSeries{
+			{Labels: map[string]string{"service.name": "frontend", "env": "prod"}, Value: 800},
+			{Labels: map[string]string{"service.name": "cart"}, Value: 500},
+		},
+		Logs: []TemplateLogLine{
+			{Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), SeverityText: "ERROR", Body: "connection refused"},
+		},
+		TraceIDs: []string{"0af7651916cd43dd8448eb211c80319c"},
+	}
+
+	cases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "sparkline", text: "{{sparkline .Points}}", expected: "▁▅▃█"},
+		{name: "summary", text: "{{summary .Points}}", expected: "min=1 max=8 avg=4.25 last=8"},
+		{name: "summary field", text: "{{(summary .Points).Max | humanizeUnit .Unit}}", expected: "8 ms"},
+		{name: "humanize unit", text: `{{humanizeUnit "bytes" 2048}}`, expected: "2.0 KiB"},
+		{name: "top", text: "{{range topN 1 .Top}}{{formatLabels .Labels}} {{.Value}}{{end}}", expected: "env=prod, service.name=frontend 800"},
+		{name: "logs", text: "{{range .Logs}}{{.}}{{end}}", expected: "2024-01-01T00:00:00Z ERROR connection refused"},
+		{name: "trace ids", text: "{{range .TraceIDs}}{{.}}{{end}}", expected: "0af7651916cd43dd8448eb211c80319c"},
+		{name: "labels still available", text: "$service.name {{$value}}", expected: "my-service 800"},
+	}
+	defs := "{{$labels := .Labels}}{{$value := .Value}}{{$threshold := .Threshold}}"
+	data := AlertTemplateDataWithContext(map[string]string{"service.name": "my-service"}, "800", "500", alertCtx)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expander := NewTemplateExpander(context.Background(), defs+c.text, "test", data, times.Time(time.Now().Unix()), nil)
+			result, err := expander.Expand()
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	require.Equal(t, "", sparkline(nil))
+	require.Equal(t, "▁▁▁", sparkline([]v3.Point{{Value: 2}, {Value: 2}, {Value: 2}}))
+	require.Equal(t, "▁█", sparkline([]v3.Point{{Value: 0}, {Value: math.NaN()}, {Value: 1}}))
+
+	long := make([]v3.Point, 400)
+	for i := range long {
+		long[i] = v3.Point{Value: float64(i)}
+	}
+	require.Equal(t, maxSparklineWidth, utf8.RuneCountInString(sparkline(long)))
+}
+
+func TestTopSeries(t *testing.T) {
+	samples := Vector{
+		{Point: Point{V: 3}, Metric: labels.FromStrings("service", "a")},
+		{Point: Point{V: 9}, Metric: labels.FromStrings("service", "b")},
+		{Point: Point{V: 1}, Metric: labels.FromStrings("service", "c")},
+		{Metric: labels.FromStrings("lastSeen", "yesterday"), IsMissing: true},
+	}
+
+	above := topSeries(samples, ValueIsAbove, 2)
+	require.Len(t, above, 2)
+	require.Equal(t, "b", above[0].Labels["service"])
+	require.Equal(t, "a", above[1].Labels["service"])
+
+	below := topSeries(samples, ValueIsBelow, 10)
+	require.Len(t, below, 3)
+	require.Equal(t, "c", below[0].Labels["service"])
+}
@@ -76,6 +76,27 @@ func defaultTestNotification(opts PrepareTestRuleOptions) (int, *model.ApiError)
 			zap.L().Error("failed to prepare a new promql rule for test", zap.String("name", rule.Name()), zap.Error(err))
 			return 0, model.BadRequest(err)
 		}
+	} else if parsedRule.RuleType == RuleTypeSLO {
+
+		// create slo rule
+		rule, err = NewSLORule(
+			alertname,
+			parsedRule,
+			opts.FF,
+			opts.Reader,
+			opts.RuleDB,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			WithSendAlways(),
+			WithSendUnmatched(),
+		)
+
+		if err != nil {
+			zap.L().Error("failed to prepare a new slo rule for test", zap.String("name", alertname), zap.Error(err))
+			return 0, model.BadRequest(err)
+		}
+	} else if parsedRule.RuleType == RuleTypeRecording {
+		return 0, model.BadRequest(fmt.Errorf("recording rules do not send notifications"))
 	} else {
 		return 0, model.BadRequest(fmt.Errorf("failed to derive ruletype with given information"))
 	}
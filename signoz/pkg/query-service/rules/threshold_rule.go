@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"strings"
 	"text/template"
 	"time"
 
@@ -261,15 +262,17 @@ func (r *ThresholdRule) GetSelectedQuery() string {
 	return r.ruleCondition.GetSelectedQueryName()
 }
 
-func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vector, error) {
+// runSelectedQuery runs the queries of the rule condition and returns the
+// result of the selected query, nil if the selected query has no result
+func (r *ThresholdRule) runSelectedQuery(ctx context.Context, ts time.Time) (*v3.QueryRangeParamsV3, *v3.Result, error) {
 
 	params, err := r.prepareQueryRange(ts)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	err = r.PopulateTemporality(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("internal error while setting temporality")
+		return nil, nil, fmt.Errorf("internal error while setting temporality")
 	}
 
 	if params.CompositeQuery.QueryType == v3.QueryTypeBuilder {
@@ -289,7 +292,7 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 			if logsv3.EnrichmentRequired(params) {
 				logsFields, err := r.reader.GetLogFields(ctx)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 				logsKeys := model.GetLogFieldsV3(ctx, params, logsFields)
 				r.logsKeys = logsKeys
@@ -300,7 +303,7 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 		if hasTracesQuery {
 			spanKeys, err := r.reader.GetSpanAttributeKeys(ctx)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			r.spansKeys = spanKeys
 			if r.useTraceNewSchema {
@@ -322,14 +325,14 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 
 	if err != nil {
 		zap.L().Error("failed to get alert query result", zap.String("rule", r.Name()), zap.Error(err), zap.Any("errors", queryErrors))
-		return nil, fmt.Errorf("internal error while querying")
+		return nil, nil, fmt.Errorf("internal error while querying")
 	}
 
 	if params.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 		results, err = postprocess.PostProcessResult(results, params)
 		if err != nil {
 			zap.L().Error("failed to post process result", zap.String("rule", r.Name()), zap.Error(err))
-			return nil, fmt.Errorf("internal error while post processing")
+			return nil, nil, fmt.Errorf("internal error while post processing")
 		}
 	}
 
@@ -343,14 +346,23 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 		}
 	}
 
+	return params, queryResult, nil
+}
+
+func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vector, error) {
+	_, queryResult, err := r.runSelectedQuery(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
 	if queryResult != nil && len(queryResult.Series) > 0 {
-		r.lastTimestampWithDatapoints = time.Now()
+		r.lastTimestampWithDatapoints = ts
 	}
 
 	var resultVector Vector
 
 	// if the data is missing for `For` duration then we should send alert
-	if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(time.Now()) {
+	if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(ts) {
 		zap.L().Info("no data found for rule condition", zap.String("ruleid", r.ID()))
 		lbls := labels.NewBuilder(labels.Labels{})
 		if !r.lastTimestampWithDatapoints.IsZero() {
@@ -366,12 +378,134 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 	for _, series := range queryResult.Series {
 		smpl, shouldAlert := r.ShouldAlert(*series)
 		if shouldAlert {
+			smpl.Points = removeGroupinSetPoints(*series)
 			resultVector = append(resultVector, smpl)
 		}
 	}
 	return resultVector, nil
 }
 
+// usesTemplateSamples reports whether any label or annotation template refers
+// to the sample log lines or trace ids, which need an additional query
+func (r *ThresholdRule) usesTemplateSamples() bool {
+	for _, lbls := range []labels.BaseLabels{r.labels, r.annotations} {
+		for _, value := range lbls.Map() {
+			if strings.Contains(value, ".Logs") || strings.Contains(value, ".TraceIDs") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prepareTemplateSamples returns the latest log lines or trace ids matching the
+// selected query and the labels of the alert
+func (r *ThresholdRule) prepareTemplateSamples(ctx context.Context, ts time.Time, lbls labels.Labels) ([]TemplateLogLine, []string, error) {
+	selectedQuery := r.GetSelectedQuery()
+
+	// samples are only fetched when the selected query is a logs or traces
+	// builder query, formula queries don't have a single source to sample from
+	q := r.ruleCondition.CompositeQuery.BuilderQueries[selectedQuery]
+	if q == nil || (q.DataSource != v3.DataSourceLogs && q.DataSource != v3.DataSourceTraces) {
+		return nil, nil, nil
+	}
+
+	keys := r.logsKeys
+	if q.DataSource == v3.DataSourceTraces {
+		keys = r.spansKeys
+	}
+	queryFilter := []v3.FilterItem{}
+	if q.Filters != nil {
+		queryFilter = q.Filters.Items
+	}
+
+	sampleQuery := &v3.BuilderQuery{
+		QueryName:         selectedQuery,
+		Expression:        selectedQuery,
+		DataSource:        q.DataSource,
+		AggregateOperator: v3.AggregateOperatorNoOp,
+		StepInterval:      60,
+		Filters: &v3.FilterSet{
+			Operator: "AND",
+			Items:    contextlinks.PrepareFilters(lbls.Map(), queryFilter, q.GroupBy, keys),
+		},
+		OrderBy: []v3.OrderBy{{ColumnName: "timestamp", Order: "desc"}},
+		Limit:   maxTemplateSamples,
+	}
+	if q.DataSource == v3.DataSourceTraces {
+		sampleQuery.SelectColumns = []v3.AttributeKey{
+			{Key: "name", DataType: v3.AttributeKeyDataTypeString, Type: v3.AttributeKeyTypeTag, IsColumn: true},
+		}
+	}
+
+	startTs, endTs := r.Timestamps(ts)
+	params := &v3.QueryRangeParamsV3{
+		Start: startTs.UnixMilli(),
+		End:   endTs.UnixMilli(),
+		Step:  60,
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType:      v3.QueryTypeBuilder,
+			PanelType:      v3.PanelTypeList,
+			BuilderQueries: map[string]*v3.BuilderQuery{selectedQuery: sampleQuery},
+		},
+		Variables: make(map[string]interface{}, 0),
+		NoCache:   true,
+	}
+	if q.DataSource == v3.DataSourceLogs {
+		logsv3.Enrich(params, keys)
+	} else if r.useTraceNewSchema {
+		tracesV4.Enrich(params, keys)
+	} else {
+		tracesV3.Enrich(params, keys)
+	}
+
+	var results []*v3.Result
+	var err error
+	if r.version == "v4" {
+		results, _, err = r.querierV2.QueryRange(ctx, params)
+	} else {
+		results, _, err = r.querier.QueryRange(ctx, params)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var logs []TemplateLogLine
+	var traceIDs []string
+	for _, res := range results {
+		for _, row := range res.List {
+			if row == nil {
+				continue
+			}
+			if q.DataSource == v3.DataSourceTraces {
+				if traceID := sampleString(row.Data["traceID"]); traceID != "" {
+					traceIDs = append(traceIDs, traceID)
+				}
+				continue
+			}
+			line := TemplateLogLine{Timestamp: row.Timestamp}
+			line.Body = sampleString(row.Data["body"])
+			line.SeverityText = sampleString(row.Data["severity_text"])
+			logs = append(logs, line)
+		}
+	}
+	return logs, traceIDs, nil
+}
+
+// sampleString returns the string value of a list result column, the
+// reader scans the columns into pointers
+func sampleString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case *string:
+		if s != nil {
+			return *s
+		}
+	}
+	return ""
+}
+
 func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
 
 	prevState := r.State()
@@ -389,6 +523,16 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 	resultFPs := map[uint64]struct{}{}
 	var alerts = make(map[uint64]*Alert, len(res))
 
+	top := topSeries(res, r.compareOp(), maxTemplateTop)
+	useSamples := r.usesTemplateSamples()
+	// samples need a query per series, only fetch them for the top series
+	sampledFPs := make(map[uint64]struct{}, len(top))
+	if useSamples {
+		for _, s := range top {
+			sampledFPs[labels.FromMap(s.Labels).Hash()] = struct{}{}
+		}
+	}
+
 	for _, smpl := range res {
 		l := make(map[string]string, len(smpl.Metric))
 		for _, lbl := range smpl.Metric {
@@ -399,7 +543,29 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 		threshold := valueFormatter.Format(r.targetVal(), r.Unit())
 		zap.L().Debug("Alert template data for rule", zap.String("name", r.Name()), zap.String("formatter", valueFormatter.Name()), zap.String("value", value), zap.String("threshold", threshold))
 
-		tmplData := AlertTemplateData(l, value, threshold)
+		tmplCtx := AlertTemplateContext{
+			Unit:   r.Unit(),
+			Points: smpl.Points,
+			Top:    top,
+		}
+		if r.typ == AlertTypeTraces {
+			if link := r.prepareLinksToTraces(ts, smpl.Metric); link != "" && r.hostFromSource() != "" {
+				tmplCtx.RelatedTraces = fmt.Sprintf("%s/traces-explorer?%s", r.hostFromSource(), link)
+			}
+		} else if r.typ == AlertTypeLogs {
+			if link := r.prepareLinksToLogs(ts, smpl.Metric); link != "" && r.hostFromSource() != "" {
+				tmplCtx.RelatedLogs = fmt.Sprintf("%s/logs/logs-explorer?%s", r.hostFromSource(), link)
+			}
+		}
+		if _, ok := sampledFPs[labels.FromMap(l).Hash()]; ok {
+			var samplesErr error
+			tmplCtx.Logs, tmplCtx.TraceIDs, samplesErr = r.prepareTemplateSamples(ctx, ts, smpl.Metric)
+			if samplesErr != nil {
+				zap.L().Error("failed to get the samples for the alert templates", zap.String("ruleid", r.ID()), zap.Error(samplesErr))
+			}
+		}
+
+		tmplData := AlertTemplateDataWithContext(l, value, threshold, tmplCtx)
 		// Inject some convenience variables that are easier to remember for users
 		// who are not used to Go's templating system.
 		defs := "{{$labels := .Labels}}{{$value := .Value}}{{$threshold := .Threshold}}"
@@ -445,18 +611,13 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 		// Links with timestamps should go in annotations since labels
 		// is used alert grouping, and we want to group alerts with the same
 		// label set, but different timestamps, together.
-		if r.typ == AlertTypeTraces {
-			link := r.prepareLinksToTraces(ts, smpl.Metric)
-			if link != "" && r.hostFromSource() != "" {
-				zap.L().Info("adding traces link to annotations", zap.String("link", fmt.Sprintf("%s/traces-explorer?%s", r.hostFromSource(), link)))
-				annotations = append(annotations, labels.Label{Name: "related_traces", Value: fmt.Sprintf("%s/traces-explorer?%s", r.hostFromSource(), link)})
-			}
-		} else if r.typ == AlertTypeLogs {
-			link := r.prepareLinksToLogs(ts, smpl.Metric)
-			if link != "" && r.hostFromSource() != "" {
-				zap.L().Info("adding logs link to annotations", zap.String("link", fmt.Sprintf("%s/logs/logs-explorer?%s", r.hostFromSource(), link)))
-				annotations = append(annotations, labels.Label{Name: "related_logs", Value: fmt.Sprintf("%s/logs/logs-explorer?%s", r.hostFromSource(), link)})
-			}
+		if tmplCtx.RelatedTraces != "" {
+			zap.L().Info("adding traces link to annotations", zap.String("link", tmplCtx.RelatedTraces))
+			annotations = append(annotations, labels.Label{Name: "related_traces", Value: tmplCtx.RelatedTraces})
+		}
+		if tmplCtx.RelatedLogs != "" {
+			zap.L().Info("adding logs link to annotations", zap.String("link", tmplCtx.RelatedLogs))
+			annotations = append(annotations, labels.Label{Name: "related_logs", Value: tmplCtx.RelatedLogs})
 		}
 
 		lbs := lb.Labels()
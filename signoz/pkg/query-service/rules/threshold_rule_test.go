@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.signoz.io/signoz/pkg/query-service/app/clickhouseReader"
 	"go.signoz.io/signoz/pkg/query-service/common"
 	"go.signoz.io/signoz/pkg/query-service/featureManager"
@@ -1606,6 +1607,99 @@ func TestThresholdRuleLogsLink(t *testing.T) {
 	}
 }
 
+func TestThresholdRuleTemplateLogSamples(t *testing.T) {
+	target := float64(10)
+	postableRule := PostableRule{
+		AlertName:  "Logs samples test",
+		AlertType:  AlertTypeLogs,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:         "A",
+						StepInterval:      60,
+						AggregateOperator: v3.AggregateOperatorCount,
+						DataSource:        v3.DataSourceLogs,
+						Expression:        "A",
+						Filters: &v3.FilterSet{
+							Operator: "AND",
+							Items: []v3.FilterItem{
+								{
+									Key:      v3.AttributeKey{Key: "k8s.container.name", IsColumn: false, Type: v3.AttributeKeyTypeTag, DataType: v3.AttributeKeyDataTypeString},
+									Value:    "testcontainer",
+									Operator: v3.FilterOperatorEqual,
+								},
+							},
+						},
+					},
+				},
+			},
+			CompareOp: ValueIsAbove,
+			MatchType: AtleastOnce,
+			Target:    &target,
+		},
+		Annotations: map[string]string{
+			"description": "{{range .Logs}}{{.SeverityText}}: {{.Body}}\n{{end}}",
+		},
+	}
+	fm := featureManager.StartManager()
+	mock, err := cmock.NewClickHouseWithQueryMatcher(nil, &queryMatcherAny{})
+	require.NoError(t, err)
+
+	metaCols := []cmock.ColumnType{{Name: "name", Type: "String"}, {Name: "dataType", Type: "String"}}
+	mock.
+		ExpectSelect("SELECT DISTINCT name, datatype from signoz_logs.distributed_logs_attribute_keys group by name, datatype").
+		WillReturnRows(cmock.NewRows(metaCols, [][]interface{}{}))
+	mock.
+		ExpectSelect("SELECT DISTINCT name, datatype from signoz_logs.distributed_logs_resource_keys group by name, datatype").
+		WillReturnRows(cmock.NewRows(metaCols, [][]interface{}{}))
+	mock.
+		ExpectSelect("SHOW CREATE TABLE signoz_logs.logs").
+		WillReturnRows(cmock.NewRows([]cmock.ColumnType{{Name: "statement", Type: "String"}}, [][]interface{}{{"statement"}}))
+
+	// the aggregate query of the rule
+	mock.
+		ExpectQuery("SELECT any").
+		WillReturnRows(cmock.NewRows(
+			[]cmock.ColumnType{{Name: "value", Type: "Float64"}},
+			[][]interface{}{{float64(20)}},
+		))
+
+	// the samples query, the reader scans the columns into pointers
+	ts := uint64(time.Now().UnixNano())
+	mock.
+		ExpectQuery("SELECT any").
+		WillReturnRows(cmock.NewRows(
+			[]cmock.ColumnType{
+				{Name: "timestamp", Type: "UInt64"},
+				{Name: "body", Type: "String"},
+				{Name: "severity_text", Type: "String"},
+			},
+			[][]interface{}{
+				{ts, "connection refused", "ERROR"},
+				{ts - 1, "retrying", "WARN"},
+			},
+		))
+
+	options := clickhouseReader.NewOptions("", "", "archiveNamespace")
+	reader := clickhouseReader.NewReaderFromClickhouseConnection(mock, options, nil, "", fm, "", true, true, time.Duration(time.Second), nil)
+
+	rule, err := NewThresholdRule("69", &postableRule, fm, reader, true, true)
+	require.NoError(t, err)
+
+	retVal, err := rule.Eval(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, retVal.(int))
+
+	for _, item := range rule.Active {
+		assert.Equal(t, "ERROR: connection refused\nWARN: retrying\n", item.Annotations.Get("description"))
+	}
+}
+
 func TestThresholdRuleShiftBy(t *testing.T) {
 	target := float64(10)
 	postableRule := PostableRule{
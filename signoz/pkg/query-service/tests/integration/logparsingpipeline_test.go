@@ -1,13 +1,16 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"runtime/debug"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -438,6 +441,122 @@ func TestCanSavePipelinesWithoutConnectedAgents(t *testing.T) {
 
 }
 
+func TestLogPipelinesCanaryRollout(t *testing.T) {
+	require := require.New(t)
+	testbed := NewLogPipelinesTestBed(t, nil)
+
+	// Connect a second agent. The testbed agent sorts first and becomes the canary.
+	agent2Id := "test-2"
+	agent2Conn := &opamp.MockOpAmpConnection{}
+	testbed.opampServer.OnMessage(agent2Conn, &protobufs.AgentToServer{
+		InstanceUid: agent2Id,
+		EffectiveConfig: &protobufs.EffectiveConfig{
+			ConfigMap: newInitialAgentConfigMap(),
+		},
+	})
+
+	postablePipelines := logparsingpipeline.PostablePipelines{
+		Pipelines: []logparsingpipeline.PostablePipeline{
+			{
+				OrderId: 1,
+				Name:    "pipeline1",
+				Alias:   "pipeline1",
+				Enabled: true,
+				Filter: &v3.FilterSet{
+					Operator: "AND",
+					Items: []v3.FilterItem{
+						{
+							Key: v3.AttributeKey{
+								Key:      "method",
+								DataType: v3.AttributeKeyDataTypeString,
+								Type:     v3.AttributeKeyTypeTag,
+							},
+							Operator: "=",
+							Value:    "GET",
+						},
+					},
+				},
+				Config: []logparsingpipeline.PipelineOperator{
+					{
+						OrderId: 1,
+						ID:      "add",
+						Type:    "add",
+						Field:   "attributes.test",
+						Value:   "val",
+						Enabled: true,
+						Name:    "test add",
+					},
+				},
+			},
+		},
+	}
+
+	stableResp := testbed.PostPipelinesToQS(postablePipelines)
+	testbed.simulateOpampClientAcknowledgementForLatestConfig()
+	testbed.simulateAgentConfigStatus(agent2Conn, agent2Id, "")
+
+	// The new version should only reach the canary agent.
+	testbed.opampClientConn.ClearMsgsFromServer()
+	agent2Conn.ClearMsgsFromServer()
+	postablePipelines.Pipelines[0].Config[0].Value = "canary"
+	postablePipelines.Rollout = &agentConf.RolloutOptions{CanaryPercent: 50, AutoRollback: true}
+	canaryResp := testbed.PostPipelinesToQS(postablePipelines)
+	require.NotNil(canaryResp.Rollout)
+	require.Equal(agentConf.RolloutCanary, canaryResp.Rollout.Status)
+	require.Equal([]string{"test"}, canaryResp.Rollout.CanaryAgents)
+	require.Equal(stableResp.Version, canaryResp.Rollout.StableVersion)
+	testbed.assertPipelinesSentToOpampClient(canaryResp.Pipelines)
+	require.Nil(agent2Conn.LatestMsgFromServer(), "agents outside the canary should keep the stable version")
+
+	// The diff between the versions should contain the changed operator.
+	diff := logparsingpipeline.PipelinesDiff{}
+	testbed.RequestQS(testbed.apiHandler.DiffLogsPipelinesHandler, fmt.Sprintf(
+		"/api/v1/logs/pipelines/diff?from=%d&to=%d", stableResp.Version, canaryResp.Version,
+	), nil, &diff)
+	require.Equal(0, len(diff.Added))
+	require.Equal(0, len(diff.Removed))
+	require.Equal(1, len(diff.Changed))
+	require.Equal("add", diff.Changed[0].Operators.Changed[0].ID)
+	require.Equal(
+		logparsingpipeline.PipelinePreviewFieldChange{Old: "val", New: "canary"},
+		diff.Changed[0].Operators.Changed[0].Fields["value"],
+	)
+
+	// Promoting the rollout should deploy the version to all agents.
+	promoteResp := logparsingpipeline.PipelinesResponse{}
+	testbed.RequestQS(
+		testbed.apiHandler.PromoteLogsPipelinesRollout,
+		fmt.Sprintf("/api/v1/logs/pipelines/rollout/%d/promote", canaryResp.Version),
+		map[string]string{"version": fmt.Sprint(canaryResp.Version)}, &promoteResp,
+	)
+	require.Equal(agentConf.RolloutPromoted, promoteResp.Rollout.Status)
+	assertPipelinesRecommendedInRemoteConfig(t, agent2Conn.LatestMsgFromServer(), canaryResp.Pipelines)
+	testbed.simulateOpampClientAcknowledgementForLatestConfig()
+	testbed.simulateAgentConfigStatus(agent2Conn, agent2Id, "")
+
+	// Failures reported by agents should roll the next version back automatically.
+	postablePipelines.Pipelines[0].Config[0].Value = "broken"
+	brokenResp := testbed.PostPipelinesToQS(postablePipelines)
+	require.Equal(canaryResp.Version, brokenResp.Rollout.StableVersion)
+	testbed.simulateAgentConfigStatus(testbed.opampClientConn, "test", "invalid config")
+
+	require.Eventually(func() bool {
+		return testbed.GetPipelinesFromQS().Version == brokenResp.Version+1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rollbackResp := testbed.GetPipelinesFromQS()
+	require.Equal(postablePipelines.Pipelines[0].Name, rollbackResp.Pipelines[0].Name)
+	require.Equal("canary", rollbackResp.Pipelines[0].Config[0].Value)
+	testbed.assertPipelinesSentToOpampClient(rollbackResp.Pipelines)
+
+	brokenRollout, apiErr := agentConf.GetRollout(
+		context.Background(), agentConf.ElementTypeLogPipelines, brokenResp.Version,
+	)
+	require.Nil(apiErr)
+	require.Equal(agentConf.RolloutRolledBack, brokenRollout.Status)
+	require.Equal(rollbackResp.Version, brokenRollout.RollbackVersion)
+}
+
 // LogPipelinesTestBed coordinates and mocks components involved in
 // configuring log pipelines and provides test helpers.
 type LogPipelinesTestBed struct {
@@ -461,7 +580,7 @@ func NewTestbedWithoutOpamp(t *testing.T, testDB *sqlx.DB) *LogPipelinesTestBed
 	}
 
 	controller, err := logparsingpipeline.NewLogParsingPipelinesController(
-		testDB, ic.GetPipelinesForInstalledIntegrations,
+		testDB, ic.GetPipelinesForInstalledIntegrations, nil,
 	)
 	if err != nil {
 		t.Fatalf("could not create a logparsingpipelines controller: %v", err)
@@ -738,6 +857,70 @@ func (tb *LogPipelinesTestBed) assertNewAgentGetsPipelinesOnConnection(
 	)
 }
 
+// simulateAgentConfigStatus reports the result of applying the latest
+// config sent to an agent, failure if errMsg is not empty.
+func (tb *LogPipelinesTestBed) simulateAgentConfigStatus(
+	conn *opamp.MockOpAmpConnection, agentId string, errMsg string,
+) {
+	lastMsg := conn.LatestMsgFromServer()
+	status := protobufs.RemoteConfigStatuses_RemoteConfigStatuses_APPLIED
+	if errMsg != "" {
+		status = protobufs.RemoteConfigStatuses_RemoteConfigStatuses_FAILED
+	}
+	tb.opampServer.OnMessage(conn, &protobufs.AgentToServer{
+		InstanceUid: agentId,
+		EffectiveConfig: &protobufs.EffectiveConfig{
+			ConfigMap: lastMsg.RemoteConfig.Config,
+		},
+		RemoteConfigStatus: &protobufs.RemoteConfigStatus{
+			Status:               status,
+			LastRemoteConfigHash: lastMsg.RemoteConfig.ConfigHash,
+			ErrorMessage:         errMsg,
+		},
+	})
+}
+
+// RequestQS calls handler and unmarshals the data of its response into result.
+func (tb *LogPipelinesTestBed) RequestQS(
+	handler http.HandlerFunc, path string, urlVars map[string]string, result interface{},
+) {
+	req, err := AuthenticatedRequestForTest(tb.testUser, path, nil)
+	if err != nil {
+		tb.t.Fatalf("couldn't create authenticated test request: %v", err)
+	}
+	req = req.WithContext(auth.AttachJwtToContext(req.Context(), req))
+	if urlVars != nil {
+		req = mux.SetURLVars(req, urlVars)
+	}
+
+	respWriter := httptest.NewRecorder()
+	handler(respWriter, req)
+	response := respWriter.Result()
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		tb.t.Fatalf("couldn't read response body received from QS: %v", err)
+	}
+	if response.StatusCode != 200 {
+		tb.t.Fatalf(
+			"request to %s failed. status: %d, body: %s", path, response.StatusCode, string(responseBody),
+		)
+	}
+
+	var apiResponse app.ApiResponse
+	err = json.Unmarshal(responseBody, &apiResponse)
+	if err != nil {
+		tb.t.Fatalf("could not unmarshal QS response into an ApiResponse: %v", err)
+	}
+	dataJson, err := json.Marshal(apiResponse.Data)
+	if err != nil {
+		tb.t.Fatalf("could not marshal apiResponse.Data: %v", err)
+	}
+	err = json.Unmarshal(dataJson, result)
+	if err != nil {
+		tb.t.Fatalf("could not unmarshal apiResponse.Data: %v", err)
+	}
+}
+
 func unmarshalPipelinesResponse(apiResponse *app.ApiResponse) (
 	*logparsingpipeline.PipelinesResponse,
 	error,
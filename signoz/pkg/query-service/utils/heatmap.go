@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HeatmapBucketLabel is the label of the upper bound of the bucket of a heatmap
+// series, it has the same name as the bucket label of the histogram metrics
+const HeatmapBucketLabel = "le"
+
+// FormatHeatmapBucket formats the upper bound of a heatmap bucket as its label value
+func FormatHeatmapBucket(bound float64) string {
+	return strconv.FormatFloat(bound, 'f', -1, 64)
+}
+
+// HeatmapBucketExpression returns the ClickHouse expression of the label of the
+// heatmap bucket the value falls in. The values above the last bucket fall in the
+// +Inf bucket, and without buckets the bounds are the powers of two.
+func HeatmapBucketExpression(value string, buckets []float64) string {
+	if len(buckets) == 0 {
+		return fmt.Sprintf("if(toFloat64(%[1]s) <= 0, '0', toString(pow(2, ceil(log2(toFloat64(%[1]s))))))", value)
+	}
+	labels := make([]string, 0, len(buckets)+1)
+	bounds := make([]string, 0, len(buckets)+1)
+	for _, bound := range buckets {
+		labels = append(labels, fmt.Sprintf("'%s'", FormatHeatmapBucket(bound)))
+		bounds = append(bounds, FormatHeatmapBucket(bound))
+	}
+	labels = append(labels, "'+Inf'")
+	bounds = append(bounds, "inf")
+	return fmt.Sprintf("arrayElement([%s], arrayFirstIndex(x -> toFloat64(%s) <= x, [%s]))",
+		strings.Join(labels, ", "), value, strings.Join(bounds, ", "))
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestHeatmapBucketExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets []float64
+		want    string
+	}{
+		{
+			name: "powers of two without buckets",
+			want: "if(toFloat64(durationNano) <= 0, '0', toString(pow(2, ceil(log2(toFloat64(durationNano))))))",
+		},
+		{
+			name:    "explicit buckets",
+			buckets: []float64{0.5, 10},
+			want:    "arrayElement(['0.5', '10', '+Inf'], arrayFirstIndex(x -> toFloat64(durationNano) <= x, [0.5, 10, inf]))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HeatmapBucketExpression("durationNano", tt.buckets); got != tt.want {
+				t.Errorf("HeatmapBucketExpression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package querylimit
+
+import (
+	"fmt"
+
+	"go.signoz.io/signoz/pkg/factory"
+)
+
+// Action is what happens to a query whose estimated cost exceeds the limits.
+type Action string
+
+const (
+	// ActionReject rejects the query with a resource limit error.
+	ActionReject Action = "reject"
+	// ActionDowngrade narrows the time range of the query until its estimated cost fits the limits.
+	// Queries whose time range cannot be narrowed, such as clickhouse queries, are rejected.
+	ActionDowngrade Action = "downgrade"
+)
+
+// Config holds the configuration for the query limits.
+type Config struct {
+	// Whether the cost of the queries is estimated before running them.
+	Enabled bool `mapstructure:"enabled"`
+	// The action to take when the estimated cost of a query exceeds the limits.
+	Action Action `mapstructure:"action"`
+	// The limits for the roles that are not listed in Roles.
+	Default Limit `mapstructure:"default"`
+	// The limits by role name (ADMIN, EDITOR, VIEWER).
+	Roles map[string]Limit `mapstructure:"roles"`
+	// The limits for the queries run without a user, such as the rule evaluations.
+	// They are not limited by default. The queries exceeding these limits are
+	// always rejected, they are never downgraded as narrowing the time range of a
+	// rule would silently change what it evaluates.
+	Rules Limit `mapstructure:"rules"`
+}
+
+// Limit holds the maximum estimated cost of a query. A zero value means no limit.
+//
+// The cost is estimated by clickhouse from the primary key and the partitions
+// of the tables before the query runs. The rows are the rows of the granules
+// selected by the primary key, an upper bound of the rows actually read. The
+// bytes are these rows multiplied by the average uncompressed size of a row of
+// the table in system.parts, which counts every column of the table and so
+// overestimates the bytes of the queries reading a few columns.
+type Limit struct {
+	// The maximum number of rows read by a query.
+	MaxRows uint64 `mapstructure:"max_rows"`
+	// The maximum number of bytes read by a query.
+	MaxBytes uint64 `mapstructure:"max_bytes"`
+}
+
+func NewConfigFactory() factory.ConfigFactory {
+	return factory.NewConfigFactory(factory.MustNewName("querylimit"), newConfig)
+}
+
+func newConfig() factory.Config {
+	return &Config{
+		Enabled: false,
+		Action:  ActionReject,
+		Roles:   map[string]Limit{},
+	}
+}
+
+func (c Config) Validate() error {
+	switch c.Action {
+	case ActionReject, ActionDowngrade:
+		return nil
+	default:
+		return fmt.Errorf("querylimit::action must be one of %q or %q, got %q", ActionReject, ActionDowngrade, c.Action)
+	}
+}
+
+// LimitForRole returns the limit for the role, falling back to the default limit.
+func (c Config) LimitForRole(role string) Limit {
+	if limit, ok := c.Roles[role]; ok {
+		return limit
+	}
+	return c.Default
+}
+
+// Exceeds returns true if the estimated rows or bytes exceed the limit.
+func (l Limit) Exceeds(rows, bytes uint64) bool {
+	return (l.MaxRows > 0 && rows > l.MaxRows) || (l.MaxBytes > 0 && bytes > l.MaxBytes)
+}
@@ -0,0 +1,63 @@
+package querylimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/config"
+	"go.signoz.io/signoz/pkg/config/envprovider"
+	"go.signoz.io/signoz/pkg/factory"
+)
+
+func TestNewWithEnvProvider(t *testing.T) {
+	t.Setenv("SIGNOZ_QUERYLIMIT_ENABLED", "true")
+	t.Setenv("SIGNOZ_QUERYLIMIT_ACTION", "downgrade")
+	t.Setenv("SIGNOZ_QUERYLIMIT_DEFAULT_MAX__ROWS", "1000000")
+	t.Setenv("SIGNOZ_QUERYLIMIT_DEFAULT_MAX__BYTES", "2000000")
+
+	conf, err := config.New(
+		context.Background(),
+		config.ResolverConfig{
+			Uris: []string{"env:"},
+			ProviderFactories: []config.ProviderFactory{
+				envprovider.NewFactory(),
+			},
+		},
+		[]factory.ConfigFactory{
+			NewConfigFactory(),
+		},
+	)
+	require.NoError(t, err)
+
+	actual := &Config{}
+	err = conf.Unmarshal("querylimit", actual)
+	require.NoError(t, err)
+
+	expected := &Config{
+		Enabled: true,
+		Action:  ActionDowngrade,
+		Default: Limit{
+			MaxRows:  1000000,
+			MaxBytes: 2000000,
+		},
+		Roles: map[string]Limit{},
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func TestLimitForRole(t *testing.T) {
+	c := Config{
+		Default: Limit{MaxRows: 100},
+		Roles: map[string]Limit{
+			"ADMIN": {},
+		},
+	}
+
+	assert.Equal(t, Limit{}, c.LimitForRole("ADMIN"))
+	assert.Equal(t, Limit{MaxRows: 100}, c.LimitForRole("VIEWER"))
+	assert.False(t, c.LimitForRole("ADMIN").Exceeds(1000, 1000))
+	assert.True(t, c.LimitForRole("VIEWER").Exceeds(1000, 0))
+}
@@ -2,8 +2,10 @@ package signoz
 
 import (
 	"go.signoz.io/signoz/pkg/cache"
+	"go.signoz.io/signoz/pkg/cache/diskcache"
 	"go.signoz.io/signoz/pkg/cache/memorycache"
 	"go.signoz.io/signoz/pkg/cache/rediscache"
+	"go.signoz.io/signoz/pkg/cache/tieredcache"
 	"go.signoz.io/signoz/pkg/factory"
 	"go.signoz.io/signoz/pkg/sqlmigration"
 	"go.signoz.io/signoz/pkg/sqlstore"
@@ -39,6 +41,8 @@ func NewProviderConfig() ProviderConfig {
 		CacheProviderFactories: factory.MustNewNamedMap(
 			memorycache.NewFactory(),
 			rediscache.NewFactory(),
+			diskcache.NewFactory(),
+			tieredcache.NewFactory(),
 		),
 		WebProviderFactories: factory.MustNewNamedMap(
 			routerweb.NewFactory(),